@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"hermes-api/internal/dto"
+	"hermes-api/internal/service"
+	"hermes-api/pkg/context"
+	"hermes-api/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminController handles HTTP requests for platform administration
+type AdminController struct {
+	providerHealthService     service.ProviderHealthService
+	providerCredentialService service.ProviderCredentialService
+}
+
+// NewAdminController creates a new admin controller
+func NewAdminController(providerHealthService service.ProviderHealthService, providerCredentialService service.ProviderCredentialService) *AdminController {
+	return &AdminController{
+		providerHealthService:     providerHealthService,
+		providerCredentialService: providerCredentialService,
+	}
+}
+
+// GetProviderHealth retrieves the current health of the configured delivery providers
+func (c *AdminController) GetProviderHealth(ctx *fiber.Ctx) error {
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	healths, err := c.providerHealthService.ListProviderHealth(serviceCtx)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(healths, "Provider health retrieved successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// RotateCredentialKeys re-encrypts the provider credentials of applications with the active credential key
+func (c *AdminController) RotateCredentialKeys(ctx *fiber.Ctx) error {
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	keyID, rotated, err := c.providerCredentialService.RotateKeys(serviceCtx)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(dto.RotateCredentialKeysResponse{KeyID: keyID, Rotated: rotated}, "Credential keys rotated successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"hermes-api/internal/dto"
+	"hermes-api/internal/model"
+	"hermes-api/internal/service"
+	"hermes-api/pkg/context"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// AnalyticsController handles HTTP requests for delivery analytics
+type AnalyticsController struct {
+	analyticsService service.AnalyticsService
+}
+
+// NewAnalyticsController creates a new analytics controller
+func NewAnalyticsController(analyticsService service.AnalyticsService) *AnalyticsController {
+	return &AnalyticsController{
+		analyticsService: analyticsService,
+	}
+}
+
+// GetAnalytics retrieves the delivery analytics of an application
+func (c *AnalyticsController) GetAnalytics(ctx *fiber.Ctx) error {
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	return c.analytics(ctx, application.UserID, application.ID.String())
+}
+
+// GetUserAnalytics retrieves the delivery analytics of all the applications of the authenticated user
+func (c *AnalyticsController) GetUserAnalytics(ctx *fiber.Ctx) error {
+	user, ok := ctx.Locals("user").(*model.User)
+	if !ok || user == nil {
+		return errorx.New(errorx.ErrorTypeUnauthorized, errorx.ErrorCodeFiberUnauthorized, "User not authenticated")
+	}
+
+	return c.analytics(ctx, user.ID, "")
+}
+
+// analytics serves the analytics of the query, applicationID restricts them to one application
+// regardless of the query
+func (c *AnalyticsController) analytics(ctx *fiber.Ctx, userID uuid.UUID, applicationID string) error {
+	var query dto.AnalyticsQuery
+	if err := ctx.QueryParser(&query); err != nil {
+		appErr := errorx.New(errorx.ErrorTypeBadRequest, errorx.ErrorCodeInvalidFormat, err.Error())
+		return appErr // return the error to the middleware
+	}
+	if applicationID != "" {
+		query.ApplicationID = applicationID
+	}
+
+	if err := query.Validate(); err != nil {
+		return err // return the error to the middleware
+	}
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	analytics, err := c.analyticsService.GetAnalytics(serviceCtx, userID, query)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(analytics, "Analytics retrieved successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
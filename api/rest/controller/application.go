@@ -53,3 +53,79 @@ func (c *ApplicationController) CreateApplication(ctx *fiber.Ctx) error {
 		WithRequestID(ctx.Locals("X-Request-ID").(string)).
 		Send(ctx)
 }
+
+// UpdateSettings changes the settings of an application
+func (c *ApplicationController) UpdateSettings(ctx *fiber.Ctx) error {
+	var req dto.UpdateApplicationSettingsRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		appErr := errorx.New(errorx.ErrorTypeBadRequest, errorx.ErrorCodeInvalidFormat, err.Error())
+		return appErr // return the error to the middleware
+	}
+
+	if err := req.Validate(); err != nil {
+		return err // return the error to the middleware
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	application, err := c.applicationService.UpdateSettings(serviceCtx, application, req)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(application, "Settings updated successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// SetQuietHours sets the default quiet hours of the application's contacts
+func (c *ApplicationController) SetQuietHours(ctx *fiber.Ctx) error {
+	var req dto.QuietHoursRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		appErr := errorx.New(errorx.ErrorTypeBadRequest, errorx.ErrorCodeInvalidFormat, err.Error())
+		return appErr // return the error to the middleware
+	}
+
+	if err := req.Validate(); err != nil {
+		return err // return the error to the middleware
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	application, err := c.applicationService.SetQuietHours(serviceCtx, application, &req)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(application, "Quiet hours updated successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// DeleteQuietHours removes the default quiet hours of the application's contacts
+func (c *ApplicationController) DeleteQuietHours(ctx *fiber.Ctx) error {
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	if _, err := c.applicationService.SetQuietHours(serviceCtx, application, nil); err != nil {
+		return err
+	}
+
+	return response.NoContentResponse("Quiet hours removed successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
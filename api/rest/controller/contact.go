@@ -0,0 +1,388 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"hermes-api/internal/dto"
+	"hermes-api/internal/model"
+	"hermes-api/internal/service"
+	"hermes-api/pkg/context"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/logger"
+	"hermes-api/pkg/response"
+	"io"
+	"mime"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// exportTimeout bounds the time spent streaming a contact export
+const exportTimeout = 10 * time.Minute
+
+// ContactController handles HTTP requests for contact operations
+type ContactController struct {
+	contactService       service.ContactService
+	contactImportService service.ContactImportService
+}
+
+// NewContactController creates a new contact controller
+func NewContactController(contactService service.ContactService, contactImportService service.ContactImportService) *ContactController {
+	return &ContactController{
+		contactService:       contactService,
+		contactImportService: contactImportService,
+	}
+}
+
+// CreateContact creates a new contact
+func (c *ContactController) CreateContact(ctx *fiber.Ctx) error {
+	var req dto.CreateContactRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		appErr := errorx.New(errorx.ErrorTypeBadRequest, errorx.ErrorCodeInvalidFormat, err.Error())
+		return appErr // return the error to the middleware
+	}
+
+	if err := req.Validate(); err != nil {
+		return err // return the error to the middleware
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	contact, err := c.contactService.CreateContact(serviceCtx, application, req)
+	if err != nil {
+		return err
+	}
+
+	return response.CreatedResponse(contact, "Contact created successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// GetContacts retrieves the contacts of an application with pagination
+func (c *ContactController) GetContacts(ctx *fiber.Ctx) error {
+	limit, offset := paginationParams(ctx)
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	contacts, err := c.contactService.ListContacts(serviceCtx, application, limit+1, offset)
+	if err != nil {
+		return err
+	}
+
+	hasNext := len(contacts) > limit
+	if hasNext {
+		contacts = contacts[:limit]
+	}
+
+	return response.SuccessResponse(contacts, "Contacts retrieved successfully").
+		WithMeta(&response.MetaInfo{
+			Limit:   limit,
+			HasNext: hasNext,
+			HasPrev: offset > 0,
+		}).
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// GetContact retrieves a contact by its external ID
+func (c *ContactController) GetContact(ctx *fiber.Ctx) error {
+	externalID, err := contactIDParam(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	contact, err := c.contactService.GetContact(serviceCtx, application, externalID)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(contact, "Contact retrieved successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// UpdateContact replaces the fields of a contact
+func (c *ContactController) UpdateContact(ctx *fiber.Ctx) error {
+	externalID, err := contactIDParam(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req dto.UpdateContactRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		appErr := errorx.New(errorx.ErrorTypeBadRequest, errorx.ErrorCodeInvalidFormat, err.Error())
+		return appErr // return the error to the middleware
+	}
+
+	if err := req.Validate(); err != nil {
+		return err // return the error to the middleware
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	contact, err := c.contactService.UpdateContact(serviceCtx, application, externalID, req)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(contact, "Contact updated successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// DeleteContact deletes a contact
+func (c *ContactController) DeleteContact(ctx *fiber.Ctx) error {
+	externalID, err := contactIDParam(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	if err := c.contactService.DeleteContact(serviceCtx, application, externalID); err != nil {
+		return err
+	}
+
+	return response.NoContentResponse("Contact deleted successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// GetPreferences retrieves the notification preferences of a contact
+func (c *ContactController) GetPreferences(ctx *fiber.Ctx) error {
+	externalID, err := contactIDParam(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	preferences, err := c.contactService.GetPreferences(serviceCtx, application, externalID)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(preferences, "Preferences retrieved successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// UpdatePreferences replaces the notification preferences of a contact
+func (c *ContactController) UpdatePreferences(ctx *fiber.Ctx) error {
+	externalID, err := contactIDParam(ctx)
+	if err != nil {
+		return err
+	}
+
+	var req dto.UpdatePreferencesRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		appErr := errorx.New(errorx.ErrorTypeBadRequest, errorx.ErrorCodeInvalidFormat, err.Error())
+		return appErr // return the error to the middleware
+	}
+
+	if err := req.Validate(); err != nil {
+		return err // return the error to the middleware
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	preferences, err := c.contactService.UpdatePreferences(serviceCtx, application, externalID, req)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(preferences, "Preferences updated successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// ImportContacts starts an asynchronous import of a CSV or NDJSON file, sent either as the "file"
+// field of a multipart form or as the raw request body
+func (c *ContactController) ImportContacts(ctx *fiber.Ctx) error {
+	format := model.ContactFormat(strings.ToLower(ctx.Query("format")))
+
+	var content io.Reader
+	if header, err := ctx.FormFile("file"); err == nil {
+		file, err := header.Open()
+		if err != nil {
+			appErr := errorx.New(errorx.ErrorTypeBadRequest, errorx.ErrorCodeInvalidFormat, err.Error())
+			return appErr
+		}
+		defer file.Close()
+
+		content = file
+		if format == "" {
+			format = contactFormatOf(header.Filename, header.Header.Get(fiber.HeaderContentType))
+		}
+	} else {
+		if len(ctx.Body()) == 0 {
+			return errorx.NewRequiredFieldError("file")
+		}
+
+		content = bytes.NewReader(ctx.Body())
+		if format == "" {
+			format = contactFormatOf("", ctx.Get(fiber.HeaderContentType))
+		}
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithLongTimeout().Build()
+	defer cancel()
+
+	job, err := c.contactImportService.ImportContacts(serviceCtx, application, format, content)
+	if err != nil {
+		return err
+	}
+
+	return response.AcceptedResponse(job, "Contact import queued successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// GetContactImport retrieves the progress of a contact import
+func (c *ContactController) GetContactImport(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errorx.NewValidationError("id", ctx.Params("id"))
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	job, err := c.contactImportService.GetImport(serviceCtx, application, id)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(job, "Contact import retrieved successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// DownloadContactImportErrors downloads the rows rejected by a contact import as CSV
+func (c *ContactController) DownloadContactImportErrors(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errorx.NewValidationError("id", ctx.Params("id"))
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	content, err := c.contactImportService.OpenImportErrors(serviceCtx, application, id)
+	if err != nil {
+		return err
+	}
+
+	ctx.Attachment(fmt.Sprintf("contact-import-%s-errors.csv", id))
+	return ctx.SendStream(content)
+}
+
+// ExportContacts streams all contacts of an application as CSV (default) or NDJSON
+func (c *ContactController) ExportContacts(ctx *fiber.Ctx) error {
+	format := model.ContactFormat(strings.ToLower(ctx.Query("format", string(model.ContactFormatCSV))))
+	if !format.IsValid() {
+		return errorx.NewWithTemplate(errorx.ErrorTypeValidation, errorx.ErrorCodeContactFormatInvalid, format)
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// The export is written after the handler returns, the service context must not depend on the request
+	serviceCtx, cancel := context.New(ctx).WithTimeout(exportTimeout).Build()
+
+	ctx.Attachment("contacts." + string(format))
+	if format == model.ContactFormatNDJSON {
+		ctx.Set(fiber.HeaderContentType, "application/x-ndjson")
+	}
+
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		if err := c.contactImportService.ExportContacts(serviceCtx, application, format, w); err != nil {
+			logger.Error("Failed to export contacts", err, zap.String("application_id", application.ID.String()))
+		}
+	})
+	return nil
+}
+
+// contactFormatOf guesses the format of an uploaded contact file from its name or content type
+func contactFormatOf(filename, contentType string) model.ContactFormat {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return model.ContactFormatCSV
+	case ".ndjson", ".jsonl":
+		return model.ContactFormatNDJSON
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return model.ContactFormatCSV
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return model.ContactFormatNDJSON
+	}
+	return model.ContactFormat(mediaType)
+}
+
+// contactIDParam returns the unescaped external ID of the contact in the path
+func contactIDParam(ctx *fiber.Ctx) (string, error) {
+	externalID, err := url.PathUnescape(ctx.Params("contact_id"))
+	if err != nil || externalID == "" {
+		return "", errorx.NewValidationError("contact_id", ctx.Params("contact_id"))
+	}
+	return externalID, nil
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"hermes-api/internal/dto"
+	"hermes-api/internal/model"
+	"hermes-api/internal/service"
+	"hermes-api/pkg/context"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// DigestController handles HTTP requests for digest rule operations
+type DigestController struct {
+	digestService service.DigestService
+}
+
+// NewDigestController creates a new digest controller
+func NewDigestController(digestService service.DigestService) *DigestController {
+	return &DigestController{
+		digestService: digestService,
+	}
+}
+
+// CreateDigestRule adds a digest rule to an application
+func (c *DigestController) CreateDigestRule(ctx *fiber.Ctx) error {
+	var req dto.CreateDigestRuleRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		appErr := errorx.New(errorx.ErrorTypeBadRequest, errorx.ErrorCodeInvalidFormat, err.Error())
+		return appErr // return the error to the middleware
+	}
+
+	if err := req.Validate(); err != nil {
+		return err // return the error to the middleware
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	rule, err := c.digestService.CreateDigestRule(serviceCtx, application, req)
+	if err != nil {
+		return err
+	}
+
+	return response.CreatedResponse(rule, "Digest rule created successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// GetDigestRules retrieves the digest rules of an application
+func (c *DigestController) GetDigestRules(ctx *fiber.Ctx) error {
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	rules, err := c.digestService.ListDigestRules(serviceCtx, application)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(rules, "Digest rules retrieved successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// DeleteDigestRule removes a digest rule of an application
+func (c *DigestController) DeleteDigestRule(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errorx.NewValidationError("id", ctx.Params("id"))
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	if err := c.digestService.DeleteDigestRule(serviceCtx, application, id); err != nil {
+		return err
+	}
+
+	return response.NoContentResponse("Digest rule deleted successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
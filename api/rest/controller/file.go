@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"hermes-api/internal/model"
+	"hermes-api/internal/service"
+	"hermes-api/pkg/context"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// FileController handles HTTP requests for file operations
+type FileController struct {
+	fileService service.FileService
+}
+
+// NewFileController creates a new file controller
+func NewFileController(fileService service.FileService) *FileController {
+	return &FileController{
+		fileService: fileService,
+	}
+}
+
+// UploadFile stores a multipart uploaded file ("file" field) for use as an attachment
+func (c *FileController) UploadFile(ctx *fiber.Ctx) error {
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		return errorx.NewRequiredFieldError("file")
+	}
+
+	content, err := header.Open()
+	if err != nil {
+		appErr := errorx.New(errorx.ErrorTypeBadRequest, errorx.ErrorCodeInvalidFormat, err.Error())
+		return appErr
+	}
+	defer content.Close()
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithLongTimeout().Build()
+	defer cancel()
+
+	file, err := c.fileService.UploadFile(serviceCtx, application, header.Filename, header.Header.Get("Content-Type"), content)
+	if err != nil {
+		return err
+	}
+
+	return response.CreatedResponse(file, "File uploaded successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// GetFileByID retrieves the metadata of an uploaded file
+func (c *FileController) GetFileByID(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errorx.NewValidationError("id", ctx.Params("id"))
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	file, err := c.fileService.GetFileByID(serviceCtx, application, id)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(file, "File retrieved successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"hermes-api/internal/dto"
+	"hermes-api/internal/model"
+	"hermes-api/internal/service"
+	"hermes-api/pkg/context"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// FrequencyCapController handles HTTP requests for frequency cap operations
+type FrequencyCapController struct {
+	frequencyCapService service.FrequencyCapService
+}
+
+// NewFrequencyCapController creates a new frequency cap controller
+func NewFrequencyCapController(frequencyCapService service.FrequencyCapService) *FrequencyCapController {
+	return &FrequencyCapController{
+		frequencyCapService: frequencyCapService,
+	}
+}
+
+// CreateFrequencyCap adds a frequency cap to an application
+func (c *FrequencyCapController) CreateFrequencyCap(ctx *fiber.Ctx) error {
+	var req dto.CreateFrequencyCapRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		appErr := errorx.New(errorx.ErrorTypeBadRequest, errorx.ErrorCodeInvalidFormat, err.Error())
+		return appErr // return the error to the middleware
+	}
+
+	if err := req.Validate(); err != nil {
+		return err // return the error to the middleware
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	frequencyCap, err := c.frequencyCapService.CreateFrequencyCap(serviceCtx, application, req)
+	if err != nil {
+		return err
+	}
+
+	return response.CreatedResponse(frequencyCap, "Frequency cap created successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// GetFrequencyCaps retrieves the frequency caps of an application
+func (c *FrequencyCapController) GetFrequencyCaps(ctx *fiber.Ctx) error {
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	frequencyCaps, err := c.frequencyCapService.ListFrequencyCaps(serviceCtx, application)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(frequencyCaps, "Frequency caps retrieved successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// DeleteFrequencyCap removes a frequency cap of an application
+func (c *FrequencyCapController) DeleteFrequencyCap(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errorx.NewValidationError("id", ctx.Params("id"))
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	if err := c.frequencyCapService.DeleteFrequencyCap(serviceCtx, application, id); err != nil {
+		return err
+	}
+
+	return response.NoContentResponse("Frequency cap deleted successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
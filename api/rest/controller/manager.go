@@ -7,9 +7,21 @@ import (
 
 // ControllerManager manages all controllers and their dependencies
 type ControllerManager struct {
-	userController        *UserController
-	authController        *AuthController
-	applicationController *ApplicationController
+	userController               *UserController
+	authController               *AuthController
+	applicationController        *ApplicationController
+	templateController           *TemplateController
+	notificationController       *NotificationController
+	fileController               *FileController
+	contactController            *ContactController
+	preferenceCenter             *PreferenceCenterController
+	suppressionController        *SuppressionController
+	frequencyCapController       *FrequencyCapController
+	digestController             *DigestController
+	adminController              *AdminController
+	providerCredentialController *ProviderCredentialController
+	webhookController            *WebhookController
+	analyticsController          *AnalyticsController
 	// Add other controllers as needed:
 	// productController *ProductController
 	// orderController   *OrderController
@@ -18,9 +30,21 @@ type ControllerManager struct {
 // NewControllerManager creates a new controller manager
 func NewControllerManager(serviceManager service.ServiceManager) *ControllerManager {
 	return &ControllerManager{
-		userController:        NewUserController(serviceManager.User()),
-		authController:        NewAuthController(serviceManager.Auth()),
-		applicationController: NewApplicationController(serviceManager.Application()),
+		userController:               NewUserController(serviceManager.User()),
+		authController:               NewAuthController(serviceManager.Auth()),
+		applicationController:        NewApplicationController(serviceManager.Application()),
+		templateController:           NewTemplateController(serviceManager.Template()),
+		notificationController:       NewNotificationController(serviceManager.Notification()),
+		fileController:               NewFileController(serviceManager.File()),
+		contactController:            NewContactController(serviceManager.Contact(), serviceManager.ContactImport()),
+		preferenceCenter:             NewPreferenceCenterController(serviceManager.PreferenceCenter()),
+		suppressionController:        NewSuppressionController(serviceManager.Suppression()),
+		frequencyCapController:       NewFrequencyCapController(serviceManager.FrequencyCap()),
+		digestController:             NewDigestController(serviceManager.Digest()),
+		adminController:              NewAdminController(serviceManager.ProviderHealth(), serviceManager.ProviderCredential()),
+		providerCredentialController: NewProviderCredentialController(serviceManager.ProviderCredential()),
+		webhookController:            NewWebhookController(serviceManager.DeliveryEvent()),
+		analyticsController:          NewAnalyticsController(serviceManager.Analytics()),
 	}
 }
 
@@ -38,3 +62,63 @@ func (cm *ControllerManager) Auth() *AuthController {
 func (cm *ControllerManager) Application() *ApplicationController {
 	return cm.applicationController
 }
+
+// Template returns the template controller
+func (cm *ControllerManager) Template() *TemplateController {
+	return cm.templateController
+}
+
+// Notification returns the notification controller
+func (cm *ControllerManager) Notification() *NotificationController {
+	return cm.notificationController
+}
+
+// File returns the file controller
+func (cm *ControllerManager) File() *FileController {
+	return cm.fileController
+}
+
+// Contact returns the contact controller
+func (cm *ControllerManager) Contact() *ContactController {
+	return cm.contactController
+}
+
+// PreferenceCenter returns the preference center controller
+func (cm *ControllerManager) PreferenceCenter() *PreferenceCenterController {
+	return cm.preferenceCenter
+}
+
+// Suppression returns the suppression controller
+func (cm *ControllerManager) Suppression() *SuppressionController {
+	return cm.suppressionController
+}
+
+// FrequencyCap returns the frequency cap controller
+func (cm *ControllerManager) FrequencyCap() *FrequencyCapController {
+	return cm.frequencyCapController
+}
+
+// Digest returns the digest controller
+func (cm *ControllerManager) Digest() *DigestController {
+	return cm.digestController
+}
+
+// Admin returns the admin controller
+func (cm *ControllerManager) Admin() *AdminController {
+	return cm.adminController
+}
+
+// ProviderCredential returns the provider credential controller
+func (cm *ControllerManager) ProviderCredential() *ProviderCredentialController {
+	return cm.providerCredentialController
+}
+
+// Webhook returns the webhook controller
+func (cm *ControllerManager) Webhook() *WebhookController {
+	return cm.webhookController
+}
+
+// Analytics returns the analytics controller
+func (cm *ControllerManager) Analytics() *AnalyticsController {
+	return cm.analyticsController
+}
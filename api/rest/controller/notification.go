@@ -0,0 +1,242 @@
+package controller
+
+import (
+	"hermes-api/internal/dto"
+	"hermes-api/internal/model"
+	"hermes-api/internal/service"
+	"hermes-api/pkg/context"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// NotificationController handles HTTP requests for notification operations
+type NotificationController struct {
+	notificationService service.NotificationService
+}
+
+// NewNotificationController creates a new notification controller
+func NewNotificationController(notificationService service.NotificationService) *NotificationController {
+	return &NotificationController{
+		notificationService: notificationService,
+	}
+}
+
+// SendNotification validates and queues a notification for delivery
+func (c *NotificationController) SendNotification(ctx *fiber.Ctx) error {
+	var req dto.SendNotificationRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		appErr := errorx.New(errorx.ErrorTypeBadRequest, errorx.ErrorCodeInvalidFormat, err.Error())
+		return appErr // return the error to the middleware
+	}
+
+	if err := req.Validate(); err != nil {
+		return err // return the error to the middleware
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	notification, err := c.notificationService.SendNotification(serviceCtx, application, req)
+	if err != nil {
+		return err
+	}
+
+	message := "Notification queued successfully"
+	if notification.Status == model.NotificationStatusDeduplicated {
+		message = "Duplicate notification dropped"
+	}
+
+	return response.AcceptedResponse(notification, message).
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// GetNotificationByID retrieves a notification with its status history and delivery attempts
+func (c *NotificationController) GetNotificationByID(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errorx.NewValidationError("id", ctx.Params("id"))
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	notification, err := c.notificationService.GetNotification(serviceCtx, application, id)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(notification, "Notification retrieved successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// GetNotifications searches the notifications of an application
+func (c *NotificationController) GetNotifications(ctx *fiber.Ctx) error {
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	return c.listNotifications(ctx, application.UserID, application.ID.String())
+}
+
+// SearchNotifications searches the notifications of all the applications of the authenticated user
+func (c *NotificationController) SearchNotifications(ctx *fiber.Ctx) error {
+	user, ok := ctx.Locals("user").(*model.User)
+	if !ok || user == nil {
+		return errorx.New(errorx.ErrorTypeUnauthorized, errorx.ErrorCodeFiberUnauthorized, "User not authenticated")
+	}
+
+	return c.listNotifications(ctx, user.ID, "")
+}
+
+// listNotifications serves a page of the notification list, applicationID restricts the list to one
+// application regardless of the query
+func (c *NotificationController) listNotifications(ctx *fiber.Ctx, userID uuid.UUID, applicationID string) error {
+	var query dto.ListNotificationsQuery
+	if err := ctx.QueryParser(&query); err != nil {
+		appErr := errorx.New(errorx.ErrorTypeBadRequest, errorx.ErrorCodeInvalidFormat, err.Error())
+		return appErr // return the error to the middleware
+	}
+	if applicationID != "" {
+		query.ApplicationID = applicationID
+	}
+
+	if err := query.Validate(); err != nil {
+		return err // return the error to the middleware
+	}
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	page, err := c.notificationService.ListNotifications(serviceCtx, userID, query)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(page.Notifications, "Notifications retrieved successfully").
+		WithMeta(&response.MetaInfo{
+			Limit:      page.Limit,
+			HasNext:    page.HasNext,
+			HasPrev:    query.Cursor != "",
+			NextCursor: page.NextCursor,
+			SortBy:     page.SortBy,
+			SortOrder:  page.SortOrder,
+			Filters:    page.Filters,
+		}).
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// GetNotificationAttempts retrieves the delivery attempts of a notification
+func (c *NotificationController) GetNotificationAttempts(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errorx.NewValidationError("id", ctx.Params("id"))
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	attempts, err := c.notificationService.ListAttempts(serviceCtx, application, id)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(attempts, "Notification attempts retrieved successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// GetUserNotificationByID retrieves a notification of any application of the authenticated user with its
+// status history and delivery attempts
+func (c *NotificationController) GetUserNotificationByID(ctx *fiber.Ctx) error {
+	user, ok := ctx.Locals("user").(*model.User)
+	if !ok || user == nil {
+		return errorx.New(errorx.ErrorTypeUnauthorized, errorx.ErrorCodeFiberUnauthorized, "User not authenticated")
+	}
+
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errorx.NewValidationError("id", ctx.Params("id"))
+	}
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	notification, err := c.notificationService.GetUserNotification(serviceCtx, user.ID, id)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(notification, "Notification retrieved successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// GetUserNotificationAttempts retrieves the delivery attempts of a notification of any application of
+// the authenticated user
+func (c *NotificationController) GetUserNotificationAttempts(ctx *fiber.Ctx) error {
+	user, ok := ctx.Locals("user").(*model.User)
+	if !ok || user == nil {
+		return errorx.New(errorx.ErrorTypeUnauthorized, errorx.ErrorCodeFiberUnauthorized, "User not authenticated")
+	}
+
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errorx.NewValidationError("id", ctx.Params("id"))
+	}
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	attempts, err := c.notificationService.ListUserAttempts(serviceCtx, user.ID, id)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(attempts, "Notification attempts retrieved successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// GetNotificationEvents retrieves the tracked opens and clicks of a notification
+func (c *NotificationController) GetNotificationEvents(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errorx.NewValidationError("id", ctx.Params("id"))
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	events, err := c.notificationService.ListTrackingEvents(serviceCtx, application, id)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(events, "Notification events retrieved successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
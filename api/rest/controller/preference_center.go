@@ -0,0 +1,223 @@
+package controller
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"hermes-api/internal/model"
+	"hermes-api/internal/service"
+	"hermes-api/pkg/context"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/logger"
+	"hermes-api/pkg/response"
+	"html/template"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+//go:embed templates/*.html
+var pageTemplates embed.FS
+
+var (
+	// preferenceCenterPage renders the hosted preference center
+	preferenceCenterPage = parsePage("preference_center.html")
+	// unsubscribePage renders the confirmation of unsubscribe links
+	unsubscribePage = parsePage("unsubscribe.html")
+)
+
+// preferenceCenterView is the data of the preference center page
+type preferenceCenterView struct {
+	*service.PreferenceCenter
+	Saved bool
+	Error string
+}
+
+// unsubscribeView is the data of the unsubscribe page
+type unsubscribeView struct {
+	*service.Unsubscription
+	Done  bool
+	Error string
+}
+
+// PreferenceCenterController handles the hosted preference center and its links
+type PreferenceCenterController struct {
+	preferenceCenterService service.PreferenceCenterService
+}
+
+// NewPreferenceCenterController creates a new preference center controller
+func NewPreferenceCenterController(preferenceCenterService service.PreferenceCenterService) *PreferenceCenterController {
+	return &PreferenceCenterController{
+		preferenceCenterService: preferenceCenterService,
+	}
+}
+
+// CreateLink issues a signed, expiring preference center link for a contact
+func (c *PreferenceCenterController) CreateLink(ctx *fiber.Ctx) error {
+	externalID, err := contactIDParam(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	link, err := c.preferenceCenterService.CreateLink(serviceCtx, application, externalID)
+	if err != nil {
+		return err
+	}
+
+	return response.CreatedResponse(link, "Preference link created successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// ShowPage renders the preference center of the contact a link was issued for
+func (c *PreferenceCenterController) ShowPage(ctx *fiber.Ctx) error {
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	center, err := c.preferenceCenterService.GetPreferenceCenter(serviceCtx, ctx.Params("token"))
+	if err != nil {
+		return renderPreferenceCenter(ctx, preferenceCenterView{}, err)
+	}
+
+	return renderPreferenceCenter(ctx, preferenceCenterView{PreferenceCenter: center}, nil)
+}
+
+// SavePage saves the choices submitted from the preference center form
+func (c *PreferenceCenterController) SavePage(ctx *fiber.Ctx) error {
+	enabled := make(map[string]bool)
+	for _, value := range ctx.Request().PostArgs().PeekMulti("pref") {
+		enabled[string(value)] = true
+	}
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	center, err := c.preferenceCenterService.SavePreferenceCenter(serviceCtx, ctx.Params("token"), enabled)
+	if err != nil {
+		return renderPreferenceCenter(ctx, preferenceCenterView{}, err)
+	}
+
+	return renderPreferenceCenter(ctx, preferenceCenterView{PreferenceCenter: center, Saved: true}, nil)
+}
+
+// ShowUnsubscribe asks the recipient of an unsubscribe link to confirm, so that link scanners
+// following it do not unsubscribe anyone
+func (c *PreferenceCenterController) ShowUnsubscribe(ctx *fiber.Ctx) error {
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	unsubscription, err := c.preferenceCenterService.GetUnsubscription(serviceCtx, ctx.Params("token"))
+	if err != nil {
+		return renderUnsubscribe(ctx, unsubscribeView{}, err)
+	}
+
+	return renderUnsubscribe(ctx, unsubscribeView{Unsubscription: unsubscription}, nil)
+}
+
+// Unsubscribe opts the contact out, it serves both the confirmation form and the RFC 8058
+// one-click POST sent by mailbox providers
+func (c *PreferenceCenterController) Unsubscribe(ctx *fiber.Ctx) error {
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	unsubscription, err := c.preferenceCenterService.Unsubscribe(serviceCtx, ctx.Params("token"))
+	if err != nil {
+		return renderUnsubscribe(ctx, unsubscribeView{}, err)
+	}
+
+	return renderUnsubscribe(ctx, unsubscribeView{Unsubscription: unsubscription, Done: true}, nil)
+}
+
+// renderPreferenceCenter writes the preference center page, or the page with an error message when err is set
+func renderPreferenceCenter(ctx *fiber.Ctx, view preferenceCenterView, err error) error {
+	if view.PreferenceCenter == nil {
+		view.PreferenceCenter = &service.PreferenceCenter{}
+	}
+	status := fiber.StatusOK
+	if err != nil {
+		status, view.Error = pageError(err)
+	}
+	return renderPage(ctx, preferenceCenterPage, status, view)
+}
+
+// renderUnsubscribe writes the unsubscribe page, or the page with an error message when err is set
+func renderUnsubscribe(ctx *fiber.Ctx, view unsubscribeView, err error) error {
+	if view.Unsubscription == nil {
+		view.Unsubscription = &service.Unsubscription{}
+	}
+	status := fiber.StatusOK
+	if err != nil {
+		status, view.Error = pageError(err)
+	}
+	return renderPage(ctx, unsubscribePage, status, view)
+}
+
+// pageError returns the status and the message shown to recipients for an error, internal
+// errors are logged and not disclosed
+func pageError(err error) (int, string) {
+	var appErr *errorx.AppError
+	if errors.As(err, &appErr) && appErr.Type != errorx.ErrorTypeInternal {
+		return appErr.GetHTTPStatus(), appErr.Message
+	}
+
+	logger.Error("Failed to serve public page", err)
+	return fiber.StatusInternalServerError, "Something went wrong, please try again later."
+}
+
+// renderPage writes a public HTML page
+func renderPage(ctx *fiber.Ctx, page *template.Template, status int, view any) error {
+	var content bytes.Buffer
+	if err := page.ExecuteTemplate(&content, "layout", view); err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	ctx.Set("Referrer-Policy", "no-referrer") // the token must not leak to other sites
+	ctx.Type("html", "utf-8")
+	return ctx.Status(status).Send(content.Bytes())
+}
+
+// parsePage parses a public page with the shared layout
+func parsePage(name string) *template.Template {
+	return template.Must(template.New(name).Funcs(template.FuncMap{
+		"categoryLabel": categoryLabel,
+		"channelLabel":  channelLabel,
+	}).ParseFS(pageTemplates, "templates/layout.html", "templates/"+name))
+}
+
+// categoryLabel turns a category such as "product-updates" into "Product updates"
+func categoryLabel(category string) string {
+	label := strings.NewReplacer("-", " ", "_", " ").Replace(category)
+	if label == "" {
+		return label
+	}
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
+// channelLabel returns the display name of a channel
+func channelLabel(channel model.Channel) string {
+	switch channel {
+	case model.ChannelEmail:
+		return "Email"
+	case model.ChannelSMS:
+		return "SMS"
+	case model.ChannelPush:
+		return "Push notifications"
+	case model.ChannelSlack:
+		return "Slack"
+	case model.ChannelWebhook:
+		return "Webhook"
+	}
+	return string(channel)
+}
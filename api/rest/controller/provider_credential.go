@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"hermes-api/internal/dto"
+	"hermes-api/internal/model"
+	"hermes-api/internal/service"
+	"hermes-api/pkg/context"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ProviderCredentialController handles HTTP requests for the provider credentials of applications
+type ProviderCredentialController struct {
+	providerCredentialService service.ProviderCredentialService
+}
+
+// NewProviderCredentialController creates a new provider credential controller
+func NewProviderCredentialController(providerCredentialService service.ProviderCredentialService) *ProviderCredentialController {
+	return &ProviderCredentialController{
+		providerCredentialService: providerCredentialService,
+	}
+}
+
+// SetProviderCredential configures the provider of a channel of an application
+func (c *ProviderCredentialController) SetProviderCredential(ctx *fiber.Ctx) error {
+	channel := model.Channel(ctx.Params("channel"))
+	if !channel.IsValid() {
+		return errorx.NewValidationError("channel", ctx.Params("channel"))
+	}
+
+	var req dto.SetProviderCredentialRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		appErr := errorx.New(errorx.ErrorTypeBadRequest, errorx.ErrorCodeInvalidFormat, err.Error())
+		return appErr // return the error to the middleware
+	}
+
+	if err := req.Validate(); err != nil {
+		return err // return the error to the middleware
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	credential, err := c.providerCredentialService.SetProviderCredential(serviceCtx, application, channel, req)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(credential, "Provider credential saved successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// GetProviderCredentials retrieves the provider credentials of an application, without their secrets
+func (c *ProviderCredentialController) GetProviderCredentials(ctx *fiber.Ctx) error {
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	credentials, err := c.providerCredentialService.ListProviderCredentials(serviceCtx, application)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(credentials, "Provider credentials retrieved successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// DeleteProviderCredential removes the provider of a channel of an application
+func (c *ProviderCredentialController) DeleteProviderCredential(ctx *fiber.Ctx) error {
+	channel := model.Channel(ctx.Params("channel"))
+	if !channel.IsValid() {
+		return errorx.NewValidationError("channel", ctx.Params("channel"))
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	if err := c.providerCredentialService.DeleteProviderCredential(serviceCtx, application, channel); err != nil {
+		return err
+	}
+
+	return response.NoContentResponse("Provider credential deleted successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
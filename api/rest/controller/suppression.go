@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"hermes-api/internal/dto"
+	"hermes-api/internal/model"
+	"hermes-api/internal/service"
+	"hermes-api/pkg/context"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// SuppressionController handles HTTP requests for suppression list operations
+type SuppressionController struct {
+	suppressionService service.SuppressionService
+}
+
+// NewSuppressionController creates a new suppression controller
+func NewSuppressionController(suppressionService service.SuppressionService) *SuppressionController {
+	return &SuppressionController{
+		suppressionService: suppressionService,
+	}
+}
+
+// CreateSuppression manually adds an address to the suppression list
+func (c *SuppressionController) CreateSuppression(ctx *fiber.Ctx) error {
+	var req dto.CreateSuppressionRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		appErr := errorx.New(errorx.ErrorTypeBadRequest, errorx.ErrorCodeInvalidFormat, err.Error())
+		return appErr // return the error to the middleware
+	}
+
+	if err := req.Validate(); err != nil {
+		return err // return the error to the middleware
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	suppression, err := c.suppressionService.CreateSuppression(serviceCtx, application, req)
+	if err != nil {
+		return err
+	}
+
+	return response.CreatedResponse(suppression, "Suppression created successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// GetSuppressions retrieves the suppression list of an application with pagination, optionally filtered by channel
+func (c *SuppressionController) GetSuppressions(ctx *fiber.Ctx) error {
+	limit, offset := paginationParams(ctx)
+
+	channel := model.Channel(ctx.Query("channel"))
+	if channel != "" && !channel.IsValid() {
+		return errorx.NewValidationError("channel", string(channel))
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	suppressions, err := c.suppressionService.ListSuppressions(serviceCtx, application, channel, limit+1, offset)
+	if err != nil {
+		return err
+	}
+
+	hasNext := len(suppressions) > limit
+	if hasNext {
+		suppressions = suppressions[:limit]
+	}
+
+	return response.SuccessResponse(suppressions, "Suppressions retrieved successfully").
+		WithMeta(&response.MetaInfo{
+			Limit:   limit,
+			HasNext: hasNext,
+			HasPrev: offset > 0,
+		}).
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// GetSuppressionByID retrieves a suppression by ID
+func (c *SuppressionController) GetSuppressionByID(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errorx.NewValidationError("id", ctx.Params("id"))
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	suppression, err := c.suppressionService.GetSuppression(serviceCtx, application, id)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(suppression, "Suppression retrieved successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// DeleteSuppression removes an address from the suppression list
+func (c *SuppressionController) DeleteSuppression(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errorx.NewValidationError("id", ctx.Params("id"))
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	if err := c.suppressionService.DeleteSuppression(serviceCtx, application, id); err != nil {
+		return err
+	}
+
+	return response.NoContentResponse("Suppression deleted successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
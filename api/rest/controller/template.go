@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"hermes-api/internal/dto"
+	"hermes-api/internal/model"
+	"hermes-api/internal/service"
+	"hermes-api/pkg/context"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/response"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// TemplateController handles HTTP requests for template operations
+type TemplateController struct {
+	templateService service.TemplateService
+}
+
+// NewTemplateController creates a new template controller
+func NewTemplateController(templateService service.TemplateService) *TemplateController {
+	return &TemplateController{
+		templateService: templateService,
+	}
+}
+
+// CreateTemplate creates a new template with its locale variants
+func (c *TemplateController) CreateTemplate(ctx *fiber.Ctx) error {
+	var req dto.CreateTemplateRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		appErr := errorx.New(errorx.ErrorTypeBadRequest, errorx.ErrorCodeInvalidFormat, err.Error())
+		return appErr // return the error to the middleware
+	}
+
+	if err := req.Validate(); err != nil {
+		return err // return the error to the middleware
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	template, err := c.templateService.CreateTemplate(serviceCtx, application, req)
+	if err != nil {
+		return err
+	}
+
+	return response.CreatedResponse(template, "Template created successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// GetTemplates retrieves the templates of an application with pagination
+func (c *TemplateController) GetTemplates(ctx *fiber.Ctx) error {
+	limit, offset := paginationParams(ctx)
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	templates, err := c.templateService.ListTemplates(serviceCtx, application, limit+1, offset)
+	if err != nil {
+		return err
+	}
+
+	hasNext := len(templates) > limit
+	if hasNext {
+		templates = templates[:limit]
+	}
+
+	return response.SuccessResponse(templates, "Templates retrieved successfully").
+		WithMeta(&response.MetaInfo{
+			Limit:   limit,
+			HasNext: hasNext,
+			HasPrev: offset > 0,
+		}).
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// GetTemplateByID retrieves a template by ID
+func (c *TemplateController) GetTemplateByID(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errorx.NewValidationError("id", ctx.Params("id"))
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	template, err := c.templateService.GetTemplateByID(serviceCtx, application, id)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(template, "Template retrieved successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// RenderTemplate renders a template for a locale without sending it
+func (c *TemplateController) RenderTemplate(ctx *fiber.Ctx) error {
+	id, err := uuid.Parse(ctx.Params("id"))
+	if err != nil {
+		return errorx.NewValidationError("id", ctx.Params("id"))
+	}
+
+	var req dto.RenderTemplateRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		appErr := errorx.New(errorx.ErrorTypeBadRequest, errorx.ErrorCodeInvalidFormat, err.Error())
+		return appErr // return the error to the middleware
+	}
+
+	if err := req.Validate(); err != nil {
+		return err // return the error to the middleware
+	}
+
+	// Get application from context (set by application access middleware)
+	application := ctx.Locals("application").(*model.Application)
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	result, err := c.templateService.RenderTemplate(serviceCtx, application, id, req)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(result, "Template rendered successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
+
+// paginationParams parses the limit and offset query parameters
+func paginationParams(ctx *fiber.Ctx) (int, int) {
+	limit := 10 // default limit
+	offset := 0 // default offset
+
+	if limitStr := ctx.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	if offsetStr := ctx.Query("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	return limit, offset
+}
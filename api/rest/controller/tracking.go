@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"hermes-api/internal/service"
+	"hermes-api/pkg/context"
+	"hermes-api/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// trackingPixel is a transparent 1x1 GIF
+var trackingPixel = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// TrackingController handles the open tracking pixels and tracked links of emails
+type TrackingController struct {
+	trackingService service.TrackingService
+}
+
+// NewTrackingController creates a new tracking controller
+func NewTrackingController(trackingService service.TrackingService) *TrackingController {
+	return &TrackingController{
+		trackingService: trackingService,
+	}
+}
+
+// Open records the open of an email and serves the tracking pixel. The pixel is served whatever
+// the outcome, a broken image in the message would tell the recipient nothing useful.
+func (c *TrackingController) Open(ctx *fiber.Ctx) error {
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	if err := c.trackingService.RecordOpen(serviceCtx, ctx.Params("token"), trackingClient(ctx)); err != nil {
+		logger.Debug("Open not recorded: " + err.Error())
+	}
+
+	ctx.Set(fiber.HeaderCacheControl, "no-store, no-cache, must-revalidate, private")
+	ctx.Set(fiber.HeaderContentType, "image/gif")
+	return ctx.Status(fiber.StatusOK).Send(trackingPixel)
+}
+
+// Click records the click of a tracked link and redirects to its target
+func (c *TrackingController) Click(ctx *fiber.Ctx) error {
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	target, err := c.trackingService.RecordClick(serviceCtx, ctx.Params("token"), trackingClient(ctx))
+	if target == "" {
+		status, message := pageError(err)
+		return ctx.Status(status).SendString(message)
+	}
+	if err != nil {
+		logger.Error("Failed to record click", err)
+	}
+
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	ctx.Set("Referrer-Policy", "no-referrer") // the token must not leak to the target site
+	return ctx.Redirect(target, fiber.StatusFound)
+}
+
+// trackingClient describes the client of a tracking request
+func trackingClient(ctx *fiber.Ctx) service.TrackingClient {
+	return service.TrackingClient{
+		Method:    ctx.Method(),
+		UserAgent: ctx.Get(fiber.HeaderUserAgent),
+	}
+}
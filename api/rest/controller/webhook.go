@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"net/http"
+
+	"hermes-api/internal/provider"
+	"hermes-api/internal/service"
+	"hermes-api/pkg/context"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// WebhookController handles the delivery events posted by providers
+type WebhookController struct {
+	deliveryEventService service.DeliveryEventService
+}
+
+// NewWebhookController creates a new webhook controller
+func NewWebhookController(deliveryEventService service.DeliveryEventService) *WebhookController {
+	return &WebhookController{
+		deliveryEventService: deliveryEventService,
+	}
+}
+
+// ReceiveEvents applies the delivery events of a provider webhook, authenticated by the provider's signature.
+// Webhooks of an application's own provider are posted with the ID of its provider credential.
+func (c *WebhookController) ReceiveEvents(ctx *fiber.Ctx) error {
+	var credentialID *uuid.UUID
+	if param := ctx.Params("credential_id"); param != "" {
+		id, err := uuid.Parse(param)
+		if err != nil {
+			return errorx.NewValidationError("credential_id", param)
+		}
+		credentialID = &id
+	}
+
+	// The signed URL is rebuilt from the public URL by the service, the path seen here may have been
+	// rewritten by a proxy
+	request := &provider.InboundRequest{
+		RawQuery: string(ctx.Request().URI().QueryString()),
+		Header:   http.Header(ctx.GetReqHeaders()),
+		Body:     ctx.Body(),
+	}
+
+	// Create a new context for the service
+	serviceCtx, cancel := context.New(ctx).WithDefaultTimeout().Build()
+	defer cancel()
+
+	result, err := c.deliveryEventService.HandleWebhook(serviceCtx, ctx.Params("driver"), credentialID, request)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessResponse(result, "Webhook processed successfully").
+		WithRequestID(ctx.Locals("X-Request-ID").(string)).
+		Send(ctx)
+}
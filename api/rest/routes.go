@@ -2,6 +2,8 @@ package rest
 
 import (
 	"hermes-api/api/rest/controller"
+	"hermes-api/internal/middleware"
+	"hermes-api/internal/model"
 	"hermes-api/internal/service"
 
 	"github.com/gofiber/fiber/v2"
@@ -10,11 +12,33 @@ import (
 // SetupRoutes configures all API routes
 func SetupRoutes(api fiber.Router, serviceManager service.ServiceManager, authMiddleware fiber.Handler) {
 	controllerManager := controller.NewControllerManager(serviceManager)
-	setupV1Routes(api, controllerManager, authMiddleware)
+	applicationAccess := middleware.ApplicationAccess(serviceManager.Application())
+	setupV1Routes(api, controllerManager, authMiddleware, applicationAccess)
+}
+
+// SetupPublicRoutes configures the pages opened by recipients from links in notifications, they are
+// authenticated by the signed token of the link
+func SetupPublicRoutes(app fiber.Router, serviceManager service.ServiceManager) {
+	preferenceCenterController := controller.NewPreferenceCenterController(serviceManager.PreferenceCenter())
+
+	preferences := app.Group("/preferences")
+	preferences.Get("/:token", preferenceCenterController.ShowPage)
+	preferences.Post("/:token", preferenceCenterController.SavePage)
+
+	unsubscribe := app.Group("/unsubscribe")
+	unsubscribe.Get("/:token", preferenceCenterController.ShowUnsubscribe)
+	unsubscribe.Post("/:token", preferenceCenterController.Unsubscribe)
+
+	// Email open and click tracking, enabled per application; HEAD requests are served by the GET routes
+	trackingController := controller.NewTrackingController(serviceManager.Tracking())
+
+	tracking := app.Group("/t")
+	tracking.Get("/open/:token", trackingController.Open)
+	tracking.Get("/click/:token", trackingController.Click)
 }
 
 // setupV1Routes configures API v1 routes
-func setupV1Routes(api fiber.Router, controllerManager *controller.ControllerManager, authMiddleware fiber.Handler) {
+func setupV1Routes(api fiber.Router, controllerManager *controller.ControllerManager, authMiddleware, applicationAccess fiber.Handler) {
 	// Auth routes (public)
 	setupAuthRoutes(api, controllerManager.Auth())
 
@@ -23,6 +47,31 @@ func setupV1Routes(api fiber.Router, controllerManager *controller.ControllerMan
 
 	// Applications routes (protected)
 	setupApplicationRoutes(api, controllerManager.Application(), authMiddleware)
+
+	// Notification search across the applications of the user (protected)
+	setupNotificationSearchRoutes(api, controllerManager.Notification(), authMiddleware)
+
+	// Delivery analytics across the applications of the user (protected)
+	setupUserAnalyticsRoutes(api, controllerManager.Analytics(), authMiddleware)
+
+	// Provider webhook routes (public, authenticated by the provider's signature)
+	setupWebhookRoutes(api, controllerManager.Webhook())
+
+	// Admin routes (protected, platform administrators only)
+	setupAdminRoutes(api, controllerManager.Admin(), authMiddleware)
+
+	// Application-scoped routes (protected by the applications group auth middleware, require application ownership)
+	application := api.Group("/applications/:app_id", applicationAccess)
+	setupApplicationSettingsRoutes(application, controllerManager.Application())
+	setupTemplateRoutes(application, controllerManager.Template())
+	setupNotificationRoutes(application, controllerManager.Notification())
+	setupFileRoutes(application, controllerManager.File())
+	setupContactRoutes(application, controllerManager.Contact(), controllerManager.PreferenceCenter())
+	setupSuppressionRoutes(application, controllerManager.Suppression())
+	setupFrequencyCapRoutes(application, controllerManager.FrequencyCap())
+	setupDigestRoutes(application, controllerManager.Digest())
+	setupProviderCredentialRoutes(application, controllerManager.ProviderCredential())
+	setupAnalyticsRoutes(application, controllerManager.Analytics())
 }
 
 // setupAuthRoutes configures authentication-related routes
@@ -57,3 +106,143 @@ func setupApplicationRoutes(api fiber.Router, applicationController *controller.
 
 	applications.Post("/", applicationController.CreateApplication)
 }
+
+// setupAdminRoutes configures platform administration routes
+func setupAdminRoutes(api fiber.Router, adminController *controller.AdminController, authMiddleware fiber.Handler) {
+	admin := api.Group("/admin")
+
+	// Apply auth middleware and require an administrator role to all admin routes
+	admin.Use(authMiddleware, middleware.RequireRole(model.UserRoleSuperAdmin, model.UserRoleAdmin))
+
+	admin.Get("/providers", adminController.GetProviderHealth)
+	admin.Post("/credential-keys/rotate", adminController.RotateCredentialKeys)
+}
+
+// setupWebhookRoutes configures the routes providers post delivery events to, by driver
+func setupWebhookRoutes(api fiber.Router, webhookController *controller.WebhookController) {
+	webhooks := api.Group("/webhooks")
+
+	webhooks.Post("/:driver", webhookController.ReceiveEvents)
+	webhooks.Post("/:driver/:credential_id", webhookController.ReceiveEvents)
+}
+
+// setupApplicationSettingsRoutes configures the settings routes of an application
+func setupApplicationSettingsRoutes(application fiber.Router, applicationController *controller.ApplicationController) {
+	application.Patch("/settings", applicationController.UpdateSettings)
+	application.Put("/quiet-hours", applicationController.SetQuietHours)
+	application.Delete("/quiet-hours", applicationController.DeleteQuietHours)
+}
+
+// setupTemplateRoutes configures template-related routes of an application
+func setupTemplateRoutes(application fiber.Router, templateController *controller.TemplateController) {
+	templates := application.Group("/templates")
+
+	templates.Get("/", templateController.GetTemplates)
+	templates.Post("/", templateController.CreateTemplate)
+	templates.Get("/:id", templateController.GetTemplateByID)
+	templates.Post("/:id/render", templateController.RenderTemplate)
+}
+
+// setupNotificationRoutes configures notification-related routes of an application
+func setupNotificationRoutes(application fiber.Router, notificationController *controller.NotificationController) {
+	notifications := application.Group("/notifications")
+
+	notifications.Get("/", notificationController.GetNotifications)
+	notifications.Post("/", notificationController.SendNotification)
+	notifications.Get("/:id", notificationController.GetNotificationByID)
+	notifications.Get("/:id/attempts", notificationController.GetNotificationAttempts)
+	notifications.Get("/:id/events", notificationController.GetNotificationEvents)
+}
+
+// setupNotificationSearchRoutes configures the search and lookup of notifications across the applications of the user
+func setupNotificationSearchRoutes(api fiber.Router, notificationController *controller.NotificationController, authMiddleware fiber.Handler) {
+	notifications := api.Group("/notifications")
+
+	// Apply auth middleware to all notification search routes
+	notifications.Use(authMiddleware)
+
+	notifications.Get("/", notificationController.SearchNotifications)
+	notifications.Get("/:id", notificationController.GetUserNotificationByID)
+	notifications.Get("/:id/attempts", notificationController.GetUserNotificationAttempts)
+}
+
+// setupUserAnalyticsRoutes configures the delivery analytics across the applications of the user
+func setupUserAnalyticsRoutes(api fiber.Router, analyticsController *controller.AnalyticsController, authMiddleware fiber.Handler) {
+	analytics := api.Group("/analytics")
+
+	// Apply auth middleware to all analytics routes
+	analytics.Use(authMiddleware)
+
+	analytics.Get("/", analyticsController.GetUserAnalytics)
+}
+
+// setupFileRoutes configures file-related routes of an application
+func setupFileRoutes(application fiber.Router, fileController *controller.FileController) {
+	files := application.Group("/files")
+
+	files.Post("/", fileController.UploadFile)
+	files.Get("/:id", fileController.GetFileByID)
+}
+
+// setupContactRoutes configures contact-related routes of an application, contacts are addressed by their external ID
+func setupContactRoutes(application fiber.Router, contactController *controller.ContactController, preferenceCenterController *controller.PreferenceCenterController) {
+	contacts := application.Group("/contacts")
+
+	contacts.Get("/", contactController.GetContacts)
+	contacts.Post("/", contactController.CreateContact)
+
+	// Bulk operations, registered before the contact routes they would otherwise match
+	contacts.Get("/export", contactController.ExportContacts)
+	contacts.Post("/imports", contactController.ImportContacts)
+	contacts.Get("/imports/:id", contactController.GetContactImport)
+	contacts.Get("/imports/:id/errors", contactController.DownloadContactImportErrors)
+
+	contacts.Get("/:contact_id", contactController.GetContact)
+	contacts.Put("/:contact_id", contactController.UpdateContact)
+	contacts.Delete("/:contact_id", contactController.DeleteContact)
+	contacts.Get("/:contact_id/preferences", contactController.GetPreferences)
+	contacts.Put("/:contact_id/preferences", contactController.UpdatePreferences)
+	contacts.Post("/:contact_id/preference-link", preferenceCenterController.CreateLink)
+}
+
+// setupSuppressionRoutes configures suppression list routes of an application
+func setupSuppressionRoutes(application fiber.Router, suppressionController *controller.SuppressionController) {
+	suppressions := application.Group("/suppressions")
+
+	suppressions.Get("/", suppressionController.GetSuppressions)
+	suppressions.Post("/", suppressionController.CreateSuppression)
+	suppressions.Get("/:id", suppressionController.GetSuppressionByID)
+	suppressions.Delete("/:id", suppressionController.DeleteSuppression)
+}
+
+// setupFrequencyCapRoutes configures frequency cap routes of an application
+func setupFrequencyCapRoutes(application fiber.Router, frequencyCapController *controller.FrequencyCapController) {
+	frequencyCaps := application.Group("/frequency-caps")
+
+	frequencyCaps.Get("/", frequencyCapController.GetFrequencyCaps)
+	frequencyCaps.Post("/", frequencyCapController.CreateFrequencyCap)
+	frequencyCaps.Delete("/:id", frequencyCapController.DeleteFrequencyCap)
+}
+
+// setupDigestRoutes configures digest rule routes of an application
+func setupDigestRoutes(application fiber.Router, digestController *controller.DigestController) {
+	digestRules := application.Group("/digest-rules")
+
+	digestRules.Get("/", digestController.GetDigestRules)
+	digestRules.Post("/", digestController.CreateDigestRule)
+	digestRules.Delete("/:id", digestController.DeleteDigestRule)
+}
+
+// setupProviderCredentialRoutes configures the routes of the providers of an application, addressed by channel
+func setupProviderCredentialRoutes(application fiber.Router, providerCredentialController *controller.ProviderCredentialController) {
+	providers := application.Group("/providers")
+
+	providers.Get("/", providerCredentialController.GetProviderCredentials)
+	providers.Put("/:channel", providerCredentialController.SetProviderCredential)
+	providers.Delete("/:channel", providerCredentialController.DeleteProviderCredential)
+}
+
+// setupAnalyticsRoutes configures the delivery analytics routes of an application
+func setupAnalyticsRoutes(application fiber.Router, analyticsController *controller.AnalyticsController) {
+	application.Get("/analytics", analyticsController.GetAnalytics)
+}
@@ -8,6 +8,7 @@ import (
 	"hermes-api/internal/repository"
 	"hermes-api/internal/service"
 	"hermes-api/pkg/logger"
+	"hermes-api/pkg/storage"
 	"log"
 	"os"
 	"os/signal"
@@ -70,6 +71,9 @@ func setupRoutes(app *fiber.App, serviceManager service.ServiceManager, authMidd
 	// API v1 routes
 	api := app.Group("/api/v1")
 	rest.SetupRoutes(api, serviceManager, authMiddleware)
+
+	// Public pages linked from notifications
+	rest.SetupPublicRoutes(app, serviceManager)
 }
 
 // setupDatabase initializes the database connection
@@ -125,11 +129,25 @@ func main() {
 		logger.Fatal("❌ Failed to setup database", err)
 	}
 
+	// Connect to Redis when enabled, shared counters are kept in PostgreSQL otherwise
+	if err := database.ConnectRedis(&cfg.Redis); err != nil {
+		logger.Fatal("❌ Failed to connect to Redis", err)
+	}
+
 	// Initialize repositories
 	repoManager := repository.NewRepositoryManager(database.DB)
 
+	// Initialize file storage
+	fileStorage, err := storage.New(storage.Config{
+		Driver:    cfg.Storage.Driver,
+		LocalPath: cfg.Storage.LocalPath,
+	})
+	if err != nil {
+		logger.Fatal("❌ Failed to initialize file storage", err)
+	}
+
 	// Initialize services
-	serviceManager := service.NewServiceManager(repoManager, cfg.Security.JWTSecret)
+	serviceManager := service.NewServiceManager(repoManager, cfg, fileStorage, database.Redis)
 
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
@@ -137,6 +155,7 @@ func main() {
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
 		IdleTimeout:  cfg.Server.IdleTimeout,
+		BodyLimit:    cfg.Server.BodyLimit,
 	})
 
 	// Setup middleware
@@ -170,7 +189,10 @@ func main() {
 		logger.Fatal("Server forced to shutdown", err)
 	}
 
-	// Close database connection
+	// Close Redis and database connections
+	if err := database.CloseRedis(); err != nil {
+		logger.Error("Failed to close Redis connection", err)
+	}
 	if err := database.Close(); err != nil {
 		logger.Error("Failed to close database connection", err)
 	} else {
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hermes-api/config"
+	"hermes-api/internal/database"
+	"hermes-api/internal/model"
+	"hermes-api/internal/provider"
+	"hermes-api/internal/repository"
+	"hermes-api/internal/service"
+	"hermes-api/internal/worker"
+	"hermes-api/pkg/logger"
+	"hermes-api/pkg/ratelimit"
+	"hermes-api/pkg/storage"
+	"log"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+// setupProviders registers the configured delivery providers of each channel, each wrapped with a circuit
+// breaker and its rate limit, and creates the pool of the providers configured by applications, which
+// are wrapped with a circuit breaker of their own and the rate limit of their credential
+func setupProviders(cfg *config.Config) (*provider.Registry, *provider.Pool, error) {
+	withBreaker := func(channelProvider provider.Provider, channel model.Channel) provider.Provider {
+		return provider.NewCircuitBreaker(channelProvider, channel, provider.BreakerConfig{
+			FailureThreshold: cfg.CircuitBreaker.FailureThreshold,
+			OpenTimeout:      cfg.CircuitBreaker.OpenTimeout,
+			SuccessThreshold: cfg.CircuitBreaker.SuccessThreshold,
+		})
+	}
+
+	// Rate limits are shared by the workers through Redis, or kept by each worker otherwise
+	var bucket ratelimit.TokenBucket = ratelimit.NewLocalTokenBucket()
+	if database.Redis != nil {
+		bucket = ratelimit.NewRedisTokenBucket(database.Redis, "hermes:throttle:")
+	}
+
+	registry := provider.NewRegistry()
+	for _, providerCfg := range cfg.Providers {
+		channelProvider, err := provider.New(providerCfg.Driver, providerCfg.Name, provider.Credentials{
+			Sender:   providerCfg.Sender,
+			Settings: providerCfg.Settings,
+			Secrets:  providerCfg.Secrets,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		channel := model.Channel(providerCfg.Channel)
+		if !provider.SupportsChannel(providerCfg.Driver, channel) {
+			return nil, nil, fmt.Errorf("driver '%s' of provider '%s' does not deliver channel '%s'", providerCfg.Driver, providerCfg.Name, channel)
+		}
+		channelProvider = withBreaker(channelProvider, channel)
+
+		// Throttled sends never reach the circuit breaker
+		if rateLimit := providerCfg.RateLimit; rateLimit.Limit > 0 {
+			channelProvider = provider.NewThrottle(channelProvider, channel, bucket, provider.ThrottleConfig{
+				Limit:     rateLimit.Limit,
+				Per:       rateLimit.Per,
+				Burst:     rateLimit.Burst,
+				PerSender: rateLimit.PerSender,
+				MaxWait:   cfg.Notifications.ThrottleWait,
+			})
+		}
+
+		registry.Register(channel, channelProvider, providerCfg.Priority, providerCfg.Weight)
+	}
+
+	pool := provider.NewPool(func(channelProvider provider.Provider, credential *model.ProviderCredential) provider.Provider {
+		channelProvider = withBreaker(channelProvider, credential.Channel)
+
+		// Keyed by credential, applications may name their providers alike
+		if rateLimit := credential.RateLimit; rateLimit != nil && rateLimit.Limit > 0 {
+			channelProvider = provider.NewThrottle(channelProvider, credential.Channel, bucket, provider.ThrottleConfig{
+				Key:       "credential:" + credential.ID.String(),
+				Limit:     rateLimit.Limit,
+				Per:       rateLimit.Per(),
+				Burst:     rateLimit.Burst,
+				PerSender: rateLimit.PerSender,
+				MaxWait:   cfg.Notifications.ThrottleWait,
+			})
+		}
+		return channelProvider
+	})
+	return registry, pool, nil
+}
+
+func main() {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("❌ Failed to load configuration: %v", err)
+	}
+
+	// Initialize logger
+	if err := logger.Init(cfg.Logging.Level, cfg.Logging.Format); err != nil {
+		log.Fatalf("❌ Failed to initialize logger: %v", err)
+	}
+
+	defer func() {
+		if err := logger.Sync(); err != nil {
+			log.Printf("Failed to sync logger: %v", err)
+		}
+	}()
+
+	logger.Info("🚀 Starting Hermes delivery worker",
+		zap.String("environment", cfg.Server.Environment),
+		zap.Int("workers", cfg.Notifications.Workers),
+	)
+
+	// Connect to database, migrations are run by the REST server
+	if err := database.Connect(&cfg.Database); err != nil {
+		logger.Fatal("❌ Failed to connect to database", err)
+	}
+
+	// Connect to Redis when enabled, shared counters are kept in PostgreSQL otherwise
+	if err := database.ConnectRedis(&cfg.Redis); err != nil {
+		logger.Fatal("❌ Failed to connect to Redis", err)
+	}
+
+	// Initialize repositories
+	repoManager := repository.NewRepositoryManager(database.DB)
+
+	// Initialize file storage
+	fileStorage, err := storage.New(storage.Config{
+		Driver:    cfg.Storage.Driver,
+		LocalPath: cfg.Storage.LocalPath,
+	})
+	if err != nil {
+		logger.Fatal("❌ Failed to initialize file storage", err)
+	}
+
+	// Initialize services
+	serviceManager := service.NewServiceManager(repoManager, cfg, fileStorage, database.Redis)
+	providers, applicationProviders, err := setupProviders(cfg)
+	if err != nil {
+		logger.Fatal("❌ Failed to set up providers", err)
+	}
+	deliveryService := service.NewDeliveryService(repoManager.Notification(), repoManager.Template(), serviceManager.File(), serviceManager.PreferenceCenter(),
+		serviceManager.Suppression(), serviceManager.FrequencyCap(), serviceManager.ProviderHealth(), serviceManager.ProviderCredential(),
+		serviceManager.Tracking(), providers, applicationProviders, cfg.Notifications)
+
+	// Run until an interrupt signal is received
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	worker.New(repoManager.Notification(), deliveryService, serviceManager.Digest(), serviceManager.Analytics(), serviceManager.ProviderHealth(),
+		cfg.Notifications, cfg.Analytics, cfg.ProviderHealth).Run(ctx)
+
+	// Close Redis and database connections
+	if err := database.CloseRedis(); err != nil {
+		logger.Error("Failed to close Redis connection", err)
+	}
+	if err := database.Close(); err != nil {
+		logger.Error("Failed to close database connection", err)
+	} else {
+		logger.Info("✅ Database connection closed")
+	}
+
+	logger.Info("✅ Worker exited gracefully")
+}
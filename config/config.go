@@ -5,16 +5,27 @@ import (
 	"strings"
 	"time"
 
+	"hermes-api/pkg/envelope"
+
 	"github.com/spf13/viper"
 )
 
 // Config holds application configuration
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	Logging  LoggingConfig  `mapstructure:"logging"`
-	Security SecurityConfig `mapstructure:"security"`
+	Server         ServerConfig         `mapstructure:"server"`
+	Database       DatabaseConfig       `mapstructure:"database"`
+	Redis          RedisConfig          `mapstructure:"redis"`
+	Logging        LoggingConfig        `mapstructure:"logging"`
+	Security       SecurityConfig       `mapstructure:"security"`
+	Storage        StorageConfig        `mapstructure:"storage"`
+	Attachments    AttachmentsConfig    `mapstructure:"attachments"`
+	Notifications  NotificationsConfig  `mapstructure:"notifications"`
+	Providers      []ProviderConfig     `mapstructure:"providers"`
+	ProviderHealth ProviderHealthConfig `mapstructure:"provider_health"`
+	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"`
+	Webhooks       WebhooksConfig       `mapstructure:"webhooks"`
+	Tracking       TrackingConfig       `mapstructure:"tracking"`
+	Analytics      AnalyticsConfig      `mapstructure:"analytics"`
 }
 
 // ServerConfig holds server-related configuration
@@ -25,6 +36,8 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
 	WriteTimeout time.Duration `mapstructure:"write_timeout"`
 	IdleTimeout  time.Duration `mapstructure:"idle_timeout"`
+	BodyLimit    int           `mapstructure:"body_limit"` // Maximum request body size in bytes
+	PublicURL    string        `mapstructure:"public_url"` // Base URL of the public links sent to recipients
 }
 
 // DatabaseConfig holds database-related configuration
@@ -39,10 +52,12 @@ type DatabaseConfig struct {
 
 // RedisConfig holds Redis-related configuration
 type RedisConfig struct {
+	Enabled  bool   `mapstructure:"enabled"` // Shared counters fall back to PostgreSQL when disabled
 	Host     string `mapstructure:"host"`
 	Port     string `mapstructure:"port"`
 	Password string `mapstructure:"password"`
 	DB       int    `mapstructure:"db"`
+	PoolSize int    `mapstructure:"pool_size"`
 }
 
 // LoggingConfig holds logging-related configuration
@@ -57,6 +72,110 @@ type SecurityConfig struct {
 	JWTSecret   string   `mapstructure:"jwt_secret"`
 	BcryptCost  int      `mapstructure:"bcrypt_cost"`
 	CORSOrigins []string `mapstructure:"cors_origins"`
+
+	// Signing of the public links sent to recipients (preference center, unsubscribe, tracking)
+	LinkSecret        string        `mapstructure:"link_secret"`
+	PreferenceLinkTTL time.Duration `mapstructure:"preference_link_ttl"`
+
+	// Envelope encryption of the provider credentials of applications. Credentials are sealed with the
+	// active key; older keys are kept to read credentials until they are rotated to the active key.
+	CredentialKeyID string            `mapstructure:"credential_key_id"`
+	CredentialKeys  map[string]string `mapstructure:"credential_keys"`
+}
+
+// StorageConfig holds file storage configuration
+type StorageConfig struct {
+	Driver    string `mapstructure:"driver"`
+	LocalPath string `mapstructure:"local_path"`
+}
+
+// AttachmentsConfig holds the platform-wide email attachment limits,
+// applications may override them with their own limits
+type AttachmentsConfig struct {
+	MaxSize      int64    `mapstructure:"max_size"` // Maximum total size of the attachments of a notification, in bytes
+	MaxCount     int      `mapstructure:"max_count"`
+	AllowedTypes []string `mapstructure:"allowed_types"`
+}
+
+// NotificationsConfig holds the configuration of the delivery worker
+type NotificationsConfig struct {
+	Workers           int           `mapstructure:"workers"`             // Number of notifications delivered concurrently
+	BatchSize         int           `mapstructure:"batch_size"`          // Number of queued notifications claimed per poll
+	PollInterval      time.Duration `mapstructure:"poll_interval"`       // Wait between polls when the queue is empty
+	DefaultRetryCount int           `mapstructure:"default_retry_count"` // Retries after the providers failed to send a notification
+	MaxRetryCount     int           `mapstructure:"max_retry_count"`     // Retries of a notification for any failure, including the database or the storage
+	RetryDelay        time.Duration `mapstructure:"retry_delay"`         // Delay before the first retry, doubled at each retry
+	DedupeWindow      time.Duration `mapstructure:"dedupe_window"`       // Default window in which duplicate notifications are dropped
+
+	// Priority lanes: critical notifications have their own workers and are claimed ahead of the other
+	// lanes, which share each batch by weight. A lane weighted zero only gets the capacity left over.
+	CriticalWorkers int            `mapstructure:"critical_workers"` // Workers reserved for critical notifications, on top of Workers
+	PriorityWeights map[string]int `mapstructure:"priority_weights"` // Weights of the high, normal and low lanes
+
+	ThrottleWait time.Duration `mapstructure:"throttle_wait"` // Longest a send waits for a provider rate limit before being deferred
+	ClaimLease   time.Duration `mapstructure:"claim_lease"`   // Claimed notifications still processing after the lease are requeued
+}
+
+// ProviderConfig configures a delivery provider of a channel. Several providers can serve the same
+// channel: the healthy providers with the lowest priority are used first, sharing traffic by weight.
+type ProviderConfig struct {
+	Name     string `mapstructure:"name"` // Unique within the channel
+	Channel  string `mapstructure:"channel"`
+	Driver   string `mapstructure:"driver"` // "log", "sendgrid" or "twilio"
+	Priority int    `mapstructure:"priority"`
+	Weight   int    `mapstructure:"weight"`
+
+	// Driver configuration, e.g. the default sender, the Twilio account SID and the API keys
+	Sender   string            `mapstructure:"sender"`
+	Settings map[string]any    `mapstructure:"settings"`
+	Secrets  map[string]string `mapstructure:"secrets"`
+
+	RateLimit ProviderRateLimitConfig `mapstructure:"rate_limit"`
+}
+
+// ProviderRateLimitConfig throttles the sends to a provider to Limit messages per Per, disabled when
+// Limit is zero. Buckets are shared by the workers through Redis when it is enabled.
+type ProviderRateLimitConfig struct {
+	Limit     int           `mapstructure:"limit"`
+	Per       time.Duration `mapstructure:"per"`
+	Burst     int           `mapstructure:"burst"`      // Defaults to Limit
+	PerSender bool          `mapstructure:"per_sender"` // Limits each sender identity separately
+}
+
+// ProviderHealthConfig holds the thresholds under which a provider is considered unhealthy
+type ProviderHealthConfig struct {
+	Window          time.Duration `mapstructure:"window"`           // Rolling window of the success rate and latency
+	MinSamples      int64         `mapstructure:"min_samples"`      // Providers with fewer sends in the window are healthy
+	MinSuccessRate  float64       `mapstructure:"min_success_rate"` // Between 0 and 1
+	MaxLatency      time.Duration `mapstructure:"max_latency"`      // Maximum average latency
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"` // How often the workers reload the health figures routing uses
+}
+
+// CircuitBreakerConfig holds the thresholds of the circuit breakers wrapping every provider
+type CircuitBreakerConfig struct {
+	FailureThreshold int           `mapstructure:"failure_threshold"` // Consecutive failures opening the circuit
+	OpenTimeout      time.Duration `mapstructure:"open_timeout"`      // Time calls fail fast before probing the provider
+	SuccessThreshold int           `mapstructure:"success_threshold"` // Consecutive successful probes closing the circuit
+}
+
+// WebhooksConfig holds the signing secrets of the inbound webhooks of the platform providers by driver,
+// e.g. the verification key of the SendGrid event webhook or the Twilio auth token. Applications with
+// their own provider set the "webhook_secret" secret of its credential instead.
+type WebhooksConfig struct {
+	Secrets map[string]string `mapstructure:"secrets"`
+}
+
+// TrackingConfig holds the settings of email open and click tracking
+type TrackingConfig struct {
+	DedupeWindow   time.Duration `mapstructure:"dedupe_window"`   // Repeated opens or clicks of the same link within the window are recorded once
+	PrefetchWindow time.Duration `mapstructure:"prefetch_window"` // Events this soon after the send are considered machine prefetches
+}
+
+// AnalyticsConfig holds the settings of the delivery rollups maintained by the workers
+type AnalyticsConfig struct {
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"` // How often the rollups of the current hour and day are refreshed
+	Backfill        time.Duration `mapstructure:"backfill"`         // How far back rollups are recomputed when a worker starts
+	HourlyRetention time.Duration `mapstructure:"hourly_retention"` // Hourly rollups are pruned after, at least a day past the backfill; daily rollups are kept
 }
 
 // Load loads configuration from multiple sources
@@ -89,9 +208,28 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
 	return &config, nil
 }
 
+// validate checks the settings that depend on each other or can't be checked by their type
+func (c *Config) validate() error {
+	// Daily rollups of the backfill are recomputed from its hourly rollups, which must not be pruned yet
+	analytics := c.Analytics
+	if analytics.HourlyRetention > 0 && analytics.HourlyRetention < analytics.Backfill+24*time.Hour {
+		return fmt.Errorf("analytics.hourly_retention (%s) must be at least analytics.backfill (%s) plus a day", analytics.HourlyRetention, analytics.Backfill)
+	}
+
+	// Provider credentials must not be sealed with keys derived from passphrases
+	if _, err := envelope.NewKeyring(c.Security.CredentialKeyID, c.Security.CredentialKeys); err != nil {
+		return fmt.Errorf("invalid security.credential_keys: %w", err)
+	}
+	return nil
+}
+
 // setDefaults sets default values for configuration
 func setDefaults(v *viper.Viper) {
 	// Server defaults
@@ -101,10 +239,16 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.read_timeout", "30s")
 	v.SetDefault("server.write_timeout", "30s")
 	v.SetDefault("server.idle_timeout", "120s")
+	v.SetDefault("server.body_limit", 16*1024*1024) // 16 MB, leaves room for base64 encoded attachments
+	v.SetDefault("server.public_url", "http://localhost:8080")
 
 	// Security defaults
 	v.SetDefault("security.jwt_secret", "your-super-secret-jwt-key-change-in-production")
 	v.SetDefault("security.bcrypt_cost", 12)
+	v.SetDefault("security.link_secret", "your-super-secret-link-key-change-in-production")
+	v.SetDefault("security.preference_link_ttl", "720h") // 30 days
+	v.SetDefault("security.credential_key_id", "v1")
+	v.SetDefault("security.credential_keys", map[string]string{"v1": "Y2hhbmdlLW1lLWNyZWRlbnRpYWwta2V5LTMyYnl0ZXM="}) // Development key, change in production
 
 	// Database defaults
 	v.SetDefault("database.host", "hermes-db")
@@ -117,6 +261,56 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("redis.host", "localhost")
 	v.SetDefault("redis.port", "6379")
 	v.SetDefault("redis.db", 0)
+	v.SetDefault("redis.enabled", false)
+	v.SetDefault("redis.pool_size", 10)
+
+	// Storage defaults
+	v.SetDefault("storage.driver", "local")
+	v.SetDefault("storage.local_path", "./data/files")
+
+	// Attachments defaults
+	v.SetDefault("attachments.max_size", 10*1024*1024) // 10 MB
+	v.SetDefault("attachments.max_count", 10)
+	v.SetDefault("attachments.allowed_types", []string{"application/pdf", "image/*", "text/csv", "text/plain"})
+
+	// Notifications defaults
+	v.SetDefault("notifications.workers", 2)
+	v.SetDefault("notifications.batch_size", 50)
+	v.SetDefault("notifications.poll_interval", "1s")
+	v.SetDefault("notifications.default_retry_count", 3)
+	v.SetDefault("notifications.max_retry_count", 5)
+	v.SetDefault("notifications.retry_delay", "5s")
+	v.SetDefault("notifications.dedupe_window", "1h")
+	v.SetDefault("notifications.critical_workers", 1)
+	v.SetDefault("notifications.priority_weights", map[string]int{"high": 3, "normal": 1, "low": 0})
+	v.SetDefault("notifications.throttle_wait", "1s")
+	v.SetDefault("notifications.claim_lease", "5m")
+
+	// Providers defaults, every channel is delivered to the log
+	providers := make([]map[string]any, 0, 5)
+	for _, channel := range []string{"email", "sms", "push", "webhook", "slack"} {
+		providers = append(providers, map[string]any{"name": "log", "channel": channel, "driver": "log", "priority": 1, "weight": 1})
+	}
+	v.SetDefault("providers", providers)
+	v.SetDefault("provider_health.window", "5m")
+	v.SetDefault("provider_health.min_samples", 20)
+	v.SetDefault("provider_health.min_success_rate", 0.8)
+	v.SetDefault("provider_health.max_latency", "10s")
+	v.SetDefault("provider_health.refresh_interval", "10s")
+
+	// Circuit breaker defaults
+	v.SetDefault("circuit_breaker.failure_threshold", 5)
+	v.SetDefault("circuit_breaker.open_timeout", "30s")
+	v.SetDefault("circuit_breaker.success_threshold", 1)
+
+	// Tracking defaults
+	v.SetDefault("tracking.dedupe_window", "1m")
+	v.SetDefault("tracking.prefetch_window", "5s")
+
+	// Analytics defaults
+	v.SetDefault("analytics.refresh_interval", "1m")
+	v.SetDefault("analytics.backfill", "168h")
+	v.SetDefault("analytics.hourly_retention", "2160h")
 
 	// Logging defaults
 	v.SetDefault("logging.level", "debug")
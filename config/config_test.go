@@ -0,0 +1,61 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validConfig returns a configuration passing validation with the given analytics settings
+func validConfig(analytics AnalyticsConfig) *Config {
+	return &Config{
+		Analytics: analytics,
+		Security: SecurityConfig{
+			CredentialKeyID: "v1",
+			CredentialKeys:  map[string]string{"v1": "Y2hhbmdlLW1lLWNyZWRlbnRpYWwta2V5LTMyYnl0ZXM="},
+		},
+	}
+}
+
+func TestValidateAnalytics(t *testing.T) {
+	const day = 24 * time.Hour
+
+	tests := []struct {
+		name      string
+		backfill  time.Duration
+		retention time.Duration
+		wantErr   bool
+	}{
+		{name: "defaults", backfill: 7 * day, retention: 90 * day},
+		{name: "retention covers the backfill and a day", backfill: 7 * day, retention: 8 * day},
+		{name: "hourly rollups kept forever", backfill: 30 * day},
+		{name: "no backfill", retention: day},
+		{name: "retention shorter than the backfill", backfill: 7 * day, retention: 3 * day, wantErr: true},
+		{name: "retention equal to the backfill", backfill: 7 * day, retention: 7 * day, wantErr: true},
+		{name: "retention an hour short", backfill: 7 * day, retention: 8*day - time.Hour, wantErr: true},
+		{name: "retention shorter than a day", retention: 12 * time.Hour, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validConfig(AnalyticsConfig{Backfill: tt.backfill, HourlyRetention: tt.retention}).validate()
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "analytics.hourly_retention")
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLoadValidates(t *testing.T) {
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, cfg.Analytics.HourlyRetention, cfg.Analytics.Backfill+24*time.Hour)
+
+	t.Setenv("ANALYTICS_BACKFILL", "2160h")
+	_, err = Load()
+	assert.ErrorContains(t, err, "analytics.hourly_retention", "a backfill longer than the retention must be refused at startup")
+}
@@ -72,7 +72,29 @@ func AutoMigrate() error {
 	}
 
 	// Add your models here for auto-migration
-	err := DB.AutoMigrate(&model.User{}, &model.Application{})
+	err := DB.AutoMigrate(
+		&model.User{},
+		&model.Application{},
+		&model.Template{},
+		&model.TemplateVariant{},
+		&model.Contact{},
+		&model.ContactPreference{},
+		&model.ContactImport{},
+		&model.Notification{},
+		&model.NotificationStatusChange{},
+		&model.File{},
+		&model.NotificationAttachment{},
+		&model.Suppression{},
+		&model.FrequencyCap{},
+		&model.FrequencyCapEvent{},
+		&model.DigestRule{},
+		&model.Digest{},
+		&model.ProviderStat{},
+		&model.ProviderCredential{},
+		&model.NotificationAttempt{},
+		&model.TrackingEvent{},
+		&model.DeliveryRollup{},
+	)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"hermes-api/config"
+	"hermes-api/pkg/logger"
+)
+
+// Redis holds the Redis client, it is nil when Redis is not enabled
+var Redis *redis.Client
+
+// ConnectRedis connects to Redis when it is enabled in the configuration
+func ConnectRedis(cfg *config.RedisConfig) error {
+	if !cfg.Enabled {
+		logger.Info("Redis is disabled, shared counters are kept in PostgreSQL")
+		return nil
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     net.JoinHostPort(cfg.Host, cfg.Port),
+		Password: cfg.Password,
+		DB:       cfg.DB,
+		PoolSize: cfg.PoolSize,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		logger.Error("Failed to connect to Redis", err)
+		return fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	Redis = client
+	logger.Info("Successfully connected to Redis")
+	return nil
+}
+
+// CloseRedis closes the Redis client
+func CloseRedis() error {
+	if Redis != nil {
+		return Redis.Close()
+	}
+	return nil
+}
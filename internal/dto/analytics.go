@@ -0,0 +1,23 @@
+package dto
+
+import (
+	"hermes-api/internal/validation"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// AnalyticsQuery selects the range, filters and breakdown of delivery analytics
+type AnalyticsQuery struct {
+	ApplicationID string `query:"application_id" validate:"omitempty,uuid"`
+	Channel       string `query:"channel" validate:"omitempty,oneof=email sms push webhook slack"`
+	Template      string `query:"template" validate:"max=255"`                                  // Name of the template
+	From          string `query:"from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"` // RFC 3339, 7 days before to when omitted
+	To            string `query:"to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`   // RFC 3339, now when omitted
+	Granularity   string `query:"granularity" validate:"omitempty,oneof=hour day"`              // hour for ranges up to 2 days, day otherwise
+	GroupBy       string `query:"group_by" validate:"omitempty,oneof=application channel template"`
+}
+
+func (q *AnalyticsQuery) Validate() error {
+	validate := validator.New()
+	return validation.MapValidationErrors(validate.Struct(q))
+}
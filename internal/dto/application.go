@@ -8,8 +8,28 @@ import (
 )
 
 type CreateApplicationRequest struct {
-	Name        string `json:"name" validate:"required,min=3,max=100"`
-	Description string `json:"description" validate:"required,min=3,max=255"`
+	Name          string `json:"name" validate:"required,min=3,max=100"`
+	Description   string `json:"description" validate:"required,min=3,max=255"`
+	DefaultLocale string `json:"default_locale" validate:"omitempty,bcp47_language_tag"`
+	BaseURL       string `json:"base_url" validate:"omitempty,url,max=255"`
+
+	// Attachment limits, the platform defaults apply when omitted
+	AttachmentMaxSize      int64    `json:"attachment_max_size" validate:"omitempty,min=1"`
+	AttachmentAllowedTypes []string `json:"attachment_allowed_types" validate:"omitempty,dive,required,max=100"`
+
+	QuietHours *QuietHoursRequest `json:"quiet_hours" validate:"omitempty"`
+
+	// Window in which notifications with the same dedupe key are dropped, the platform default applies when omitted
+	DedupeWindowSeconds int64 `json:"dedupe_window_seconds" validate:"omitempty,min=1,max=604800"`
+	AutoDedupe          bool  `json:"auto_dedupe"` // Derive a dedupe key from the template, recipient and data when none is sent
+}
+
+// UpdateApplicationSettingsRequest changes the given settings of an application, omitted settings are kept
+type UpdateApplicationSettingsRequest struct {
+	DedupeWindowSeconds *int64 `json:"dedupe_window_seconds" validate:"omitempty,min=0,max=604800"` // Zero restores the platform default
+	AutoDedupe          *bool  `json:"auto_dedupe"`
+	TrackOpens          *bool  `json:"track_opens"`
+	TrackClicks         *bool  `json:"track_clicks"`
 }
 
 type CreateApplicationResponse struct {
@@ -21,6 +41,11 @@ func (r *CreateApplicationRequest) Validate() error {
 	return validation.MapValidationErrors(validate.Struct(r))
 }
 
+func (r *UpdateApplicationSettingsRequest) Validate() error {
+	validate := validator.New()
+	return validation.MapValidationErrors(validate.Struct(r))
+}
+
 func (r *CreateApplicationResponse) Validate() error {
 	validate := validator.New()
 	return validation.MapValidationErrors(validate.Struct(r))
@@ -0,0 +1,83 @@
+package dto
+
+import (
+	"hermes-api/internal/model"
+	"hermes-api/internal/validation"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type CreateContactRequest struct {
+	ExternalID string `json:"external_id" validate:"required,max=255"`
+	UpdateContactRequest
+}
+
+// UpdateContactRequest replaces every field of a contact but its external ID
+type UpdateContactRequest struct {
+	Email        string             `json:"email" validate:"omitempty,email,max=255"`
+	Phone        string             `json:"phone" validate:"omitempty,max=32"` // Normalised to E.164
+	DeviceTokens []string           `json:"device_tokens" validate:"omitempty,dive,required,max=4096"`
+	Locale       string             `json:"locale" validate:"omitempty,bcp47_language_tag"`
+	Timezone     string             `json:"timezone" validate:"omitempty,timezone"`
+	Attributes   map[string]any     `json:"attributes"`
+	QuietHours   *QuietHoursRequest `json:"quiet_hours" validate:"omitempty"`
+	DoNotTrack   bool               `json:"do_not_track"`
+}
+
+// QuietHoursRequest defines a daily window in local time, e.g. 22:00 to 08:00, during which
+// non-urgent notifications are deferred
+type QuietHoursRequest struct {
+	Start    string   `json:"start" validate:"required,datetime=15:04"`
+	End      string   `json:"end" validate:"required,datetime=15:04,nefield=Start"`
+	Timezone string   `json:"timezone" validate:"omitempty,timezone"`
+	Channels []string `json:"channels" validate:"omitempty,dive,oneof=email sms push webhook slack"`
+}
+
+type UpdatePreferencesRequest struct {
+	Preferences []PreferenceRequest `json:"preferences" validate:"dive"`
+}
+
+// PreferenceRequest opts in or out of a category on a channel, leave either empty to cover all of them
+type PreferenceRequest struct {
+	Category string `json:"category" validate:"max=50"`
+	Channel  string `json:"channel" validate:"omitempty,oneof=email sms push webhook slack"`
+	Enabled  *bool  `json:"enabled" validate:"required"`
+}
+
+func (r *CreateContactRequest) Validate() error {
+	validate := validator.New()
+	return validation.MapValidationErrors(validate.Struct(r))
+}
+
+func (r *UpdateContactRequest) Validate() error {
+	validate := validator.New()
+	return validation.MapValidationErrors(validate.Struct(r))
+}
+
+func (r *UpdatePreferencesRequest) Validate() error {
+	validate := validator.New()
+	return validation.MapValidationErrors(validate.Struct(r))
+}
+
+func (r *QuietHoursRequest) Validate() error {
+	validate := validator.New()
+	return validation.MapValidationErrors(validate.Struct(r))
+}
+
+// ToModel converts the request to the quiet hours stored on applications and contacts
+func (r *QuietHoursRequest) ToModel() *model.QuietHours {
+	if r == nil {
+		return nil
+	}
+
+	channels := make([]model.Channel, len(r.Channels))
+	for i, channel := range r.Channels {
+		channels[i] = model.Channel(channel)
+	}
+	return &model.QuietHours{
+		Start:    r.Start,
+		End:      r.End,
+		Timezone: r.Timezone,
+		Channels: channels,
+	}
+}
@@ -0,0 +1,22 @@
+package dto
+
+import (
+	"hermes-api/internal/validation"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// CreateDigestRuleRequest batches the notifications of a template, or of a category, sent to a contact
+// into a digest delivered through DigestTemplate once the window has passed or MaxItems were collected
+type CreateDigestRuleRequest struct {
+	Template       string `json:"template" validate:"required_without=Category,excluded_with=Category,max=100"`
+	Category       string `json:"category" validate:"required_without=Template,max=50"`
+	DigestTemplate string `json:"digest_template" validate:"required,max=100"`
+	WindowSeconds  int64  `json:"window_seconds" validate:"required,min=60,max=604800"` // Up to 7 days
+	MaxItems       int    `json:"max_items" validate:"omitempty,min=2,max=1000"`
+}
+
+func (r *CreateDigestRuleRequest) Validate() error {
+	validate := validator.New()
+	return validation.MapValidationErrors(validate.Struct(r))
+}
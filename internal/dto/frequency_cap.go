@@ -0,0 +1,21 @@
+package dto
+
+import (
+	"hermes-api/internal/validation"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// CreateFrequencyCapRequest caps the notifications of a category and/or channel per recipient,
+// leave either empty to cover all of them
+type CreateFrequencyCapRequest struct {
+	Category      string `json:"category" validate:"max=50"`
+	Channel       string `json:"channel" validate:"omitempty,oneof=email sms push webhook slack"`
+	Limit         int    `json:"limit" validate:"required,min=1,max=10000"`
+	WindowSeconds int64  `json:"window_seconds" validate:"required,min=1,max=2592000"` // Up to 30 days
+}
+
+func (r *CreateFrequencyCapRequest) Validate() error {
+	validate := validator.New()
+	return validation.MapValidationErrors(validate.Struct(r))
+}
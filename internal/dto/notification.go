@@ -0,0 +1,59 @@
+package dto
+
+import (
+	"hermes-api/internal/validation"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type SendNotificationRequest struct {
+	Template    string                `json:"template" validate:"required"`
+	Recipient   string                `json:"recipient" validate:"required_without=ContactID,excluded_with=ContactID,max=255"`
+	ContactID   string                `json:"contact_id" validate:"max=255"` // External ID of a contact, its address is resolved for the template's channel
+	Locale      string                `json:"locale" validate:"omitempty,bcp47_language_tag"`
+	Data        map[string]any        `json:"data"`
+	Metadata    map[string]string     `json:"metadata" validate:"max=20,dive,keys,min=1,max=64,endkeys,max=255"` // Searchable key/values, not passed to templates
+	Urgent      bool                  `json:"urgent"`                                                            // Delivered during quiet hours, never batched into a digest
+	Priority    string                `json:"priority" validate:"omitempty,oneof=critical high normal low"`      // Queue lane, normal when omitted
+	DedupeKey   string                `json:"dedupe_key" validate:"max=255"`                                     // Derived from the template, recipient and data when omitted and the application enables auto_dedupe
+	Attachments []AttachmentRequest   `json:"attachments" validate:"omitempty,dive"`
+	Fallbacks   []FallbackStepRequest `json:"fallbacks" validate:"omitempty,excluded_without=ContactID,max=5,dive"` // Replaces the fallback chain of the template
+}
+
+// AttachmentRequest is an email attachment given either inline as base64 content or as the ID of an uploaded file
+type AttachmentRequest struct {
+	FileID      string `json:"file_id" validate:"required_without=Content,omitempty,uuid"`
+	Content     string `json:"content" validate:"required_without=FileID,omitempty,base64"`
+	Filename    string `json:"filename" validate:"required_with=Content,max=255"`
+	ContentType string `json:"content_type" validate:"max=100"`
+	ContentID   string `json:"content_id" validate:"max=100"` // Makes the attachment an inline image referenced as "cid:<content_id>"
+}
+
+func (r *SendNotificationRequest) Validate() error {
+	validate := validator.New()
+	return validation.MapValidationErrors(validate.Struct(r))
+}
+
+// ListNotificationsQuery filters, sorts and paginates the notification list. Filters are combined with AND,
+// repeated statuses and metadata pairs match any status and every pair.
+type ListNotificationsQuery struct {
+	ApplicationID string   `query:"application_id" validate:"omitempty,uuid"`
+	Recipient     string   `query:"recipient" validate:"max=255"` // A single address, also matching notifications sent to several
+	ContactID     string   `query:"contact_id" validate:"omitempty,uuid"`
+	ExternalID    string   `query:"external_id" validate:"max=255"` // External ID of the contact
+	Status        []string `query:"status" validate:"max=10,dive,oneof=queued processing deferred sent delivered failed bounced skipped_preference skipped_suppressed skipped_frequency_cap skipped_fallback batched deduplicated"`
+	Channel       string   `query:"channel" validate:"omitempty,oneof=email sms push webhook slack"`
+	Template      string   `query:"template" validate:"max=255"`                                  // Name of the template
+	From          string   `query:"from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"` // Created at or after, RFC 3339
+	To            string   `query:"to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`   // Created before, RFC 3339
+	Metadata      []string `query:"metadata" validate:"max=10,dive,contains=:"`                   // "<key>:<value>" pairs
+	SortBy        string   `query:"sort_by" validate:"omitempty,oneof=created_at updated_at"`     // created_at when omitted
+	SortOrder     string   `query:"sort_order" validate:"omitempty,oneof=asc desc"`               // desc when omitted
+	Limit         int      `query:"limit" validate:"omitempty,min=1,max=100"`                     // 20 when omitted
+	Cursor        string   `query:"cursor" validate:"max=512"`                                    // next_cursor of the previous page
+}
+
+func (q *ListNotificationsQuery) Validate() error {
+	validate := validator.New()
+	return validation.MapValidationErrors(validate.Struct(q))
+}
@@ -0,0 +1,38 @@
+package dto
+
+import (
+	"hermes-api/internal/validation"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// SetProviderCredentialRequest configures the provider an application delivers a channel with.
+// Secrets are write-only, they are encrypted at rest and never returned.
+type SetProviderCredentialRequest struct {
+	Driver   string            `json:"driver" validate:"required,oneof=log sendgrid twilio"`
+	Name     string            `json:"name" validate:"required,min=1,max=100"`
+	Sender   string            `json:"sender" validate:"max=255"`
+	Settings map[string]any    `json:"settings" validate:"max=50"`
+	Secrets  map[string]string `json:"secrets" validate:"max=20,dive,keys,min=1,max=64,endkeys,required,max=4096"`
+
+	RateLimit *RateLimitRequest `json:"rate_limit" validate:"omitempty"` // Throttles the sends to the provider, unlimited when omitted
+}
+
+// RateLimitRequest limits the sends to a provider to Limit messages per PerSeconds
+type RateLimitRequest struct {
+	Limit      int  `json:"limit" validate:"required,min=1,max=100000"`
+	PerSeconds int  `json:"per_seconds" validate:"omitempty,min=1,max=86400"` // Defaults to one second
+	Burst      int  `json:"burst" validate:"omitempty,min=1,max=100000"`      // Defaults to Limit
+	PerSender  bool `json:"per_sender"`                                       // Limits each sender identity separately
+}
+
+func (r *SetProviderCredentialRequest) Validate() error {
+	validate := validator.New()
+	return validation.MapValidationErrors(validate.Struct(r))
+}
+
+// RotateCredentialKeysResponse reports the provider credentials re-encrypted with the active key
+type RotateCredentialKeysResponse struct {
+	KeyID   string `json:"key_id"`
+	Rotated int    `json:"rotated"`
+}
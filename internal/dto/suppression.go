@@ -0,0 +1,22 @@
+package dto
+
+import (
+	"time"
+
+	"hermes-api/internal/validation"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type CreateSuppressionRequest struct {
+	Channel     string     `json:"channel" validate:"required,oneof=email sms push webhook slack"`
+	Address     string     `json:"address" validate:"required,max=4096"`
+	Reason      string     `json:"reason" validate:"omitempty,oneof=bounce complaint manual"` // Defaults to manual
+	Description string     `json:"description" validate:"max=255"`
+	ExpiresAt   *time.Time `json:"expires_at"` // Permanent when omitted
+}
+
+func (r *CreateSuppressionRequest) Validate() error {
+	validate := validator.New()
+	return validation.MapValidationErrors(validate.Struct(r))
+}
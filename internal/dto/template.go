@@ -0,0 +1,66 @@
+package dto
+
+import (
+	"hermes-api/internal/model"
+	"hermes-api/internal/validation"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type CreateTemplateRequest struct {
+	Name          string                   `json:"name" validate:"required,min=3,max=100"`
+	Channel       string                   `json:"channel" validate:"required,oneof=email sms push webhook slack"`
+	Category      string                   `json:"category" validate:"omitempty,max=50"`
+	DefaultLocale string                   `json:"default_locale" validate:"omitempty,bcp47_language_tag"`
+	Markup        string                   `json:"markup" validate:"omitempty,oneof=html mjml"`
+	DataSchema    map[string]any           `json:"data_schema"`
+	Variants      []TemplateVariantRequest `json:"variants" validate:"required,min=1,dive"`
+	Fallbacks     []FallbackStepRequest    `json:"fallbacks" validate:"omitempty,max=5,dive"` // Applies to notifications sent to contacts
+}
+
+type TemplateVariantRequest struct {
+	Locale   string `json:"locale" validate:"required,bcp47_language_tag"`
+	Subject  string `json:"subject" validate:"max=255"`
+	Body     string `json:"body" validate:"required_without=HTMLBody"`
+	HTMLBody string `json:"html_body" validate:"required_without=Body"`
+}
+
+// FallbackStepRequest is a step of a fallback chain, sending the notification again through another
+// template when the condition is met by the previous step
+type FallbackStepRequest struct {
+	Template     string `json:"template" validate:"required,max=100"`
+	Condition    string `json:"condition" validate:"required,oneof=failed no_address not_delivered"`
+	AfterSeconds int64  `json:"after_seconds" validate:"required_if=Condition not_delivered,excluded_unless=Condition not_delivered,omitempty,min=60,max=604800"`
+}
+
+type RenderTemplateRequest struct {
+	Locale string         `json:"locale" validate:"omitempty,bcp47_language_tag"`
+	Data   map[string]any `json:"data"`
+}
+
+func (r *CreateTemplateRequest) Validate() error {
+	validate := validator.New()
+	return validation.MapValidationErrors(validate.Struct(r))
+}
+
+func (r *RenderTemplateRequest) Validate() error {
+	validate := validator.New()
+	return validation.MapValidationErrors(validate.Struct(r))
+}
+
+// FallbackChain converts fallback step requests to the fallback chain stored on templates and notifications
+func FallbackChain(steps []FallbackStepRequest) []model.FallbackStep {
+	if len(steps) == 0 {
+		return nil
+	}
+
+	chain := make([]model.FallbackStep, len(steps))
+	for i, step := range steps {
+		chain[i] = model.FallbackStep{
+			Template:     step.Template,
+			Condition:    model.FallbackCondition(step.Condition),
+			AfterSeconds: step.AfterSeconds,
+		}
+	}
+	return chain
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"hermes-api/internal/model"
+	"hermes-api/internal/service"
+	"hermes-api/pkg/context"
+	"hermes-api/pkg/errorx"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ApplicationAccess creates middleware that loads the application referenced by the ":app_id" route
+// parameter and checks that it belongs to the authenticated user. Must run after AuthMiddleware.
+func ApplicationAccess(applicationService service.ApplicationService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, ok := c.Locals("user").(*model.User)
+		if !ok || user == nil {
+			return errorx.New(errorx.ErrorTypeUnauthorized, errorx.ErrorCodeFiberUnauthorized, "User not authenticated")
+		}
+
+		appID, err := uuid.Parse(c.Params("app_id"))
+		if err != nil {
+			return errorx.NewValidationError("app_id", c.Params("app_id"))
+		}
+
+		// Create a new context for the service
+		serviceCtx, cancel := context.New(c).WithShortTimeout().Build()
+		defer cancel()
+
+		application, err := applicationService.GetUserApplication(serviceCtx, user.ID, appID)
+		if err != nil {
+			return err
+		}
+
+		// Set application in context
+		c.Locals("application", application)
+
+		return c.Next()
+	}
+}
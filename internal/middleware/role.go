@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"slices"
+
+	"hermes-api/internal/model"
+	"hermes-api/pkg/errorx"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireRole creates middleware that only lets users with one of the given platform roles through.
+// Must run after AuthMiddleware.
+func RequireRole(roles ...model.UserRole) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, ok := c.Locals("user").(*model.User)
+		if !ok || user == nil {
+			return errorx.New(errorx.ErrorTypeUnauthorized, errorx.ErrorCodeFiberUnauthorized, "User not authenticated")
+		}
+
+		if !slices.Contains(roles, user.Role) {
+			return errorx.New(errorx.ErrorTypeForbidden, errorx.ErrorCodeFiberForbidden, "Insufficient permissions")
+		}
+
+		return c.Next()
+	}
+}
@@ -16,16 +16,32 @@ const (
 )
 
 type Application struct {
-	ID          uuid.UUID         `json:"id" gorm:"primaryKey"`
-	UserID      uuid.UUID         `json:"user_id" gorm:"not null"`
-	User        User              `json:"-" gorm:"foreignKey:UserID"`
-	Name        string            `json:"name" gorm:"not null"`
-	Description string            `json:"description" gorm:"not null"`
-	APIKey      string            `json:"api_key" gorm:"uniqueIndex;not null"`
-	Status      ApplicationStatus `json:"status" gorm:"default:'active'"`
-	CreatedAt   time.Time         `json:"created_at"`
-	UpdatedAt   time.Time         `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt    `json:"-" gorm:"index"` // Soft delete
+	ID            uuid.UUID         `json:"id" gorm:"primaryKey"`
+	UserID        uuid.UUID         `json:"user_id" gorm:"not null"`
+	User          User              `json:"-" gorm:"foreignKey:UserID"`
+	Name          string            `json:"name" gorm:"not null"`
+	Description   string            `json:"description" gorm:"not null"`
+	APIKey        string            `json:"api_key" gorm:"uniqueIndex;not null"`
+	Status        ApplicationStatus `json:"status" gorm:"default:'active'"`
+	DefaultLocale string            `json:"default_locale" gorm:"default:'en'"` // Last step of the template locale fallback chain
+	BaseURL       string            `json:"base_url,omitempty"`                 // Used to resolve relative links in emails
+
+	// Attachment limits, zero values fall back to the platform defaults
+	AttachmentMaxSize      int64    `json:"attachment_max_size,omitempty"`
+	AttachmentAllowedTypes []string `json:"attachment_allowed_types,omitempty" gorm:"type:jsonb;serializer:json"`
+
+	QuietHours *QuietHours `json:"quiet_hours,omitempty" gorm:"type:jsonb;serializer:json"` // Default of contacts without their own
+
+	DedupeWindowSeconds int64 `json:"dedupe_window_seconds,omitempty"`           // Zero falls back to the platform default
+	AutoDedupe          bool  `json:"auto_dedupe" gorm:"not null;default:false"` // Derive a dedupe key for notifications sent without one
+
+	// Engagement tracking of emails, contacts can opt out with their do-not-track flag
+	TrackOpens  bool `json:"track_opens" gorm:"not null;default:false"`
+	TrackClicks bool `json:"track_clicks" gorm:"not null;default:false"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"` // Soft delete
 }
 
 // TableName specifies the table name for the Application model
@@ -0,0 +1,21 @@
+package model
+
+// Channel represents a delivery channel for notifications
+type Channel string
+
+const (
+	ChannelEmail   Channel = "email"
+	ChannelSMS     Channel = "sms"
+	ChannelPush    Channel = "push"
+	ChannelWebhook Channel = "webhook"
+	ChannelSlack   Channel = "slack"
+)
+
+// IsValid reports whether the channel is one of the supported channels
+func (c Channel) IsValid() bool {
+	switch c {
+	case ChannelEmail, ChannelSMS, ChannelPush, ChannelWebhook, ChannelSlack:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Contact is a recipient of an application, identified by the application's own user ID
+type Contact struct {
+	ID            uuid.UUID      `json:"id" gorm:"primaryKey"`
+	ApplicationID uuid.UUID      `json:"application_id" gorm:"not null;uniqueIndex:idx_contacts_application_external_id"`
+	Application   Application    `json:"-" gorm:"foreignKey:ApplicationID"`
+	ExternalID    string         `json:"external_id" gorm:"not null;uniqueIndex:idx_contacts_application_external_id"`
+	Email         string         `json:"email,omitempty"`
+	Phone         string         `json:"phone,omitempty"` // E.164
+	DeviceTokens  []string       `json:"device_tokens,omitempty" gorm:"type:jsonb;serializer:json"`
+	Locale        string         `json:"locale,omitempty"`
+	Timezone      string         `json:"timezone,omitempty"` // IANA time zone name
+	Attributes    map[string]any `json:"attributes,omitempty" gorm:"type:jsonb;serializer:json"`
+	QuietHours    *QuietHours    `json:"quiet_hours,omitempty" gorm:"type:jsonb;serializer:json"` // Overrides the application's
+	DoNotTrack    bool           `json:"do_not_track" gorm:"not null;default:false"`              // Opens and clicks of the contact are not tracked
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+
+	Preferences []ContactPreference `json:"-" gorm:"foreignKey:ContactID;constraint:OnDelete:CASCADE"`
+}
+
+// ContactPreference opts a contact in or out of notifications of a category on a channel.
+// An empty category or channel applies to every category or channel.
+type ContactPreference struct {
+	ID        uuid.UUID `json:"-" gorm:"primaryKey"`
+	ContactID uuid.UUID `json:"-" gorm:"not null;uniqueIndex:idx_contact_preferences_scope"`
+	Category  string    `json:"category" gorm:"not null;default:'';uniqueIndex:idx_contact_preferences_scope"`
+	Channel   Channel   `json:"channel" gorm:"not null;default:'';uniqueIndex:idx_contact_preferences_scope"`
+	Enabled   bool      `json:"enabled" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for the Contact model
+func (Contact) TableName() string {
+	return "contacts"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (c *Contact) BeforeCreate(tx *gorm.DB) error {
+	// Generate UUID for the contact
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName specifies the table name for the ContactPreference model
+func (ContactPreference) TableName() string {
+	return "contact_preferences"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (p *ContactPreference) BeforeCreate(tx *gorm.DB) error {
+	// Generate UUID for the preference
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// Allows reports whether the contact accepts notifications of a category on a channel.
+// The most specific preference wins: category and channel, then category, then channel;
+// contacts accept everything they have not opted out of.
+func (c *Contact) Allows(category string, channel Channel) bool {
+	best, allowed := -1, true
+	for _, preference := range c.Preferences {
+		if (preference.Category != "" && preference.Category != category) || (preference.Channel != "" && preference.Channel != channel) {
+			continue
+		}
+
+		specificity := 0
+		if preference.Category != "" {
+			specificity += 2
+		}
+		if preference.Channel != "" {
+			specificity++
+		}
+		if specificity > best {
+			best, allowed = specificity, preference.Enabled
+		}
+	}
+	return allowed
+}
+
+// Addresses returns the addresses of the contact on a channel, push notifications
+// are delivered to every device token of the contact
+func (c *Contact) Addresses(channel Channel) []string {
+	switch channel {
+	case ChannelEmail:
+		if c.Email != "" {
+			return []string{c.Email}
+		}
+	case ChannelSMS:
+		if c.Phone != "" {
+			return []string{c.Phone}
+		}
+	case ChannelPush:
+		return c.DeviceTokens
+	}
+	return nil
+}
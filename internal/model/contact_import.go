@@ -0,0 +1,68 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ContactFormat is a file format used to import and export contacts
+type ContactFormat string
+
+const (
+	ContactFormatCSV    ContactFormat = "csv"
+	ContactFormatNDJSON ContactFormat = "ndjson"
+)
+
+// IsValid reports whether the format is one of the supported formats
+func (f ContactFormat) IsValid() bool {
+	return f == ContactFormatCSV || f == ContactFormatNDJSON
+}
+
+// ContactImportStatus represents the progress of a contact import
+type ContactImportStatus string
+
+const (
+	ContactImportStatusPending    ContactImportStatus = "pending"
+	ContactImportStatusProcessing ContactImportStatus = "processing"
+	ContactImportStatusCompleted  ContactImportStatus = "completed"
+	ContactImportStatusFailed     ContactImportStatus = "failed"
+)
+
+// ContactImport is an asynchronous job upserting contacts from an uploaded file
+type ContactImport struct {
+	ID            uuid.UUID           `json:"id" gorm:"primaryKey"`
+	ApplicationID uuid.UUID           `json:"application_id" gorm:"not null;index"`
+	Application   Application         `json:"-" gorm:"foreignKey:ApplicationID"`
+	Format        ContactFormat       `json:"format" gorm:"not null"`
+	Status        ContactImportStatus `json:"status" gorm:"not null;default:'pending'"`
+	Error         string              `json:"error,omitempty"` // Set when the whole import failed
+	TotalRows     int                 `json:"total_rows"`
+	CreatedCount  int                 `json:"created_count"`
+	UpdatedCount  int                 `json:"updated_count"`
+	FailedCount   int                 `json:"failed_count"` // Rows reported in the downloadable error result
+	StorageKey    string              `json:"-" gorm:"not null"`
+	ErrorsKey     string              `json:"-"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+	CompletedAt   *time.Time          `json:"completed_at,omitempty"`
+}
+
+// TableName specifies the table name for the ContactImport model
+func (ContactImport) TableName() string {
+	return "contact_imports"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (i *ContactImport) BeforeCreate(tx *gorm.DB) error {
+	// Generate UUID for the import
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+
+	if i.Status == "" {
+		i.Status = ContactImportStatusPending
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package model
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RollupGranularity is the length of the buckets of delivery rollups
+type RollupGranularity string
+
+const (
+	RollupGranularityHour RollupGranularity = "hour"
+	RollupGranularityDay  RollupGranularity = "day" // UTC days
+)
+
+// Duration returns the length of a bucket of the granularity
+func (g RollupGranularity) Duration() time.Duration {
+	if g == RollupGranularityDay {
+		return 24 * time.Hour
+	}
+	return time.Hour
+}
+
+// DeliveryRollup counts the delivery events of the notifications of a template during a bucket. Events
+// are counted in the bucket they happened in, e.g. a notification sent at 10:59 and delivered at 11:01
+// counts as sent in the 10:00 bucket and as delivered in the 11:00 bucket.
+type DeliveryRollup struct {
+	Granularity   RollupGranularity `json:"granularity" gorm:"primaryKey"`
+	BucketStart   time.Time         `json:"bucket_start" gorm:"primaryKey"`
+	ApplicationID uuid.UUID         `json:"application_id" gorm:"primaryKey;index"`
+	Channel       Channel           `json:"channel" gorm:"primaryKey"`
+	TemplateID    uuid.UUID         `json:"template_id" gorm:"primaryKey"`
+	Sent          int64             `json:"sent" gorm:"not null;default:0"`
+	Delivered     int64             `json:"delivered" gorm:"not null;default:0"`
+	Failed        int64             `json:"failed" gorm:"not null;default:0"`
+	Bounced       int64             `json:"bounced" gorm:"not null;default:0"`
+	Skipped       int64             `json:"skipped" gorm:"not null;default:0"`
+	Opened        int64             `json:"opened" gorm:"not null;default:0"`  // First opens by recipients
+	Clicked       int64             `json:"clicked" gorm:"not null;default:0"` // First clicks by recipients
+
+	// End-to-end latencies from the creation of a notification to its hand-over to the provider and to
+	// the confirmation of its delivery, counted in the bucket of the send and of the delivery
+	SendLatency     LatencyHistogram `json:"send_latency,omitempty" gorm:"type:jsonb;serializer:json"`
+	DeliveryLatency LatencyHistogram `json:"delivery_latency,omitempty" gorm:"type:jsonb;serializer:json"`
+}
+
+// TableName specifies the table name for the DeliveryRollup model
+func (DeliveryRollup) TableName() string {
+	return "delivery_rollups"
+}
+
+// Add adds the counts and latencies of another rollup
+func (r *DeliveryRollup) Add(other *DeliveryRollup) {
+	r.Sent += other.Sent
+	r.Delivered += other.Delivered
+	r.Failed += other.Failed
+	r.Bounced += other.Bounced
+	r.Skipped += other.Skipped
+	r.Opened += other.Opened
+	r.Clicked += other.Clicked
+	r.SendLatency = r.SendLatency.Merge(other.SendLatency)
+	r.DeliveryLatency = r.DeliveryLatency.Merge(other.DeliveryLatency)
+}
+
+// LatencyBucketsPerDoubling is the resolution of latency histograms: bucket i counts the latencies from
+// 2^(i/4) up to 2^((i+1)/4) milliseconds, so percentiles are estimated within about 10%
+const LatencyBucketsPerDoubling = 4
+
+// MaxLatencyBucket is the last bucket of latency histograms, it also counts the longer latencies
+const MaxLatencyBucket = 32 * LatencyBucketsPerDoubling
+
+// LatencyHistogram counts latencies by logarithmic bucket. Unlike percentiles, histograms can be added
+// up, so percentiles of any range are estimated from the hourly or daily histograms.
+type LatencyHistogram map[int]int64
+
+// Merge returns the sum of two histograms
+func (h LatencyHistogram) Merge(other LatencyHistogram) LatencyHistogram {
+	if len(other) == 0 {
+		return h
+	}
+	merged := make(LatencyHistogram, len(h)+len(other))
+	for bucket, count := range h {
+		merged[bucket] += count
+	}
+	for bucket, count := range other {
+		merged[bucket] += count
+	}
+	return merged
+}
+
+// Count returns the number of latencies in the histogram
+func (h LatencyHistogram) Count() int64 {
+	var count int64
+	for _, n := range h {
+		count += n
+	}
+	return count
+}
+
+// Percentile estimates the latency in milliseconds below which a fraction p of the latencies fall,
+// as the geometric middle of its bucket. Zero is returned for an empty histogram.
+func (h LatencyHistogram) Percentile(p float64) float64 {
+	total := h.Count()
+	if total == 0 {
+		return 0
+	}
+
+	buckets := make([]int, 0, len(h))
+	for bucket := range h {
+		buckets = append(buckets, bucket)
+	}
+	sort.Ints(buckets)
+
+	rank := int64(math.Ceil(p * float64(total)))
+	var seen int64
+	for _, bucket := range buckets {
+		seen += h[bucket]
+		if seen >= rank {
+			return math.Round(math.Pow(2, (float64(bucket)+0.5)/LatencyBucketsPerDoubling))
+		}
+	}
+	return math.Round(math.Pow(2, (float64(buckets[len(buckets)-1])+0.5)/LatencyBucketsPerDoubling))
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DigestRule collects the notifications of a template, or of a category, sent to a contact and delivers
+// them as a single notification rendered through a digest template with the collected items
+type DigestRule struct {
+	ID               uuid.UUID   `json:"id" gorm:"primaryKey"`
+	ApplicationID    uuid.UUID   `json:"application_id" gorm:"not null;index"`
+	Application      Application `json:"-" gorm:"foreignKey:ApplicationID"`
+	TemplateID       *uuid.UUID  `json:"template_id,omitempty" gorm:"index"`            // Matched before categories
+	Category         string      `json:"category,omitempty" gorm:"not null;default:''"` // Used when no template is set
+	DigestTemplateID uuid.UUID   `json:"digest_template_id" gorm:"not null"`            // Rendered with the "items" list
+	DigestTemplate   Template    `json:"-" gorm:"foreignKey:DigestTemplateID"`
+	WindowSeconds    int64       `json:"window_seconds" gorm:"not null"`                // Time after the first item at which a digest is sent
+	MaxItems         int         `json:"max_items,omitempty" gorm:"not null;default:0"` // Sends the digest early once reached, unlimited when zero
+	CreatedAt        time.Time   `json:"created_at"`
+	UpdatedAt        time.Time   `json:"updated_at"`
+}
+
+// DigestStatus represents the progress of a digest
+type DigestStatus string
+
+const (
+	DigestStatusOpen    DigestStatus = "open"    // Collecting notifications
+	DigestStatusFlushed DigestStatus = "flushed" // Its notification has been queued
+)
+
+// Digest collects the notifications matching a digest rule for a contact until it is flushed
+type Digest struct {
+	ID             uuid.UUID    `json:"id" gorm:"primaryKey"`
+	ApplicationID  uuid.UUID    `json:"application_id" gorm:"not null;index"`
+	RuleID         uuid.UUID    `json:"rule_id" gorm:"not null;index"`
+	Rule           DigestRule   `json:"-" gorm:"foreignKey:RuleID;constraint:OnDelete:CASCADE"`
+	ContactID      uuid.UUID    `json:"contact_id" gorm:"not null;index"`
+	Status         DigestStatus `json:"status" gorm:"not null;default:'open'"`
+	ItemCount      int          `json:"item_count" gorm:"not null;default:0"`
+	FlushAt        time.Time    `json:"flush_at" gorm:"not null;index"`
+	NotificationID *uuid.UUID   `json:"notification_id,omitempty"` // The notification the digest was sent as
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+// TableName specifies the table name for the DigestRule model
+func (DigestRule) TableName() string {
+	return "digest_rules"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (r *DigestRule) BeforeCreate(tx *gorm.DB) error {
+	// Generate UUID for the digest rule
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// Window returns the duration during which a digest of the rule collects notifications
+func (r *DigestRule) Window() time.Duration {
+	return time.Duration(r.WindowSeconds) * time.Second
+}
+
+// TableName specifies the table name for the Digest model
+func (Digest) TableName() string {
+	return "digests"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (d *Digest) BeforeCreate(tx *gorm.DB) error {
+	// Generate UUID for the digest
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
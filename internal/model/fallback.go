@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// FallbackCondition decides when the next step of a fallback chain is sent
+type FallbackCondition string
+
+const (
+	FallbackConditionFailed       FallbackCondition = "failed"        // The delivery failed for any reason
+	FallbackConditionNoAddress    FallbackCondition = "no_address"    // The contact has no address for the channel
+	FallbackConditionNotDelivered FallbackCondition = "not_delivered" // No notification of the chain was delivered within the delay
+)
+
+// FallbackStep is a step of the ordered fallback chain of a notification: when its condition is met,
+// the notification is sent again through the step's template, usually on another channel
+type FallbackStep struct {
+	Template     string            `json:"template"` // Name of the template
+	Condition    FallbackCondition `json:"condition"`
+	AfterSeconds int64             `json:"after_seconds,omitempty"` // Delay of the not_delivered condition
+}
+
+// After returns the delay after which a sent notification is considered not delivered
+func (s FallbackStep) After() time.Duration {
+	return time.Duration(s.AfterSeconds) * time.Second
+}
+
+// Matches reports whether the step applies to a notification that ended with the given status,
+// including a bounce reported by the provider after the notification was sent. A notification that
+// failed is not delivered either, so not_delivered steps apply right away.
+func (s FallbackStep) Matches(status NotificationStatus, noAddress bool) bool {
+	failed := status == NotificationStatusFailed || status == NotificationStatusBounced
+	switch s.Condition {
+	case FallbackConditionFailed:
+		return failed
+	case FallbackConditionNoAddress:
+		return status == NotificationStatusFailed && noAddress
+	case FallbackConditionNotDelivered:
+		return failed || status == NotificationStatusSent
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFallbackStepMatches(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition FallbackCondition
+		status    NotificationStatus
+		noAddress bool
+		want      bool
+	}{
+		{name: "failed on failure", condition: FallbackConditionFailed, status: NotificationStatusFailed, want: true},
+		{name: "failed on missing address", condition: FallbackConditionFailed, status: NotificationStatusFailed, noAddress: true, want: true},
+		{name: "failed on bounce", condition: FallbackConditionFailed, status: NotificationStatusBounced, want: true},
+		{name: "failed on send", condition: FallbackConditionFailed, status: NotificationStatusSent},
+		{name: "failed on delivery", condition: FallbackConditionFailed, status: NotificationStatusDelivered},
+		{name: "failed on suppression", condition: FallbackConditionFailed, status: NotificationStatusSkippedSuppressed},
+		{name: "failed on preference", condition: FallbackConditionFailed, status: NotificationStatusSkippedPreference},
+
+		{name: "no address on missing address", condition: FallbackConditionNoAddress, status: NotificationStatusFailed, noAddress: true, want: true},
+		{name: "no address on other failure", condition: FallbackConditionNoAddress, status: NotificationStatusFailed},
+		{name: "no address on bounce", condition: FallbackConditionNoAddress, status: NotificationStatusBounced},
+		{name: "no address on send", condition: FallbackConditionNoAddress, status: NotificationStatusSent},
+
+		{name: "not delivered on send", condition: FallbackConditionNotDelivered, status: NotificationStatusSent, want: true},
+		{name: "not delivered on failure", condition: FallbackConditionNotDelivered, status: NotificationStatusFailed, want: true},
+		{name: "not delivered on missing address", condition: FallbackConditionNotDelivered, status: NotificationStatusFailed, noAddress: true, want: true},
+		{name: "not delivered on bounce", condition: FallbackConditionNotDelivered, status: NotificationStatusBounced, want: true},
+		{name: "not delivered on delivery", condition: FallbackConditionNotDelivered, status: NotificationStatusDelivered},
+		{name: "not delivered on deferral", condition: FallbackConditionNotDelivered, status: NotificationStatusDeferred},
+		{name: "not delivered on deduplication", condition: FallbackConditionNotDelivered, status: NotificationStatusDeduplicated},
+
+		{name: "unknown condition", condition: "opened", status: NotificationStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := FallbackStep{Template: "reminder-sms", Condition: tt.condition}
+			assert.Equal(t, tt.want, step.Matches(tt.status, tt.noAddress))
+		})
+	}
+}
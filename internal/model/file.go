@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// File represents an uploaded file kept in the file storage, e.g. an attachment
+type File struct {
+	ID            uuid.UUID      `json:"id" gorm:"primaryKey"`
+	ApplicationID uuid.UUID      `json:"application_id" gorm:"not null;index"`
+	Application   Application    `json:"-" gorm:"foreignKey:ApplicationID"`
+	Filename      string         `json:"filename" gorm:"not null"`
+	ContentType   string         `json:"content_type" gorm:"not null"`
+	Size          int64          `json:"size" gorm:"not null"`
+	Checksum      string         `json:"checksum" gorm:"not null"` // SHA-256 of the content, hex encoded
+	StorageKey    string         `json:"-" gorm:"not null"`
+	CreatedAt     time.Time      `json:"created_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"` // Soft delete
+}
+
+// TableName specifies the table name for the File model
+func (File) TableName() string {
+	return "files"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (f *File) BeforeCreate(tx *gorm.DB) error {
+	// Generate UUID for the file
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FrequencyCap limits how many notifications a recipient receives within a sliding window,
+// e.g. at most 3 "marketing" notifications per day or at most 1 push per 10 minutes
+type FrequencyCap struct {
+	ID            uuid.UUID   `json:"id" gorm:"primaryKey"`
+	ApplicationID uuid.UUID   `json:"application_id" gorm:"not null;index"`
+	Application   Application `json:"-" gorm:"foreignKey:ApplicationID"`
+	Category      string      `json:"category,omitempty" gorm:"not null;default:''"` // Applies to every category when empty
+	Channel       Channel     `json:"channel,omitempty" gorm:"not null;default:''"`  // Applies to every channel when empty
+	Limit         int         `json:"limit" gorm:"not null"`
+	WindowSeconds int64       `json:"window_seconds" gorm:"not null"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+// TableName specifies the table name for the FrequencyCap model
+func (FrequencyCap) TableName() string {
+	return "frequency_caps"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (c *FrequencyCap) BeforeCreate(tx *gorm.DB) error {
+	// Generate UUID for the frequency cap
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// Window returns the duration of the sliding window of the cap
+func (c *FrequencyCap) Window() time.Duration {
+	return time.Duration(c.WindowSeconds) * time.Second
+}
+
+// Matches reports whether the cap applies to notifications of a category on a channel
+func (c *FrequencyCap) Matches(category string, channel Channel) bool {
+	return (c.Category == "" || c.Category == category) && (c.Channel == "" || c.Channel == channel)
+}
+
+// FrequencyCapEvent records a notification counted by a frequency cap, used when Redis is not enabled
+type FrequencyCapEvent struct {
+	ID         uuid.UUID `gorm:"primaryKey"`
+	Key        string    `gorm:"not null;index:idx_frequency_cap_events_key_time,priority:1"`
+	Event      string    `gorm:"not null;default:''"` // The notification counted, counted once per window
+	OccurredAt time.Time `gorm:"not null;index:idx_frequency_cap_events_key_time,priority:2"`
+}
+
+// TableName specifies the table name for the FrequencyCapEvent model
+func (FrequencyCapEvent) TableName() string {
+	return "frequency_cap_events"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (e *FrequencyCapEvent) BeforeCreate(tx *gorm.DB) error {
+	// Generate UUID for the event
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
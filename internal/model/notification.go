@@ -0,0 +1,223 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NotificationStatus represents the delivery status of a notification
+type NotificationStatus string
+
+const (
+	NotificationStatusQueued     NotificationStatus = "queued"
+	NotificationStatusProcessing NotificationStatus = "processing"
+	NotificationStatusDeferred   NotificationStatus = "deferred" // Waiting for its scheduled time, e.g. the end of quiet hours
+	NotificationStatusSent       NotificationStatus = "sent"
+	NotificationStatusDelivered  NotificationStatus = "delivered" // Delivery confirmed by the provider
+	NotificationStatusFailed     NotificationStatus = "failed"
+	NotificationStatusBounced    NotificationStatus = "bounced" // Sent, then rejected by the recipient's server
+
+	// Skipped notifications were deliberately not delivered, the reason is recorded with the status
+	NotificationStatusSkippedPreference NotificationStatus = "skipped_preference"
+	NotificationStatusSkippedSuppressed NotificationStatus = "skipped_suppressed"
+	NotificationStatusSkippedCapped     NotificationStatus = "skipped_frequency_cap"
+	NotificationStatusSkippedFallback   NotificationStatus = "skipped_fallback" // An earlier notification of the fallback chain was delivered
+
+	// Batched notifications are collected into a digest, which is delivered as its own notification
+	NotificationStatusBatched NotificationStatus = "batched"
+
+	// Deduplicated notifications repeat a notification sent within the deduplication window, they are never delivered
+	NotificationStatusDeduplicated NotificationStatus = "deduplicated"
+)
+
+// notificationTransitions lists the statuses a notification can move to from each status. Deduplicated
+// notifications are created as such; the other statuses without transitions are final.
+var notificationTransitions = map[NotificationStatus][]NotificationStatus{
+	NotificationStatusQueued:   {NotificationStatusProcessing, NotificationStatusBatched},
+	NotificationStatusDeferred: {NotificationStatusProcessing},
+	NotificationStatusProcessing: {
+		NotificationStatusQueued, // Requeued when the claim of the worker expired
+		NotificationStatusSent,
+		NotificationStatusFailed,
+		NotificationStatusDeferred,
+		NotificationStatusSkippedPreference,
+		NotificationStatusSkippedSuppressed,
+		NotificationStatusSkippedCapped,
+		NotificationStatusSkippedFallback,
+	},
+	NotificationStatusSent: {NotificationStatusDelivered, NotificationStatusFailed, NotificationStatusBounced},
+}
+
+// CanTransitionTo reports whether a notification with the status can move to another status
+func (s NotificationStatus) CanTransitionTo(to NotificationStatus) bool {
+	for _, next := range notificationTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFinal reports whether the status ends the delivery of a notification
+func (s NotificationStatus) IsFinal() bool {
+	return len(notificationTransitions[s]) == 0
+}
+
+// IsSkipped reports whether the status records a notification deliberately not delivered
+func (s NotificationStatus) IsSkipped() bool {
+	switch s {
+	case NotificationStatusSkippedPreference, NotificationStatusSkippedSuppressed, NotificationStatusSkippedCapped, NotificationStatusSkippedFallback:
+		return true
+	}
+	return false
+}
+
+// NotificationStatusSources returns the statuses a notification can move to a status from
+func NotificationStatusSources(to NotificationStatus) []NotificationStatus {
+	var sources []NotificationStatus
+	for from := range notificationTransitions {
+		if from.CanTransitionTo(to) {
+			sources = append(sources, from)
+		}
+	}
+	return sources
+}
+
+// NotificationPriority selects the queue lane a notification is delivered from
+type NotificationPriority string
+
+const (
+	NotificationPriorityCritical NotificationPriority = "critical" // Delivered by reserved workers ahead of every other lane
+	NotificationPriorityHigh     NotificationPriority = "high"
+	NotificationPriorityNormal   NotificationPriority = "normal"
+	NotificationPriorityLow      NotificationPriority = "low" // May wait indefinitely while the other lanes are busy
+)
+
+// NotificationPriorities lists the priorities from the highest to the lowest
+var NotificationPriorities = []NotificationPriority{
+	NotificationPriorityCritical,
+	NotificationPriorityHigh,
+	NotificationPriorityNormal,
+	NotificationPriorityLow,
+}
+
+// Notification represents a message sent by an application through a template
+type Notification struct {
+	ID            uuid.UUID                  `json:"id" gorm:"primaryKey"`
+	ApplicationID uuid.UUID                  `json:"application_id" gorm:"not null;index;index:idx_notifications_dedupe,priority:1"`
+	Application   Application                `json:"-" gorm:"foreignKey:ApplicationID"`
+	TemplateID    uuid.UUID                  `json:"template_id" gorm:"not null;index"`
+	Template      Template                   `json:"-" gorm:"foreignKey:TemplateID"`
+	ContactID     *uuid.UUID                 `json:"contact_id,omitempty" gorm:"index"`
+	Contact       *Contact                   `json:"-" gorm:"foreignKey:ContactID;constraint:OnDelete:SET NULL"`
+	Channel       Channel                    `json:"channel" gorm:"not null"`
+	Category      string                     `json:"category,omitempty" gorm:"index"` // Copied from the template
+	Recipient     string                     `json:"recipient"`                       // Resolved from the contact by the worker when empty
+	Locale        string                     `json:"locale,omitempty"`
+	Data          map[string]any             `json:"data,omitempty" gorm:"type:jsonb;serializer:json"`
+	Metadata      map[string]string          `json:"metadata,omitempty" gorm:"type:jsonb;serializer:json;index:idx_notifications_metadata,type:gin"` // Caller-defined keys to find the notification by, not passed to templates
+	Urgent        bool                       `json:"urgent,omitempty"`                                                                               // Urgent notifications ignore quiet hours and digests
+	Priority      NotificationPriority       `json:"priority" gorm:"not null;default:'normal';index"`
+	DedupeKey     string                     `json:"dedupe_key" gorm:"index:idx_notifications_dedupe,priority:2"` // Given by the caller or derived from the template, recipient and data
+	Status        NotificationStatus         `json:"status" gorm:"not null;default:'queued';index"`
+	StatusReason  string                     `json:"status_reason,omitempty"`
+	StatusHistory []NotificationStatusChange `json:"status_history,omitempty" gorm:"foreignKey:NotificationID;constraint:OnDelete:CASCADE"`
+	Attachments   []NotificationAttachment   `json:"attachments,omitempty" gorm:"foreignKey:NotificationID;constraint:OnDelete:CASCADE"`
+	ParentID      *uuid.UUID                 `json:"parent_id,omitempty" gorm:"index"`                           // Set for fallbacks, the notification the chain started from
+	FallbackOf    *uuid.UUID                 `json:"fallback_of,omitempty" gorm:"uniqueIndex"`                   // Set for fallbacks, the notification whose outcome queued it
+	FallbackStep  int                        `json:"fallback_step,omitempty" gorm:"not null;default:0"`          // Position in the fallback chain, 0 for the notification it started from
+	FallbackChain []FallbackStep             `json:"fallback_chain,omitempty" gorm:"type:jsonb;serializer:json"` // Remaining steps, the first applies to this notification
+	Fallbacks     []Notification             `json:"fallbacks,omitempty" gorm:"foreignKey:ParentID"`             // Notifications sent by the fallback chain
+	DigestID      *uuid.UUID                 `json:"digest_id,omitempty" gorm:"index"`                           // Set for batched notifications
+	Attempts      int                        `json:"attempts" gorm:"not null;default:0"`                         // Hand-overs to the providers, failed ones are retried
+	Retries       int                        `json:"retries" gorm:"not null;default:0"`                          // Deliveries retried after a failure, of the providers or the infrastructure
+	AttemptLog    []NotificationAttempt      `json:"attempt_log,omitempty" gorm:"foreignKey:NotificationID;constraint:OnDelete:CASCADE"`
+	ScheduledAt   *time.Time                 `json:"scheduled_at,omitempty" gorm:"index"` // Not delivered before, set for deferred notifications
+	ClaimedAt     *time.Time                 `json:"claimed_at,omitempty" gorm:"index"`   // Claimed by a worker, requeued when processing outlasts the claim lease
+	SentAt        *time.Time                 `json:"sent_at,omitempty"`
+	OpenedAt      *time.Time                 `json:"opened_at,omitempty" gorm:"index"`  // First open by the recipient, when tracked
+	ClickedAt     *time.Time                 `json:"clicked_at,omitempty" gorm:"index"` // First click by the recipient, when tracked
+	CreatedAt     time.Time                  `json:"created_at" gorm:"index:idx_notifications_dedupe,priority:3"`
+	UpdatedAt     time.Time                  `json:"updated_at"`
+}
+
+// TableName specifies the table name for the Notification model
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	// Generate UUID for the notification
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+
+	if n.Status == "" {
+		n.Status = NotificationStatusQueued
+	}
+	if n.Priority == "" {
+		n.Priority = NotificationPriorityNormal
+	}
+	return nil
+}
+
+// NotificationStatusChange records a status a notification went through
+type NotificationStatusChange struct {
+	ID             uuid.UUID          `json:"-" gorm:"primaryKey"`
+	NotificationID uuid.UUID          `json:"-" gorm:"not null;index"`
+	Status         NotificationStatus `json:"status" gorm:"not null"`
+	Reason         string             `json:"reason,omitempty"`
+	CreatedAt      time.Time          `json:"created_at" gorm:"index"` // Delivery rollups are aggregated by the time of the change
+}
+
+// TableName specifies the table name for the NotificationStatusChange model
+func (NotificationStatusChange) TableName() string {
+	return "notification_status_changes"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (c *NotificationStatusChange) BeforeCreate(tx *gorm.DB) error {
+	// Generate UUID for the status change
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// AttachmentDisposition tells email clients how to present an attachment
+type AttachmentDisposition string
+
+const (
+	AttachmentDispositionAttachment AttachmentDisposition = "attachment"
+	AttachmentDispositionInline     AttachmentDisposition = "inline" // Referenced from the HTML body with "cid:<content_id>"
+)
+
+// NotificationAttachment links a stored file to an email notification
+type NotificationAttachment struct {
+	ID             uuid.UUID             `json:"id" gorm:"primaryKey"`
+	NotificationID uuid.UUID             `json:"notification_id" gorm:"not null;index"`
+	FileID         uuid.UUID             `json:"file_id" gorm:"not null"`
+	File           File                  `json:"-" gorm:"foreignKey:FileID"`
+	Filename       string                `json:"filename" gorm:"not null"`
+	ContentType    string                `json:"content_type" gorm:"not null"`
+	Size           int64                 `json:"size"`
+	Disposition    AttachmentDisposition `json:"disposition" gorm:"not null;default:'attachment'"`
+	ContentID      string                `json:"content_id,omitempty"`
+}
+
+// TableName specifies the table name for the NotificationAttachment model
+func (NotificationAttachment) TableName() string {
+	return "notification_attachments"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (a *NotificationAttachment) BeforeCreate(tx *gorm.DB) error {
+	// Generate UUID for the attachment
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
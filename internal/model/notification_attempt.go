@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AttemptOutcome is the result of handing a notification to a provider
+type AttemptOutcome string
+
+const (
+	AttemptOutcomeSucceeded AttemptOutcome = "succeeded"
+	AttemptOutcomeFailed    AttemptOutcome = "failed"
+	AttemptOutcomeRejected  AttemptOutcome = "rejected" // The provider refused the recipients, they are suppressed
+)
+
+// NotificationAttempt records a hand-over of a notification to a provider. A delivery failing over to
+// other providers records an attempt per provider with the same number.
+type NotificationAttempt struct {
+	ID                uuid.UUID      `json:"id" gorm:"primaryKey"`
+	NotificationID    uuid.UUID      `json:"notification_id" gorm:"not null;index"`
+	Number            int            `json:"number" gorm:"not null"` // The delivery attempt of the notification, starting at 1
+	Provider          string         `json:"provider" gorm:"not null;index:idx_notification_attempts_message,priority:1"`
+	ProviderMessageID string         `json:"provider_message_id,omitempty" gorm:"index:idx_notification_attempts_message,priority:2"`
+	Outcome           AttemptOutcome `json:"outcome" gorm:"not null"`
+	LatencyMs         int64          `json:"latency_ms" gorm:"not null;default:0"`
+	ResponseCode      string         `json:"response_code,omitempty"`
+	Response          string         `json:"response,omitempty"` // Sanitized response body of the provider
+	Error             string         `json:"error,omitempty"`
+	CreatedAt         time.Time      `json:"created_at"`
+}
+
+// TableName specifies the table name for the NotificationAttempt model
+func (NotificationAttempt) TableName() string {
+	return "notification_attempts"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (a *NotificationAttempt) BeforeCreate(tx *gorm.DB) error {
+	// Generate UUID for the attempt
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
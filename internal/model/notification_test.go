@@ -0,0 +1,50 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationStatusCanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from NotificationStatus
+		to   NotificationStatus
+		want bool
+	}{
+		{from: NotificationStatusQueued, to: NotificationStatusProcessing, want: true},
+		{from: NotificationStatusQueued, to: NotificationStatusBatched, want: true},
+		{from: NotificationStatusQueued, to: NotificationStatusSent},
+		{from: NotificationStatusQueued, to: NotificationStatusQueued},
+		{from: NotificationStatusDeferred, to: NotificationStatusProcessing, want: true},
+		{from: NotificationStatusDeferred, to: NotificationStatusQueued},
+		{from: NotificationStatusProcessing, to: NotificationStatusQueued, want: true},
+		{from: NotificationStatusProcessing, to: NotificationStatusSent, want: true},
+		{from: NotificationStatusProcessing, to: NotificationStatusFailed, want: true},
+		{from: NotificationStatusProcessing, to: NotificationStatusDeferred, want: true},
+		{from: NotificationStatusProcessing, to: NotificationStatusSkippedPreference, want: true},
+		{from: NotificationStatusProcessing, to: NotificationStatusSkippedSuppressed, want: true},
+		{from: NotificationStatusProcessing, to: NotificationStatusSkippedCapped, want: true},
+		{from: NotificationStatusProcessing, to: NotificationStatusSkippedFallback, want: true},
+		{from: NotificationStatusProcessing, to: NotificationStatusDelivered},
+		{from: NotificationStatusProcessing, to: NotificationStatusBounced},
+		{from: NotificationStatusSent, to: NotificationStatusDelivered, want: true},
+		{from: NotificationStatusSent, to: NotificationStatusFailed, want: true},
+		{from: NotificationStatusSent, to: NotificationStatusBounced, want: true},
+		{from: NotificationStatusSent, to: NotificationStatusProcessing},
+		{from: NotificationStatusDelivered, to: NotificationStatusBounced},
+		{from: NotificationStatusDelivered, to: NotificationStatusFailed},
+		{from: NotificationStatusFailed, to: NotificationStatusQueued},
+		{from: NotificationStatusBounced, to: NotificationStatusDelivered},
+		{from: NotificationStatusSkippedFallback, to: NotificationStatusProcessing},
+		{from: NotificationStatusBatched, to: NotificationStatusSent},
+		{from: NotificationStatusDeduplicated, to: NotificationStatusQueued},
+		{from: "archived", to: NotificationStatusQueued},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" to "+string(tt.to), func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.from.CanTransitionTo(tt.to))
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ProviderCredential is the provider an application delivers a channel with instead of the platform
+// providers, e.g. its own SendGrid or Twilio account. The secrets are encrypted at rest with a data key
+// which is itself encrypted with the credential key KeyID of the configuration.
+type ProviderCredential struct {
+	ID               uuid.UUID      `json:"id" gorm:"primaryKey"`
+	ApplicationID    uuid.UUID      `json:"application_id" gorm:"not null;uniqueIndex:idx_provider_credentials_application_channel"`
+	Application      Application    `json:"-" gorm:"foreignKey:ApplicationID"`
+	Channel          Channel        `json:"channel" gorm:"not null;uniqueIndex:idx_provider_credentials_application_channel"`
+	Driver           string         `json:"driver" gorm:"not null"`
+	Name             string         `json:"name" gorm:"not null"`
+	Sender           string         `json:"sender,omitempty"`                                       // Default sender identity of the application's messages
+	Settings         map[string]any `json:"settings,omitempty" gorm:"type:jsonb;serializer:json"`   // Non-secret driver settings
+	SecretNames      []string       `json:"secret_names" gorm:"type:jsonb;serializer:json"`         // Names of the encrypted secrets, never their values
+	RateLimit        *RateLimit     `json:"rate_limit,omitempty" gorm:"type:jsonb;serializer:json"` // Throttles the sends to the application's provider
+	KeyID            string         `json:"key_id" gorm:"not null;index"`
+	EncryptedKey     []byte         `json:"-" gorm:"not null"`
+	EncryptedSecrets []byte         `json:"-" gorm:"not null"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+// RateLimit limits the sends to a provider to Limit messages per PerSeconds, with bursts of up to Burst
+type RateLimit struct {
+	Limit      int  `json:"limit"`
+	PerSeconds int  `json:"per_seconds"`
+	Burst      int  `json:"burst,omitempty"`      // Defaults to Limit
+	PerSender  bool `json:"per_sender,omitempty"` // Limits each sender identity separately
+}
+
+// Per returns the period of the rate limit, one second by default
+func (r RateLimit) Per() time.Duration {
+	if r.PerSeconds <= 0 {
+		return time.Second
+	}
+	return time.Duration(r.PerSeconds) * time.Second
+}
+
+// TableName specifies the table name for the ProviderCredential model
+func (ProviderCredential) TableName() string {
+	return "provider_credentials"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (c *ProviderCredential) BeforeCreate(tx *gorm.DB) error {
+	// Generate UUID for the provider credential
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
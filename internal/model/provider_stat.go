@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// ProviderStat counts the sends of a provider during a minute, the buckets of a rolling window are
+// added up to score the health of the provider
+type ProviderStat struct {
+	Channel     Channel   `json:"channel" gorm:"primaryKey"`
+	Provider    string    `json:"provider" gorm:"primaryKey"`
+	BucketStart time.Time `json:"bucket_start" gorm:"primaryKey"`
+	Successes   int64     `json:"successes" gorm:"not null;default:0"`
+	Failures    int64     `json:"failures" gorm:"not null;default:0"`
+	LatencyMs   int64     `json:"latency_ms" gorm:"not null;default:0"` // Total latency of the sends
+}
+
+// TableName specifies the table name for the ProviderStat model
+func (ProviderStat) TableName() string {
+	return "provider_stats"
+}
+
+// ProviderHealth is the health of a configured provider over the rolling window
+type ProviderHealth struct {
+	Channel      Channel `json:"channel"`
+	Provider     string  `json:"provider"`
+	Priority     int     `json:"priority"`
+	Weight       int     `json:"weight"`
+	Successes    int64   `json:"successes"`
+	Failures     int64   `json:"failures"`
+	SuccessRate  float64 `json:"success_rate"` // 1 when the provider was not used
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	Healthy      bool    `json:"healthy"`
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"time"
+)
+
+// quietHoursLayout is the layout of the start and end of quiet hours
+const quietHoursLayout = "15:04"
+
+// QuietHours is a daily window, in local time, during which non-urgent notifications are deferred.
+// A window whose end is before its start spans midnight, e.g. 22:00 to 08:00.
+type QuietHours struct {
+	Start    string    `json:"start"`
+	End      string    `json:"end"`
+	Timezone string    `json:"timezone,omitempty"` // Falls back to the contact's timezone, then UTC
+	Channels []Channel `json:"channels,omitempty"` // Applies to every channel when empty
+}
+
+// WindowEnd reports whether at falls within the quiet hours for a channel and returns the end of the window
+func (q *QuietHours) WindowEnd(at time.Time, channel Channel, fallbackTimezone string) (time.Time, bool) {
+	if !q.applies(channel) {
+		return time.Time{}, false
+	}
+
+	start, errStart := time.Parse(quietHoursLayout, q.Start)
+	end, errEnd := time.Parse(quietHoursLayout, q.End)
+	if errStart != nil || errEnd != nil || q.Start == q.End {
+		return time.Time{}, false
+	}
+
+	location := loadLocation(q.Timezone, fallbackTimezone)
+	local := at.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+
+	// endOn returns the end of the window on the given day offset from the local date
+	endOn := func(days int) time.Time {
+		return time.Date(local.Year(), local.Month(), local.Day()+days, end.Hour(), end.Minute(), 0, 0, location)
+	}
+
+	switch {
+	case startMinute < endMinute && minute >= startMinute && minute < endMinute:
+		return endOn(0), true
+	case startMinute > endMinute && minute >= startMinute:
+		return endOn(1), true
+	case startMinute > endMinute && minute < endMinute:
+		return endOn(0), true
+	}
+	return time.Time{}, false
+}
+
+// applies reports whether the quiet hours cover a channel
+func (q *QuietHours) applies(channel Channel) bool {
+	if len(q.Channels) == 0 {
+		return true
+	}
+	for _, c := range q.Channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// loadLocation returns the first valid time zone among names, or UTC
+func loadLocation(names ...string) *time.Location {
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		if location, err := time.LoadLocation(name); err == nil {
+			return location
+		}
+	}
+	return time.UTC
+}
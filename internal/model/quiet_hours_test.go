@@ -0,0 +1,109 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuietHoursWindowEnd(t *testing.T) {
+	utc := func(value string) time.Time {
+		t.Helper()
+		parsed, err := time.Parse(time.RFC3339, value)
+		require.NoError(t, err, "invalid test time %s", value)
+		return parsed
+	}
+
+	overnight := QuietHours{Start: "22:00", End: "07:00"}
+	lunch := QuietHours{Start: "12:00", End: "14:00"}
+
+	tests := []struct {
+		name             string
+		quietHours       QuietHours
+		at               string
+		channel          Channel
+		fallbackTimezone string
+		wantEnd          string // Empty when at is outside the quiet hours
+	}{
+		{name: "same day window", quietHours: lunch, at: "2024-03-01T13:00:00Z", wantEnd: "2024-03-01T14:00:00Z"},
+		{name: "same day window start is quiet", quietHours: lunch, at: "2024-03-01T12:00:00Z", wantEnd: "2024-03-01T14:00:00Z"},
+		{name: "same day window end is not quiet", quietHours: lunch, at: "2024-03-01T14:00:00Z"},
+		{name: "before same day window", quietHours: lunch, at: "2024-03-01T11:59:00Z"},
+		{name: "overnight window before midnight", quietHours: overnight, at: "2024-03-01T23:15:00Z", wantEnd: "2024-03-02T07:00:00Z"},
+		{name: "overnight window after midnight", quietHours: overnight, at: "2024-03-02T05:00:00Z", wantEnd: "2024-03-02T07:00:00Z"},
+		{name: "overnight window end of month", quietHours: overnight, at: "2024-02-29T22:00:00Z", wantEnd: "2024-03-01T07:00:00Z"},
+		{name: "outside overnight window", quietHours: overnight, at: "2024-03-01T07:00:00Z"},
+		{
+			name:       "own timezone",
+			quietHours: QuietHours{Start: "22:00", End: "07:00", Timezone: "America/New_York"},
+			at:         "2024-03-01T03:30:00Z", // 22:30 in New York
+			wantEnd:    "2024-03-01T12:00:00Z",
+		},
+		{
+			name:             "own timezone takes precedence over the fallback",
+			quietHours:       QuietHours{Start: "22:00", End: "07:00", Timezone: "America/New_York"},
+			at:               "2024-03-01T03:30:00Z",
+			fallbackTimezone: "Europe/Berlin",
+			wantEnd:          "2024-03-01T12:00:00Z",
+		},
+		{
+			name:             "fallback timezone",
+			quietHours:       overnight,
+			at:               "2024-03-01T21:30:00Z", // 22:30 in Berlin
+			fallbackTimezone: "Europe/Berlin",
+			wantEnd:          "2024-03-02T06:00:00Z",
+		},
+		{
+			name:             "invalid own timezone uses the fallback",
+			quietHours:       QuietHours{Start: "22:00", End: "07:00", Timezone: "Mars/Olympus_Mons"},
+			at:               "2024-03-01T21:30:00Z",
+			fallbackTimezone: "Europe/Berlin",
+			wantEnd:          "2024-03-02T06:00:00Z",
+		},
+		{
+			name:             "invalid timezones use UTC",
+			quietHours:       QuietHours{Start: "22:00", End: "07:00", Timezone: "Mars/Olympus_Mons"},
+			at:               "2024-03-01T21:30:00Z",
+			fallbackTimezone: "Nowhere",
+		},
+		{
+			name:             "window spanning a daylight saving change",
+			quietHours:       overnight,
+			at:               "2024-03-30T22:00:00Z", // 23:00 CET, the window ends at 07:00 CEST
+			fallbackTimezone: "Europe/Berlin",
+			wantEnd:          "2024-03-31T05:00:00Z",
+		},
+		{
+			name:       "channel covered",
+			quietHours: QuietHours{Start: "12:00", End: "14:00", Channels: []Channel{ChannelSMS, ChannelPush}},
+			at:         "2024-03-01T13:00:00Z",
+			channel:    ChannelSMS,
+			wantEnd:    "2024-03-01T14:00:00Z",
+		},
+		{
+			name:       "channel not covered",
+			quietHours: QuietHours{Start: "12:00", End: "14:00", Channels: []Channel{ChannelSMS, ChannelPush}},
+			at:         "2024-03-01T13:00:00Z",
+			channel:    ChannelEmail,
+		},
+		{name: "empty window", quietHours: QuietHours{Start: "12:00", End: "12:00"}, at: "2024-03-01T12:00:00Z"},
+		{name: "invalid start", quietHours: QuietHours{Start: "noon", End: "14:00"}, at: "2024-03-01T13:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channel := tt.channel
+			if channel == "" {
+				channel = ChannelEmail
+			}
+
+			end, quiet := tt.quietHours.WindowEnd(utc(tt.at), channel, tt.fallbackTimezone)
+			require.Equal(t, tt.wantEnd != "", quiet, "quiet")
+			if quiet {
+				assert.Equal(t, tt.wantEnd, end.UTC().Format(time.RFC3339))
+			}
+		})
+	}
+}
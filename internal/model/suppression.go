@@ -0,0 +1,50 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SuppressionReason tells why an address must not receive notifications
+type SuppressionReason string
+
+const (
+	SuppressionReasonBounce    SuppressionReason = "bounce"    // The address permanently rejected a message
+	SuppressionReasonComplaint SuppressionReason = "complaint" // The recipient marked a message as spam
+	SuppressionReasonManual    SuppressionReason = "manual"
+)
+
+// SuppressionSourceAPI is the source of suppressions added through the API, suppressions fed by
+// delivery events have the name of the provider that reported them as source
+const SuppressionSourceAPI = "api"
+
+// Suppression blocks the delivery of an application's notifications to an address on a channel
+type Suppression struct {
+	ID            uuid.UUID         `json:"id" gorm:"primaryKey"`
+	ApplicationID uuid.UUID         `json:"application_id" gorm:"not null;uniqueIndex:idx_suppressions_address"`
+	Application   Application       `json:"-" gorm:"foreignKey:ApplicationID"`
+	Channel       Channel           `json:"channel" gorm:"not null;uniqueIndex:idx_suppressions_address"`
+	Address       string            `json:"address" gorm:"not null;uniqueIndex:idx_suppressions_address"`
+	Reason        SuppressionReason `json:"reason" gorm:"not null"`
+	Source        string            `json:"source" gorm:"not null"`
+	Description   string            `json:"description,omitempty"`
+	ExpiresAt     *time.Time        `json:"expires_at,omitempty"` // Permanent when nil
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// TableName specifies the table name for the Suppression model
+func (Suppression) TableName() string {
+	return "suppressions"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (s *Suppression) BeforeCreate(tx *gorm.DB) error {
+	// Generate UUID for the suppression
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
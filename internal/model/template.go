@@ -0,0 +1,92 @@
+package model
+
+import (
+	"time"
+
+	"hermes-api/pkg/i18n"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Template represents a notification template owned by an application
+type Template struct {
+	ID            uuid.UUID         `json:"id" gorm:"primaryKey"`
+	ApplicationID uuid.UUID         `json:"application_id" gorm:"not null;uniqueIndex:idx_templates_application_name"`
+	Application   Application       `json:"-" gorm:"foreignKey:ApplicationID"`
+	Name          string            `json:"name" gorm:"not null;uniqueIndex:idx_templates_application_name"`
+	Channel       Channel           `json:"channel" gorm:"not null"`
+	Category      string            `json:"category,omitempty" gorm:"index"` // Topic recipients can opt out of, e.g. "marketing"
+	DefaultLocale string            `json:"default_locale"`
+	Markup        string            `json:"markup" gorm:"default:'html'"`                                      // Markup of the HTML body: "html" or "mjml"
+	DataSchema    map[string]any    `json:"data_schema,omitempty" gorm:"type:jsonb;serializer:json"`           // JSON Schema of the notification data
+	Fallbacks     []FallbackStep    `json:"fallbacks,omitempty" gorm:"type:jsonb;serializer:json"`             // Fallback chain of the notifications sent to contacts
+	Variants      []TemplateVariant `json:"variants" gorm:"foreignKey:TemplateID;constraint:OnDelete:CASCADE"` // 1 to many relationship
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt    `json:"-" gorm:"index"` // Soft delete
+}
+
+// TemplateVariant holds the locale-specific content of a template
+type TemplateVariant struct {
+	ID         uuid.UUID `json:"id" gorm:"primaryKey"`
+	TemplateID uuid.UUID `json:"template_id" gorm:"not null;uniqueIndex:idx_template_variants_template_locale"`
+	Locale     string    `json:"locale" gorm:"not null;uniqueIndex:idx_template_variants_template_locale"`
+	Subject    string    `json:"subject"`
+	Body       string    `json:"body" gorm:"type:text;not null"`
+	HTMLBody   string    `json:"html_body,omitempty" gorm:"type:text"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for the Template model
+func (Template) TableName() string {
+	return "templates"
+}
+
+// TableName specifies the table name for the TemplateVariant model
+func (TemplateVariant) TableName() string {
+	return "template_variants"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (t *Template) BeforeCreate(tx *gorm.DB) error {
+	// Generate UUID for the template
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (v *TemplateVariant) BeforeCreate(tx *gorm.DB) error {
+	// Generate UUID for the variant
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return nil
+}
+
+// Locales returns the locales this template has variants for
+func (t *Template) Locales() []string {
+	locales := make([]string, 0, len(t.Variants))
+	for _, variant := range t.Variants {
+		locales = append(locales, variant.Locale)
+	}
+	return locales
+}
+
+// Variant returns the first variant matching the locale fallback chain
+func (t *Template) Variant(chain []string) (*TemplateVariant, bool) {
+	locale, ok := i18n.Match(t.Locales(), chain)
+	if !ok {
+		return nil, false
+	}
+
+	for i := range t.Variants {
+		if t.Variants[i].Locale == locale {
+			return &t.Variants[i], true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TrackingEventType is the kind of an engagement event of an email
+type TrackingEventType string
+
+const (
+	TrackingEventOpen  TrackingEventType = "open"  // The tracking pixel was loaded
+	TrackingEventClick TrackingEventType = "click" // A tracked link was followed
+)
+
+// TrackingEvent records an open or a click of a notification. Events of machines prefetching the
+// pixel or the links, e.g. privacy proxies and link scanners, are kept apart from the recipient's.
+type TrackingEvent struct {
+	ID             uuid.UUID         `json:"id" gorm:"primaryKey"`
+	NotificationID uuid.UUID         `json:"notification_id" gorm:"not null;index"`
+	ApplicationID  uuid.UUID         `json:"application_id" gorm:"not null;index"`
+	Type           TrackingEventType `json:"type" gorm:"not null"`
+	URL            string            `json:"url,omitempty"` // The link followed by clicks
+	Machine        bool              `json:"machine" gorm:"not null;default:false"`
+	UserAgent      string            `json:"user_agent,omitempty"`
+	CreatedAt      time.Time         `json:"created_at" gorm:"index"`
+}
+
+// TableName specifies the table name for the TrackingEvent model
+func (TrackingEvent) TableName() string {
+	return "tracking_events"
+}
+
+// BeforeCreate is a GORM hook that runs before creating a record
+func (e *TrackingEvent) BeforeCreate(tx *gorm.DB) error {
+	// Generate UUID for the tracking event
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
@@ -26,6 +26,7 @@ type User struct {
 	FirstName    string         `json:"first_name" gorm:"not null"`
 	LastName     string         `json:"last_name" gorm:"not null"`
 	IsActive     bool           `json:"is_active" gorm:"default:true"`
+	Role         UserRole       `json:"role" gorm:"not null;default:'viewer'"` // Platform role, granted by operators
 	Applications []Application  `json:"applications" gorm:"foreignKey:UserID"` // 1 to many relationship
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
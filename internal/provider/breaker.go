@@ -0,0 +1,184 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"hermes-api/internal/model"
+	"hermes-api/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// ErrCircuitOpen is returned without calling the provider while its circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// probeRetryAfter is the wait suggested to calls refused while a half-open circuit probes the provider
+const probeRetryAfter = time.Second
+
+// CircuitOpenError is returned without calling the provider while its circuit breaker is open, with the
+// time left until the provider is probed again. It matches ErrCircuitOpen.
+type CircuitOpenError struct {
+	Provider   string
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker of provider '%s' is open, retry after %s", e.Provider, e.RetryAfter.Round(time.Millisecond))
+}
+
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CircuitState is the state of a circuit breaker
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"    // Calls go through, failures are counted
+	CircuitOpen     CircuitState = "open"      // Calls fail fast until the open timeout has passed
+	CircuitHalfOpen CircuitState = "half-open" // A single probe call at a time decides whether to close again
+)
+
+// BreakerConfig holds the thresholds of a circuit breaker
+type BreakerConfig struct {
+	FailureThreshold int           // Consecutive failures opening the circuit
+	OpenTimeout      time.Duration // Time the circuit stays open before probing the provider
+	SuccessThreshold int           // Consecutive successful probes closing the circuit
+}
+
+// CircuitBreaker wraps a provider so that calls fail fast while the provider keeps failing, instead of
+// tying up workers. Rejected recipients do not count as failures, the provider handled the call.
+type CircuitBreaker struct {
+	provider Provider
+	channel  model.Channel
+	config   BreakerConfig
+
+	mu        sync.Mutex
+	state     CircuitState
+	failures  int
+	successes int
+	openedAt  time.Time
+	probing   bool
+}
+
+// NewCircuitBreaker wraps the provider of a channel with a closed circuit breaker
+func NewCircuitBreaker(provider Provider, channel model.Channel, cfg BreakerConfig) *CircuitBreaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+	if cfg.SuccessThreshold <= 0 {
+		cfg.SuccessThreshold = 1
+	}
+
+	return &CircuitBreaker{
+		provider: provider,
+		channel:  channel,
+		config:   cfg,
+		state:    CircuitClosed,
+	}
+}
+
+// Name returns the name of the wrapped provider
+func (b *CircuitBreaker) Name() string {
+	return b.provider.Name()
+}
+
+// State returns the current state of the circuit
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Send calls the wrapped provider unless the circuit is open
+func (b *CircuitBreaker) Send(ctx context.Context, message *Message) (*Result, error) {
+	if retryAfter, ok := b.allow(); !ok {
+		return nil, &CircuitOpenError{Provider: b.provider.Name(), RetryAfter: retryAfter}
+	}
+
+	result, err := b.provider.Send(ctx, message)
+
+	var rejected *RejectedError
+	b.done(err == nil || errors.As(err, &rejected))
+	return result, err
+}
+
+// allow reports whether a call may go through, moving an open circuit whose timeout has passed to
+// half-open. Refused calls get the time to wait before trying again.
+func (b *CircuitBreaker) allow() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if elapsed := time.Since(b.openedAt); elapsed < b.config.OpenTimeout {
+			return b.config.OpenTimeout - elapsed, false
+		}
+		b.transition(CircuitHalfOpen)
+		fallthrough
+	case CircuitHalfOpen:
+		if b.probing {
+			return probeRetryAfter, false
+		}
+		b.probing = true
+	}
+	return 0, true
+}
+
+// done records the outcome of a call that went through
+func (b *CircuitBreaker) done(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitClosed:
+		if success {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.config.FailureThreshold {
+			b.transition(CircuitOpen)
+		}
+	case CircuitHalfOpen:
+		b.probing = false
+		if !success {
+			b.transition(CircuitOpen)
+			return
+		}
+		b.successes++
+		if b.successes >= b.config.SuccessThreshold {
+			b.transition(CircuitClosed)
+		}
+	}
+}
+
+// transition moves the circuit to a new state and logs it; the caller holds the lock
+func (b *CircuitBreaker) transition(state CircuitState) {
+	from := b.state
+	b.state = state
+	b.failures = 0
+	b.successes = 0
+	if state == CircuitOpen {
+		b.openedAt = time.Now()
+	}
+
+	fields := []zap.Field{
+		zap.String("provider", b.provider.Name()),
+		zap.String("channel", string(b.channel)),
+		zap.String("from", string(from)),
+		zap.String("to", string(state)),
+	}
+	if state == CircuitOpen {
+		logger.Warn("Provider circuit breaker opened", append(fields, zap.Duration("open_timeout", b.config.OpenTimeout))...)
+	} else {
+		logger.Info("Provider circuit breaker state changed", fields...)
+	}
+}
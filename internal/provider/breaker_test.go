@@ -0,0 +1,177 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"hermes-api/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubProvider answers sends with the queued errors, succeeding once none is left
+type stubProvider struct {
+	name   string
+	errs   []error
+	sent   []*Message
+	called int
+}
+
+func (p *stubProvider) Name() string {
+	return p.name
+}
+
+func (p *stubProvider) Send(ctx context.Context, message *Message) (*Result, error) {
+	p.called++
+	p.sent = append(p.sent, message)
+	if len(p.errs) == 0 {
+		return &Result{MessageID: "message"}, nil
+	}
+	err := p.errs[0]
+	p.errs = p.errs[1:]
+	return nil, err
+}
+
+func TestCircuitBreakerStates(t *testing.T) {
+	errUnavailable := errors.New("503 service unavailable")
+	rejected := &RejectedError{Addresses: []string{"ada@example.com"}, Reason: model.SuppressionReasonBounce}
+
+	// call sends through the breaker once the open timeout passed if expired is set; err is the answer
+	// of the provider when it is called
+	type call struct {
+		expired   bool
+		err       error
+		wantCall  bool
+		wantState CircuitState
+	}
+
+	tests := []struct {
+		name  string
+		calls []call
+	}{
+		{
+			name: "failures below the threshold",
+			calls: []call{
+				{err: errUnavailable, wantCall: true, wantState: CircuitClosed},
+				{err: errUnavailable, wantCall: true, wantState: CircuitClosed},
+			},
+		},
+		{
+			name: "success resets the failures",
+			calls: []call{
+				{err: errUnavailable, wantCall: true, wantState: CircuitClosed},
+				{err: errUnavailable, wantCall: true, wantState: CircuitClosed},
+				{wantCall: true, wantState: CircuitClosed},
+				{err: errUnavailable, wantCall: true, wantState: CircuitClosed},
+				{err: errUnavailable, wantCall: true, wantState: CircuitClosed},
+			},
+		},
+		{
+			name: "rejected recipients are not failures",
+			calls: []call{
+				{err: rejected, wantCall: true, wantState: CircuitClosed},
+				{err: rejected, wantCall: true, wantState: CircuitClosed},
+				{err: rejected, wantCall: true, wantState: CircuitClosed},
+			},
+		},
+		{
+			name: "consecutive failures open the circuit",
+			calls: []call{
+				{err: errUnavailable, wantCall: true, wantState: CircuitClosed},
+				{err: errUnavailable, wantCall: true, wantState: CircuitClosed},
+				{err: errUnavailable, wantCall: true, wantState: CircuitOpen},
+				{wantState: CircuitOpen},
+			},
+		},
+		{
+			name: "failed probe opens the circuit again",
+			calls: []call{
+				{err: errUnavailable, wantCall: true, wantState: CircuitClosed},
+				{err: errUnavailable, wantCall: true, wantState: CircuitClosed},
+				{err: errUnavailable, wantCall: true, wantState: CircuitOpen},
+				{expired: true, err: errUnavailable, wantCall: true, wantState: CircuitOpen},
+				{wantState: CircuitOpen},
+			},
+		},
+		{
+			name: "successful probes close the circuit",
+			calls: []call{
+				{err: errUnavailable, wantCall: true, wantState: CircuitClosed},
+				{err: errUnavailable, wantCall: true, wantState: CircuitClosed},
+				{err: errUnavailable, wantCall: true, wantState: CircuitOpen},
+				{expired: true, wantCall: true, wantState: CircuitHalfOpen},
+				{wantCall: true, wantState: CircuitClosed},
+				{err: errUnavailable, wantCall: true, wantState: CircuitClosed},
+			},
+		},
+	}
+
+	cfg := BreakerConfig{FailureThreshold: 3, OpenTimeout: time.Minute, SuccessThreshold: 2}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &stubProvider{name: "sendgrid"}
+			breaker := NewCircuitBreaker(provider, model.ChannelEmail, cfg)
+
+			for i, c := range tt.calls {
+				if c.expired {
+					breaker.openedAt = breaker.openedAt.Add(-cfg.OpenTimeout)
+				}
+				if c.err != nil {
+					provider.errs = []error{c.err}
+				}
+
+				called := provider.called
+				_, err := breaker.Send(context.Background(), &Message{})
+
+				require.Equal(t, c.wantCall, provider.called > called, "call %d: provider called", i)
+				if c.wantCall {
+					require.ErrorIs(t, err, c.err, "call %d", i)
+				} else {
+					require.ErrorIs(t, err, ErrCircuitOpen, "call %d", i)
+				}
+				require.Equal(t, c.wantState, breaker.State(), "call %d: state", i)
+				provider.errs = nil
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerRetryAfter(t *testing.T) {
+	cfg := BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute}
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration // Time since the circuit opened
+		probing bool          // Whether a probe call is in flight
+		want    time.Duration
+	}{
+		{name: "just opened", want: time.Minute},
+		{name: "partway through the timeout", elapsed: 45 * time.Second, want: 15 * time.Second},
+		{name: "probe in flight", elapsed: time.Minute, probing: true, want: probeRetryAfter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &stubProvider{name: "twilio", errs: []error{errors.New("timeout")}}
+			breaker := NewCircuitBreaker(provider, model.ChannelSMS, cfg)
+			_, _ = breaker.Send(context.Background(), &Message{})
+
+			breaker.openedAt = breaker.openedAt.Add(-tt.elapsed)
+			if tt.probing {
+				_, ok := breaker.allow()
+				require.True(t, ok, "the probe call must be allowed")
+			}
+
+			_, err := breaker.Send(context.Background(), &Message{})
+			var open *CircuitOpenError
+			require.ErrorAs(t, err, &open)
+			assert.Equal(t, "twilio", open.Provider)
+			assert.InDelta(t, tt.want, open.RetryAfter, float64(time.Second))
+			assert.LessOrEqual(t, open.RetryAfter, tt.want)
+		})
+	}
+}
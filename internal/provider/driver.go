@@ -0,0 +1,147 @@
+package provider
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"hermes-api/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// driverChannels lists the channels of the provider drivers, the log driver accepts every channel
+var driverChannels = map[string][]model.Channel{
+	"log":      nil,
+	"sendgrid": {model.ChannelEmail},
+	"twilio":   {model.ChannelSMS},
+}
+
+// SupportsChannel reports whether a driver delivers a channel
+func SupportsChannel(driver string, channel model.Channel) bool {
+	channels, ok := driverChannels[driver]
+	return ok && (channels == nil || slices.Contains(channels, channel))
+}
+
+// Credentials configure a provider driver
+type Credentials struct {
+	Sender   string
+	Settings map[string]any
+	Secrets  map[string]string
+}
+
+// New creates a provider of a driver
+func New(driver, name string, credentials Credentials) (Provider, error) {
+	switch driver {
+	case "log":
+		return NewLogProvider(name), nil
+	case "sendgrid":
+		provider, err := NewSendGridProvider(name, credentials)
+		if err != nil {
+			return nil, err
+		}
+		return provider, nil
+	case "twilio":
+		provider, err := NewTwilioProvider(name, credentials)
+		if err != nil {
+			return nil, err
+		}
+		return provider, nil
+	default:
+		return nil, fmt.Errorf("unknown driver '%s' of provider '%s'", driver, name)
+	}
+}
+
+// poolIdleTimeout is how long a pooled provider stays unused before it is evicted, e.g. once its
+// credential was deleted
+const poolIdleTimeout = 30 * time.Minute
+
+// Pool caches the providers built from the credentials of applications, so that their circuit breakers
+// and connections outlive a single send. A provider is rebuilt when its credential is updated, and
+// evicted when it was not used for a while.
+type Pool struct {
+	mu        sync.Mutex
+	providers map[uuid.UUID]*pooledProvider
+	decorate  func(Provider, *model.ProviderCredential) Provider
+	sweptAt   time.Time
+}
+
+type pooledProvider struct {
+	version  time.Time
+	provider Provider
+	usedAt   time.Time
+}
+
+// NewPool creates a provider pool, decorate wraps the providers it builds for a credential, e.g. with a
+// circuit breaker and the credential's rate limit
+func NewPool(decorate func(Provider, *model.ProviderCredential) Provider) *Pool {
+	return &Pool{
+		providers: make(map[uuid.UUID]*pooledProvider),
+		decorate:  decorate,
+		sweptAt:   time.Now(),
+	}
+}
+
+// Get returns the provider of a credential, building it with the secrets returned by open when the
+// credential was not used yet or has changed since. Providers are built without holding the pool, a
+// provider built concurrently for the same version is kept instead.
+func (p *Pool) Get(credential *model.ProviderCredential, open func() (map[string]string, error)) (Provider, error) {
+	if provider, ok := p.lookup(credential); ok {
+		return provider, nil
+	}
+
+	secrets, err := open()
+	if err != nil {
+		return nil, err
+	}
+	provider, err := New(credential.Driver, credential.Name, Credentials{
+		Sender:   credential.Sender,
+		Settings: credential.Settings,
+		Secrets:  secrets,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if p.decorate != nil {
+		provider = p.decorate(provider, credential)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pooled, ok := p.providers[credential.ID]
+	switch {
+	case ok && pooled.version.Equal(credential.UpdatedAt):
+		provider = pooled.provider
+	case ok && pooled.version.After(credential.UpdatedAt):
+		// Built from a stale read of the credential, the pool keeps the newer provider
+	default:
+		p.providers[credential.ID] = &pooledProvider{version: credential.UpdatedAt, provider: provider, usedAt: time.Now()}
+	}
+	return provider, nil
+}
+
+// lookup returns the pooled provider of the current version of a credential, evicting idle providers
+// once in a while
+func (p *Pool) lookup(credential *model.ProviderCredential) (Provider, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(p.sweptAt) >= poolIdleTimeout {
+		for id, pooled := range p.providers {
+			if now.Sub(pooled.usedAt) >= poolIdleTimeout {
+				delete(p.providers, id)
+			}
+		}
+		p.sweptAt = now
+	}
+
+	pooled, ok := p.providers[credential.ID]
+	if !ok || !pooled.version.Equal(credential.UpdatedAt) {
+		return nil, false
+	}
+	pooled.usedAt = now
+	return pooled.provider, true
+}
@@ -0,0 +1,42 @@
+package provider
+
+import (
+	"context"
+	"strings"
+
+	"hermes-api/pkg/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// LogProvider writes messages to the log instead of delivering them, for development
+type LogProvider struct {
+	name string
+}
+
+// NewLogProvider creates a new log provider
+func NewLogProvider(name string) *LogProvider {
+	return &LogProvider{name: name}
+}
+
+// Name returns the name of the provider
+func (p *LogProvider) Name() string {
+	return p.name
+}
+
+// Send logs the message and returns a generated message ID
+func (p *LogProvider) Send(ctx context.Context, message *Message) (*Result, error) {
+	messageID := uuid.New().String()
+
+	logger.WithNotification(message.NotificationID.String(), strings.Join(message.To, ","), string(message.Channel)).
+		Info("📨 Message delivered to log provider",
+			zap.String("provider", p.name),
+			zap.String("message_id", messageID),
+			zap.String("subject", message.Subject),
+			zap.Int("attachments", len(message.Attachments)),
+			zap.Any("headers", message.Headers),
+		)
+
+	return &Result{MessageID: messageID}, nil
+}
@@ -0,0 +1,152 @@
+// Package provider defines the adapters that hand rendered notifications to delivery providers
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"sync"
+
+	"hermes-api/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// Message is a rendered notification ready to be delivered
+type Message struct {
+	NotificationID uuid.UUID
+	ApplicationID  uuid.UUID
+	Channel        model.Channel
+	Sender         string // Sender identity, e.g. a from address or SMS sender ID; empty for the provider default
+	To             []string
+	Subject        string
+	Body           string
+	HTMLBody       string
+	Headers        map[string]string // Extra email headers such as List-Unsubscribe
+	Attachments    []Attachment
+}
+
+// Attachment is the content of an email attachment or inline image
+type Attachment struct {
+	Filename    string
+	ContentType string
+	ContentID   string // Set for inline images
+	Content     []byte
+}
+
+// Result is the outcome of a delivery. Providers also return it with an error when the provider
+// answered, so that its response is recorded with the failed attempt.
+type Result struct {
+	MessageID    string // ID assigned by the provider, used to correlate delivery events
+	ResponseCode string // e.g. the HTTP status or SMTP reply code
+	Response     string // Raw response body, sanitized before it is stored
+}
+
+// RejectedError is returned by providers when recipients permanently refused a message, e.g. a hard
+// bounce or a spam complaint; the addresses are added to the application's suppression list
+type RejectedError struct {
+	Addresses   []string
+	Reason      model.SuppressionReason
+	Description string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Reason, e.Description)
+}
+
+// Provider delivers messages of a channel, Send may return a Result along with an error
+type Provider interface {
+	Name() string
+	Send(ctx context.Context, message *Message) (*Result, error)
+}
+
+// Route is a provider registered for a channel with its routing priority and weight
+type Route struct {
+	Provider Provider
+	Priority int // Lower priorities are tried first
+	Weight   int // Share of the traffic among the providers of the same priority
+}
+
+// Registry holds the providers of each channel
+type Registry struct {
+	mu     sync.RWMutex
+	routes map[model.Channel][]Route
+}
+
+// NewRegistry creates an empty provider registry
+func NewRegistry() *Registry {
+	return &Registry{
+		routes: make(map[model.Channel][]Route),
+	}
+}
+
+// Register adds a provider to a channel
+func (r *Registry) Register(channel model.Channel, provider Provider, priority, weight int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	routes := append(r.routes[channel], Route{Provider: provider, Priority: priority, Weight: max(weight, 1)})
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].Priority < routes[j].Priority
+	})
+	r.routes[channel] = routes
+}
+
+// Routes returns the providers of a channel by priority
+func (r *Registry) Routes(channel model.Channel) []Route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.routes[channel])
+}
+
+// Order returns the providers of a channel in the order they should be tried: the healthy providers
+// by priority, drawn by weight among the same priority, then the unhealthy ones as a last resort
+func (r *Registry) Order(channel model.Channel, healthy func(name string) bool) []Provider {
+	routes := r.Routes(channel)
+
+	var preferred, fallback []Route
+	for _, route := range routes {
+		if healthy(route.Provider.Name()) {
+			preferred = append(preferred, route)
+		} else {
+			fallback = append(fallback, route)
+		}
+	}
+
+	providers := make([]Provider, 0, len(routes))
+	for _, group := range [][]Route{preferred, fallback} {
+		for start := 0; start < len(group); {
+			end := start + 1
+			for end < len(group) && group[end].Priority == group[start].Priority {
+				end++
+			}
+			providers = append(providers, drawByWeight(group[start:end])...)
+			start = end
+		}
+	}
+	return providers
+}
+
+// drawByWeight orders routes by drawing them one after the other with a probability proportional to their weight
+func drawByWeight(routes []Route) []Provider {
+	remaining := slices.Clone(routes)
+	providers := make([]Provider, 0, len(routes))
+	for len(remaining) > 0 {
+		total := 0
+		for _, route := range remaining {
+			total += route.Weight
+		}
+
+		pick := rand.Intn(total)
+		i := 0
+		for ; pick >= remaining[i].Weight; i++ {
+			pick -= remaining[i].Weight
+		}
+
+		providers = append(providers, remaining[i].Provider)
+		remaining = slices.Delete(remaining, i, i+1)
+	}
+	return providers
+}
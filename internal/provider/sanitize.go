@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxResponseLength is the length responses of providers are truncated to before they are stored
+const maxResponseLength = 2048
+
+// redacted replaces the values of sensitive fields in provider responses
+const redacted = "[REDACTED]"
+
+var (
+	// sensitiveKey matches the names of fields holding credentials
+	sensitiveKey = regexp.MustCompile(`(?i)(pass(word)?|secret|token|api[_-]?key|auth(orization)?|credential|signature|private[_-]?key)`)
+
+	// sensitiveParam matches credentials in form encoded or plain text responses, e.g. "api_key=..." or "token: ..."
+	sensitiveParam = regexp.MustCompile(`(?i)((?:pass(?:word)?|secret|token|api[_-]?key|authorization|credential|signature)["']?\s*[:=]\s*["']?)(?:bearer\s+|basic\s+)?[^\s&"',;}]+`)
+)
+
+// SanitizeResponse prepares the response of a provider to be stored: credentials are redacted, control
+// characters removed and the response truncated
+func SanitizeResponse(response string) string {
+	response = strings.TrimSpace(response)
+	if response == "" {
+		return ""
+	}
+
+	var document any
+	if err := json.Unmarshal([]byte(response), &document); err == nil {
+		if encoded, err := json.Marshal(redactValue(document)); err == nil {
+			response = string(encoded)
+		}
+	} else {
+		response = sensitiveParam.ReplaceAllString(response, "${1}"+redacted)
+	}
+
+	response = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' || !unicode.IsControl(r) {
+			return r
+		}
+		return -1
+	}, response)
+
+	if len(response) > maxResponseLength {
+		cut := maxResponseLength
+		for cut > 0 && !utf8.RuneStart(response[cut]) {
+			cut--
+		}
+		response = response[:cut] + "…"
+	}
+	return response
+}
+
+// redactValue replaces the values of sensitive fields in a decoded JSON document
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if sensitiveKey.MatchString(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redactValue(field)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
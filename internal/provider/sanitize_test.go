@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeResponse(t *testing.T) {
+	long := strings.Repeat("a", maxResponseLength+10)
+	// A multi-byte rune straddling the limit must not be cut in half
+	straddling := strings.Repeat("a", maxResponseLength-1) + "é" + "tail"
+
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{name: "empty", response: "  \n ", want: ""},
+		{name: "plain text", response: "  250 OK queued as 12345\n", want: "250 OK queued as 12345"},
+		{
+			name:     "JSON without credentials",
+			response: `{"message_id": "abc", "status": 202}`,
+			want:     `{"message_id":"abc","status":202}`,
+		},
+		{
+			name:     "JSON credentials",
+			response: `{"api_key": "SG.secret", "Authorization": "Bearer abc", "id": "1"}`,
+			want:     `{"Authorization":"[REDACTED]","api_key":"[REDACTED]","id":"1"}`,
+		},
+		{
+			name:     "nested JSON credentials",
+			response: `{"errors": [{"field": "token", "auth_token": "xyz"}], "account": {"password": "hunter2", "sid": "AC1"}}`,
+			want:     `{"account":{"password":"[REDACTED]","sid":"AC1"},"errors":[{"auth_token":"[REDACTED]","field":"token"}]}`,
+		},
+		{
+			name:     "form encoded credentials",
+			response: "status=failed&api_key=SG.secret&secret=s3cr3t&id=42",
+			want:     "status=failed&api_key=[REDACTED]&secret=[REDACTED]&id=42",
+		},
+		{
+			name:     "header credentials",
+			response: "401 Unauthorized\nAuthorization: Bearer eyJhbGciOi\ntoken: 'abc'",
+			want:     "401 Unauthorized\nAuthorization: [REDACTED]\ntoken: '[REDACTED]'",
+		},
+		{
+			name:     "control characters",
+			response: "bad\x00 request\x1b[31m\tline\r\nnext",
+			want:     "bad request[31m\tline\nnext",
+		},
+		{name: "truncated", response: long, want: long[:maxResponseLength] + "…"},
+		{name: "truncated at a rune boundary", response: straddling, want: straddling[:maxResponseLength-1] + "…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SanitizeResponse(tt.response))
+		})
+	}
+}
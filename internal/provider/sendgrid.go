@@ -0,0 +1,141 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// httpClient sends the requests of the HTTP API providers
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// maxResponseRead is the part of a provider's response body that is read
+const maxResponseRead = 64 * 1024
+
+// SendGridProvider delivers emails through the SendGrid v3 mail send API
+type SendGridProvider struct {
+	name    string
+	apiKey  string
+	sender  string
+	baseURL string
+}
+
+// NewSendGridProvider creates a SendGrid provider from its "api_key" secret, the "base_url" setting
+// overrides the API URL
+func NewSendGridProvider(name string, credentials Credentials) (*SendGridProvider, error) {
+	if credentials.Secrets["api_key"] == "" {
+		return nil, fmt.Errorf("provider '%s' has no 'api_key' secret", name)
+	}
+	return &SendGridProvider{
+		name:    name,
+		apiKey:  credentials.Secrets["api_key"],
+		sender:  credentials.Sender,
+		baseURL: stringSetting(credentials.Settings, "base_url", "https://api.sendgrid.com"),
+	}, nil
+}
+
+// Name returns the name of the provider
+func (p *SendGridProvider) Name() string {
+	return p.name
+}
+
+// Send posts the message to the mail send API, the message ID is the X-Message-Id of the response
+func (p *SendGridProvider) Send(ctx context.Context, message *Message) (*Result, error) {
+	sender := message.Sender
+	if sender == "" {
+		sender = p.sender
+	}
+	if sender == "" {
+		return nil, errors.New("no sender configured")
+	}
+
+	type address struct {
+		Email string `json:"email"`
+	}
+	type content struct {
+		Type  string `json:"type"`
+		Value string `json:"value"`
+	}
+	type attachment struct {
+		Content     string `json:"content"`
+		Type        string `json:"type,omitempty"`
+		Filename    string `json:"filename"`
+		Disposition string `json:"disposition,omitempty"`
+		ContentID   string `json:"content_id,omitempty"`
+	}
+
+	to := make([]address, len(message.To))
+	for i, recipient := range message.To {
+		to[i] = address{Email: recipient}
+	}
+	contents := []content{{Type: "text/plain", Value: message.Body}}
+	if message.HTMLBody != "" {
+		contents = append(contents, content{Type: "text/html", Value: message.HTMLBody})
+	}
+	var attachments []attachment
+	for _, file := range message.Attachments {
+		disposition := "attachment"
+		if file.ContentID != "" {
+			disposition = "inline"
+		}
+		attachments = append(attachments, attachment{
+			Content:     base64.StdEncoding.EncodeToString(file.Content),
+			Type:        file.ContentType,
+			Filename:    file.Filename,
+			Disposition: disposition,
+			ContentID:   file.ContentID,
+		})
+	}
+
+	payload, err := json.Marshal(map[string]any{
+		"personalizations": []map[string]any{{"to": to}},
+		"from":             address{Email: sender},
+		"subject":          message.Subject,
+		"content":          contents,
+		"attachments":      attachments,
+		"headers":          message.Headers,
+		"custom_args":      map[string]string{"notification_id": message.NotificationID.String()},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/v3/mail/send", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Authorization", "Bearer "+p.apiKey)
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(response.Body, maxResponseRead))
+	result := &Result{
+		MessageID:    response.Header.Get("X-Message-Id"),
+		ResponseCode: strconv.Itoa(response.StatusCode),
+		Response:     string(body),
+	}
+	if response.StatusCode >= 300 {
+		return result, fmt.Errorf("sendgrid responded %s", response.Status)
+	}
+	return result, nil
+}
+
+// stringSetting returns a string setting of a provider, or fallback when it is not set
+func stringSetting(settings map[string]any, name, fallback string) string {
+	if value, ok := settings[name].(string); ok && value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"hermes-api/internal/model"
+	"hermes-api/pkg/logger"
+	"hermes-api/pkg/ratelimit"
+
+	"go.uber.org/zap"
+)
+
+// ThrottledError is returned without calling the provider when its rate limit leaves no token for
+// longer than the throttle is allowed to wait
+type ThrottledError struct {
+	Provider   string
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("rate limit of provider '%s' reached, retry after %s", e.Provider, e.RetryAfter)
+}
+
+// ThrottleConfig holds the rate limit of a provider: Limit messages per Per, with bursts of up to Burst
+type ThrottleConfig struct {
+	Key       string // Bucket of the rate limit, defaults to the channel and name of the provider
+	Limit     int
+	Per       time.Duration
+	Burst     int  // Defaults to Limit
+	PerSender bool // Limits each sender identity separately instead of the provider as a whole
+	MaxWait   time.Duration
+}
+
+// Throttle wraps a provider with a token bucket rate limit. Sends wait for a token up to MaxWait,
+// beyond which a ThrottledError lets the notification be deferred. Sends go through when the bucket
+// cannot be reached.
+type Throttle struct {
+	provider Provider
+	key      string
+	bucket   ratelimit.TokenBucket
+	rate     float64
+	config   ThrottleConfig
+}
+
+// NewThrottle wraps the provider of a channel with a rate limit kept in bucket
+func NewThrottle(provider Provider, channel model.Channel, bucket ratelimit.TokenBucket, cfg ThrottleConfig) *Throttle {
+	if cfg.Per <= 0 {
+		cfg.Per = time.Second
+	}
+	if cfg.Burst <= 0 {
+		cfg.Burst = cfg.Limit
+	}
+	if cfg.Key == "" {
+		cfg.Key = string(channel) + ":" + provider.Name()
+	}
+
+	return &Throttle{
+		provider: provider,
+		key:      cfg.Key,
+		bucket:   bucket,
+		rate:     float64(cfg.Limit) / cfg.Per.Seconds(),
+		config:   cfg,
+	}
+}
+
+// Name returns the name of the wrapped provider
+func (t *Throttle) Name() string {
+	return t.provider.Name()
+}
+
+// Send calls the wrapped provider once a token is available
+func (t *Throttle) Send(ctx context.Context, message *Message) (*Result, error) {
+	key := t.key
+	if t.config.PerSender {
+		key += ":" + message.Sender
+	}
+
+	for {
+		// An unavailable rate limit store must not stop deliveries, the provider enforces its own limit anyway
+		wait, err := t.bucket.Take(ctx, key, t.rate, t.config.Burst)
+		if err != nil {
+			logger.Error("Failed to take provider rate limit token", err,
+				zap.String("provider", t.provider.Name()),
+				zap.String("key", key),
+			)
+			return t.provider.Send(ctx, message)
+		}
+		if wait == 0 {
+			return t.provider.Send(ctx, message)
+		}
+		if wait > t.config.MaxWait {
+			return nil, &ThrottledError{Provider: t.provider.Name(), RetryAfter: wait}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
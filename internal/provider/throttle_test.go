@@ -0,0 +1,95 @@
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hermes-api/internal/model"
+	"hermes-api/pkg/ratelimit"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottleSend(t *testing.T) {
+	// send goes through the throttle of a credential; wantThrottled is set when it must be refused
+	type send struct {
+		credential    string
+		sender        string
+		wantThrottled bool
+	}
+
+	tests := []struct {
+		name   string
+		config ThrottleConfig
+		sends  []send
+	}{
+		{
+			name:   "within the burst",
+			config: ThrottleConfig{Limit: 1, Per: time.Hour, Burst: 2},
+			sends:  []send{{credential: "a"}, {credential: "a"}},
+		},
+		{
+			name:   "over the limit",
+			config: ThrottleConfig{Limit: 1, Per: time.Hour},
+			sends:  []send{{credential: "a"}, {credential: "a", wantThrottled: true}},
+		},
+		{
+			name:   "credentials have their own limit",
+			config: ThrottleConfig{Limit: 1, Per: time.Hour},
+			sends:  []send{{credential: "a"}, {credential: "b"}, {credential: "a", wantThrottled: true}},
+		},
+		{
+			name:   "senders share the limit of their credential",
+			config: ThrottleConfig{Limit: 1, Per: time.Hour},
+			sends:  []send{{credential: "a", sender: "billing@example.com"}, {credential: "a", sender: "news@example.com", wantThrottled: true}},
+		},
+		{
+			name:   "senders have their own limit",
+			config: ThrottleConfig{Limit: 1, Per: time.Hour, PerSender: true},
+			sends: []send{
+				{credential: "a", sender: "billing@example.com"},
+				{credential: "a", sender: "news@example.com"},
+				{credential: "a", sender: "billing@example.com", wantThrottled: true},
+			},
+		},
+		{
+			name:   "short waits are waited out",
+			config: ThrottleConfig{Limit: 100, Per: time.Second, Burst: 1, MaxWait: time.Second},
+			sends:  []send{{credential: "a"}, {credential: "a"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket := ratelimit.NewLocalTokenBucket()
+			providers := make(map[string]*stubProvider)
+
+			for i, s := range tt.sends {
+				provider, ok := providers[s.credential]
+				if !ok {
+					provider = &stubProvider{name: "sendgrid"}
+					providers[s.credential] = provider
+				}
+
+				// The pool throttles each credential with its own bucket key
+				cfg := tt.config
+				cfg.Key = "credential:" + s.credential
+				throttle := NewThrottle(provider, model.ChannelEmail, bucket, cfg)
+
+				called := provider.called
+				_, err := throttle.Send(context.Background(), &Message{Sender: s.sender})
+
+				var throttled *ThrottledError
+				if s.wantThrottled {
+					require.ErrorAs(t, err, &throttled, "send %d", i)
+					assert.Greater(t, throttled.RetryAfter, tt.config.MaxWait, "send %d: retry after", i)
+				} else {
+					require.NoError(t, err, "send %d", i)
+				}
+				assert.Equal(t, !s.wantThrottled, provider.called > called, "send %d: provider called", i)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"hermes-api/internal/model"
+)
+
+// twilioRejections maps the Twilio error codes of recipients that cannot be messaged to a suppression reason
+var twilioRejections = map[int]model.SuppressionReason{
+	21211: model.SuppressionReasonBounce,    // Invalid number
+	21614: model.SuppressionReasonBounce,    // Not a mobile number
+	21610: model.SuppressionReasonComplaint, // The recipient replied STOP
+}
+
+// TwilioProvider delivers SMS through the Twilio Messages API
+type TwilioProvider struct {
+	name       string
+	accountSID string
+	authToken  string
+	sender     string
+	baseURL    string
+}
+
+// NewTwilioProvider creates a Twilio provider from its "account_sid" setting and "auth_token" secret, the
+// "base_url" setting overrides the API URL. Senders starting with "MG" are messaging services.
+func NewTwilioProvider(name string, credentials Credentials) (*TwilioProvider, error) {
+	accountSID := stringSetting(credentials.Settings, "account_sid", "")
+	if accountSID == "" || credentials.Secrets["auth_token"] == "" {
+		return nil, fmt.Errorf("provider '%s' needs the 'account_sid' setting and the 'auth_token' secret", name)
+	}
+	return &TwilioProvider{
+		name:       name,
+		accountSID: accountSID,
+		authToken:  credentials.Secrets["auth_token"],
+		sender:     credentials.Sender,
+		baseURL:    stringSetting(credentials.Settings, "base_url", "https://api.twilio.com"),
+	}, nil
+}
+
+// Name returns the name of the provider
+func (p *TwilioProvider) Name() string {
+	return p.name
+}
+
+// Send creates a message for the first recipient, SMS notifications have a single phone number
+func (p *TwilioProvider) Send(ctx context.Context, message *Message) (*Result, error) {
+	sender := message.Sender
+	if sender == "" {
+		sender = p.sender
+	}
+	if sender == "" {
+		return nil, errors.New("no sender configured")
+	}
+	if len(message.To) == 0 {
+		return nil, errors.New("no recipient")
+	}
+
+	form := url.Values{"To": {message.To[0]}, "Body": {message.Body}}
+	if strings.HasPrefix(sender, "MG") {
+		form.Set("MessagingServiceSid", sender)
+	} else {
+		form.Set("From", sender)
+	}
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", p.baseURL, url.PathEscape(p.accountSID))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.SetBasicAuth(p.accountSID, p.authToken)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(response.Body, maxResponseRead))
+	var reply struct {
+		SID     string `json:"sid"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(body, &reply)
+
+	result := &Result{
+		MessageID:    reply.SID,
+		ResponseCode: strconv.Itoa(response.StatusCode),
+		Response:     string(body),
+	}
+	if response.StatusCode < 300 {
+		return result, nil
+	}
+	if reason, ok := twilioRejections[reply.Code]; ok {
+		return result, &RejectedError{Addresses: message.To[:1], Reason: reason, Description: reply.Message}
+	}
+	return result, fmt.Errorf("twilio responded %s: %s", response.Status, reply.Message)
+}
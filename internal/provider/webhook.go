@@ -0,0 +1,220 @@
+package provider
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidSignature is returned for webhook requests whose signature does not verify, or which were
+	// signed too long ago
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrInvalidWebhookKey is returned when the configured key verifying the webhooks cannot be used
+	ErrInvalidWebhookKey = errors.New("invalid webhook verification key")
+	// ErrUnknownWebhook is returned for drivers without inbound webhooks
+	ErrUnknownWebhook = errors.New("unknown webhook driver")
+)
+
+// EventType is the kind of a delivery event reported by a provider
+type EventType string
+
+const (
+	EventDelivered  EventType = "delivered"  // Accepted by the recipient's server or handset
+	EventBounced    EventType = "bounced"    // Permanently rejected by the recipient's server
+	EventFailed     EventType = "failed"     // Not delivered, without blaming the address
+	EventComplained EventType = "complained" // The recipient reported the message as spam or opted out
+)
+
+// DeliveryEvent is a delivery event reported by a provider for a message it accepted
+type DeliveryEvent struct {
+	MessageID   string // The ID the provider returned when the message was sent
+	Type        EventType
+	Address     string
+	Description string
+	OccurredAt  time.Time
+}
+
+// InboundRequest is a webhook request posted by a provider
+type InboundRequest struct {
+	URL      string // The public URL the provider posted to, signed by some providers
+	RawQuery string // The query string of the request, without the "?"
+	Header   http.Header
+	Body     []byte
+}
+
+// WebhookParser verifies the signature of the webhook requests of a provider type and parses their
+// delivery events; events irrelevant to delivery, e.g. opens, are left out
+type WebhookParser func(request *InboundRequest, secret string) ([]DeliveryEvent, error)
+
+// webhookParsers holds the webhook parsers by driver
+var webhookParsers = map[string]WebhookParser{
+	"sendgrid": parseSendGridWebhook,
+	"twilio":   parseTwilioWebhook,
+}
+
+// ParseWebhook verifies and parses a webhook request of a driver with the driver's signing secret
+func ParseWebhook(driver string, request *InboundRequest, secret string) ([]DeliveryEvent, error) {
+	parse, ok := webhookParsers[driver]
+	if !ok {
+		return nil, ErrUnknownWebhook
+	}
+	return parse(request, secret)
+}
+
+// HasWebhook reports whether a driver has inbound webhooks
+func HasWebhook(driver string) bool {
+	_, ok := webhookParsers[driver]
+	return ok
+}
+
+// sendGridEvent is an event of the SendGrid event webhook
+type sendGridEvent struct {
+	Email     string `json:"email"`
+	Timestamp int64  `json:"timestamp"`
+	Event     string `json:"event"`
+	Type      string `json:"type"` // "bounce" or "blocked" for bounce events
+	Reason    string `json:"reason"`
+	MessageID string `json:"sg_message_id"`
+}
+
+// sendGridTimestampTolerance is how far the signing time of a SendGrid webhook may be from now, older
+// requests are refused as replays
+const sendGridTimestampTolerance = 5 * time.Minute
+
+// parseSendGridWebhook verifies a signed SendGrid event webhook, secret is its base64 encoded ECDSA public key
+func parseSendGridWebhook(request *InboundRequest, secret string) ([]DeliveryEvent, error) {
+	der, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("%w: sendgrid: %v", ErrInvalidWebhookKey, err)
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: sendgrid: %v", ErrInvalidWebhookKey, err)
+	}
+	publicKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: sendgrid: not an ECDSA key", ErrInvalidWebhookKey)
+	}
+
+	timestamp := request.Header.Get("X-Twilio-Email-Event-Webhook-Timestamp")
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	if age := time.Since(time.Unix(signedAt, 0)); age > sendGridTimestampTolerance || age < -sendGridTimestampTolerance {
+		return nil, ErrInvalidSignature
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(request.Header.Get("X-Twilio-Email-Event-Webhook-Signature"))
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	digest := sha256.Sum256(append([]byte(timestamp), request.Body...))
+	if !ecdsa.VerifyASN1(publicKey, digest[:], signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	var payload []sendGridEvent
+	if err := json.Unmarshal(request.Body, &payload); err != nil {
+		return nil, err
+	}
+
+	var events []DeliveryEvent
+	for _, item := range payload {
+		event := DeliveryEvent{
+			// The event message ID extends the X-Message-Id returned by the send request
+			MessageID:   strings.SplitN(item.MessageID, ".", 2)[0],
+			Address:     item.Email,
+			Description: item.Reason,
+			OccurredAt:  time.Unix(item.Timestamp, 0).UTC(),
+		}
+		switch {
+		case item.Event == "delivered":
+			event.Type = EventDelivered
+		case item.Event == "bounce" && item.Type != "blocked":
+			event.Type = EventBounced
+		case item.Event == "bounce", item.Event == "dropped":
+			event.Type = EventFailed
+		case item.Event == "spamreport":
+			event.Type = EventComplained
+		default:
+			continue
+		}
+		if event.MessageID != "" {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// twilioUnreachable lists the Twilio error codes of numbers that cannot receive messages
+var twilioUnreachable = map[string]bool{
+	"30005": true, // Unknown destination handset
+	"30006": true, // Landline or unreachable carrier
+}
+
+// parseTwilioWebhook verifies a Twilio status callback, secret is the auth token of the account
+func parseTwilioWebhook(request *InboundRequest, secret string) ([]DeliveryEvent, error) {
+	form, err := url.ParseQuery(string(request.Body))
+	if err != nil {
+		return nil, err
+	}
+
+	// The signature covers the URL followed by the form parameters sorted by name
+	names := make([]string, 0, len(form))
+	for name := range form {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write([]byte(request.URL))
+	for _, name := range names {
+		for _, value := range form[name] {
+			mac.Write([]byte(name + value))
+		}
+	}
+	signature, err := base64.StdEncoding.DecodeString(request.Header.Get("X-Twilio-Signature"))
+	if err != nil || !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrInvalidSignature
+	}
+
+	event := DeliveryEvent{
+		MessageID:  form.Get("MessageSid"),
+		Address:    form.Get("To"),
+		OccurredAt: time.Now().UTC(),
+	}
+	if code := form.Get("ErrorCode"); code != "" {
+		event.Description = "Twilio error " + code
+	}
+
+	switch status := form.Get("MessageStatus"); {
+	case status == "delivered":
+		event.Type = EventDelivered
+	case status != "undelivered" && status != "failed":
+		return nil, nil
+	case form.Get("ErrorCode") == "21610": // The recipient replied STOP
+		event.Type = EventComplained
+	case twilioUnreachable[form.Get("ErrorCode")]:
+		event.Type = EventBounced
+	default:
+		event.Type = EventFailed
+	}
+	if event.MessageID == "" {
+		return nil, nil
+	}
+	return []DeliveryEvent{event}, nil
+}
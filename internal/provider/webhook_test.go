@@ -0,0 +1,312 @@
+package provider
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sendGridKey generates a key pair for the SendGrid event webhook, returning the private key and the
+// base64 encoded public key configured as the webhook secret
+func sendGridKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	return key, base64.StdEncoding.EncodeToString(der)
+}
+
+// sendGridRequest signs body the way SendGrid does, at the given time
+func sendGridRequest(t *testing.T, key *ecdsa.PrivateKey, signedAt time.Time, body string) *InboundRequest {
+	t.Helper()
+
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	digest := sha256.Sum256([]byte(timestamp + body))
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+
+	header := http.Header{}
+	header.Set("X-Twilio-Email-Event-Webhook-Timestamp", timestamp)
+	header.Set("X-Twilio-Email-Event-Webhook-Signature", base64.StdEncoding.EncodeToString(signature))
+	return &InboundRequest{Header: header, Body: []byte(body)}
+}
+
+func TestParseSendGridWebhookSignature(t *testing.T) {
+	key, secret := sendGridKey(t)
+	_, otherSecret := sendGridKey(t)
+	body := `[{"email":"ada@example.com","timestamp":1700000000,"event":"delivered","sg_message_id":"abc.filter0001"}]`
+
+	tests := []struct {
+		name    string
+		request func() *InboundRequest
+		secret  string
+		wantErr error
+	}{
+		{
+			name:    "valid signature",
+			request: func() *InboundRequest { return sendGridRequest(t, key, time.Now(), body) },
+			secret:  secret,
+		},
+		{
+			name:    "signed within the tolerance",
+			request: func() *InboundRequest { return sendGridRequest(t, key, time.Now().Add(-4*time.Minute), body) },
+			secret:  secret,
+		},
+		{
+			name: "tampered body",
+			request: func() *InboundRequest {
+				request := sendGridRequest(t, key, time.Now(), body)
+				request.Body = []byte(`[{"email":"eve@example.com","timestamp":1700000000,"event":"bounce","sg_message_id":"abc"}]`)
+				return request
+			},
+			secret:  secret,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "tampered timestamp",
+			request: func() *InboundRequest {
+				request := sendGridRequest(t, key, time.Now().Add(-time.Minute), body)
+				request.Header.Set("X-Twilio-Email-Event-Webhook-Timestamp", strconv.FormatInt(time.Now().Unix(), 10))
+				return request
+			},
+			secret:  secret,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "stale timestamp",
+			request: func() *InboundRequest { return sendGridRequest(t, key, time.Now().Add(-6*time.Minute), body) },
+			secret:  secret,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "timestamp in the future",
+			request: func() *InboundRequest { return sendGridRequest(t, key, time.Now().Add(6*time.Minute), body) },
+			secret:  secret,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "missing signature",
+			request: func() *InboundRequest {
+				request := sendGridRequest(t, key, time.Now(), body)
+				request.Header.Del("X-Twilio-Email-Event-Webhook-Signature")
+				return request
+			},
+			secret:  secret,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "key of another account",
+			request: func() *InboundRequest { return sendGridRequest(t, key, time.Now(), body) },
+			secret:  otherSecret,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "key is not base64",
+			request: func() *InboundRequest { return sendGridRequest(t, key, time.Now(), body) },
+			secret:  "not a key!",
+			wantErr: ErrInvalidWebhookKey,
+		},
+		{
+			name:    "key is not a public key",
+			request: func() *InboundRequest { return sendGridRequest(t, key, time.Now(), body) },
+			secret:  base64.StdEncoding.EncodeToString([]byte("secret")),
+			wantErr: ErrInvalidWebhookKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := ParseWebhook("sendgrid", tt.request(), tt.secret)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []DeliveryEvent{{
+				MessageID:  "abc",
+				Type:       EventDelivered,
+				Address:    "ada@example.com",
+				OccurredAt: time.Unix(1700000000, 0).UTC(),
+			}}, events)
+		})
+	}
+}
+
+func TestParseSendGridWebhookEvents(t *testing.T) {
+	key, secret := sendGridKey(t)
+
+	tests := []struct {
+		name     string
+		event    string
+		wantType EventType // Empty when the event must be left out
+	}{
+		{name: "delivered", event: `"event":"delivered"`, wantType: EventDelivered},
+		{name: "bounce", event: `"event":"bounce","type":"bounce"`, wantType: EventBounced},
+		{name: "bounce without a type", event: `"event":"bounce"`, wantType: EventBounced},
+		{name: "blocked", event: `"event":"bounce","type":"blocked"`, wantType: EventFailed},
+		{name: "dropped", event: `"event":"dropped"`, wantType: EventFailed},
+		{name: "spam report", event: `"event":"spamreport"`, wantType: EventComplained},
+		{name: "open", event: `"event":"open"`},
+		{name: "deferred", event: `"event":"deferred"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `[{"email":"ada@example.com","timestamp":1700000000,"sg_message_id":"abc.filter0001.1",` +
+				`"reason":"550 5.1.1 unknown user",` + tt.event + `}]`
+			events, err := ParseWebhook("sendgrid", sendGridRequest(t, key, time.Now(), body), secret)
+			require.NoError(t, err)
+
+			if tt.wantType == "" {
+				assert.Empty(t, events)
+				return
+			}
+			require.Len(t, events, 1)
+			assert.Equal(t, tt.wantType, events[0].Type)
+			assert.Equal(t, "abc", events[0].MessageID)
+			assert.Equal(t, "550 5.1.1 unknown user", events[0].Description)
+		})
+	}
+
+	t.Run("events without a message ID are left out", func(t *testing.T) {
+		body := `[{"email":"ada@example.com","timestamp":1700000000,"event":"delivered"}]`
+		events, err := ParseWebhook("sendgrid", sendGridRequest(t, key, time.Now(), body), secret)
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("malformed payload", func(t *testing.T) {
+		_, err := ParseWebhook("sendgrid", sendGridRequest(t, key, time.Now(), `{"event":`), secret)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrInvalidSignature)
+	})
+}
+
+// twilioRequest signs a status callback the way Twilio does: the URL followed by the form parameters
+// sorted by name, with the account auth token
+func twilioRequest(webhookURL, authToken string, form url.Values) *InboundRequest {
+	names := make([]string, 0, len(form))
+	for name := range form {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(webhookURL))
+	for _, name := range names {
+		mac.Write([]byte(name + form.Get(name)))
+	}
+
+	header := http.Header{}
+	header.Set("X-Twilio-Signature", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	return &InboundRequest{URL: webhookURL, Header: header, Body: []byte(form.Encode())}
+}
+
+func TestParseTwilioWebhookSignature(t *testing.T) {
+	// The example of Twilio's request validation documentation
+	fixture := url.Values{
+		"CallSid": {"CA1234567890ABCDE"},
+		"Caller":  {"+12349013030"},
+		"Digits":  {"1234"},
+		"From":    {"+12349013030"},
+		"To":      {"+18005551212"},
+	}
+	fixtureURL := "https://mycompany.com/myapp.php?foo=1&bar=2"
+	fixtureSignature := "0/KCTR6DLpKmkAf8muzZqo1nDgQ="
+
+	tests := []struct {
+		name      string
+		url       string
+		body      string
+		signature string
+		secret    string
+		wantErr   bool
+	}{
+		{name: "valid signature", url: fixtureURL, body: fixture.Encode(), signature: fixtureSignature, secret: "12345"},
+		{name: "wrong auth token", url: fixtureURL, body: fixture.Encode(), signature: fixtureSignature, secret: "54321", wantErr: true},
+		{name: "tampered body", url: fixtureURL, body: fixture.Encode() + "&Digits=9", signature: fixtureSignature, secret: "12345", wantErr: true},
+		{name: "query string left out", url: "https://mycompany.com/myapp.php", body: fixture.Encode(), signature: fixtureSignature, secret: "12345", wantErr: true},
+		{name: "other path", url: "https://mycompany.com/prefix/myapp.php?foo=1&bar=2", body: fixture.Encode(), signature: fixtureSignature, secret: "12345", wantErr: true},
+		{name: "signature is not base64", url: fixtureURL, body: fixture.Encode(), signature: "not a signature!", secret: "12345", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set("X-Twilio-Signature", tt.signature)
+			events, err := ParseWebhook("twilio", &InboundRequest{URL: tt.url, Header: header, Body: []byte(tt.body)}, tt.secret)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidSignature)
+				return
+			}
+			require.NoError(t, err)
+			assert.Empty(t, events, "a call callback has no delivery event")
+		})
+	}
+}
+
+func TestParseTwilioWebhookEvents(t *testing.T) {
+	const webhookURL = "https://hermes.example.com/api/v1/webhooks/twilio"
+
+	tests := []struct {
+		name      string
+		status    string
+		errorCode string
+		wantType  EventType // Empty when the callback must be left out
+	}{
+		{name: "delivered", status: "delivered", wantType: EventDelivered},
+		{name: "sent", status: "sent"},
+		{name: "queued", status: "queued"},
+		{name: "failed", status: "failed", errorCode: "30001", wantType: EventFailed},
+		{name: "undelivered", status: "undelivered", errorCode: "30003", wantType: EventFailed},
+		{name: "unknown handset", status: "undelivered", errorCode: "30005", wantType: EventBounced},
+		{name: "landline", status: "undelivered", errorCode: "30006", wantType: EventBounced},
+		{name: "opted out", status: "failed", errorCode: "21610", wantType: EventComplained},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"MessageSid": {"SM123"}, "MessageStatus": {tt.status}, "To": {"+15551234567"}}
+			if tt.errorCode != "" {
+				form.Set("ErrorCode", tt.errorCode)
+			}
+
+			events, err := ParseWebhook("twilio", twilioRequest(webhookURL, "token", form), "token")
+			require.NoError(t, err)
+			if tt.wantType == "" {
+				assert.Empty(t, events)
+				return
+			}
+			require.Len(t, events, 1)
+			assert.Equal(t, tt.wantType, events[0].Type)
+			assert.Equal(t, "SM123", events[0].MessageID)
+			assert.Equal(t, "+15551234567", events[0].Address)
+			if tt.errorCode != "" {
+				assert.Equal(t, "Twilio error "+tt.errorCode, events[0].Description)
+			}
+		})
+	}
+}
+
+func TestParseWebhookUnknownDriver(t *testing.T) {
+	_, err := ParseWebhook("smtp", &InboundRequest{}, "secret")
+	assert.ErrorIs(t, err, ErrUnknownWebhook)
+	assert.False(t, HasWebhook("smtp"))
+	assert.True(t, HasWebhook("twilio"))
+}
@@ -0,0 +1,311 @@
+package render
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// cssDeclaration is a single "property: value" pair, possibly flagged !important
+type cssDeclaration struct {
+	property  string
+	value     string
+	important bool
+}
+
+// cssSelector is a compound selector made of an optional tag, id and classes (e.g. "td.header")
+type cssSelector struct {
+	tag     string
+	id      string
+	classes []string
+}
+
+// cssRule is an inlinable rule of a stylesheet
+type cssRule struct {
+	selector     cssSelector
+	declarations []cssDeclaration
+	specificity  int
+	order        int
+}
+
+var (
+	cssCommentPattern  = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	cssSelectorPattern = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9-]*|\*)?((?:[.#][a-zA-Z0-9_-]+)*)$`)
+	cssPartPattern     = regexp.MustCompile(`[.#][a-zA-Z0-9_-]+`)
+	cssImportantSuffix = regexp.MustCompile(`(?i)\s*!\s*important\s*$`)
+)
+
+// InlineCSS moves the rules of the document's <style> elements into style attributes of the matching
+// elements, as most email clients ignore or strip stylesheets. Only compound selectors (tag, .class,
+// #id and combinations like "td.header") are inlined; at-rules such as @media queries and rules with
+// combinators or pseudo-classes are kept in the stylesheet. Existing style attributes take precedence,
+// except over !important declarations of the stylesheet.
+func InlineCSS(doc *html.Node) {
+	var rules []cssRule
+	var styles []*html.Node
+
+	walk(doc, func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Style {
+			styles = append(styles, n)
+		}
+	})
+
+	for _, style := range styles {
+		var css strings.Builder
+		for c := style.FirstChild; c != nil; c = c.NextSibling {
+			css.WriteString(c.Data)
+		}
+
+		parsed, leftover := parseStylesheet(css.String(), len(rules))
+		rules = append(rules, parsed...)
+
+		// Keep what could not be inlined, drop the element otherwise
+		if strings.TrimSpace(leftover) == "" {
+			style.Parent.RemoveChild(style)
+			continue
+		}
+		for c := style.FirstChild; c != nil; c = style.FirstChild {
+			style.RemoveChild(c)
+		}
+		style.AppendChild(&html.Node{Type: html.TextNode, Data: leftover})
+	}
+
+	if len(rules) == 0 {
+		return
+	}
+
+	// Apply less specific rules first so more specific ones override them; ties keep source order
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].specificity != rules[j].specificity {
+			return rules[i].specificity < rules[j].specificity
+		}
+		return rules[i].order < rules[j].order
+	})
+
+	walk(doc, func(n *html.Node) {
+		if n.Type != html.ElementNode || n.DataAtom == atom.Head || isInHead(n) {
+			return
+		}
+
+		var declarations []cssDeclaration
+		for _, rule := range rules {
+			if rule.selector.matches(n) {
+				declarations = append(declarations, rule.declarations...)
+			}
+		}
+		if len(declarations) == 0 {
+			return
+		}
+
+		existing, _ := attr(n, "style")
+		declarations = append(declarations, parseDeclarations(existing)...)
+		setAttr(n, "style", formatDeclarations(declarations))
+	})
+}
+
+// parseStylesheet splits a stylesheet into inlinable rules and the CSS that must stay in a <style> element
+func parseStylesheet(css string, order int) ([]cssRule, string) {
+	css = cssCommentPattern.ReplaceAllString(css, "")
+
+	var rules []cssRule
+	var leftover strings.Builder
+
+	for {
+		open := strings.Index(css, "{")
+		if open < 0 {
+			break
+		}
+		prelude := strings.TrimSpace(css[:open])
+
+		// Find the matching closing brace, accounting for nested blocks of at-rules
+		depth, end := 0, -1
+		for i := open; i < len(css); i++ {
+			if css[i] == '{' {
+				depth++
+			} else if css[i] == '}' {
+				depth--
+				if depth == 0 {
+					end = i
+					break
+				}
+			}
+		}
+		if end < 0 {
+			break
+		}
+		body := css[open+1 : end]
+		css = css[end+1:]
+
+		if strings.HasPrefix(prelude, "@") {
+			leftover.WriteString(prelude + " {" + body + "}\n")
+			continue
+		}
+
+		declarations := parseDeclarations(body)
+		for _, raw := range strings.Split(prelude, ",") {
+			raw = strings.TrimSpace(raw)
+			selector, specificity, ok := parseSelector(raw)
+			if !ok {
+				leftover.WriteString(raw + " {" + body + "}\n")
+				continue
+			}
+			rules = append(rules, cssRule{
+				selector:     selector,
+				declarations: declarations,
+				specificity:  specificity,
+				order:        order,
+			})
+			order++
+		}
+	}
+
+	return rules, leftover.String()
+}
+
+// parseSelector parses a compound selector and computes its specificity
+func parseSelector(raw string) (cssSelector, int, bool) {
+	match := cssSelectorPattern.FindStringSubmatch(raw)
+	if match == nil || raw == "" {
+		return cssSelector{}, 0, false
+	}
+
+	selector := cssSelector{}
+	specificity := 0
+	if match[1] != "" && match[1] != "*" {
+		selector.tag = strings.ToLower(match[1])
+		specificity++
+	}
+
+	for _, part := range cssPartPattern.FindAllString(match[2], -1) {
+		if part[0] == '#' {
+			selector.id = part[1:]
+			specificity += 100
+		} else {
+			selector.classes = append(selector.classes, part[1:])
+			specificity += 10
+		}
+	}
+
+	return selector, specificity, true
+}
+
+// matches reports whether the element n matches the selector
+func (s cssSelector) matches(n *html.Node) bool {
+	if s.tag != "" && s.tag != n.Data {
+		return false
+	}
+
+	if s.id != "" {
+		if id, _ := attr(n, "id"); id != s.id {
+			return false
+		}
+	}
+
+	if len(s.classes) > 0 {
+		class, _ := attr(n, "class")
+		present := make(map[string]bool)
+		for _, c := range strings.Fields(class) {
+			present[c] = true
+		}
+		for _, c := range s.classes {
+			if !present[c] {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// parseDeclarations parses the body of a rule or a style attribute
+func parseDeclarations(body string) []cssDeclaration {
+	var declarations []cssDeclaration
+	for _, part := range splitDeclarations(body) {
+		property, value, ok := strings.Cut(part, ":")
+		property, value = strings.TrimSpace(property), strings.TrimSpace(value)
+
+		important := false
+		if loc := cssImportantSuffix.FindStringIndex(value); loc != nil {
+			value, important = strings.TrimSpace(value[:loc[0]]), true
+		}
+		if !ok || property == "" || value == "" {
+			continue
+		}
+		declarations = append(declarations, cssDeclaration{
+			property:  strings.ToLower(property),
+			value:     value,
+			important: important,
+		})
+	}
+	return declarations
+}
+
+// splitDeclarations splits a declaration block on the semicolons that are outside of parentheses and
+// quoted strings, so values like url(data:image/png;base64,...) or content: ";" are kept whole
+func splitDeclarations(body string) []string {
+	var parts []string
+	var quote byte
+	depth, start := 0, 0
+
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case c == '\\':
+			i++ // The escaped character never delimits anything
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')' && depth > 0:
+			depth--
+		case c == ';' && depth == 0:
+			parts = append(parts, body[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, body[start:])
+}
+
+// formatDeclarations merges declarations into a style attribute value. Later declarations win, except
+// that a normal declaration never overrides an !important one.
+func formatDeclarations(declarations []cssDeclaration) string {
+	merged := make(map[string]cssDeclaration)
+	var properties []string
+	for _, d := range declarations {
+		current, exists := merged[d.property]
+		if !exists {
+			properties = append(properties, d.property)
+		} else if current.important && !d.important {
+			continue
+		}
+		merged[d.property] = d
+	}
+
+	parts := make([]string, 0, len(properties))
+	for _, property := range properties {
+		d := merged[property]
+		part := property + ": " + d.value
+		if d.important {
+			part += " !important"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// isInHead reports whether n is inside the document's <head>
+func isInHead(n *html.Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.DataAtom == atom.Head {
+			return true
+		}
+	}
+	return false
+}
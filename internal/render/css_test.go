@@ -0,0 +1,155 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInlineCSS(t *testing.T) {
+	tests := []struct {
+		name         string
+		style        string
+		body         string
+		want         string // Expected style attribute of the element with id "target"
+		wantLeftover string // Expected content of the remaining <style> element, empty when it must be removed
+	}{
+		{
+			name:  "tag selector",
+			style: `p { color: red; }`,
+			body:  `<p id="target">Hi</p>`,
+			want:  "color: red",
+		},
+		{
+			name:  "class beats tag regardless of source order",
+			style: `.note { color: blue } p { color: red; margin: 0 }`,
+			body:  `<p id="target" class="note">Hi</p>`,
+			want:  "color: blue; margin: 0",
+		},
+		{
+			name:  "id beats classes",
+			style: `#target { color: green } p.note.wide { color: blue }`,
+			body:  `<p id="target" class="note wide">Hi</p>`,
+			want:  "color: green",
+		},
+		{
+			name:  "later rule wins on equal specificity",
+			style: `.a { color: red } .b { color: blue }`,
+			body:  `<p id="target" class="b a">Hi</p>`,
+			want:  "color: blue",
+		},
+		{
+			name:  "selector lists and comments",
+			style: `/* headings */ h1, .title { font-weight: bold }`,
+			body:  `<span id="target" class="title">Hi</span>`,
+			want:  "font-weight: bold",
+		},
+		{
+			name:  "existing style attribute wins",
+			style: `p { color: red; padding: 4px }`,
+			body:  `<p id="target" style="color: black">Hi</p>`,
+			want:  "color: black; padding: 4px",
+		},
+		{
+			name:  "important declaration beats the style attribute",
+			style: `p { color: red !important }`,
+			body:  `<p id="target" style="color: black">Hi</p>`,
+			want:  "color: red !important",
+		},
+		{
+			name:  "important declaration beats a more specific rule",
+			style: `p { color: red ! IMPORTANT } #target { color: blue }`,
+			body:  `<p id="target">Hi</p>`,
+			want:  "color: red !important",
+		},
+		{
+			name:  "semicolons inside url and strings",
+			style: `td { background: url(data:image/png;base64,iVBORw0KGgo=) no-repeat; font-family: "A;B", sans-serif }`,
+			body:  `<table><tr><td id="target">Hi</td></tr></table>`,
+			want:  `background: url(data:image/png;base64,iVBORw0KGgo=) no-repeat; font-family: "A;B", sans-serif`,
+		},
+		{
+			name:         "media queries are left in the stylesheet",
+			style:        `p { color: red } @media (max-width: 480px) { p { color: blue } }`,
+			body:         `<p id="target">Hi</p>`,
+			want:         "color: red",
+			wantLeftover: "@media (max-width: 480px) { p { color: blue } }",
+		},
+		{
+			name:         "combinators and pseudo-classes are left in the stylesheet",
+			style:        `a:hover { color: red } td p { margin: 0 } p { color: black }`,
+			body:         `<p id="target">Hi</p>`,
+			want:         "color: black",
+			wantLeftover: "a:hover { color: red }\ntd p { margin: 0 }",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := parseHTML(t, "<html><head><style>"+tt.style+"</style></head><body>"+tt.body+"</body></html>")
+			InlineCSS(doc)
+
+			target := elementByID(doc, "target")
+			require.NotNil(t, target)
+			style, _ := attr(target, "style")
+			assert.Equal(t, tt.want, style)
+
+			stylesheet := findElement(doc, "style")
+			if tt.wantLeftover == "" {
+				assert.Nil(t, stylesheet, "the style element must be removed once everything is inlined")
+				return
+			}
+			require.NotNil(t, stylesheet)
+			assert.Equal(t, tt.wantLeftover, strings.TrimSpace(textContent(stylesheet)))
+		})
+	}
+}
+
+func TestParseDeclarations(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []cssDeclaration
+	}{
+		{name: "empty", body: " ; ;", want: nil},
+		{
+			name: "lower-cases properties",
+			body: "Color: Red; MARGIN:0",
+			want: []cssDeclaration{{property: "color", value: "Red"}, {property: "margin", value: "0"}},
+		},
+		{
+			name: "skips declarations without a value",
+			body: "color:; margin; padding: 1px",
+			want: []cssDeclaration{{property: "padding", value: "1px"}},
+		},
+		{
+			name: "colon in the value",
+			body: "background-image: url(https://example.com/a.png)",
+			want: []cssDeclaration{{property: "background-image", value: "url(https://example.com/a.png)"}},
+		},
+		{
+			name: "quoted semicolons and escaped quotes",
+			body: `content: 'a;\'b'; color: red`,
+			want: []cssDeclaration{{property: "content", value: `'a;\'b'`}, {property: "color", value: "red"}},
+		},
+		{
+			name: "nested parentheses",
+			body: "width: calc(100% - (2 * var(--gap;x))); color: red",
+			want: []cssDeclaration{{property: "width", value: "calc(100% - (2 * var(--gap;x)))"}, {property: "color", value: "red"}},
+		},
+		{
+			name: "important",
+			body: "color: red !important; margin: 0 ! important",
+			want: []cssDeclaration{{property: "color", value: "red", important: true}, {property: "margin", value: "0", important: true}},
+		},
+		{name: "important without a value", body: "color: !important", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseDeclarations(tt.body))
+		})
+	}
+}
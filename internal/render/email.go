@@ -0,0 +1,140 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Markup languages supported for the HTML body of email templates
+const (
+	MarkupHTML = "html"
+	MarkupMJML = "mjml"
+)
+
+// EmailOptions configures the email rendering stage
+type EmailOptions struct {
+	Markup  string // MarkupHTML (default) or MarkupMJML
+	BaseURL string // Base URL used to resolve relative links and image sources
+}
+
+// Email post-processes a rendered template for the email channel: it compiles MJML-like markup
+// into table-based HTML, inlines CSS, resolves relative links against the base URL and derives a
+// plain-text alternative when the template does not provide one
+func Email(result *Result, opts EmailOptions) (*Result, error) {
+	if result.HTMLBody == "" {
+		return result, nil
+	}
+
+	source := result.HTMLBody
+	if opts.Markup == MarkupMJML {
+		compiled, err := CompileMJML(source)
+		if err != nil {
+			return nil, err
+		}
+		source = compiled
+	}
+
+	doc, err := html.Parse(strings.NewReader(source))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse html body: %w", err)
+	}
+
+	InlineCSS(doc)
+
+	if opts.BaseURL != "" {
+		if err := RewriteLinks(doc, opts.BaseURL); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := html.Render(&buf, doc); err != nil {
+		return nil, fmt.Errorf("failed to render html body: %w", err)
+	}
+
+	rendered := *result
+	rendered.HTMLBody = buf.String()
+	if strings.TrimSpace(rendered.Body) == "" {
+		rendered.Body = PlainText(doc)
+	}
+
+	return &rendered, nil
+}
+
+// linkAttributes lists the URL-bearing attributes rewritten by RewriteLinks
+var linkAttributes = map[atom.Atom]string{
+	atom.A:      "href",
+	atom.Area:   "href",
+	atom.Img:    "src",
+	atom.Source: "src",
+	atom.Td:     "background",
+	atom.Table:  "background",
+}
+
+// RewriteLinks resolves relative link and image URLs in the document against baseURL.
+// Fragment-only, mailto: and tel: links as well as absolute URLs are left untouched.
+func RewriteLinks(doc *html.Node, baseURL string) error {
+	base, err := url.Parse(baseURL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return fmt.Errorf("invalid base url '%s'", baseURL)
+	}
+
+	walk(doc, func(n *html.Node) {
+		key, ok := linkAttributes[n.DataAtom]
+		if n.Type != html.ElementNode || !ok {
+			return
+		}
+
+		for i, attr := range n.Attr {
+			if attr.Key != key {
+				continue
+			}
+			value := strings.TrimSpace(attr.Val)
+			if value == "" || strings.HasPrefix(value, "#") {
+				continue
+			}
+
+			ref, err := url.Parse(value)
+			if err != nil || ref.IsAbs() || ref.Host != "" {
+				continue
+			}
+			n.Attr[i].Val = base.ResolveReference(ref).String()
+		}
+	})
+
+	return nil
+}
+
+// walk calls fn for n and all of its descendants in document order
+func walk(n *html.Node, fn func(*html.Node)) {
+	fn(n)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
+
+// attr returns the value of an attribute of n
+func attr(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// setAttr sets or replaces an attribute of n
+func setAttr(n *html.Node, key, value string) {
+	for i, a := range n.Attr {
+		if a.Key == key {
+			n.Attr[i].Val = value
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: value})
+}
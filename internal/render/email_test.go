@@ -0,0 +1,73 @@
+package render
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+// parseHTML parses a document, failing the test when it is malformed
+func parseHTML(t *testing.T, source string) *html.Node {
+	t.Helper()
+
+	doc, err := html.Parse(strings.NewReader(source))
+	require.NoError(t, err)
+	return doc
+}
+
+// renderHTML renders a document back to markup
+func renderHTML(t *testing.T, doc *html.Node) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, html.Render(&buf, doc))
+	return buf.String()
+}
+
+// elementByID returns the element of the document with the given id attribute
+func elementByID(doc *html.Node, id string) *html.Node {
+	var found *html.Node
+	walk(doc, func(n *html.Node) {
+		if value, ok := attr(n, "id"); found == nil && ok && value == id {
+			found = n
+		}
+	})
+	return found
+}
+
+func TestRewriteLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "relative path", body: `<a href="account/settings">x</a>`, want: `<a href="https://app.example.com/mail/account/settings">x</a>`},
+		{name: "absolute path", body: `<a href="/unsubscribe?u=1">x</a>`, want: `<a href="https://app.example.com/unsubscribe?u=1">x</a>`},
+		{name: "image source", body: `<img src="logo.png"/>`, want: `<img src="https://app.example.com/mail/logo.png"/>`},
+		{name: "table background", body: `<table background="bg.jpg"></table>`, want: `<table background="https://app.example.com/mail/bg.jpg"></table>`},
+		{name: "absolute URL", body: `<a href="https://other.example.com/a">x</a>`, want: `<a href="https://other.example.com/a">x</a>`},
+		{name: "protocol relative URL", body: `<img src="//cdn.example.com/a.png"/>`, want: `<img src="//cdn.example.com/a.png"/>`},
+		{name: "fragment", body: `<a href="#top">x</a>`, want: `<a href="#top">x</a>`},
+		{name: "mailto", body: `<a href="mailto:ada@example.com">x</a>`, want: `<a href="mailto:ada@example.com">x</a>`},
+		{name: "tel", body: `<a href="tel:+15551234567">x</a>`, want: `<a href="tel:+15551234567">x</a>`},
+		{name: "other attributes", body: `<div title="page.html">x</div>`, want: `<div title="page.html">x</div>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := parseHTML(t, "<html><body>"+tt.body+"</body></html>")
+			require.NoError(t, RewriteLinks(doc, "https://app.example.com/mail/"))
+			assert.Equal(t, "<html><head></head><body>"+tt.want+"</body></html>", renderHTML(t, doc))
+		})
+	}
+
+	t.Run("invalid base URL", func(t *testing.T) {
+		for _, base := range []string{"", "/relative", "example.com", "https://"} {
+			assert.Error(t, RewriteLinks(parseHTML(t, "<a href='x'>x</a>"), base), "base %q", base)
+		}
+	})
+}
@@ -0,0 +1,274 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// mjmlBodyWidth is the width in pixels of the email body container
+const mjmlBodyWidth = 600
+
+// mjmlSelfClosingPattern matches self-closing component tags such as <mj-image ... />, which the
+// HTML parser would otherwise treat as opening tags
+var mjmlSelfClosingPattern = regexp.MustCompile(`<(mj-[a-z-]+)([^<>]*?)/>`)
+
+// mjmlResponsiveCSS stacks columns on small screens
+const mjmlResponsiveCSS = `@media only screen and (max-width: 480px) {
+  .mj-column { width: 100% !important; display: block !important; }
+  .mj-container { width: 100% !important; }
+}
+`
+
+// CompileMJML compiles a simplified MJML-like component syntax into table-based HTML that renders
+// consistently across email clients, including Outlook. Supported components:
+//
+//	<mjml>
+//	  <mj-head> <mj-title>, <mj-preview>, <mj-style> </mj-head>
+//	  <mj-body background-color width>
+//	    <mj-section background-color padding>
+//	      <mj-column width background-color padding>
+//	        <mj-text>, <mj-button href>, <mj-image src alt href width />,
+//	        <mj-divider />, <mj-spacer height />, <mj-raw>
+func CompileMJML(source string) (string, error) {
+	source = mjmlSelfClosingPattern.ReplaceAllString(source, "<$1$2></$1>")
+
+	doc, err := html.Parse(strings.NewReader(source))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse mjml: %w", err)
+	}
+
+	root := findElement(doc, "mjml")
+	if root == nil {
+		return "", fmt.Errorf("mjml: missing <mjml> root element")
+	}
+	body := findElement(root, "mj-body")
+	if body == nil {
+		return "", fmt.Errorf("mjml: missing <mj-body> element")
+	}
+
+	c := &mjmlCompiler{}
+	if head := findElement(root, "mj-head"); head != nil {
+		c.compileHead(head)
+	}
+
+	width := mjmlBodyWidth
+	if w, ok := attr(body, "width"); ok {
+		if parsed, err := strconv.Atoi(strings.TrimSuffix(w, "px")); err == nil && parsed > 0 {
+			width = parsed
+		}
+	}
+	background := attrOr(body, "background-color", "#ffffff")
+
+	var sections bytes.Buffer
+	for _, child := range children(body) {
+		if child.Data != "mj-section" {
+			return "", fmt.Errorf("mjml: unsupported component <%s> in <mj-body>", child.Data)
+		}
+		if err := c.compileSection(&sections, child, width); err != nil {
+			return "", err
+		}
+	}
+
+	var out bytes.Buffer
+	out.WriteString("<!DOCTYPE html>\n<html><head>")
+	out.WriteString(`<meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1">`)
+	if c.title != "" {
+		fmt.Fprintf(&out, "<title>%s</title>", template.HTMLEscapeString(c.title))
+	}
+	out.WriteString("<style>" + mjmlResponsiveCSS + c.styles.String() + "</style></head>")
+	fmt.Fprintf(&out, `<body style="margin:0;padding:0;background-color:%s;">`, escapeAttr(background))
+	if c.preview != "" {
+		fmt.Fprintf(&out, `<div style="display:none;max-height:0;overflow:hidden;">%s</div>`, template.HTMLEscapeString(c.preview))
+	}
+	fmt.Fprintf(&out, `<table role="presentation" width="100%%" cellpadding="0" cellspacing="0" border="0" style="background-color:%s;"><tr><td align="center">`, escapeAttr(background))
+	fmt.Fprintf(&out, `<table role="presentation" class="mj-container" width="%d" cellpadding="0" cellspacing="0" border="0" style="width:%dpx;max-width:100%%;">`, width, width)
+	out.Write(sections.Bytes())
+	out.WriteString("</table></td></tr></table></body></html>")
+
+	return out.String(), nil
+}
+
+// mjmlCompiler collects head information while compiling the body
+type mjmlCompiler struct {
+	title   string
+	preview string
+	styles  strings.Builder
+}
+
+// compileHead reads <mj-title>, <mj-preview> and <mj-style>
+func (c *mjmlCompiler) compileHead(head *html.Node) {
+	for _, child := range children(head) {
+		switch child.Data {
+		case "mj-title":
+			c.title = strings.TrimSpace(textContent(child))
+		case "mj-preview":
+			c.preview = strings.TrimSpace(textContent(child))
+		case "mj-style":
+			c.styles.WriteString(textContent(child) + "\n")
+		}
+	}
+}
+
+// compileSection renders a section as a table row holding its columns side by side
+func (c *mjmlCompiler) compileSection(out *bytes.Buffer, section *html.Node, width int) error {
+	var columns []*html.Node
+	for _, child := range children(section) {
+		if child.Data != "mj-column" {
+			return fmt.Errorf("mjml: unsupported component <%s> in <mj-section>", child.Data)
+		}
+		columns = append(columns, child)
+	}
+
+	fmt.Fprintf(out, `<tr><td style="background-color:%s;padding:%s;">`,
+		escapeAttr(attrOr(section, "background-color", "transparent")),
+		escapeAttr(attrOr(section, "padding", "20px 0")))
+	out.WriteString(`<table role="presentation" width="100%" cellpadding="0" cellspacing="0" border="0"><tr>`)
+
+	for _, column := range columns {
+		columnWidth := fmt.Sprintf("%d%%", 100/len(columns))
+		if w, ok := attr(column, "width"); ok {
+			columnWidth = w
+		}
+
+		fmt.Fprintf(out, `<td class="mj-column" width="%s" valign="top" style="width:%s;vertical-align:top;background-color:%s;padding:%s;">`,
+			escapeAttr(columnWidth), escapeAttr(columnWidth),
+			escapeAttr(attrOr(column, "background-color", "transparent")),
+			escapeAttr(attrOr(column, "padding", "0")))
+		out.WriteString(`<table role="presentation" width="100%" cellpadding="0" cellspacing="0" border="0">`)
+
+		for _, component := range children(column) {
+			if err := c.compileComponent(out, component, width); err != nil {
+				return err
+			}
+		}
+
+		out.WriteString("</table></td>")
+	}
+
+	out.WriteString("</tr></table></td></tr>")
+	return nil
+}
+
+// compileComponent renders a content component as a table row
+func (c *mjmlCompiler) compileComponent(out *bytes.Buffer, n *html.Node, width int) error {
+	align := escapeAttr(attrOr(n, "align", "left"))
+	padding := escapeAttr(attrOr(n, "padding", "10px 25px"))
+
+	switch n.Data {
+	case "mj-text":
+		fmt.Fprintf(out, `<tr><td align="%s" style="padding:%s;font-family:%s;font-size:%s;line-height:%s;color:%s;text-align:%s;">%s</td></tr>`,
+			align, padding,
+			escapeAttr(attrOr(n, "font-family", "Arial, Helvetica, sans-serif")),
+			escapeAttr(attrOr(n, "font-size", "14px")),
+			escapeAttr(attrOr(n, "line-height", "1.5")),
+			escapeAttr(attrOr(n, "color", "#000000")),
+			align, innerHTML(n))
+
+	case "mj-button":
+		background := escapeAttr(attrOr(n, "background-color", "#414141"))
+		fmt.Fprintf(out, `<tr><td align="%s" style="padding:%s;">`, escapeAttr(attrOr(n, "align", "center")), padding)
+		out.WriteString(`<table role="presentation" cellpadding="0" cellspacing="0" border="0" style="border-collapse:separate;">`)
+		fmt.Fprintf(out, `<tr><td bgcolor="%s" style="background-color:%s;border-radius:%s;padding:%s;">`,
+			background, background,
+			escapeAttr(attrOr(n, "border-radius", "3px")),
+			escapeAttr(attrOr(n, "inner-padding", "10px 25px")))
+		fmt.Fprintf(out, `<a href="%s" target="_blank" style="display:inline-block;color:%s;font-family:%s;font-size:%s;text-decoration:none;">%s</a>`,
+			escapeAttr(attrOr(n, "href", "#")),
+			escapeAttr(attrOr(n, "color", "#ffffff")),
+			escapeAttr(attrOr(n, "font-family", "Arial, Helvetica, sans-serif")),
+			escapeAttr(attrOr(n, "font-size", "14px")),
+			innerHTML(n))
+		out.WriteString("</td></tr></table></td></tr>")
+
+	case "mj-image":
+		imageWidth := strings.TrimSuffix(attrOr(n, "width", strconv.Itoa(width)), "px")
+		image := fmt.Sprintf(`<img src="%s" alt="%s" width="%s" style="display:block;border:0;outline:none;width:100%%;max-width:%spx;height:auto;">`,
+			escapeAttr(attrOr(n, "src", "")), escapeAttr(attrOr(n, "alt", "")), escapeAttr(imageWidth), escapeAttr(imageWidth))
+		if href, ok := attr(n, "href"); ok {
+			image = fmt.Sprintf(`<a href="%s" target="_blank">%s</a>`, escapeAttr(href), image)
+		}
+		fmt.Fprintf(out, `<tr><td align="%s" style="padding:%s;">%s</td></tr>`, escapeAttr(attrOr(n, "align", "center")), padding, image)
+
+	case "mj-divider":
+		fmt.Fprintf(out, `<tr><td style="padding:%s;"><p style="border-top:%s %s %s;font-size:1px;line-height:1px;margin:0;">&nbsp;</p></td></tr>`,
+			padding,
+			escapeAttr(attrOr(n, "border-width", "1px")),
+			escapeAttr(attrOr(n, "border-style", "solid")),
+			escapeAttr(attrOr(n, "border-color", "#000000")))
+
+	case "mj-spacer":
+		height := escapeAttr(attrOr(n, "height", "20px"))
+		fmt.Fprintf(out, `<tr><td style="height:%s;line-height:%s;font-size:0;">&nbsp;</td></tr>`, height, height)
+
+	case "mj-raw":
+		fmt.Fprintf(out, "<tr><td>%s</td></tr>", innerHTML(n))
+
+	default:
+		return fmt.Errorf("mjml: unsupported component <%s> in <mj-column>", n.Data)
+	}
+
+	return nil
+}
+
+// findElement returns the first element named name in the subtree of n
+func findElement(n *html.Node, name string) *html.Node {
+	if n.Type == html.ElementNode && n.Data == name {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// children returns the element children of n, skipping text and comments
+func children(n *html.Node) []*html.Node {
+	var elements []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			elements = append(elements, c)
+		}
+	}
+	return elements
+}
+
+// attrOr returns the value of an attribute of n, or fallback when it is missing
+func attrOr(n *html.Node, key, fallback string) string {
+	if value, ok := attr(n, key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// innerHTML renders the children of n
+func innerHTML(n *html.Node) string {
+	var buf bytes.Buffer
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		_ = html.Render(&buf, c)
+	}
+	return buf.String()
+}
+
+// textContent returns the concatenated text of n and its descendants
+func textContent(n *html.Node) string {
+	var buf strings.Builder
+	walk(n, func(c *html.Node) {
+		if c.Type == html.TextNode {
+			buf.WriteString(c.Data)
+		}
+	})
+	return buf.String()
+}
+
+// escapeAttr escapes a value for use inside a double-quoted attribute
+func escapeAttr(value string) string {
+	return html.EscapeString(value)
+}
@@ -0,0 +1,141 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileMJML(t *testing.T) {
+	// column wraps components in the smallest document CompileMJML accepts
+	column := func(components string) string {
+		return "<mjml><mj-body><mj-section><mj-column>" + components + "</mj-column></mj-section></mj-body></mjml>"
+	}
+
+	tests := []struct {
+		name   string
+		source string
+		want   []string // Fragments the compiled HTML must contain
+	}{
+		{
+			name:   "text",
+			source: column(`<mj-text color="#333333" font-size="16px">Hello <b>Ada</b></mj-text>`),
+			want:   []string{`font-size:16px;line-height:1.5;color:#333333;text-align:left;">Hello <b>Ada</b></td>`},
+		},
+		{
+			name:   "button",
+			source: column(`<mj-button href="https://example.com/a?b=1&amp;c=2" background-color="#ff0000">Open</mj-button>`),
+			want: []string{
+				`<td align="center" style="padding:10px 25px;">`,
+				`<td bgcolor="#ff0000" style="background-color:#ff0000;`,
+				`<a href="https://example.com/a?b=1&amp;c=2" target="_blank"`,
+				`>Open</a>`,
+			},
+		},
+		{
+			name:   "self-closing image with a link",
+			source: column(`<mj-image src="https://example.com/logo.png" alt="Logo" width="120px" href="https://example.com" />`),
+			want: []string{
+				`<a href="https://example.com" target="_blank"><img src="https://example.com/logo.png" alt="Logo" width="120"`,
+				`max-width:120px;`,
+			},
+		},
+		{
+			name:   "image defaults to the body width",
+			source: column(`<mj-image src="a.png"/>`),
+			want:   []string{`<img src="a.png" alt="" width="600"`},
+		},
+		{
+			name:   "divider",
+			source: column(`<mj-divider border-color="#cccccc" />`),
+			want:   []string{`border-top:1px solid #cccccc;`},
+		},
+		{
+			name:   "spacer",
+			source: column(`<mj-spacer height="40px" />`),
+			want:   []string{`<td style="height:40px;line-height:40px;font-size:0;">&nbsp;</td>`},
+		},
+		{
+			name:   "raw",
+			source: column(`<mj-raw><p class="legal">Terms</p></mj-raw>`),
+			want:   []string{`<tr><td><p class="legal">Terms</p></td></tr>`},
+		},
+		{
+			name:   "columns share the section width",
+			source: `<mjml><mj-body width="500px"><mj-section><mj-column></mj-column><mj-column width="30%"></mj-column></mj-section></mj-body></mjml>`,
+			want: []string{
+				`class="mj-container" width="500"`,
+				`<td class="mj-column" width="50%"`,
+				`<td class="mj-column" width="30%"`,
+			},
+		},
+		{
+			name:   "attributes are escaped",
+			source: column(`<mj-text color="red;&quot;><script>">x</mj-text>`),
+			want:   []string{`color:red;&#34;&gt;&lt;script&gt;;`},
+		},
+		{
+			name: "head",
+			source: `<mjml><mj-head><mj-title>Orders &amp; receipts</mj-title><mj-preview>Thanks for your order</mj-preview>` +
+				`<mj-style>.legal { color: gray }</mj-style></mj-head><mj-body background-color="#f4f4f4"></mj-body></mjml>`,
+			want: []string{
+				`<title>Orders &amp; receipts</title>`,
+				`.legal { color: gray }`,
+				`<div style="display:none;max-height:0;overflow:hidden;">Thanks for your order</div>`,
+				`<body style="margin:0;padding:0;background-color:#f4f4f4;">`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CompileMJML(tt.source)
+			require.NoError(t, err)
+			assert.Contains(t, got, mjmlResponsiveCSS)
+			for _, fragment := range tt.want {
+				assert.Contains(t, got, fragment)
+			}
+		})
+	}
+}
+
+func TestCompileMJMLMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		wantErr string
+	}{
+		{name: "empty", source: "", wantErr: "missing <mjml> root element"},
+		{name: "plain HTML", source: "<html><body><p>Hi</p></body></html>", wantErr: "missing <mjml> root element"},
+		{name: "missing body", source: "<mjml><mj-head></mj-head></mjml>", wantErr: "missing <mj-body> element"},
+		{
+			name:    "column directly in the body",
+			source:  "<mjml><mj-body><mj-column></mj-column></mj-body></mjml>",
+			wantErr: "unsupported component <mj-column> in <mj-body>",
+		},
+		{
+			name:    "text directly in a section",
+			source:  "<mjml><mj-body><mj-section><mj-text>Hi</mj-text></mj-section></mj-body></mjml>",
+			wantErr: "unsupported component <mj-text> in <mj-section>",
+		},
+		{
+			name:    "unknown component",
+			source:  "<mjml><mj-body><mj-section><mj-column><mj-carousel></mj-carousel></mj-column></mj-section></mj-body></mjml>",
+			wantErr: "unsupported component <mj-carousel> in <mj-column>",
+		},
+		{
+			name:    "HTML in a column",
+			source:  "<mjml><mj-body><mj-section><mj-column><div>Hi</div></mj-column></mj-section></mj-body></mjml>",
+			wantErr: "unsupported component <div> in <mj-column>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CompileMJML(tt.source)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package render
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	whitespacePattern = regexp.MustCompile(`[ \t\r\n\f]+`)
+	blankLinePattern  = regexp.MustCompile(`\n{3,}`)
+)
+
+// blockElements start on a new line in the plain-text rendering
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Table: true, atom.Tr: true, atom.Ul: true, atom.Ol: true,
+	atom.Li: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true,
+	atom.H6: true, atom.Blockquote: true, atom.Pre: true, atom.Hr: true, atom.Section: true,
+	atom.Article: true, atom.Header: true, atom.Footer: true,
+}
+
+// skippedElements have no readable content
+var skippedElements = map[atom.Atom]bool{
+	atom.Head: true, atom.Style: true, atom.Script: true, atom.Title: true,
+}
+
+// PlainText derives a readable plain-text alternative from an HTML document: block elements and
+// table rows become lines, list items are bulleted, links are followed by their URL and images
+// are replaced by their alt text
+func PlainText(doc *html.Node) string {
+	var buf strings.Builder
+	writePlainText(&buf, doc)
+
+	lines := strings.Split(buf.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+
+	text := blankLinePattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(text)
+}
+
+// writePlainText appends the plain-text rendering of n to buf
+func writePlainText(buf *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		buf.WriteString(whitespacePattern.ReplaceAllString(n.Data, " "))
+		return
+	case html.ElementNode:
+		if skippedElements[n.DataAtom] || isHidden(n) {
+			return
+		}
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		buf.WriteString("\n")
+		return
+	case atom.Hr:
+		buf.WriteString("\n----------\n")
+		return
+	case atom.Img:
+		if alt, _ := attr(n, "alt"); alt != "" {
+			buf.WriteString(alt)
+		}
+		return
+	case atom.Td, atom.Th:
+		buf.WriteString(" ")
+	case atom.Li:
+		buf.WriteString("\n- ")
+	}
+
+	// Paragraph-like blocks are separated by a blank line, table rows end with a line break (the
+	// enclosing table already starts on a new line)
+	separator := ""
+	if n.Type == html.ElementNode && blockElements[n.DataAtom] && n.DataAtom != atom.Li {
+		separator = "\n\n"
+		if n.DataAtom == atom.Tr {
+			separator = "\n"
+		} else {
+			buf.WriteString(separator)
+		}
+	}
+
+	var inner strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writePlainText(&inner, c)
+	}
+	text := inner.String()
+
+	// Top-level headings are upper-cased and links keep their target URL
+	switch n.DataAtom {
+	case atom.H1, atom.H2:
+		text = strings.ToUpper(text)
+	case atom.A:
+		href, _ := attr(n, "href")
+		label := strings.TrimSpace(text)
+		if href != "" && !strings.HasPrefix(href, "#") && href != label && !strings.HasPrefix(href, "mailto:"+label) {
+			if label == "" {
+				text = href
+			} else {
+				text = label + " (" + href + ")"
+			}
+		}
+	}
+
+	buf.WriteString(text)
+	buf.WriteString(separator)
+}
+
+// isHidden reports whether an element is hidden with an inline display:none style (e.g. preheaders)
+func isHidden(n *html.Node) bool {
+	style, _ := attr(n, "style")
+	style = strings.ReplaceAll(strings.ToLower(style), " ", "")
+	return strings.Contains(style, "display:none")
+}
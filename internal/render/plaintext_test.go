@@ -0,0 +1,47 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{name: "empty", html: "", want: ""},
+		{name: "collapses whitespace", html: "<p>Hello\n\t   Ada</p>", want: "Hello Ada"},
+		{name: "paragraphs", html: "<p>One</p><p>Two</p><div>Three</div>", want: "One\n\nTwo\n\nThree"},
+		{name: "line breaks", html: "<p>One<br>Two</p>", want: "One\nTwo"},
+		{name: "headings", html: "<h1>Welcome</h1><h3>Details</h3>", want: "WELCOME\n\nDetails"},
+		{name: "lists", html: "<ul><li>Milk</li><li>Eggs</li></ul>", want: "- Milk\n- Eggs"},
+		{
+			name: "table rows",
+			html: "<table><tr><td>Item</td><td>Price</td></tr><tr><td>Book</td><td>$12</td></tr></table>",
+			want: "Item Price\nBook $12",
+		},
+		{name: "horizontal rule", html: "<p>Above</p><hr><p>Below</p>", want: "Above\n\n----------\n\nBelow"},
+		{name: "link", html: `<a href="https://example.com/a">Open</a>`, want: "Open (https://example.com/a)"},
+		{name: "link labelled with its URL", html: `<a href="https://example.com">https://example.com</a>`, want: "https://example.com"},
+		{name: "link without a label", html: `<a href="https://example.com"><img src="a.png"></a>`, want: "https://example.com"},
+		{name: "fragment link", html: `<a href="#top">Back to top</a>`, want: "Back to top"},
+		{name: "mailto link", html: `<a href="mailto:ada@example.com">ada@example.com</a>`, want: "ada@example.com"},
+		{name: "image alt text", html: `<p>Logo: <img src="a.png" alt="Hermes"></p>`, want: "Logo: Hermes"},
+		{
+			name: "head, style and script are skipped",
+			html: "<html><head><title>T</title><style>p{color:red}</style></head><body><script>x()</script><p>Body</p></body></html>",
+			want: "Body",
+		},
+		{name: "hidden preheader", html: `<div style="display: none">Preview</div><p>Body</p>`, want: "Body"},
+		{name: "blank lines are collapsed", html: "<div><div><p>One</p></div></div><div><p>Two</p></div>", want: "One\n\nTwo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, PlainText(parseHTML(t, tt.html)))
+		})
+	}
+}
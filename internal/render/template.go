@@ -0,0 +1,113 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"text/template"
+
+	"hermes-api/internal/model"
+	"hermes-api/pkg/i18n"
+)
+
+// Result holds the rendered content of a template variant
+type Result struct {
+	Locale   string `json:"locale"`
+	Subject  string `json:"subject,omitempty"`
+	Body     string `json:"body"`
+	HTMLBody string `json:"html_body,omitempty"`
+}
+
+// LocaleNotFoundError is returned when a template has no variant for any locale of the fallback chain
+type LocaleNotFoundError struct {
+	Template string
+	Chain    []string
+}
+
+// Error implements the error interface
+func (e *LocaleNotFoundError) Error() string {
+	return fmt.Sprintf("template '%s' has no variant for locales %v", e.Template, e.Chain)
+}
+
+// Render selects the template variant for the first matching locale and renders it with data.
+// Locales are tried in order, each falling back to its parent locale, then the template's
+// and finally the application's default locale (e.g. "de-AT" -> "de" -> "en").
+func Render(tpl *model.Template, appDefaultLocale string, data map[string]any, locales ...string) (*Result, error) {
+	candidates := append(append([]string{}, locales...), tpl.DefaultLocale)
+	chain := i18n.FallbackChain(appDefaultLocale, candidates...)
+
+	variant, ok := tpl.Variant(chain)
+	if !ok {
+		return nil, &LocaleNotFoundError{Template: tpl.Name, Chain: chain}
+	}
+
+	// Helpers format for the most specific locale requested, even when a parent variant was selected
+	funcs := i18n.FuncMap(chain[0])
+
+	result := &Result{Locale: variant.Locale}
+	var err error
+
+	if result.Subject, err = executeText("subject", variant.Subject, funcs, data); err != nil {
+		return nil, err
+	}
+	if result.Body, err = executeText("body", variant.Body, funcs, data); err != nil {
+		return nil, err
+	}
+	if result.HTMLBody, err = executeHTML("html_body", variant.HTMLBody, funcs, data); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Validate parses every part of a variant so syntax errors are reported when the template is saved
+func Validate(variant *model.TemplateVariant) error {
+	funcs := i18n.FuncMap(variant.Locale)
+
+	if _, err := template.New("subject").Funcs(funcs).Parse(variant.Subject); err != nil {
+		return err
+	}
+	if _, err := template.New("body").Funcs(funcs).Parse(variant.Body); err != nil {
+		return err
+	}
+	if _, err := htmltemplate.New("html_body").Funcs(htmltemplate.FuncMap(funcs)).Parse(variant.HTMLBody); err != nil {
+		return err
+	}
+	return nil
+}
+
+// executeText renders a plain text template
+func executeText(name, text string, funcs template.FuncMap, data map[string]any) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// executeHTML renders an HTML template, escaping data according to its context
+func executeHTML(name, text string, funcs template.FuncMap, data map[string]any) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+
+	tmpl, err := htmltemplate.New(name).Funcs(htmltemplate.FuncMap(funcs)).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
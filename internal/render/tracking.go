@@ -0,0 +1,95 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// TrackingOptions configures the instrumentation of an email body for open and click tracking
+type TrackingOptions struct {
+	PixelURL string                              // URL of the open tracking pixel, opens are not tracked when empty
+	ClickURL func(target string) (string, error) // Returns the tracked URL of a link, clicks are not tracked when nil
+	Exclude  []string                            // URL prefixes of links left untouched, e.g. the preference center
+}
+
+// AddTracking rewrites the absolute http(s) links of an HTML email body through the click tracking URL
+// and appends the open tracking pixel to its body. Fragment-only, mailto: and tel: links are left untouched.
+func AddTracking(htmlBody string, opts TrackingOptions) (string, error) {
+	if htmlBody == "" || (opts.PixelURL == "" && opts.ClickURL == nil) {
+		return htmlBody, nil
+	}
+
+	doc, err := html.Parse(strings.NewReader(htmlBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse html body: %w", err)
+	}
+
+	var body *html.Node
+	var rewriteErr error
+	walk(doc, func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		switch n.DataAtom {
+		case atom.Body:
+			body = n
+		case atom.A, atom.Area:
+			if opts.ClickURL == nil || rewriteErr != nil {
+				return
+			}
+			href, ok := attr(n, "href")
+			if !ok || !trackable(strings.TrimSpace(href), opts.Exclude) {
+				return
+			}
+			tracked, err := opts.ClickURL(strings.TrimSpace(href))
+			if err != nil {
+				rewriteErr = err
+				return
+			}
+			setAttr(n, "href", tracked)
+		}
+	})
+	if rewriteErr != nil {
+		return "", rewriteErr
+	}
+
+	if opts.PixelURL != "" && body != nil {
+		body.AppendChild(&html.Node{
+			Type:     html.ElementNode,
+			Data:     "img",
+			DataAtom: atom.Img,
+			Attr: []html.Attribute{
+				{Key: "src", Val: opts.PixelURL},
+				{Key: "width", Val: "1"},
+				{Key: "height", Val: "1"},
+				{Key: "alt", Val: ""},
+				{Key: "style", Val: "display:block;width:1px;height:1px;border:0;"},
+			},
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := html.Render(&buf, doc); err != nil {
+		return "", fmt.Errorf("failed to render html body: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// trackable reports whether a link is an absolute http(s) URL outside of the excluded prefixes
+func trackable(href string, exclude []string) bool {
+	ref, err := url.Parse(href)
+	if err != nil || (ref.Scheme != "http" && ref.Scheme != "https") || ref.Host == "" {
+		return false
+	}
+	for _, prefix := range exclude {
+		if prefix != "" && strings.HasPrefix(href, prefix) {
+			return false
+		}
+	}
+	return true
+}
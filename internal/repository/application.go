@@ -4,6 +4,7 @@ import (
 	"context"
 	"hermes-api/internal/model"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -15,6 +16,7 @@ type ApplicationRepository interface {
 
 	// Query operations
 	GetByAPIKey(ctx context.Context, apiKey string) (*model.Application, error)
+	GetNames(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]string, error)
 }
 
 type applicationRepository struct {
@@ -37,3 +39,22 @@ func (r *applicationRepository) GetByAPIKey(ctx context.Context, apiKey string)
 	}
 	return &application, nil
 }
+
+// GetNames retrieves the names of applications by ID, including deleted applications
+func (r *applicationRepository) GetNames(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	var applications []*model.Application
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Select("id", "name").
+		Where("id IN ?", ids).
+		Find(&applications).Error
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[uuid.UUID]string, len(applications))
+	for _, application := range applications {
+		names[application.ID] = application.Name
+	}
+	return names, nil
+}
@@ -3,11 +3,23 @@ package repository
 
 import (
 	"context"
+	"errors"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
 )
 
+// uniqueViolation is the PostgreSQL error code of unique constraint violations
+const uniqueViolation = "23505"
+
+// IsUniqueViolation reports whether err is a violation of a unique constraint, e.g. a row inserted
+// concurrently with the same name
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
+
 // BaseRepository provides common CRUD operations
 type BaseRepository[T any] interface {
 	Create(ctx context.Context, entity *T) error
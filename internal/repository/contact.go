@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"hermes-api/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ContactRepository defines the interface for contact data operations
+type ContactRepository interface {
+
+	// Basic CRUD operations
+	BaseRepository[model.Contact]
+
+	// Query operations
+	GetByExternalID(ctx context.Context, applicationID uuid.UUID, externalID string) (*model.Contact, error)
+	ListByApplication(ctx context.Context, applicationID uuid.UUID, limit, offset int) ([]*model.Contact, error)
+	ListAfter(ctx context.Context, applicationID uuid.UUID, afterExternalID string, limit int) ([]*model.Contact, error)
+	ExistingExternalIDs(ctx context.Context, applicationID uuid.UUID, externalIDs []string) (map[string]bool, error)
+
+	// Bulk operations
+	Upsert(ctx context.Context, contacts []*model.Contact) error
+
+	// Preference operations
+	ListPreferences(ctx context.Context, contactID uuid.UUID) ([]model.ContactPreference, error)
+	ReplacePreferences(ctx context.Context, contactID uuid.UUID, preferences []model.ContactPreference) error
+	SetPreference(ctx context.Context, preference *model.ContactPreference) error
+}
+
+// contactRepository implements ContactRepository
+type contactRepository struct {
+	BaseRepository[model.Contact]
+	db *gorm.DB
+}
+
+// NewContactRepository creates a new contact repository
+func NewContactRepository(db *gorm.DB) ContactRepository {
+	return &contactRepository{
+		BaseRepository: NewBaseRepository[model.Contact](db),
+		db:             db,
+	}
+}
+
+// GetByExternalID retrieves a contact by the application's own user ID
+func (r *contactRepository) GetByExternalID(ctx context.Context, applicationID uuid.UUID, externalID string) (*model.Contact, error) {
+	var contact model.Contact
+	err := r.db.WithContext(ctx).
+		Where("application_id = ? AND external_id = ?", applicationID, externalID).
+		First(&contact).Error
+	if err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
+
+// ListByApplication retrieves the contacts of an application with pagination
+func (r *contactRepository) ListByApplication(ctx context.Context, applicationID uuid.UUID, limit, offset int) ([]*model.Contact, error) {
+	var contacts []*model.Contact
+	err := r.db.WithContext(ctx).
+		Where("application_id = ?", applicationID).
+		Order("external_id ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&contacts).Error
+	return contacts, err
+}
+
+// ListAfter retrieves the contacts of an application ordered by external ID, starting after the given
+// external ID; used to walk through all contacts without the cost of large offsets
+func (r *contactRepository) ListAfter(ctx context.Context, applicationID uuid.UUID, afterExternalID string, limit int) ([]*model.Contact, error) {
+	var contacts []*model.Contact
+	err := r.db.WithContext(ctx).
+		Where("application_id = ? AND external_id > ?", applicationID, afterExternalID).
+		Order("external_id ASC").
+		Limit(limit).
+		Find(&contacts).Error
+	return contacts, err
+}
+
+// ExistingExternalIDs returns which of the given external IDs already belong to a contact of the application
+func (r *contactRepository) ExistingExternalIDs(ctx context.Context, applicationID uuid.UUID, externalIDs []string) (map[string]bool, error) {
+	var existing []string
+	err := r.db.WithContext(ctx).
+		Model(&model.Contact{}).
+		Where("application_id = ? AND external_id IN ?", applicationID, externalIDs).
+		Pluck("external_id", &existing).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]bool, len(existing))
+	for _, externalID := range existing {
+		result[externalID] = true
+	}
+	return result, nil
+}
+
+// Upsert creates the contacts, or replaces the fields of existing contacts with the same external ID.
+// The external IDs must be unique within the slice.
+func (r *contactRepository) Upsert(ctx context.Context, contacts []*model.Contact) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "application_id"}, {Name: "external_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"email", "phone", "device_tokens", "locale", "timezone", "attributes", "quiet_hours", "updated_at"}),
+		}).
+		Create(&contacts).Error
+}
+
+// ListPreferences retrieves the preferences of a contact
+func (r *contactRepository) ListPreferences(ctx context.Context, contactID uuid.UUID) ([]model.ContactPreference, error) {
+	var preferences []model.ContactPreference
+	err := r.db.WithContext(ctx).
+		Where("contact_id = ?", contactID).
+		Order("category ASC, channel ASC").
+		Find(&preferences).Error
+	return preferences, err
+}
+
+// ReplacePreferences replaces all preferences of a contact
+func (r *contactRepository) ReplacePreferences(ctx context.Context, contactID uuid.UUID, preferences []model.ContactPreference) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("contact_id = ?", contactID).Delete(&model.ContactPreference{}).Error; err != nil {
+			return err
+		}
+		if len(preferences) == 0 {
+			return nil
+		}
+
+		for i := range preferences {
+			preferences[i].ContactID = contactID
+		}
+		return tx.Create(&preferences).Error
+	})
+}
+
+// SetPreference creates or updates the preference of a contact for the preference's category and channel
+func (r *contactRepository) SetPreference(ctx context.Context, preference *model.ContactPreference) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "contact_id"}, {Name: "category"}, {Name: "channel"}},
+			DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+		}).
+		Create(preference).Error
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"hermes-api/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ContactImportRepository defines the interface for contact import data operations
+type ContactImportRepository interface {
+
+	// Basic CRUD operations
+	BaseRepository[model.ContactImport]
+
+	// Query operations
+	GetByApplication(ctx context.Context, applicationID, id uuid.UUID) (*model.ContactImport, error)
+}
+
+// contactImportRepository implements ContactImportRepository
+type contactImportRepository struct {
+	BaseRepository[model.ContactImport]
+	db *gorm.DB
+}
+
+// NewContactImportRepository creates a new contact import repository
+func NewContactImportRepository(db *gorm.DB) ContactImportRepository {
+	return &contactImportRepository{
+		BaseRepository: NewBaseRepository[model.ContactImport](db),
+		db:             db,
+	}
+}
+
+// GetByApplication retrieves a contact import scoped to an application
+func (r *contactImportRepository) GetByApplication(ctx context.Context, applicationID, id uuid.UUID) (*model.ContactImport, error) {
+	var contactImport model.ContactImport
+	err := r.db.WithContext(ctx).
+		Where("application_id = ? AND id = ?", applicationID, id).
+		First(&contactImport).Error
+	if err != nil {
+		return nil, err
+	}
+	return &contactImport, nil
+}
@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"hermes-api/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DeliveryRollupRepository defines the interface for the delivery rollups behind analytics
+type DeliveryRollupRepository interface {
+	Aggregate(ctx context.Context, start, end time.Time) ([]*model.DeliveryRollup, error)
+	Replace(ctx context.Context, granularity model.RollupGranularity, bucketStart time.Time, rollups []*model.DeliveryRollup) error
+	List(ctx context.Context, granularity model.RollupGranularity, from, to time.Time) ([]*model.DeliveryRollup, error)
+	Search(ctx context.Context, filter RollupFilter) ([]*model.DeliveryRollup, error)
+	Prune(ctx context.Context, granularity model.RollupGranularity, before time.Time) error
+}
+
+// RollupFilter selects the rollups of the applications of a user, zero fields match every rollup
+type RollupFilter struct {
+	UserID        uuid.UUID // Owner of the applications, always applied
+	ApplicationID *uuid.UUID
+	Channel       model.Channel
+	Template      string // Name of the template
+	Granularity   model.RollupGranularity
+	From          time.Time // First bucket
+	To            time.Time // Buckets starting before
+}
+
+// rollupKey identifies the rollup of a template during a bucket
+type rollupKey struct {
+	ApplicationID uuid.UUID
+	Channel       model.Channel
+	TemplateID    uuid.UUID
+}
+
+// rollupStatusCount is a row of the status change counts of Aggregate
+type rollupStatusCount struct {
+	Key       rollupKey `gorm:"embedded"`
+	Sent      int64
+	Delivered int64
+	Failed    int64
+	Bounced   int64
+	Skipped   int64
+}
+
+// rollupEngagementCount is a row of the first open and click counts of Aggregate
+type rollupEngagementCount struct {
+	Key     rollupKey `gorm:"embedded"`
+	Opened  int64
+	Clicked int64
+}
+
+// rollupLatencyCount is a row of the latency histograms of Aggregate
+type rollupLatencyCount struct {
+	Key    rollupKey `gorm:"embedded"`
+	Status model.NotificationStatus
+	Bucket int
+	Count  int64
+}
+
+// latencyBucketExpr computes the model.LatencyHistogram bucket of the time from the creation of a
+// notification to a status change
+var latencyBucketExpr = fmt.Sprintf(
+	"LEAST(FLOOR(%d * LOG(2, GREATEST(EXTRACT(EPOCH FROM c.created_at - n.created_at) * 1000, 1)::numeric)), %d)::int",
+	model.LatencyBucketsPerDoubling, model.MaxLatencyBucket,
+)
+
+// deliveryRollupRepository implements DeliveryRollupRepository
+type deliveryRollupRepository struct {
+	db *gorm.DB
+}
+
+// NewDeliveryRollupRepository creates a new delivery rollup repository
+func NewDeliveryRollupRepository(db *gorm.DB) DeliveryRollupRepository {
+	return &deliveryRollupRepository{
+		db: db,
+	}
+}
+
+// Aggregate computes the hourly rollups of the events that happened from start until end
+func (r *deliveryRollupRepository) Aggregate(ctx context.Context, start, end time.Time) ([]*model.DeliveryRollup, error) {
+	db := r.db.WithContext(ctx)
+	rollups := make(map[rollupKey]*model.DeliveryRollup)
+	rollupFor := func(key rollupKey) *model.DeliveryRollup {
+		rollup, ok := rollups[key]
+		if !ok {
+			rollup = &model.DeliveryRollup{
+				Granularity:   model.RollupGranularityHour,
+				BucketStart:   start,
+				ApplicationID: key.ApplicationID,
+				Channel:       key.Channel,
+				TemplateID:    key.TemplateID,
+			}
+			rollups[key] = rollup
+		}
+		return rollup
+	}
+
+	var statusCounts []rollupStatusCount
+	err := db.Raw(`
+		SELECT n.application_id, n.channel, n.template_id,
+			COUNT(*) FILTER (WHERE c.status = ?) AS sent,
+			COUNT(*) FILTER (WHERE c.status = ?) AS delivered,
+			COUNT(*) FILTER (WHERE c.status = ?) AS failed,
+			COUNT(*) FILTER (WHERE c.status = ?) AS bounced,
+			COUNT(*) FILTER (WHERE c.status LIKE 'skipped_%') AS skipped
+		FROM notification_status_changes c
+		JOIN notifications n ON n.id = c.notification_id
+		WHERE c.created_at >= ? AND c.created_at < ?
+		GROUP BY n.application_id, n.channel, n.template_id`,
+		model.NotificationStatusSent, model.NotificationStatusDelivered, model.NotificationStatusFailed, model.NotificationStatusBounced,
+		start, end,
+	).Scan(&statusCounts).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, count := range statusCounts {
+		rollup := rollupFor(count.Key)
+		rollup.Sent = count.Sent
+		rollup.Delivered = count.Delivered
+		rollup.Failed = count.Failed
+		rollup.Bounced = count.Bounced
+		rollup.Skipped = count.Skipped
+	}
+
+	var engagementCounts []rollupEngagementCount
+	err = db.Raw(`
+		SELECT application_id, channel, template_id,
+			COUNT(*) FILTER (WHERE opened_at >= @start AND opened_at < @end) AS opened,
+			COUNT(*) FILTER (WHERE clicked_at >= @start AND clicked_at < @end) AS clicked
+		FROM notifications
+		WHERE (opened_at >= @start AND opened_at < @end) OR (clicked_at >= @start AND clicked_at < @end)
+		GROUP BY application_id, channel, template_id`,
+		map[string]any{"start": start, "end": end},
+	).Scan(&engagementCounts).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, count := range engagementCounts {
+		rollup := rollupFor(count.Key)
+		rollup.Opened = count.Opened
+		rollup.Clicked = count.Clicked
+	}
+
+	var latencyCounts []rollupLatencyCount
+	err = db.Raw(`
+		SELECT n.application_id, n.channel, n.template_id, c.status, `+latencyBucketExpr+` AS bucket, COUNT(*) AS count
+		FROM notification_status_changes c
+		JOIN notifications n ON n.id = c.notification_id
+		WHERE c.created_at >= ? AND c.created_at < ? AND c.status IN ?
+		GROUP BY n.application_id, n.channel, n.template_id, c.status, bucket`,
+		start, end, []model.NotificationStatus{model.NotificationStatusSent, model.NotificationStatusDelivered},
+	).Scan(&latencyCounts).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, count := range latencyCounts {
+		rollup := rollupFor(count.Key)
+		histogram := &rollup.SendLatency
+		if count.Status == model.NotificationStatusDelivered {
+			histogram = &rollup.DeliveryLatency
+		}
+		if *histogram == nil {
+			*histogram = make(model.LatencyHistogram)
+		}
+		(*histogram)[count.Bucket] += count.Count
+	}
+
+	result := make([]*model.DeliveryRollup, 0, len(rollups))
+	for _, rollup := range rollups {
+		result = append(result, rollup)
+	}
+	return result, nil
+}
+
+// Replace replaces the rollups of a bucket. Workers refreshing the same bucket concurrently are
+// serialized by a transaction-level advisory lock.
+func (r *deliveryRollupRepository) Replace(ctx context.Context, granularity model.RollupGranularity, bucketStart time.Time, rollups []*model.DeliveryRollup) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		lockKey := "rollup:" + string(granularity) + ":" + bucketStart.UTC().Format(time.RFC3339)
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", lockKey).Error; err != nil {
+			return err
+		}
+
+		err := tx.Where("granularity = ? AND bucket_start = ?", granularity, bucketStart).
+			Delete(&model.DeliveryRollup{}).Error
+		if err != nil || len(rollups) == 0 {
+			return err
+		}
+		return tx.CreateInBatches(rollups, 500).Error
+	})
+}
+
+// List retrieves the rollups of a granularity whose buckets start from from until to
+func (r *deliveryRollupRepository) List(ctx context.Context, granularity model.RollupGranularity, from, to time.Time) ([]*model.DeliveryRollup, error) {
+	var rollups []*model.DeliveryRollup
+	err := r.db.WithContext(ctx).
+		Where("granularity = ? AND bucket_start >= ? AND bucket_start < ?", granularity, from, to).
+		Find(&rollups).Error
+	return rollups, err
+}
+
+// Search retrieves the rollups matching a filter, oldest bucket first
+func (r *deliveryRollupRepository) Search(ctx context.Context, filter RollupFilter) ([]*model.DeliveryRollup, error) {
+	query := r.db.WithContext(ctx).
+		Where("application_id IN (?)", r.db.Model(&model.Application{}).Select("id").Where("user_id = ?", filter.UserID)).
+		Where("granularity = ? AND bucket_start >= ? AND bucket_start < ?", filter.Granularity, filter.From, filter.To)
+	if filter.ApplicationID != nil {
+		query = query.Where("application_id = ?", *filter.ApplicationID)
+	}
+	if filter.Channel != "" {
+		query = query.Where("channel = ?", filter.Channel)
+	}
+	if filter.Template != "" {
+		// Deleted templates keep their analytics
+		query = query.Where("template_id IN (?)", r.db.Unscoped().Model(&model.Template{}).Select("id").Where("name = ?", filter.Template))
+	}
+
+	var rollups []*model.DeliveryRollup
+	err := query.Order("bucket_start ASC").Find(&rollups).Error
+	return rollups, err
+}
+
+// Prune deletes the rollups of a granularity whose buckets started before a time
+func (r *deliveryRollupRepository) Prune(ctx context.Context, granularity model.RollupGranularity, before time.Time) error {
+	return r.db.WithContext(ctx).
+		Where("granularity = ? AND bucket_start < ?", granularity, before.UTC()).
+		Delete(&model.DeliveryRollup{}).Error
+}
@@ -0,0 +1,256 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"hermes-api/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DigestRepository defines the interface for digest rule and digest data operations
+type DigestRepository interface {
+
+	// Basic CRUD operations
+	BaseRepository[model.DigestRule]
+
+	// Query operations
+	GetRuleByApplication(ctx context.Context, applicationID, id uuid.UUID) (*model.DigestRule, error)
+	ListRulesByApplication(ctx context.Context, applicationID uuid.UUID) ([]*model.DigestRule, error)
+	FindMatchingRule(ctx context.Context, applicationID, templateID uuid.UUID, category string) (*model.DigestRule, error)
+	ListDue(ctx context.Context, limit int) ([]*model.Digest, error)
+
+	// Write operations
+	AddItem(ctx context.Context, rule *model.DigestRule, notification *model.Notification) (*model.Digest, error)
+	Flush(ctx context.Context, id uuid.UUID, build DigestBuilder) error
+	DeleteRule(ctx context.Context, id uuid.UUID, build DigestBuilder) error
+}
+
+// DigestBuilder turns a digest and its items into the notification the digest is delivered as
+type DigestBuilder func(digest *model.Digest, items []*model.Notification) (*model.Notification, error)
+
+// errNotificationClaimed rolls back adding a notification to a digest after a worker claimed it, or
+// after its digest rule was deleted
+var errNotificationClaimed = errors.New("notification already claimed")
+
+// digestRepository implements DigestRepository
+type digestRepository struct {
+	BaseRepository[model.DigestRule]
+	db *gorm.DB
+}
+
+// NewDigestRepository creates a new digest repository
+func NewDigestRepository(db *gorm.DB) DigestRepository {
+	return &digestRepository{
+		BaseRepository: NewBaseRepository[model.DigestRule](db),
+		db:             db,
+	}
+}
+
+// GetRuleByApplication retrieves a digest rule scoped to an application
+func (r *digestRepository) GetRuleByApplication(ctx context.Context, applicationID, id uuid.UUID) (*model.DigestRule, error) {
+	var rule model.DigestRule
+	err := r.db.WithContext(ctx).
+		Where("application_id = ? AND id = ?", applicationID, id).
+		First(&rule).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// ListRulesByApplication retrieves all digest rules of an application
+func (r *digestRepository) ListRulesByApplication(ctx context.Context, applicationID uuid.UUID) ([]*model.DigestRule, error) {
+	var rules []*model.DigestRule
+	err := r.db.WithContext(ctx).
+		Where("application_id = ?", applicationID).
+		Order("created_at ASC").
+		Find(&rules).Error
+	return rules, err
+}
+
+// FindMatchingRule retrieves the digest rule of a template, or else of a category
+func (r *digestRepository) FindMatchingRule(ctx context.Context, applicationID, templateID uuid.UUID, category string) (*model.DigestRule, error) {
+	var rule model.DigestRule
+	err := r.db.WithContext(ctx).
+		Where("application_id = ?", applicationID).
+		Where("template_id = ? OR (template_id IS NULL AND category <> '' AND category = ?)", templateID, category).
+		Order("template_id NULLS LAST, created_at ASC").
+		First(&rule).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// ListDue retrieves open digests whose window has ended, oldest first
+func (r *digestRepository) ListDue(ctx context.Context, limit int) ([]*model.Digest, error) {
+	var digests []*model.Digest
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND flush_at <= ?", model.DigestStatusOpen, time.Now().UTC()).
+		Order("flush_at ASC").
+		Limit(limit).
+		Find(&digests).Error
+	return digests, err
+}
+
+// AddItem collects a queued notification into the open digest of the rule for its contact, opening a
+// digest when there is none, and returns the digest. Nil is returned when a worker claimed the
+// notification first or the rule was deleted, it is then delivered on its own.
+func (r *digestRepository) AddItem(ctx context.Context, rule *model.DigestRule, notification *model.Notification) (*model.Digest, error) {
+	var digest *model.Digest
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Keeps the rule from being deleted until the notification is collected
+		err := tx.Clauses(clause.Locking{Strength: "SHARE"}).
+			Select("id").
+			First(&model.DigestRule{}, "id = ?", rule.ID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errNotificationClaimed
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := lockDigest(tx, rule.ID, *notification.ContactID); err != nil {
+			return err
+		}
+
+		var open model.Digest
+		err = tx.Where("rule_id = ? AND contact_id = ? AND status = ?", rule.ID, *notification.ContactID, model.DigestStatusOpen).
+			First(&open).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			open = model.Digest{
+				ApplicationID: rule.ApplicationID,
+				RuleID:        rule.ID,
+				ContactID:     *notification.ContactID,
+				Status:        model.DigestStatusOpen,
+				FlushAt:       time.Now().UTC().Add(rule.Window()),
+			}
+			err = tx.Create(&open).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&model.Notification{}).
+			Where("id = ? AND status = ?", notification.ID, model.NotificationStatusQueued).
+			Updates(map[string]any{"status": model.NotificationStatusBatched, "digest_id": open.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errNotificationClaimed
+		}
+
+		change := &model.NotificationStatusChange{NotificationID: notification.ID, Status: model.NotificationStatusBatched}
+		if err := tx.Create(change).Error; err != nil {
+			return err
+		}
+
+		open.ItemCount++
+		if err := tx.Model(&open).Update("item_count", open.ItemCount).Error; err != nil {
+			return err
+		}
+
+		notification.Status = model.NotificationStatusBatched
+		notification.DigestID = &open.ID
+		digest = &open
+		return nil
+	})
+	if errors.Is(err, errNotificationClaimed) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return digest, nil
+}
+
+// Flush sends an open digest: build turns the digest and its items into the notification it is
+// delivered as, which is created with the digest marked as flushed. Digests flushed concurrently are
+// left untouched.
+func (r *digestRepository) Flush(ctx context.Context, id uuid.UUID, build DigestBuilder) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return flushDigest(tx, id, build)
+	})
+}
+
+// DeleteRule flushes the open digests of a digest rule, so that the notifications they collected are
+// delivered, then deletes the rule with its digests. Notifications matching the rule meanwhile wait for
+// the deletion and are then delivered on their own.
+func (r *digestRepository) DeleteRule(ctx context.Context, id uuid.UUID, build DigestBuilder) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&model.DigestRule{}, "id = ?", id).Error
+		if err != nil {
+			return err
+		}
+
+		var open []uuid.UUID
+		err = tx.Model(&model.Digest{}).
+			Where("rule_id = ? AND status = ?", id, model.DigestStatusOpen).
+			Pluck("id", &open).Error
+		if err != nil {
+			return err
+		}
+		for _, digestID := range open {
+			if err := flushDigest(tx, digestID, build); err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(&model.DigestRule{}, "id = ?", id).Error
+	})
+}
+
+// flushDigest sends an open digest within a transaction, see Flush
+func flushDigest(tx *gorm.DB, id uuid.UUID, build DigestBuilder) error {
+	var digest model.Digest
+	if err := tx.First(&digest, "id = ?", id).Error; err != nil {
+		return err
+	}
+
+	// Wait for notifications being added to the digest, then make sure it was not flushed meanwhile
+	if err := lockDigest(tx, digest.RuleID, digest.ContactID); err != nil {
+		return err
+	}
+	err := tx.Preload("Rule.DigestTemplate").
+		Where("id = ? AND status = ?", id, model.DigestStatusOpen).
+		First(&digest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var items []*model.Notification
+	err = tx.Preload("Template").
+		Where("digest_id = ?", digest.ID).
+		Order("created_at ASC").
+		Find(&items).Error
+	if err != nil {
+		return err
+	}
+
+	notification, err := build(&digest, items)
+	if err != nil {
+		return err
+	}
+	if err := tx.Create(notification).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&digest).Updates(map[string]any{
+		"status":          model.DigestStatusFlushed,
+		"notification_id": notification.ID,
+	}).Error
+}
+
+// lockDigest serializes the changes to the open digest of a rule for a contact until the end of the transaction
+func lockDigest(tx *gorm.DB, ruleID, contactID uuid.UUID) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "digest:"+ruleID.String()+":"+contactID.String()).Error
+}
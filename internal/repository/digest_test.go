@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"hermes-api/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// digestRows fills the destinations of the queries of a dry run database with the digests, items and
+// open digest IDs the database would return
+type digestRows struct {
+	digests map[uuid.UUID]model.Digest
+	items   []*model.Notification
+	open    []uuid.UUID
+}
+
+// register makes the dry run database return the rows
+func (rows *digestRows) register(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	fill := func(tx *gorm.DB) {
+		switch dest := tx.Statement.Dest.(type) {
+		case *model.Digest:
+			if id, ok := tx.Statement.Vars[0].(uuid.UUID); ok {
+				*dest = rows.digests[id]
+			}
+		case *[]*model.Notification:
+			*dest = rows.items
+		case *[]uuid.UUID:
+			*dest = rows.open
+		}
+	}
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:rows", fill))
+}
+
+// indexOf returns the index of the first statement starting with prefix, or -1
+func indexOf(built *statements, prefix string) int {
+	for i, statement := range *built {
+		if strings.HasPrefix(statement, prefix) {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestDigestFlush(t *testing.T) {
+	digest := model.Digest{
+		ID:        uuid.MustParse("7c9e6679-7425-40de-944b-e07fc1f90ae7"),
+		RuleID:    uuid.MustParse("16fd2706-8baf-433b-82eb-8c7fada847da"),
+		ContactID: uuid.MustParse("886313e1-3b8a-5372-9b90-0c9aee199e5d"),
+	}
+	items := []*model.Notification{{ID: uuid.New()}, {ID: uuid.New()}}
+
+	db, built := dryRun(t)
+	(&digestRows{digests: map[uuid.UUID]model.Digest{digest.ID: digest}, items: items}).register(t, db)
+	repo := NewDigestRepository(db)
+
+	sent := &model.Notification{ID: uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8")}
+	var collectedItems []*model.Notification
+	err := repo.Flush(context.Background(), digest.ID, func(d *model.Digest, collected []*model.Notification) (*model.Notification, error) {
+		assert.Equal(t, digest.ID, d.ID)
+		collectedItems = collected
+		return sent, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, items, collectedItems)
+
+	// The digest is read again once locked, it may have been flushed meanwhile
+	lock := indexOf(built, "SELECT pg_advisory_xact_lock(hashtext('digest:16fd2706-8baf-433b-82eb-8c7fada847da:886313e1-3b8a-5372-9b90-0c9aee199e5d'))")
+	reread := indexOf(built, `SELECT * FROM "digests" WHERE (id = '7c9e6679-7425-40de-944b-e07fc1f90ae7' AND status = 'open')`)
+	load := indexOf(built, `SELECT * FROM "notifications" WHERE digest_id = '7c9e6679-7425-40de-944b-e07fc1f90ae7' ORDER BY created_at ASC`)
+	create := indexOf(built, `INSERT INTO "notifications" ("id"`)
+	update := indexOf(built, `UPDATE "digests" SET "notification_id"='6ba7b810-9dad-11d1-80b4-00c04fd430c8',"status"='flushed'`)
+	require.NotContains(t, []int{lock, reread, load, create, update}, -1, "statements %v", *built)
+	assert.Less(t, lock, reread)
+	assert.Less(t, reread, load)
+	assert.Less(t, load, create)
+	assert.Less(t, create, update)
+	assert.Contains(t, (*built)[create], "'6ba7b810-9dad-11d1-80b4-00c04fd430c8'")
+	assert.Contains(t, (*built)[update], `WHERE "id" = '7c9e6679-7425-40de-944b-e07fc1f90ae7'`)
+}
+
+func TestDigestDeleteRule(t *testing.T) {
+	ruleID := uuid.MustParse("16fd2706-8baf-433b-82eb-8c7fada847da")
+	first := model.Digest{ID: uuid.MustParse("7c9e6679-7425-40de-944b-e07fc1f90ae7"), RuleID: ruleID, ContactID: uuid.New()}
+	second := model.Digest{ID: uuid.MustParse("886313e1-3b8a-5372-9b90-0c9aee199e5d"), RuleID: ruleID, ContactID: uuid.New()}
+	rows := &digestRows{
+		digests: map[uuid.UUID]model.Digest{first.ID: first, second.ID: second},
+		items:   []*model.Notification{{ID: uuid.New()}},
+		open:    []uuid.UUID{first.ID, second.ID},
+	}
+
+	t.Run("open digests are sent first", func(t *testing.T) {
+		db, built := dryRun(t)
+		rows.register(t, db)
+		repo := NewDigestRepository(db)
+
+		var flushed []uuid.UUID
+		err := repo.DeleteRule(context.Background(), ruleID, func(digest *model.Digest, items []*model.Notification) (*model.Notification, error) {
+			flushed = append(flushed, digest.ID)
+			return &model.Notification{ID: uuid.New()}, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{first.ID, second.ID}, flushed)
+
+		// The rule is locked so that no notification is collected into a digest being sent
+		assert.Contains(t, built.find(`SELECT * FROM "digest_rules"`), "WHERE id = '16fd2706-8baf-433b-82eb-8c7fada847da'")
+		assert.True(t, strings.HasSuffix(built.find(`SELECT * FROM "digest_rules"`), "FOR UPDATE"))
+		assert.Equal(t, `SELECT "id" FROM "digests" WHERE rule_id = '16fd2706-8baf-433b-82eb-8c7fada847da' AND status = 'open'`, built.find(`SELECT "id" FROM "digests"`))
+
+		var updates, deleted int
+		for _, statement := range *built {
+			switch {
+			case strings.HasPrefix(statement, `UPDATE "digests"`):
+				assert.Zero(t, deleted, "digests are sent before the rule is deleted")
+				updates++
+			case strings.HasPrefix(statement, `DELETE FROM "digest_rules"`):
+				assert.Equal(t, `DELETE FROM "digest_rules" WHERE id = '16fd2706-8baf-433b-82eb-8c7fada847da'`, statement)
+				deleted++
+			}
+		}
+		assert.Equal(t, 2, updates)
+		assert.Equal(t, 1, deleted)
+	})
+
+	t.Run("rule is kept when a digest cannot be sent", func(t *testing.T) {
+		db, built := dryRun(t)
+		rows.register(t, db)
+		repo := NewDigestRepository(db)
+
+		failure := errors.New("digest template deleted")
+		err := repo.DeleteRule(context.Background(), ruleID, func(digest *model.Digest, items []*model.Notification) (*model.Notification, error) {
+			return nil, failure
+		})
+		assert.ErrorIs(t, err, failure)
+		assert.Empty(t, built.find(`DELETE FROM "digest_rules"`))
+	})
+}
+
+func TestDigestAddItem(t *testing.T) {
+	db, built := dryRun(t)
+	repo := NewDigestRepository(db)
+
+	ruleID := uuid.MustParse("16fd2706-8baf-433b-82eb-8c7fada847da")
+	contactID := uuid.MustParse("886313e1-3b8a-5372-9b90-0c9aee199e5d")
+	_, err := repo.AddItem(context.Background(), &model.DigestRule{ID: ruleID, WindowSeconds: 60}, &model.Notification{ID: uuid.New(), ContactID: &contactID})
+	require.NoError(t, err)
+
+	// The rule is share-locked before the digest, a rule being deleted makes the notification wait
+	share := indexOf(built, `SELECT "id" FROM "digest_rules" WHERE id = '16fd2706-8baf-433b-82eb-8c7fada847da' ORDER BY "digest_rules"."id" LIMIT 1 FOR SHARE`)
+	lock := indexOf(built, "SELECT pg_advisory_xact_lock(hashtext('digest:16fd2706-8baf-433b-82eb-8c7fada847da:886313e1-3b8a-5372-9b90-0c9aee199e5d'))")
+	require.NotContains(t, []int{share, lock}, -1, "statements %v", *built)
+	assert.Less(t, share, lock)
+}
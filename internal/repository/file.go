@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"hermes-api/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FileRepository defines the interface for file data operations
+type FileRepository interface {
+
+	// Basic CRUD operations
+	BaseRepository[model.File]
+
+	// Query operations
+	GetByApplication(ctx context.Context, applicationID, id uuid.UUID) (*model.File, error)
+}
+
+// fileRepository implements FileRepository
+type fileRepository struct {
+	BaseRepository[model.File]
+	db *gorm.DB
+}
+
+// NewFileRepository creates a new file repository
+func NewFileRepository(db *gorm.DB) FileRepository {
+	return &fileRepository{
+		BaseRepository: NewBaseRepository[model.File](db),
+		db:             db,
+	}
+}
+
+// GetByApplication retrieves a file scoped to an application
+func (r *fileRepository) GetByApplication(ctx context.Context, applicationID, id uuid.UUID) (*model.File, error) {
+	var file model.File
+	err := r.db.WithContext(ctx).
+		Where("application_id = ? AND id = ?", applicationID, id).
+		First(&file).Error
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"hermes-api/internal/model"
+	"hermes-api/pkg/ratelimit"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FrequencyCapRepository defines the interface for frequency cap data operations
+type FrequencyCapRepository interface {
+
+	// Basic CRUD operations
+	BaseRepository[model.FrequencyCap]
+
+	// Query operations
+	GetByApplication(ctx context.Context, applicationID, id uuid.UUID) (*model.FrequencyCap, error)
+	ListByApplication(ctx context.Context, applicationID uuid.UUID) ([]*model.FrequencyCap, error)
+
+	// Allow implements ratelimit.SlidingWindow in PostgreSQL for deployments without Redis
+	Allow(ctx context.Context, event string, limits []ratelimit.Limit) (int, error)
+}
+
+// frequencyCapRepository implements FrequencyCapRepository
+type frequencyCapRepository struct {
+	BaseRepository[model.FrequencyCap]
+	db *gorm.DB
+}
+
+// NewFrequencyCapRepository creates a new frequency cap repository
+func NewFrequencyCapRepository(db *gorm.DB) FrequencyCapRepository {
+	return &frequencyCapRepository{
+		BaseRepository: NewBaseRepository[model.FrequencyCap](db),
+		db:             db,
+	}
+}
+
+// GetByApplication retrieves a frequency cap scoped to an application
+func (r *frequencyCapRepository) GetByApplication(ctx context.Context, applicationID, id uuid.UUID) (*model.FrequencyCap, error) {
+	var frequencyCap model.FrequencyCap
+	err := r.db.WithContext(ctx).
+		Where("application_id = ? AND id = ?", applicationID, id).
+		First(&frequencyCap).Error
+	if err != nil {
+		return nil, err
+	}
+	return &frequencyCap, nil
+}
+
+// ListByApplication retrieves all frequency caps of an application
+func (r *frequencyCapRepository) ListByApplication(ctx context.Context, applicationID uuid.UUID) ([]*model.FrequencyCap, error) {
+	var frequencyCaps []*model.FrequencyCap
+	err := r.db.WithContext(ctx).
+		Where("application_id = ?", applicationID).
+		Order("created_at ASC").
+		Find(&frequencyCaps).Error
+	return frequencyCaps, err
+}
+
+// Allow records an event under the key of every limit unless one of them already has its limit of events
+// within its window, and returns the index of the first limit reached or -1. Events are timed with the
+// database clock. Concurrent calls for the same keys are serialized by transaction-level advisory locks,
+// taken in key order so that calls for overlapping keys can't deadlock.
+func (r *frequencyCapRepository) Allow(ctx context.Context, event string, limits []ratelimit.Limit) (int, error) {
+	if len(limits) == 0 {
+		return -1, nil
+	}
+
+	reached := -1
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		keys := make([]string, len(limits))
+		for i, limit := range limits {
+			keys[i] = limit.Key
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", key).Error; err != nil {
+				return err
+			}
+		}
+
+		var now time.Time
+		if err := tx.Raw("SELECT clock_timestamp()").Scan(&now).Error; err != nil {
+			return err
+		}
+
+		// Limits under which the event is not recorded yet
+		var unrecorded []ratelimit.Limit
+		for i, limit := range limits {
+			err := tx.Where("key = ? AND occurred_at <= ?", limit.Key, now.Add(-limit.Window)).
+				Delete(&model.FrequencyCapEvent{}).Error
+			if err != nil {
+				return err
+			}
+
+			var recorded int64
+			if err := tx.Model(&model.FrequencyCapEvent{}).Where("key = ? AND event = ?", limit.Key, event).Count(&recorded).Error; err != nil {
+				return err
+			}
+			if recorded > 0 {
+				continue
+			}
+
+			var count int64
+			if err := tx.Model(&model.FrequencyCapEvent{}).Where("key = ?", limit.Key).Count(&count).Error; err != nil {
+				return err
+			}
+			if count >= int64(limit.Limit) {
+				reached = i
+				return nil
+			}
+			unrecorded = append(unrecorded, limit)
+		}
+
+		if len(unrecorded) == 0 {
+			return nil
+		}
+		events := make([]*model.FrequencyCapEvent, len(unrecorded))
+		for i, limit := range unrecorded {
+			events[i] = &model.FrequencyCapEvent{Key: limit.Key, Event: event, OccurredAt: now}
+		}
+		return tx.Create(&events).Error
+	})
+	return reached, err
+}
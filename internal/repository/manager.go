@@ -10,6 +10,18 @@ import (
 type RepositoryManager interface {
 	User() UserRepository
 	Application() ApplicationRepository
+	Template() TemplateRepository
+	Notification() NotificationRepository
+	File() FileRepository
+	Contact() ContactRepository
+	ContactImport() ContactImportRepository
+	Suppression() SuppressionRepository
+	FrequencyCap() FrequencyCapRepository
+	Digest() DigestRepository
+	ProviderStat() ProviderStatRepository
+	ProviderCredential() ProviderCredentialRepository
+	TrackingEvent() TrackingEventRepository
+	DeliveryRollup() DeliveryRollupRepository
 
 	// Transaction support
 	WithTransaction(ctx context.Context, fn func(RepositoryManager) error) error
@@ -17,17 +29,41 @@ type RepositoryManager interface {
 
 // repositoryManager implements RepositoryManager
 type repositoryManager struct {
-	db          *gorm.DB
-	user        UserRepository
-	application ApplicationRepository
+	db                 *gorm.DB
+	user               UserRepository
+	application        ApplicationRepository
+	template           TemplateRepository
+	notification       NotificationRepository
+	file               FileRepository
+	contact            ContactRepository
+	contactImport      ContactImportRepository
+	suppression        SuppressionRepository
+	frequencyCap       FrequencyCapRepository
+	digest             DigestRepository
+	providerStat       ProviderStatRepository
+	providerCredential ProviderCredentialRepository
+	trackingEvent      TrackingEventRepository
+	deliveryRollup     DeliveryRollupRepository
 }
 
 // NewRepositoryManager creates a new repository manager
 func NewRepositoryManager(db *gorm.DB) RepositoryManager {
 	return &repositoryManager{
-		db:          db,
-		user:        NewUserRepository(db),
-		application: NewApplicationRepository(db),
+		db:                 db,
+		user:               NewUserRepository(db),
+		application:        NewApplicationRepository(db),
+		template:           NewTemplateRepository(db),
+		notification:       NewNotificationRepository(db),
+		file:               NewFileRepository(db),
+		contact:            NewContactRepository(db),
+		contactImport:      NewContactImportRepository(db),
+		suppression:        NewSuppressionRepository(db),
+		frequencyCap:       NewFrequencyCapRepository(db),
+		digest:             NewDigestRepository(db),
+		providerStat:       NewProviderStatRepository(db),
+		providerCredential: NewProviderCredentialRepository(db),
+		trackingEvent:      NewTrackingEventRepository(db),
+		deliveryRollup:     NewDeliveryRollupRepository(db),
 	}
 }
 
@@ -41,13 +77,86 @@ func (rm *repositoryManager) Application() ApplicationRepository {
 	return rm.application
 }
 
+// Template returns the template repository
+func (rm *repositoryManager) Template() TemplateRepository {
+	return rm.template
+}
+
+// Notification returns the notification repository
+func (rm *repositoryManager) Notification() NotificationRepository {
+	return rm.notification
+}
+
+// File returns the file repository
+func (rm *repositoryManager) File() FileRepository {
+	return rm.file
+}
+
+// Contact returns the contact repository
+func (rm *repositoryManager) Contact() ContactRepository {
+	return rm.contact
+}
+
+// ContactImport returns the contact import repository
+func (rm *repositoryManager) ContactImport() ContactImportRepository {
+	return rm.contactImport
+}
+
+// Suppression returns the suppression repository
+func (rm *repositoryManager) Suppression() SuppressionRepository {
+	return rm.suppression
+}
+
+// FrequencyCap returns the frequency cap repository
+func (rm *repositoryManager) FrequencyCap() FrequencyCapRepository {
+	return rm.frequencyCap
+}
+
+// Digest returns the digest repository
+func (rm *repositoryManager) Digest() DigestRepository {
+	return rm.digest
+}
+
 // WithTransaction executes a function within a database transaction
 func (rm *repositoryManager) WithTransaction(ctx context.Context, fn func(RepositoryManager) error) error {
 	return rm.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		txManager := &repositoryManager{
-			db:   tx,
-			user: NewUserRepository(tx),
+			db:                 tx,
+			user:               NewUserRepository(tx),
+			application:        NewApplicationRepository(tx),
+			template:           NewTemplateRepository(tx),
+			notification:       NewNotificationRepository(tx),
+			file:               NewFileRepository(tx),
+			contact:            NewContactRepository(tx),
+			contactImport:      NewContactImportRepository(tx),
+			suppression:        NewSuppressionRepository(tx),
+			frequencyCap:       NewFrequencyCapRepository(tx),
+			digest:             NewDigestRepository(tx),
+			providerStat:       NewProviderStatRepository(tx),
+			providerCredential: NewProviderCredentialRepository(tx),
+			trackingEvent:      NewTrackingEventRepository(tx),
+			deliveryRollup:     NewDeliveryRollupRepository(tx),
 		}
 		return fn(txManager)
 	})
 }
+
+// ProviderStat returns the provider stat repository
+func (rm *repositoryManager) ProviderStat() ProviderStatRepository {
+	return rm.providerStat
+}
+
+// ProviderCredential returns the provider credential repository
+func (rm *repositoryManager) ProviderCredential() ProviderCredentialRepository {
+	return rm.providerCredential
+}
+
+// TrackingEvent returns the tracking event repository
+func (rm *repositoryManager) TrackingEvent() TrackingEventRepository {
+	return rm.trackingEvent
+}
+
+// DeliveryRollup returns the delivery rollup repository
+func (rm *repositoryManager) DeliveryRollup() DeliveryRollupRepository {
+	return rm.deliveryRollup
+}
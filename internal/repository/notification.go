@@ -0,0 +1,433 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hermes-api/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NotificationRepository defines the interface for notification data operations
+type NotificationRepository interface {
+
+	// Basic CRUD operations
+	BaseRepository[model.Notification]
+
+	// Query operations
+	GetByApplication(ctx context.Context, applicationID, id uuid.UUID) (*model.Notification, error)
+	GetByUser(ctx context.Context, userID, id uuid.UUID) (*model.Notification, error)
+	IsChainDelivered(ctx context.Context, rootID uuid.UUID) (bool, error)
+	ListAttempts(ctx context.Context, notificationID uuid.UUID) ([]*model.NotificationAttempt, error)
+	GetAttemptByMessageID(ctx context.Context, messageID string) (*model.NotificationAttempt, error)
+	Search(ctx context.Context, filter NotificationFilter) ([]*model.Notification, error)
+
+	// Write operations
+	CreateDeduplicated(ctx context.Context, notification *model.Notification, window time.Duration) (*model.Notification, error)
+
+	// Delivery operations
+	ClaimQueued(ctx context.Context, priority model.NotificationPriority, limit int) ([]*model.Notification, error)
+	RequeueExpired(ctx context.Context, lease time.Duration, limit int) (int, error)
+	GetForDelivery(ctx context.Context, id uuid.UUID) (*model.Notification, error)
+	UpdateDelivery(ctx context.Context, notification *model.Notification) error
+	UpdateStatus(ctx context.Context, notification *model.Notification, status model.NotificationStatus, reason string) error
+	ExpediteFallback(ctx context.Context, notification *model.Notification) error
+}
+
+// ErrInvalidStatusTransition is returned when a notification cannot move from its current status to a new one
+var ErrInvalidStatusTransition = errors.New("invalid notification status transition")
+
+// NotificationFilter selects notifications of the applications of a user, zero fields match every notification
+type NotificationFilter struct {
+	UserID        uuid.UUID // Owner of the applications, always applied
+	ApplicationID *uuid.UUID
+	Recipient     string // A single address, notifications sent to several addresses match any of them
+	ContactID     *uuid.UUID
+	ExternalID    string // External ID of the contact
+	Statuses      []model.NotificationStatus
+	Channel       model.Channel
+	Template      string // Name of the template
+	From          *time.Time
+	To            *time.Time
+	Metadata      map[string]string // Every pair must match
+
+	SortBy     string // created_at or updated_at
+	Descending bool
+	Limit      int
+	After      *NotificationCursor // Position of the last notification of the previous page
+}
+
+// NotificationCursor is the position of a notification in a sorted list
+type NotificationCursor struct {
+	Value time.Time `json:"v"`
+	ID    uuid.UUID `json:"i"`
+}
+
+// notificationSortColumns lists the columns notifications can be sorted by
+var notificationSortColumns = map[string]bool{"created_at": true, "updated_at": true}
+
+// notificationRepository implements NotificationRepository
+type notificationRepository struct {
+	BaseRepository[model.Notification]
+	db *gorm.DB
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{
+		BaseRepository: NewBaseRepository[model.Notification](db),
+		db:             db,
+	}
+}
+
+// GetByApplication retrieves a notification scoped to an application with its attachments, status history,
+// delivery attempts and fallbacks
+func (r *notificationRepository) GetByApplication(ctx context.Context, applicationID, id uuid.UUID) (*model.Notification, error) {
+	var notification model.Notification
+	err := r.withDetails(ctx).
+		Where("application_id = ? AND id = ?", applicationID, id).
+		First(&notification).Error
+	if err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// GetByUser retrieves a notification scoped to the applications of a user with its attachments, status
+// history, delivery attempts and fallbacks
+func (r *notificationRepository) GetByUser(ctx context.Context, userID, id uuid.UUID) (*model.Notification, error) {
+	var notification model.Notification
+	err := r.withDetails(ctx).
+		Where("application_id IN (?)", r.db.Model(&model.Application{}).Select("id").Where("user_id = ?", userID)).
+		Where("id = ?", id).
+		First(&notification).Error
+	if err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// withDetails preloads the attachments, status history, delivery attempts and fallbacks of notifications
+func (r *notificationRepository) withDetails(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).
+		Preload("Attachments").
+		Preload("StatusHistory", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		Preload("AttemptLog", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		Preload("Fallbacks", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		})
+}
+
+// IsChainDelivered reports whether the notification a fallback chain started from, or one of its
+// fallbacks, was delivered
+func (r *notificationRepository) IsChainDelivered(ctx context.Context, rootID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.Notification{}).
+		Where("(id = ? OR parent_id = ?) AND status = ?", rootID, rootID, model.NotificationStatusDelivered).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ListAttempts retrieves the delivery attempts of a notification, oldest first
+func (r *notificationRepository) ListAttempts(ctx context.Context, notificationID uuid.UUID) ([]*model.NotificationAttempt, error) {
+	var attempts []*model.NotificationAttempt
+	err := r.db.WithContext(ctx).
+		Where("notification_id = ?", notificationID).
+		Order("created_at ASC").
+		Find(&attempts).Error
+	return attempts, err
+}
+
+// GetAttemptByMessageID retrieves the successful delivery attempt a provider assigned a message ID to
+func (r *notificationRepository) GetAttemptByMessageID(ctx context.Context, messageID string) (*model.NotificationAttempt, error) {
+	var attempt model.NotificationAttempt
+	err := r.db.WithContext(ctx).
+		Where("provider_message_id = ? AND outcome = ?", messageID, model.AttemptOutcomeSucceeded).
+		Order("created_at DESC").
+		First(&attempt).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// Search retrieves the notifications matching a filter in its sort order, the ID breaks ties so that
+// cursors are stable when several notifications share a timestamp
+func (r *notificationRepository) Search(ctx context.Context, filter NotificationFilter) ([]*model.Notification, error) {
+	sortBy := filter.SortBy
+	if !notificationSortColumns[sortBy] {
+		sortBy = "created_at"
+	}
+	direction, comparison := "ASC", ">"
+	if filter.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	query := r.db.WithContext(ctx).
+		Where("application_id IN (?)", r.db.Model(&model.Application{}).Select("id").Where("user_id = ?", filter.UserID))
+	if filter.ApplicationID != nil {
+		query = query.Where("application_id = ?", *filter.ApplicationID)
+	}
+	if filter.Recipient != "" {
+		// Notifications sent to several addresses store them joined by commas
+		query = query.Where("lower(?) = ANY(string_to_array(lower(recipient), ','))", filter.Recipient)
+	}
+	if filter.ContactID != nil {
+		query = query.Where("contact_id = ?", *filter.ContactID)
+	}
+	if filter.ExternalID != "" {
+		query = query.Where("contact_id IN (?)", r.db.Model(&model.Contact{}).Select("id").Where("external_id = ?", filter.ExternalID))
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.Channel != "" {
+		query = query.Where("channel = ?", filter.Channel)
+	}
+	if filter.Template != "" {
+		query = query.Where("template_id IN (?)", r.db.Model(&model.Template{}).Select("id").Where("name = ?", filter.Template))
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	if len(filter.Metadata) > 0 {
+		contained, err := json.Marshal(filter.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("metadata @> ?::jsonb", string(contained))
+	}
+	if filter.After != nil {
+		query = query.Where("("+sortBy+", id) "+comparison+" (?, ?)", filter.After.Value, filter.After.ID)
+	}
+
+	var notifications []*model.Notification
+	err := query.
+		Order(sortBy + " " + direction).
+		Order("id " + direction).
+		Limit(filter.Limit).
+		Find(&notifications).Error
+	return notifications, err
+}
+
+// CreateDeduplicated creates a notification, recording it as deduplicated when a notification of the
+// application with the same dedupe key was created within window; the original notification is returned
+// in that case. Concurrent requests with the same key are serialized by a transaction-level advisory lock.
+// Notifications without a dedupe key are always created.
+func (r *notificationRepository) CreateDeduplicated(ctx context.Context, notification *model.Notification, window time.Duration) (*model.Notification, error) {
+	if notification.DedupeKey == "" {
+		return nil, r.db.WithContext(ctx).Create(notification).Error
+	}
+
+	var original *model.Notification
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		lockKey := notification.ApplicationID.String() + ":" + notification.DedupeKey
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", lockKey).Error; err != nil {
+			return err
+		}
+
+		var existing model.Notification
+		err := tx.Where("application_id = ? AND dedupe_key = ? AND created_at > ?", notification.ApplicationID, notification.DedupeKey, time.Now().Add(-window)).
+			Where("status <> ?", model.NotificationStatusDeduplicated).
+			Order("created_at DESC").
+			First(&existing).Error
+		switch {
+		case err == nil:
+			original = &existing
+			notification.Status = model.NotificationStatusDeduplicated
+			notification.StatusReason = fmt.Sprintf("Duplicate of notification %s", existing.ID)
+			notification.StatusHistory = []model.NotificationStatusChange{{Status: notification.Status, Reason: notification.StatusReason}}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		return tx.Create(notification).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return original, nil
+}
+
+// ClaimQueued marks up to limit queued notifications of a priority, and deferred notifications whose
+// scheduled time has come, as processing and returns them, oldest first. Rows locked by another worker
+// are skipped so that several workers can poll concurrently.
+func (r *notificationRepository) ClaimQueued(ctx context.Context, priority model.NotificationPriority, limit int) ([]*model.Notification, error) {
+	var notifications []*model.Notification
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status IN ?", []model.NotificationStatus{model.NotificationStatusQueued, model.NotificationStatusDeferred}).
+			Where("priority = ?", priority).
+			Where("scheduled_at IS NULL OR scheduled_at <= ?", time.Now().UTC()).
+			Order("created_at ASC").
+			Limit(limit).
+			Find(&notifications).Error
+		if err != nil || len(notifications) == 0 {
+			return err
+		}
+
+		claimedAt := time.Now().UTC()
+		ids := make([]uuid.UUID, len(notifications))
+		changes := make([]model.NotificationStatusChange, len(notifications))
+		for i, notification := range notifications {
+			ids[i] = notification.ID
+			notification.Status = model.NotificationStatusProcessing
+			notification.ClaimedAt = &claimedAt
+			changes[i] = model.NotificationStatusChange{NotificationID: notification.ID, Status: notification.Status}
+		}
+
+		err = tx.Model(&model.Notification{}).
+			Where("id IN ?", ids).
+			Updates(map[string]any{"status": model.NotificationStatusProcessing, "claimed_at": claimedAt}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&changes).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// RequeueExpired moves up to limit notifications claimed longer than lease ago back to the queue, e.g.
+// after their worker crashed, and returns how many were requeued
+func (r *notificationRepository) RequeueExpired(ctx context.Context, lease time.Duration, limit int) (int, error) {
+	var notifications []*model.Notification
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Notifications claimed before claims were recorded expire with their last update
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Select("id").
+			Where("status = ?", model.NotificationStatusProcessing).
+			Where("COALESCE(claimed_at, updated_at) < ?", time.Now().UTC().Add(-lease)).
+			Limit(limit).
+			Find(&notifications).Error
+		if err != nil || len(notifications) == 0 {
+			return err
+		}
+
+		reason := fmt.Sprintf("Requeued after processing for longer than %s", lease)
+		ids := make([]uuid.UUID, len(notifications))
+		changes := make([]model.NotificationStatusChange, len(notifications))
+		for i, notification := range notifications {
+			ids[i] = notification.ID
+			changes[i] = model.NotificationStatusChange{NotificationID: notification.ID, Status: model.NotificationStatusQueued, Reason: reason}
+		}
+
+		err = tx.Model(&model.Notification{}).
+			Where("id IN ?", ids).
+			Updates(map[string]any{"status": model.NotificationStatusQueued, "status_reason": reason, "claimed_at": nil}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&changes).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(notifications), nil
+}
+
+// GetForDelivery retrieves a notification with everything needed to deliver it
+func (r *notificationRepository) GetForDelivery(ctx context.Context, id uuid.UUID) (*model.Notification, error) {
+	var notification model.Notification
+	err := r.db.WithContext(ctx).
+		Preload("Application").
+		Preload("Template.Variants").
+		Preload("Contact.Preferences").
+		Preload("Attachments.File").
+		First(&notification, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// UpdateDelivery saves the delivery outcome of a notification without touching its other associations,
+// records its new status in the status history and adds its new delivery attempts. ErrInvalidStatusTransition
+// is returned when the stored status cannot move to the new one, e.g. once the notification is final, or
+// when the notification was requeued and claimed again since it was loaded.
+func (r *notificationRepository) UpdateDelivery(ctx context.Context, notification *model.Notification) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(notification).
+			Omit(clause.Associations).
+			Where("status IN ?", model.NotificationStatusSources(notification.Status))
+		if notification.ClaimedAt != nil {
+			query = query.Where("claimed_at = ?", *notification.ClaimedAt)
+		}
+		result := query.
+			Select("status", "status_reason", "recipient", "attempts", "retries", "scheduled_at", "sent_at").
+			Updates(notification)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w to '%s'", ErrInvalidStatusTransition, notification.Status)
+		}
+
+		var attempts []*model.NotificationAttempt
+		for i := range notification.AttemptLog {
+			if notification.AttemptLog[i].ID == uuid.Nil {
+				notification.AttemptLog[i].NotificationID = notification.ID
+				attempts = append(attempts, &notification.AttemptLog[i])
+			}
+		}
+		if len(attempts) > 0 {
+			if err := tx.Create(&attempts).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(&model.NotificationStatusChange{
+			NotificationID: notification.ID,
+			Status:         notification.Status,
+			Reason:         notification.StatusReason,
+		}).Error
+	})
+}
+
+// UpdateStatus moves a notification to a status reported after its delivery, e.g. by a provider webhook,
+// and records it in the status history. ErrInvalidStatusTransition is returned when the stored status
+// cannot move to the new one, e.g. for events reported twice or out of order.
+func (r *notificationRepository) UpdateStatus(ctx context.Context, notification *model.Notification, status model.NotificationStatus, reason string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Notification{}).
+			Where("id = ? AND status IN ?", notification.ID, model.NotificationStatusSources(status)).
+			Updates(map[string]any{"status": status, "status_reason": reason})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w to '%s'", ErrInvalidStatusTransition, status)
+		}
+
+		notification.Status = status
+		notification.StatusReason = reason
+		return tx.Create(&model.NotificationStatusChange{
+			NotificationID: notification.ID,
+			Status:         status,
+			Reason:         reason,
+		}).Error
+	})
+}
+
+// ExpediteFallback schedules the deferred fallback of a notification, waiting to learn whether the
+// notification is delivered, for right away
+func (r *notificationRepository) ExpediteFallback(ctx context.Context, notification *model.Notification) error {
+	return r.db.WithContext(ctx).Model(&model.Notification{}).
+		Where("fallback_of = ? AND status = ?", notification.ID, model.NotificationStatusDeferred).
+		Update("scheduled_at", time.Now().UTC()).Error
+}
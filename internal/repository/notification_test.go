@@ -0,0 +1,330 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"hermes-api/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// errDryRun is returned when a dry run database is asked to reach the database
+var errDryRun = errors.New("dry run database cannot run statements")
+
+// dryRunPool is the connection pool of dry run databases: statements are never run, transactions begin
+// and end without a database
+type dryRunPool struct{}
+
+func (*dryRunPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errDryRun
+}
+
+func (*dryRunPool) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return nil, errDryRun
+}
+
+func (*dryRunPool) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return nil, errDryRun
+}
+
+func (*dryRunPool) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return nil
+}
+
+func (p *dryRunPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	return p, nil
+}
+
+func (*dryRunPool) Commit() error {
+	return nil
+}
+
+func (*dryRunPool) Rollback() error {
+	return nil
+}
+
+// statements are the SQL statements built by a dry run database, with their variables inlined
+type statements []string
+
+// last returns the last statement built
+func (s *statements) last() string {
+	if len(*s) == 0 {
+		return ""
+	}
+	return (*s)[len(*s)-1]
+}
+
+// find returns the first statement starting with prefix
+func (s *statements) find(prefix string) string {
+	for _, statement := range *s {
+		if strings.HasPrefix(statement, prefix) {
+			return statement
+		}
+	}
+	return ""
+}
+
+// dryRun returns a database that builds statements without running them, and the statements it built
+func dryRun(t *testing.T) (*gorm.DB, *statements) {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: &dryRunPool{}}), &gorm.Config{DryRun: true})
+	require.NoError(t, err, "failed to open dry run database")
+
+	built := &statements{}
+	capture := func(tx *gorm.DB) {
+		*built = append(*built, tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...))
+	}
+	callbacks := db.Callback()
+	require.NoError(t, callbacks.Query().After("gorm:query").Register("test:capture", capture))
+	require.NoError(t, callbacks.Update().After("gorm:update").Register("test:capture", capture))
+	require.NoError(t, callbacks.Create().After("gorm:create").Register("test:capture", capture))
+	require.NoError(t, callbacks.Delete().After("gorm:delete").Register("test:capture", capture))
+	require.NoError(t, callbacks.Raw().After("gorm:raw").Register("test:capture", capture))
+	return db, built
+}
+
+func TestNotificationExpediteFallback(t *testing.T) {
+	db, built := dryRun(t)
+	repo := NewNotificationRepository(db)
+
+	notification := &model.Notification{ID: uuid.MustParse("7c9e6679-7425-40de-944b-e07fc1f90ae7")}
+	require.NoError(t, repo.ExpediteFallback(context.Background(), notification))
+
+	query := built.last()
+	assert.Contains(t, query, `UPDATE "notifications" SET "scheduled_at"=`)
+	assert.Contains(t, query, `WHERE fallback_of = '7c9e6679-7425-40de-944b-e07fc1f90ae7' AND status = 'deferred'`)
+	assert.NotContains(t, query, "dedupe_key", "the caller's dedupe key is not used to link fallbacks")
+}
+
+func TestNotificationSearchKeyset(t *testing.T) {
+	after := &NotificationCursor{
+		Value: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		ID:    uuid.MustParse("7c9e6679-7425-40de-944b-e07fc1f90ae7"),
+	}
+	position := "('2024-03-01 12:00:00', '7c9e6679-7425-40de-944b-e07fc1f90ae7')"
+
+	tests := []struct {
+		name      string
+		filter    NotificationFilter
+		wantWhere string // Empty when the query must not have a keyset condition
+		wantOrder string
+	}{
+		{
+			name:      "first page newest first",
+			filter:    NotificationFilter{SortBy: "created_at", Descending: true, Limit: 21},
+			wantOrder: "ORDER BY created_at DESC,id DESC LIMIT 21",
+		},
+		{
+			name:      "next page newest first",
+			filter:    NotificationFilter{SortBy: "created_at", Descending: true, Limit: 21, After: after},
+			wantWhere: "(created_at, id) < " + position,
+			wantOrder: "ORDER BY created_at DESC,id DESC LIMIT 21",
+		},
+		{
+			name:      "next page oldest first",
+			filter:    NotificationFilter{SortBy: "created_at", Limit: 11, After: after},
+			wantWhere: "(created_at, id) > " + position,
+			wantOrder: "ORDER BY created_at ASC,id ASC LIMIT 11",
+		},
+		{
+			name:      "next page by update",
+			filter:    NotificationFilter{SortBy: "updated_at", Descending: true, Limit: 21, After: after},
+			wantWhere: "(updated_at, id) < " + position,
+			wantOrder: "ORDER BY updated_at DESC,id DESC LIMIT 21",
+		},
+		{
+			name:      "unknown sort column",
+			filter:    NotificationFilter{SortBy: "recipient; DROP TABLE notifications", Descending: true, Limit: 21, After: after},
+			wantWhere: "(created_at, id) < " + position,
+			wantOrder: "ORDER BY created_at DESC,id DESC LIMIT 21",
+		},
+		{
+			name:      "single recipient address",
+			filter:    NotificationFilter{SortBy: "created_at", Recipient: "Ada@example.com", Limit: 21},
+			wantWhere: "lower('Ada@example.com') = ANY(string_to_array(lower(recipient), ','))",
+			wantOrder: "ORDER BY created_at ASC,id ASC LIMIT 21",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, built := dryRun(t)
+			repo := NewNotificationRepository(db)
+
+			tt.filter.UserID = uuid.New()
+			_, err := repo.Search(context.Background(), tt.filter)
+			require.NoError(t, err)
+
+			query := built.last()
+			if tt.wantWhere != "" {
+				assert.Contains(t, query, tt.wantWhere)
+			} else {
+				assert.NotContains(t, query, ", id) ", "keyset condition")
+			}
+			assert.True(t, strings.HasSuffix(query, tt.wantOrder), "query %s must end with %s", query, tt.wantOrder)
+		})
+	}
+}
+
+// cutoff returns the time compared with column in a statement built by a dry run database
+func cutoff(t *testing.T, query, column string) time.Time {
+	t.Helper()
+
+	_, rest, found := strings.Cut(query, column+" < '")
+	require.True(t, found, "query %s must compare %s", query, column)
+	value, _, _ := strings.Cut(rest, "'")
+	at, err := time.Parse("2006-01-02 15:04:05.999999999", value)
+	require.NoError(t, err)
+	return at
+}
+
+func TestNotificationClaimQueued(t *testing.T) {
+	for _, priority := range []model.NotificationPriority{model.NotificationPriorityCritical, model.NotificationPriorityLow} {
+		t.Run(string(priority), func(t *testing.T) {
+			db, built := dryRun(t)
+			repo := NewNotificationRepository(db)
+
+			claimed, err := repo.ClaimQueued(context.Background(), priority, 25)
+			require.NoError(t, err)
+			assert.Empty(t, claimed)
+
+			query := built.find("SELECT")
+			assert.Contains(t, query, "WHERE status IN ('queued','deferred') AND priority = '"+string(priority)+"'")
+			assert.Contains(t, query, "(scheduled_at IS NULL OR scheduled_at <= ", "deferred notifications wait for their time")
+			assert.True(t, strings.HasSuffix(query, "ORDER BY created_at ASC LIMIT 25 FOR UPDATE SKIP LOCKED"),
+				"rows claimed by another worker must be skipped: %s", query)
+			assert.Empty(t, built.find(`UPDATE "notifications"`), "nothing is claimed without queued notifications")
+		})
+	}
+}
+
+func TestNotificationRequeueExpired(t *testing.T) {
+	for _, lease := range []time.Duration{2 * time.Minute, 5 * time.Minute, time.Hour} {
+		t.Run(lease.String(), func(t *testing.T) {
+			db, built := dryRun(t)
+			repo := NewNotificationRepository(db)
+
+			requeued, err := repo.RequeueExpired(context.Background(), lease, 50)
+			require.NoError(t, err)
+			assert.Zero(t, requeued)
+
+			query := built.find("SELECT")
+			assert.Contains(t, query, `SELECT "id" FROM "notifications" WHERE status = 'processing'`)
+			assert.True(t, strings.HasSuffix(query, "LIMIT 50 FOR UPDATE SKIP LOCKED"), query)
+
+			// Claims expire a lease after they were made, or after the last update for claims made
+			// before they were recorded
+			expiredBefore := cutoff(t, query, "COALESCE(claimed_at, updated_at)")
+			assert.WithinDuration(t, time.Now().UTC().Add(-lease), expiredBefore, time.Second)
+		})
+	}
+}
+
+func TestNotificationUpdateDelivery(t *testing.T) {
+	claimedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		claimedAt *time.Time
+		status    model.NotificationStatus
+		wantWhere string
+	}{
+		{
+			name:      "claimed notification",
+			claimedAt: &claimedAt,
+			status:    model.NotificationStatusSent,
+			wantWhere: "WHERE status IN ('processing') AND claimed_at = '2024-03-01 12:00:00'",
+		},
+		{
+			name:      "notification claimed before claims were recorded",
+			status:    model.NotificationStatusSent,
+			wantWhere: "WHERE status IN ('processing') AND \"id\" = ",
+		},
+		{
+			name:      "retry",
+			claimedAt: &claimedAt,
+			status:    model.NotificationStatusDeferred,
+			wantWhere: "AND claimed_at = '2024-03-01 12:00:00'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, built := dryRun(t)
+			repo := NewNotificationRepository(db)
+
+			notification := &model.Notification{ID: uuid.New(), Status: tt.status, ClaimedAt: tt.claimedAt}
+			err := repo.UpdateDelivery(context.Background(), notification)
+
+			// A dry run matches no row, like the update of a worker whose claim was requeued and claimed
+			// again by another worker: the outcome is refused and nothing else is written
+			assert.ErrorIs(t, err, ErrInvalidStatusTransition)
+			query := built.find(`UPDATE "notifications" SET`)
+			assert.Contains(t, query, tt.wantWhere)
+			if tt.claimedAt == nil {
+				assert.NotContains(t, query, "claimed_at")
+			}
+			assert.Empty(t, built.find("INSERT INTO"), "a refused outcome must not be recorded")
+		})
+	}
+}
+
+func TestNotificationCreateDeduplicated(t *testing.T) {
+	applicationID := uuid.MustParse("7c9e6679-7425-40de-944b-e07fc1f90ae7")
+
+	t.Run("without a dedupe key", func(t *testing.T) {
+		db, built := dryRun(t)
+		repo := NewNotificationRepository(db)
+
+		notification := &model.Notification{ID: uuid.New(), ApplicationID: applicationID, Status: model.NotificationStatusQueued}
+		original, err := repo.CreateDeduplicated(context.Background(), notification, time.Hour)
+		require.NoError(t, err)
+		assert.Nil(t, original)
+
+		assert.Empty(t, built.find("SELECT"), "notifications without a key are neither locked nor looked up")
+		assert.NotEmpty(t, built.find(`INSERT INTO "notifications"`))
+	})
+
+	t.Run("with a dedupe key", func(t *testing.T) {
+		db, built := dryRun(t)
+		repo := NewNotificationRepository(db)
+
+		notification := &model.Notification{ID: uuid.New(), ApplicationID: applicationID, DedupeKey: "order-42", Status: model.NotificationStatusQueued}
+		_, err := repo.CreateDeduplicated(context.Background(), notification, time.Hour)
+		require.NoError(t, err)
+
+		// The lock is taken before the lookup, so a concurrent request with the same key sees this notification
+		var order []string
+		for _, statement := range *built {
+			if strings.HasPrefix(statement, "SELECT") || strings.HasPrefix(statement, `INSERT INTO "notifications"`) {
+				order = append(order, statement)
+			}
+		}
+		require.Len(t, order, 3)
+		assert.Equal(t, "SELECT pg_advisory_xact_lock(hashtext('7c9e6679-7425-40de-944b-e07fc1f90ae7:order-42'))", order[0])
+
+		lookup := order[1]
+		assert.True(t, strings.HasPrefix(lookup, `SELECT * FROM "notifications"`), lookup)
+		assert.Contains(t, lookup, "application_id = '7c9e6679-7425-40de-944b-e07fc1f90ae7' AND dedupe_key = 'order-42' AND created_at > '")
+		assert.Contains(t, lookup, "status <> 'deduplicated'", "duplicates are matched against the original notification")
+		assert.Contains(t, lookup, "ORDER BY created_at DESC")
+
+		_, rest, _ := strings.Cut(lookup, "created_at > '")
+		value, _, _ := strings.Cut(rest, "'")
+		since, err := time.ParseInLocation("2006-01-02 15:04:05.999999999", value, time.Local)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(-time.Hour), since, time.Minute)
+
+		assert.True(t, strings.HasPrefix(order[2], `INSERT INTO "notifications"`))
+	})
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"hermes-api/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProviderCredentialRepository defines the interface for the provider credentials of applications
+type ProviderCredentialRepository interface {
+
+	// Query operations
+	GetByID(ctx context.Context, id uuid.UUID) (*model.ProviderCredential, error)
+	GetForChannel(ctx context.Context, applicationID uuid.UUID, channel model.Channel) (*model.ProviderCredential, error)
+	ListByApplication(ctx context.Context, applicationID uuid.UUID) ([]*model.ProviderCredential, error)
+
+	// Write operations
+	Upsert(ctx context.Context, credential *model.ProviderCredential) error
+	DeleteForChannel(ctx context.Context, applicationID uuid.UUID, channel model.Channel) (bool, error)
+
+	// Key rotation
+	ListSealedWithOtherKey(ctx context.Context, keyID string, after uuid.UUID, limit int) ([]*model.ProviderCredential, error)
+	UpdateKey(ctx context.Context, credential *model.ProviderCredential, previousKeyID string) (bool, error)
+}
+
+// providerCredentialRepository implements ProviderCredentialRepository
+type providerCredentialRepository struct {
+	db *gorm.DB
+}
+
+// NewProviderCredentialRepository creates a new provider credential repository
+func NewProviderCredentialRepository(db *gorm.DB) ProviderCredentialRepository {
+	return &providerCredentialRepository{
+		db: db,
+	}
+}
+
+// GetByID retrieves a provider credential by its ID
+func (r *providerCredentialRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.ProviderCredential, error) {
+	var credential model.ProviderCredential
+	if err := r.db.WithContext(ctx).First(&credential, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// GetForChannel retrieves the provider credential of an application for a channel
+func (r *providerCredentialRepository) GetForChannel(ctx context.Context, applicationID uuid.UUID, channel model.Channel) (*model.ProviderCredential, error) {
+	var credential model.ProviderCredential
+	err := r.db.WithContext(ctx).
+		Where("application_id = ? AND channel = ?", applicationID, channel).
+		First(&credential).Error
+	if err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// ListByApplication retrieves the provider credentials of an application
+func (r *providerCredentialRepository) ListByApplication(ctx context.Context, applicationID uuid.UUID) ([]*model.ProviderCredential, error) {
+	var credentials []*model.ProviderCredential
+	err := r.db.WithContext(ctx).
+		Where("application_id = ?", applicationID).
+		Order("channel ASC").
+		Find(&credentials).Error
+	return credentials, err
+}
+
+// ErrProviderCredentialReplaced is returned when the provider credential of a channel was created with
+// another ID since it was read
+var ErrProviderCredentialReplaced = errors.New("provider credential was replaced concurrently")
+
+// Upsert creates the provider credential of an application for a channel, or replaces the existing one
+// with the same ID keeping its creation time. ErrProviderCredentialReplaced is returned when the stored
+// credential of the channel has another ID.
+func (r *providerCredentialRepository) Upsert(ctx context.Context, credential *model.ProviderCredential) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing model.ProviderCredential
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("application_id = ? AND channel = ?", credential.ApplicationID, credential.Channel).
+			First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Another credential of the channel may have been created meanwhile
+			err := tx.Create(credential).Error
+			if IsUniqueViolation(err) {
+				return ErrProviderCredentialReplaced
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		if existing.ID != credential.ID {
+			return ErrProviderCredentialReplaced
+		}
+		credential.CreatedAt = existing.CreatedAt
+		return tx.Save(credential).Error
+	})
+}
+
+// DeleteForChannel deletes the provider credential of an application for a channel, reporting whether it existed
+func (r *providerCredentialRepository) DeleteForChannel(ctx context.Context, applicationID uuid.UUID, channel model.Channel) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("application_id = ? AND channel = ?", applicationID, channel).
+		Delete(&model.ProviderCredential{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ListSealedWithOtherKey retrieves a batch of provider credentials, ordered by ID after the given one,
+// whose data key is not encrypted with keyID
+func (r *providerCredentialRepository) ListSealedWithOtherKey(ctx context.Context, keyID string, after uuid.UUID, limit int) ([]*model.ProviderCredential, error) {
+	var credentials []*model.ProviderCredential
+	err := r.db.WithContext(ctx).
+		Where("key_id <> ? AND id > ?", keyID, after).
+		Order("id ASC").
+		Limit(limit).
+		Find(&credentials).Error
+	return credentials, err
+}
+
+// UpdateKey stores the re-encrypted data key of a provider credential unless the credential was replaced
+// or rotated since it was read, reporting whether it was updated
+func (r *providerCredentialRepository) UpdateKey(ctx context.Context, credential *model.ProviderCredential, previousKeyID string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.ProviderCredential{}).
+		Where("id = ? AND key_id = ? AND updated_at = ?", credential.ID, previousKeyID, credential.UpdatedAt).
+		Updates(map[string]any{
+			"key_id":        credential.KeyID,
+			"encrypted_key": credential.EncryptedKey,
+		})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"hermes-api/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProviderStatRepository defines the interface for the send statistics of providers
+type ProviderStatRepository interface {
+	Add(ctx context.Context, stats []*model.ProviderStat) error
+	Summarize(ctx context.Context, since time.Time) ([]*model.ProviderStat, error)
+	Prune(ctx context.Context, before time.Time) error
+}
+
+// providerStatRepository implements ProviderStatRepository
+type providerStatRepository struct {
+	db *gorm.DB
+}
+
+// NewProviderStatRepository creates a new provider stat repository
+func NewProviderStatRepository(db *gorm.DB) ProviderStatRepository {
+	return &providerStatRepository{
+		db: db,
+	}
+}
+
+// Add adds the sends counted by the workers to the buckets of the stats, in a single statement
+func (r *providerStatRepository) Add(ctx context.Context, stats []*model.ProviderStat) error {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "channel"}, {Name: "provider"}, {Name: "bucket_start"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"successes":  gorm.Expr("provider_stats.successes + excluded.successes"),
+				"failures":   gorm.Expr("provider_stats.failures + excluded.failures"),
+				"latency_ms": gorm.Expr("provider_stats.latency_ms + excluded.latency_ms"),
+			}),
+		}).
+		Create(stats).Error
+}
+
+// Summarize adds up the buckets since a time into one stat per provider
+func (r *providerStatRepository) Summarize(ctx context.Context, since time.Time) ([]*model.ProviderStat, error) {
+	var stats []*model.ProviderStat
+	err := r.db.WithContext(ctx).
+		Model(&model.ProviderStat{}).
+		Select("channel, provider, SUM(successes) AS successes, SUM(failures) AS failures, SUM(latency_ms) AS latency_ms").
+		Where("bucket_start >= ?", since.UTC().Truncate(time.Minute)).
+		Group("channel, provider").
+		Find(&stats).Error
+	return stats, err
+}
+
+// Prune deletes the buckets that started before a time
+func (r *providerStatRepository) Prune(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).
+		Where("bucket_start < ?", before.UTC()).
+		Delete(&model.ProviderStat{}).Error
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"hermes-api/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SuppressionRepository defines the interface for suppression data operations
+type SuppressionRepository interface {
+
+	// Basic CRUD operations
+	BaseRepository[model.Suppression]
+
+	// Query operations
+	GetByApplication(ctx context.Context, applicationID, id uuid.UUID) (*model.Suppression, error)
+	GetByAddress(ctx context.Context, applicationID uuid.UUID, channel model.Channel, address string) (*model.Suppression, error)
+	ListByApplication(ctx context.Context, applicationID uuid.UUID, channel model.Channel, limit, offset int) ([]*model.Suppression, error)
+	ListActive(ctx context.Context, applicationID uuid.UUID, channel model.Channel, addresses []string) ([]*model.Suppression, error)
+
+	// Write operations
+	Upsert(ctx context.Context, suppression *model.Suppression) error
+}
+
+// suppressionRepository implements SuppressionRepository
+type suppressionRepository struct {
+	BaseRepository[model.Suppression]
+	db *gorm.DB
+}
+
+// NewSuppressionRepository creates a new suppression repository
+func NewSuppressionRepository(db *gorm.DB) SuppressionRepository {
+	return &suppressionRepository{
+		BaseRepository: NewBaseRepository[model.Suppression](db),
+		db:             db,
+	}
+}
+
+// GetByApplication retrieves a suppression scoped to an application
+func (r *suppressionRepository) GetByApplication(ctx context.Context, applicationID, id uuid.UUID) (*model.Suppression, error) {
+	var suppression model.Suppression
+	err := r.db.WithContext(ctx).
+		Where("application_id = ? AND id = ?", applicationID, id).
+		First(&suppression).Error
+	if err != nil {
+		return nil, err
+	}
+	return &suppression, nil
+}
+
+// GetByAddress retrieves the suppression of an address on a channel, expired or not
+func (r *suppressionRepository) GetByAddress(ctx context.Context, applicationID uuid.UUID, channel model.Channel, address string) (*model.Suppression, error) {
+	var suppression model.Suppression
+	err := r.db.WithContext(ctx).
+		Where("application_id = ? AND channel = ? AND address = ?", applicationID, channel, address).
+		First(&suppression).Error
+	if err != nil {
+		return nil, err
+	}
+	return &suppression, nil
+}
+
+// ListByApplication retrieves the suppressions of an application, optionally of a single channel
+func (r *suppressionRepository) ListByApplication(ctx context.Context, applicationID uuid.UUID, channel model.Channel, limit, offset int) ([]*model.Suppression, error) {
+	var suppressions []*model.Suppression
+	query := r.db.WithContext(ctx).Where("application_id = ?", applicationID)
+	if channel != "" {
+		query = query.Where("channel = ?", channel)
+	}
+	err := query.
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&suppressions).Error
+	return suppressions, err
+}
+
+// ListActive retrieves the unexpired suppressions among the given addresses of a channel
+func (r *suppressionRepository) ListActive(ctx context.Context, applicationID uuid.UUID, channel model.Channel, addresses []string) ([]*model.Suppression, error) {
+	var suppressions []*model.Suppression
+	err := r.db.WithContext(ctx).
+		Where("application_id = ? AND channel = ? AND address IN ?", applicationID, channel, addresses).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now().UTC()).
+		Find(&suppressions).Error
+	return suppressions, err
+}
+
+// Upsert creates the suppression of an address, or replaces the reason, source and expiry of the existing one
+func (r *suppressionRepository) Upsert(ctx context.Context, suppression *model.Suppression) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "application_id"}, {Name: "channel"}, {Name: "address"}},
+			DoUpdates: clause.AssignmentColumns([]string{"reason", "source", "description", "expires_at", "updated_at"}),
+		}).
+		Create(suppression).Error
+}
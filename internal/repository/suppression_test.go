@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hermes-api/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuppressionListActive(t *testing.T) {
+	db, built := dryRun(t)
+	repo := NewSuppressionRepository(db)
+
+	applicationID := uuid.MustParse("7c9e6679-7425-40de-944b-e07fc1f90ae7")
+	_, err := repo.ListActive(context.Background(), applicationID, model.ChannelEmail, []string{"a@example.com", "b@example.com"})
+	require.NoError(t, err)
+
+	query := built.last()
+	assert.Contains(t, query, `WHERE (application_id = '7c9e6679-7425-40de-944b-e07fc1f90ae7' AND channel = 'email' AND address IN ('a@example.com','b@example.com'))`)
+	assert.Contains(t, query, "AND (expires_at IS NULL OR expires_at > ", "suppressions without an expiry must not match other applications")
+}
+
+func TestSuppressionUpsert(t *testing.T) {
+	db, built := dryRun(t)
+	repo := NewSuppressionRepository(db)
+
+	expiresAt := time.Now().Add(time.Hour)
+	require.NoError(t, repo.Upsert(context.Background(), &model.Suppression{
+		ApplicationID: uuid.New(),
+		Channel:       model.ChannelSMS,
+		Address:       "+15551234567",
+		Reason:        model.SuppressionReasonBounce,
+		ExpiresAt:     &expiresAt,
+	}))
+
+	query := built.last()
+	assert.Contains(t, query, `ON CONFLICT ("application_id","channel","address") DO UPDATE SET`)
+	assert.Contains(t, query, `"expires_at"="excluded"."expires_at"`, "a permanent suppression replaces an expiring one")
+	assert.NotContains(t, query, `"created_at"="excluded"."created_at"`)
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"hermes-api/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TemplateRepository defines the interface for template data operations
+type TemplateRepository interface {
+
+	// Basic CRUD operations
+	BaseRepository[model.Template]
+
+	// Query operations
+	GetByApplication(ctx context.Context, applicationID, id uuid.UUID) (*model.Template, error)
+	GetByName(ctx context.Context, applicationID uuid.UUID, name string) (*model.Template, error)
+	ListByApplication(ctx context.Context, applicationID uuid.UUID, limit, offset int) ([]*model.Template, error)
+	ListCategories(ctx context.Context, applicationID uuid.UUID) ([]TemplateCategory, error)
+	GetNames(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]string, error)
+}
+
+// TemplateCategory is a category used by the templates of an application on a channel
+type TemplateCategory struct {
+	Category string
+	Channel  model.Channel
+}
+
+// templateRepository implements TemplateRepository
+type templateRepository struct {
+	BaseRepository[model.Template]
+	db *gorm.DB
+}
+
+// NewTemplateRepository creates a new template repository
+func NewTemplateRepository(db *gorm.DB) TemplateRepository {
+	return &templateRepository{
+		BaseRepository: NewBaseRepository[model.Template](db),
+		db:             db,
+	}
+}
+
+// GetByApplication retrieves a template with its variants, scoped to an application
+func (r *templateRepository) GetByApplication(ctx context.Context, applicationID, id uuid.UUID) (*model.Template, error) {
+	var template model.Template
+	err := r.db.WithContext(ctx).
+		Preload("Variants").
+		Where("application_id = ? AND id = ?", applicationID, id).
+		First(&template).Error
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// GetByName retrieves a template with its variants by its name within an application
+func (r *templateRepository) GetByName(ctx context.Context, applicationID uuid.UUID, name string) (*model.Template, error) {
+	var template model.Template
+	err := r.db.WithContext(ctx).
+		Preload("Variants").
+		Where("application_id = ? AND name = ?", applicationID, name).
+		First(&template).Error
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// ListByApplication retrieves the templates of an application with pagination
+func (r *templateRepository) ListByApplication(ctx context.Context, applicationID uuid.UUID, limit, offset int) ([]*model.Template, error) {
+	var templates []*model.Template
+	err := r.db.WithContext(ctx).
+		Preload("Variants").
+		Where("application_id = ?", applicationID).
+		Order("name ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&templates).Error
+	return templates, err
+}
+
+// ListCategories retrieves the distinct categories and channels of the templates of an application
+func (r *templateRepository) ListCategories(ctx context.Context, applicationID uuid.UUID) ([]TemplateCategory, error) {
+	var categories []TemplateCategory
+	err := r.db.WithContext(ctx).
+		Model(&model.Template{}).
+		Distinct("category", "channel").
+		Where("application_id = ? AND category <> ''", applicationID).
+		Order("category ASC, channel ASC").
+		Scan(&categories).Error
+	return categories, err
+}
+
+// GetNames retrieves the names of templates by ID, including deleted templates
+func (r *templateRepository) GetNames(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	var templates []*model.Template
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Select("id", "name").
+		Where("id IN ?", ids).
+		Find(&templates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[uuid.UUID]string, len(templates))
+	for _, template := range templates {
+		names[template.ID] = template.Name
+	}
+	return names, nil
+}
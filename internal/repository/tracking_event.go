@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"hermes-api/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TrackingEventRepository defines the interface for tracking event data operations
+type TrackingEventRepository interface {
+
+	// Query operations
+	ListByNotification(ctx context.Context, notificationID uuid.UUID) ([]*model.TrackingEvent, error)
+
+	// Write operations
+	Record(ctx context.Context, event *model.TrackingEvent, window time.Duration) (bool, error)
+}
+
+// trackingEventRepository implements TrackingEventRepository
+type trackingEventRepository struct {
+	db *gorm.DB
+}
+
+// NewTrackingEventRepository creates a new tracking event repository
+func NewTrackingEventRepository(db *gorm.DB) TrackingEventRepository {
+	return &trackingEventRepository{db: db}
+}
+
+// ListByNotification retrieves the tracking events of a notification, oldest first
+func (r *trackingEventRepository) ListByNotification(ctx context.Context, notificationID uuid.UUID) ([]*model.TrackingEvent, error) {
+	var events []*model.TrackingEvent
+	err := r.db.WithContext(ctx).
+		Where("notification_id = ?", notificationID).
+		Order("created_at ASC").
+		Find(&events).Error
+	return events, err
+}
+
+// Record creates a tracking event unless the same event, from a machine or from the recipient alike, was
+// recorded within window, and reports whether it was recorded. The first event of the recipient sets the
+// opened or clicked time of the notification. Concurrent events are serialized by an advisory lock.
+func (r *trackingEventRepository) Record(ctx context.Context, event *model.TrackingEvent, window time.Duration) (bool, error) {
+	recorded := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		lockKey := event.NotificationID.String() + ":" + string(event.Type) + ":" + event.URL
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", lockKey).Error; err != nil {
+			return err
+		}
+
+		var existing model.TrackingEvent
+		err := tx.Where("notification_id = ? AND type = ? AND url = ? AND machine = ? AND created_at > ?",
+			event.NotificationID, event.Type, event.URL, event.Machine, time.Now().Add(-window)).
+			First(&existing).Error
+		switch {
+		case err == nil:
+			return nil
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+		recorded = true
+		if event.Machine {
+			return nil
+		}
+
+		column := "opened_at"
+		if event.Type == model.TrackingEventClick {
+			column = "clicked_at"
+		}
+		return tx.Model(&model.Notification{}).
+			Where("id = ? AND "+column+" IS NULL", event.NotificationID).
+			Update(column, event.CreatedAt).Error
+	})
+	return recorded, err
+}
@@ -0,0 +1,387 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"hermes-api/config"
+	"hermes-api/internal/dto"
+	"hermes-api/internal/model"
+	"hermes-api/internal/repository"
+	"hermes-api/pkg/errorx"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultAnalyticsRange is the range of analytics queries without a start
+	defaultAnalyticsRange = 7 * 24 * time.Hour
+	// hourlyAnalyticsRange is the longest range served with hourly buckets by default
+	hourlyAnalyticsRange = 48 * time.Hour
+	// maxAnalyticsBuckets bounds the length of the time series of a query
+	maxAnalyticsBuckets = 1000
+)
+
+// LatencyStats summarizes a latency histogram, percentiles are in milliseconds
+type LatencyStats struct {
+	Count int64   `json:"count"`
+	P50   float64 `json:"p50_ms"`
+	P90   float64 `json:"p90_ms"`
+	P95   float64 `json:"p95_ms"`
+	P99   float64 `json:"p99_ms"`
+}
+
+// AnalyticsCounts are the delivery counts and latencies of a bucket, a group or a whole range
+type AnalyticsCounts struct {
+	Sent            int64        `json:"sent"`
+	Delivered       int64        `json:"delivered"`
+	Failed          int64        `json:"failed"`
+	Bounced         int64        `json:"bounced"`
+	Skipped         int64        `json:"skipped"`
+	Opened          int64        `json:"opened"`
+	Clicked         int64        `json:"clicked"`
+	SendLatency     LatencyStats `json:"send_latency"`     // From the creation to the hand-over to the provider
+	DeliveryLatency LatencyStats `json:"delivery_latency"` // From the creation to the delivery confirmation
+}
+
+// AnalyticsPoint is a bucket of the time series
+type AnalyticsPoint struct {
+	BucketStart time.Time `json:"bucket_start"`
+	AnalyticsCounts
+}
+
+// AnalyticsGroup is a row of the breakdown by application, channel or template
+type AnalyticsGroup struct {
+	Key  string `json:"key"`            // ID of the application or template, or the channel
+	Name string `json:"name,omitempty"` // Name of the application or template
+	AnalyticsCounts
+}
+
+// Analytics are the delivery analytics of a range
+type Analytics struct {
+	From        time.Time               `json:"from"` // Start of the first bucket
+	To          time.Time               `json:"to"`
+	Granularity model.RollupGranularity `json:"granularity"`
+	GroupBy     string                  `json:"group_by,omitempty"`
+	Filters     map[string]any          `json:"filters,omitempty"`
+	Totals      AnalyticsCounts         `json:"totals"`
+	Series      []AnalyticsPoint        `json:"series"`
+	Breakdown   []AnalyticsGroup        `json:"breakdown,omitempty"`
+}
+
+// AnalyticsService defines the interface for delivery analytics
+type AnalyticsService interface {
+	Refresh(ctx context.Context) error
+	GetAnalytics(ctx context.Context, userID uuid.UUID, query dto.AnalyticsQuery) (*Analytics, error)
+}
+
+// analyticsService implements AnalyticsService
+type analyticsService struct {
+	rollupRepo      repository.DeliveryRollupRepository
+	templateRepo    repository.TemplateRepository
+	applicationRepo repository.ApplicationRepository
+	config          config.AnalyticsConfig
+
+	mu             sync.Mutex
+	refreshedUntil time.Time // Start of the last hour refreshed by this process
+}
+
+// NewAnalyticsService creates a new analytics service
+func NewAnalyticsService(rollupRepo repository.DeliveryRollupRepository, templateRepo repository.TemplateRepository, applicationRepo repository.ApplicationRepository, cfg config.AnalyticsConfig) AnalyticsService {
+	return &analyticsService{
+		rollupRepo:      rollupRepo,
+		templateRepo:    templateRepo,
+		applicationRepo: applicationRepo,
+		config:          cfg,
+	}
+}
+
+// Refresh recomputes the hourly rollups from the notifications, from the backfill window on the first
+// call and from the hour before the last one refreshed afterwards, then the daily rollups of the days
+// those hours belong to. Rollups are recomputed rather than incremented, so refreshing twice, or from
+// several workers, is harmless.
+func (s *analyticsService) Refresh(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	current := now.Truncate(time.Hour)
+	start := now.Add(-s.config.Backfill).Truncate(time.Hour)
+	if !s.refreshedUntil.IsZero() && s.refreshedUntil.Add(-time.Hour).After(start) {
+		// The previous hour may still receive changes committed after the last refresh
+		start = s.refreshedUntil.Add(-time.Hour)
+	}
+
+	days := make(map[time.Time]bool)
+	for hour := start; !hour.After(current); hour = hour.Add(time.Hour) {
+		rollups, err := s.rollupRepo.Aggregate(ctx, hour, hour.Add(time.Hour))
+		if err != nil {
+			return fmt.Errorf("failed to aggregate rollups of %s: %w", hour.Format(time.RFC3339), err)
+		}
+		if err := s.rollupRepo.Replace(ctx, model.RollupGranularityHour, hour, rollups); err != nil {
+			return fmt.Errorf("failed to save rollups of %s: %w", hour.Format(time.RFC3339), err)
+		}
+		days[hour.Truncate(24*time.Hour)] = true
+	}
+
+	for day := range days {
+		if err := s.refreshDay(ctx, day); err != nil {
+			return err
+		}
+	}
+	s.refreshedUntil = current
+
+	if s.config.HourlyRetention > 0 {
+		if err := s.rollupRepo.Prune(ctx, model.RollupGranularityHour, now.Add(-s.config.HourlyRetention)); err != nil {
+			return fmt.Errorf("failed to prune hourly rollups: %w", err)
+		}
+	}
+	return nil
+}
+
+// refreshDay adds up the hourly rollups of a day into its daily rollups
+func (s *analyticsService) refreshDay(ctx context.Context, day time.Time) error {
+	hourly, err := s.rollupRepo.List(ctx, model.RollupGranularityHour, day, day.Add(24*time.Hour))
+	if err != nil {
+		return fmt.Errorf("failed to list rollups of %s: %w", day.Format(time.DateOnly), err)
+	}
+
+	type key struct {
+		applicationID uuid.UUID
+		channel       model.Channel
+		templateID    uuid.UUID
+	}
+	daily := make(map[key]*model.DeliveryRollup)
+	for _, rollup := range hourly {
+		k := key{rollup.ApplicationID, rollup.Channel, rollup.TemplateID}
+		if _, ok := daily[k]; !ok {
+			daily[k] = &model.DeliveryRollup{
+				Granularity:   model.RollupGranularityDay,
+				BucketStart:   day,
+				ApplicationID: rollup.ApplicationID,
+				Channel:       rollup.Channel,
+				TemplateID:    rollup.TemplateID,
+			}
+		}
+		daily[k].Add(rollup)
+	}
+
+	rollups := make([]*model.DeliveryRollup, 0, len(daily))
+	for _, rollup := range daily {
+		rollups = append(rollups, rollup)
+	}
+	if err := s.rollupRepo.Replace(ctx, model.RollupGranularityDay, day, rollups); err != nil {
+		return fmt.Errorf("failed to save rollups of %s: %w", day.Format(time.DateOnly), err)
+	}
+	return nil
+}
+
+// GetAnalytics returns the time series, totals and optional breakdown of the delivery rollups of the
+// user's applications matching the query. Buckets are aligned on UTC hours or days, the range starts
+// with the bucket containing its start.
+func (s *analyticsService) GetAnalytics(ctx context.Context, userID uuid.UUID, query dto.AnalyticsQuery) (*Analytics, error) {
+	to := time.Now().UTC()
+	if query.To != "" {
+		parsed, err := time.Parse(time.RFC3339, query.To)
+		if err != nil {
+			return nil, errorx.NewValidationError("to", query.To)
+		}
+		to = parsed.UTC()
+	}
+	from := to.Add(-defaultAnalyticsRange)
+	if query.From != "" {
+		parsed, err := time.Parse(time.RFC3339, query.From)
+		if err != nil {
+			return nil, errorx.NewValidationError("from", query.From)
+		}
+		from = parsed.UTC()
+	}
+	if !from.Before(to) {
+		return nil, errorx.NewValidationError("from", query.From)
+	}
+
+	granularity := model.RollupGranularity(query.Granularity)
+	if granularity == "" {
+		granularity = model.RollupGranularityDay
+		if to.Sub(from) <= hourlyAnalyticsRange {
+			granularity = model.RollupGranularityHour
+		}
+	}
+	from = from.Truncate(granularity.Duration())
+	if to.Sub(from) > maxAnalyticsBuckets*granularity.Duration() {
+		appErr := errorx.New(
+			errorx.ErrorTypeValidation,
+			errorx.ErrorCodeInvalidValue,
+			fmt.Sprintf("The range spans more than %d %s buckets", maxAnalyticsBuckets, granularity),
+		)
+		return nil, appErr
+	}
+
+	analytics := &Analytics{
+		From:        from,
+		To:          to,
+		Granularity: granularity,
+		GroupBy:     query.GroupBy,
+		Filters:     make(map[string]any),
+	}
+	filter := repository.RollupFilter{
+		UserID:      userID,
+		Channel:     model.Channel(query.Channel),
+		Template:    query.Template,
+		Granularity: granularity,
+		From:        from,
+		To:          to,
+	}
+	if query.ApplicationID != "" {
+		applicationID, err := uuid.Parse(query.ApplicationID)
+		if err != nil {
+			return nil, errorx.NewValidationError("application_id", query.ApplicationID)
+		}
+		filter.ApplicationID = &applicationID
+		analytics.Filters["application_id"] = query.ApplicationID
+	}
+	if query.Channel != "" {
+		analytics.Filters["channel"] = query.Channel
+	}
+	if query.Template != "" {
+		analytics.Filters["template"] = query.Template
+	}
+
+	rollups, err := s.rollupRepo.Search(ctx, filter)
+	if err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch analytics",
+		)
+		return nil, appErr
+	}
+
+	var totals model.DeliveryRollup
+	buckets := make(map[time.Time]*model.DeliveryRollup)
+	groups := make(map[string]*model.DeliveryRollup)
+	for _, rollup := range rollups {
+		totals.Add(rollup)
+
+		bucketStart := rollup.BucketStart.UTC()
+		if _, ok := buckets[bucketStart]; !ok {
+			buckets[bucketStart] = &model.DeliveryRollup{}
+		}
+		buckets[bucketStart].Add(rollup)
+
+		if key := groupKey(rollup, query.GroupBy); key != "" {
+			if _, ok := groups[key]; !ok {
+				groups[key] = &model.DeliveryRollup{ApplicationID: rollup.ApplicationID, TemplateID: rollup.TemplateID}
+			}
+			groups[key].Add(rollup)
+		}
+	}
+
+	analytics.Totals = summarizeRollup(&totals)
+	for bucketStart := from; bucketStart.Before(to); bucketStart = bucketStart.Add(granularity.Duration()) {
+		point := AnalyticsPoint{BucketStart: bucketStart}
+		if rollup, ok := buckets[bucketStart]; ok {
+			point.AnalyticsCounts = summarizeRollup(rollup)
+		}
+		analytics.Series = append(analytics.Series, point)
+	}
+
+	if query.GroupBy != "" {
+		analytics.Breakdown, err = s.breakdown(ctx, groups, query.GroupBy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return analytics, nil
+}
+
+// breakdown turns the groups of rollups into breakdown rows named after their application or template,
+// the groups with the most sends first
+func (s *analyticsService) breakdown(ctx context.Context, groups map[string]*model.DeliveryRollup, groupBy string) ([]AnalyticsGroup, error) {
+	ids := make([]uuid.UUID, 0, len(groups))
+	for _, group := range groups {
+		switch groupBy {
+		case "application":
+			ids = append(ids, group.ApplicationID)
+		case "template":
+			ids = append(ids, group.TemplateID)
+		}
+	}
+
+	var names map[uuid.UUID]string
+	var err error
+	switch {
+	case len(ids) == 0:
+	case groupBy == "application":
+		names, err = s.applicationRepo.GetNames(ctx, ids)
+	case groupBy == "template":
+		names, err = s.templateRepo.GetNames(ctx, ids)
+	}
+	if err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch analytics",
+		)
+		return nil, appErr
+	}
+
+	breakdown := make([]AnalyticsGroup, 0, len(groups))
+	for key, group := range groups {
+		row := AnalyticsGroup{Key: key, AnalyticsCounts: summarizeRollup(group)}
+		if id, err := uuid.Parse(key); err == nil {
+			row.Name = names[id]
+		}
+		breakdown = append(breakdown, row)
+	}
+	sort.Slice(breakdown, func(i, j int) bool {
+		if breakdown[i].Sent != breakdown[j].Sent {
+			return breakdown[i].Sent > breakdown[j].Sent
+		}
+		return breakdown[i].Key < breakdown[j].Key
+	})
+	return breakdown, nil
+}
+
+// groupKey returns the key of the breakdown group of a rollup, empty without breakdown
+func groupKey(rollup *model.DeliveryRollup, groupBy string) string {
+	switch groupBy {
+	case "application":
+		return rollup.ApplicationID.String()
+	case "channel":
+		return string(rollup.Channel)
+	case "template":
+		return rollup.TemplateID.String()
+	}
+	return ""
+}
+
+// summarizeRollup returns the counts of a rollup with the percentiles of its latency histograms
+func summarizeRollup(rollup *model.DeliveryRollup) AnalyticsCounts {
+	return AnalyticsCounts{
+		Sent:            rollup.Sent,
+		Delivered:       rollup.Delivered,
+		Failed:          rollup.Failed,
+		Bounced:         rollup.Bounced,
+		Skipped:         rollup.Skipped,
+		Opened:          rollup.Opened,
+		Clicked:         rollup.Clicked,
+		SendLatency:     summarizeLatency(rollup.SendLatency),
+		DeliveryLatency: summarizeLatency(rollup.DeliveryLatency),
+	}
+}
+
+// summarizeLatency returns the count and percentiles of a latency histogram
+func summarizeLatency(histogram model.LatencyHistogram) LatencyStats {
+	return LatencyStats{
+		Count: histogram.Count(),
+		P50:   histogram.Percentile(0.50),
+		P90:   histogram.Percentile(0.90),
+		P95:   histogram.Percentile(0.95),
+		P99:   histogram.Percentile(0.99),
+	}
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hermes-api/config"
+	"hermes-api/internal/dto"
+	"hermes-api/internal/model"
+	"hermes-api/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRollupRepo keeps rollups in memory, the hourly rollups it aggregates count one send per hour
+type memoryRollupRepo struct {
+	repository.DeliveryRollupRepository
+	rollups    map[model.RollupGranularity]map[time.Time][]*model.DeliveryRollup
+	aggregated []time.Time // Start of the hours aggregated
+	searched   repository.RollupFilter
+	found      []*model.DeliveryRollup
+}
+
+func newMemoryRollupRepo() *memoryRollupRepo {
+	return &memoryRollupRepo{rollups: map[model.RollupGranularity]map[time.Time][]*model.DeliveryRollup{
+		model.RollupGranularityHour: {},
+		model.RollupGranularityDay:  {},
+	}}
+}
+
+func (r *memoryRollupRepo) Aggregate(ctx context.Context, start, end time.Time) ([]*model.DeliveryRollup, error) {
+	r.aggregated = append(r.aggregated, start)
+	return []*model.DeliveryRollup{{Granularity: model.RollupGranularityHour, BucketStart: start, Channel: model.ChannelEmail, Sent: 1}}, nil
+}
+
+func (r *memoryRollupRepo) Replace(ctx context.Context, granularity model.RollupGranularity, bucketStart time.Time, rollups []*model.DeliveryRollup) error {
+	r.rollups[granularity][bucketStart] = rollups
+	return nil
+}
+
+func (r *memoryRollupRepo) List(ctx context.Context, granularity model.RollupGranularity, from, to time.Time) ([]*model.DeliveryRollup, error) {
+	var rollups []*model.DeliveryRollup
+	for bucketStart, bucket := range r.rollups[granularity] {
+		if !bucketStart.Before(from) && bucketStart.Before(to) {
+			rollups = append(rollups, bucket...)
+		}
+	}
+	return rollups, nil
+}
+
+func (r *memoryRollupRepo) Search(ctx context.Context, filter repository.RollupFilter) ([]*model.DeliveryRollup, error) {
+	r.searched = filter
+	return r.found, nil
+}
+
+func (r *memoryRollupRepo) Prune(ctx context.Context, granularity model.RollupGranularity, before time.Time) error {
+	return nil
+}
+
+func TestAnalyticsRefreshDays(t *testing.T) {
+	// Two days of backfill always cross at least two UTC midnights
+	repo := newMemoryRollupRepo()
+	service := NewAnalyticsService(repo, nil, nil, config.AnalyticsConfig{Backfill: 48 * time.Hour})
+	require.NoError(t, service.Refresh(context.Background()))
+
+	// Every hour is counted by the day it starts in, whatever side of midnight
+	hoursByDay := make(map[time.Time]int64)
+	for _, hour := range repo.aggregated {
+		assert.Equal(t, time.UTC, hour.Location())
+		assert.Zero(t, hour.Minute())
+		hoursByDay[time.Date(hour.Year(), hour.Month(), hour.Day(), 0, 0, 0, 0, time.UTC)]++
+	}
+	require.GreaterOrEqual(t, len(hoursByDay), 3)
+	require.Len(t, repo.rollups[model.RollupGranularityDay], len(hoursByDay))
+	for day, hours := range hoursByDay {
+		daily := repo.rollups[model.RollupGranularityDay][day]
+		require.Len(t, daily, 1, "daily rollups of %s", day)
+		assert.Equal(t, day, daily[0].BucketStart)
+		assert.Equal(t, model.RollupGranularityDay, daily[0].Granularity)
+		assert.Equal(t, hours, daily[0].Sent, "sends of %s", day.Format(time.DateOnly))
+	}
+
+	// The next refresh starts again from the previous hour and only recomputes its days
+	last := repo.aggregated[len(repo.aggregated)-1]
+	repo.aggregated = nil
+	require.NoError(t, service.Refresh(context.Background()))
+	require.NotEmpty(t, repo.aggregated)
+	assert.Equal(t, last.Add(-time.Hour), repo.aggregated[0])
+}
+
+func TestGetAnalyticsBuckets(t *testing.T) {
+	day := func(month time.Month, d int) time.Time { return time.Date(2024, month, d, 0, 0, 0, 0, time.UTC) }
+	hour := func(d, h int) time.Time { return time.Date(2024, time.March, d, h, 0, 0, 0, time.UTC) }
+	paris := time.FixedZone("CET", 3600)
+
+	tests := []struct {
+		name        string
+		query       dto.AnalyticsQuery
+		found       []*model.DeliveryRollup
+		wantFrom    time.Time
+		wantBuckets []time.Time
+		wantSent    []int64
+	}{
+		{
+			name:        "hours across midnight",
+			query:       dto.AnalyticsQuery{From: "2024-03-01T22:30:00Z", To: "2024-03-02T02:00:00Z"},
+			found:       []*model.DeliveryRollup{{BucketStart: hour(1, 23), Sent: 2}, {BucketStart: hour(2, 0), Sent: 3}, {BucketStart: hour(2, 0), Sent: 1}},
+			wantFrom:    hour(1, 22),
+			wantBuckets: []time.Time{hour(1, 22), hour(1, 23), hour(2, 0), hour(2, 1)},
+			wantSent:    []int64{0, 2, 4, 0},
+		},
+		{
+			name:        "range given in another offset",
+			query:       dto.AnalyticsQuery{From: "2024-03-02T00:15:00+01:00", To: "2024-03-02T02:00:00+01:00"},
+			found:       []*model.DeliveryRollup{{BucketStart: hour(1, 23).In(paris), Sent: 5}},
+			wantFrom:    hour(1, 23),
+			wantBuckets: []time.Time{hour(1, 23), hour(2, 0)},
+			wantSent:    []int64{5, 0},
+		},
+		{
+			name:        "days are UTC days",
+			query:       dto.AnalyticsQuery{From: "2024-02-28T20:00:00-05:00", To: "2024-03-03T00:30:00+01:00"},
+			found:       []*model.DeliveryRollup{{BucketStart: day(time.February, 29).In(paris), Sent: 1}, {BucketStart: day(time.March, 2), Sent: 7}},
+			wantFrom:    day(time.February, 29),
+			wantBuckets: []time.Time{day(time.February, 29), day(time.March, 1), day(time.March, 2)},
+			wantSent:    []int64{1, 0, 7},
+		},
+		{
+			name:        "day granularity requested for a short range",
+			query:       dto.AnalyticsQuery{From: "2024-03-01T23:00:00Z", To: "2024-03-02T01:00:00Z", Granularity: "day"},
+			found:       []*model.DeliveryRollup{{BucketStart: day(time.March, 1), Sent: 4}, {BucketStart: day(time.March, 2), Sent: 6}},
+			wantFrom:    day(time.March, 1),
+			wantBuckets: []time.Time{day(time.March, 1), day(time.March, 2)},
+			wantSent:    []int64{4, 6},
+		},
+		{
+			name:        "range ending at midnight",
+			query:       dto.AnalyticsQuery{From: "2024-03-01T22:00:00Z", To: "2024-03-02T00:00:00Z"},
+			wantFrom:    hour(1, 22),
+			wantBuckets: []time.Time{hour(1, 22), hour(1, 23)},
+			wantSent:    []int64{0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryRollupRepo()
+			repo.found = tt.found
+			service := NewAnalyticsService(repo, nil, nil, config.AnalyticsConfig{})
+
+			analytics, err := service.GetAnalytics(context.Background(), uuid.New(), tt.query)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantFrom, analytics.From)
+			assert.Equal(t, tt.wantFrom, repo.searched.From, "rollups are searched from the start of the first bucket")
+			assert.Equal(t, analytics.To, repo.searched.To)
+			assert.Equal(t, time.UTC, analytics.To.Location())
+
+			buckets := make([]time.Time, len(analytics.Series))
+			sent := make([]int64, len(analytics.Series))
+			for i, point := range analytics.Series {
+				buckets[i] = point.BucketStart
+				sent[i] = point.Sent
+			}
+			assert.Equal(t, tt.wantBuckets, buckets)
+			assert.Equal(t, tt.wantSent, sent)
+
+			var total int64
+			for _, rollup := range tt.found {
+				total += rollup.Sent
+			}
+			assert.Equal(t, total, analytics.Totals.Sent)
+		})
+	}
+
+	t.Run("invalid ranges", func(t *testing.T) {
+		service := NewAnalyticsService(newMemoryRollupRepo(), nil, nil, config.AnalyticsConfig{})
+		for _, query := range []dto.AnalyticsQuery{
+			{From: "2024-03-02T00:00:00Z", To: "2024-03-02T00:00:00Z"},
+			{From: "2024-03-02T00:00:00Z", To: "2024-03-01T00:00:00Z"},
+			{From: "2024-03-02", To: "2024-03-03T00:00:00Z"},
+			{From: "2020-01-01T00:00:00Z", To: "2024-03-01T00:00:00Z", Granularity: "hour"},
+		} {
+			_, err := service.GetAnalytics(context.Background(), uuid.New(), query)
+			assert.Error(t, err, "%+v", query)
+		}
+	})
+}
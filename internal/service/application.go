@@ -2,19 +2,25 @@ package service
 
 import (
 	"context"
+	"errors"
 	"hermes-api/internal/dto"
 	"hermes-api/internal/model"
 	"hermes-api/internal/repository"
 	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/i18n"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // ApplicationService defines the interface for application business logic
 type ApplicationService interface {
 	CreateApplication(ctx context.Context, userID uuid.UUID, req dto.CreateApplicationRequest) (*model.Application, error)
 	GetApplicationByID(ctx context.Context, id uuid.UUID) (*model.Application, error)
+	GetUserApplication(ctx context.Context, userID, id uuid.UUID) (*model.Application, error)
 	UpdateApplication(ctx context.Context, application *model.Application) error
+	SetQuietHours(ctx context.Context, application *model.Application, req *dto.QuietHoursRequest) (*model.Application, error)
+	UpdateSettings(ctx context.Context, application *model.Application, req dto.UpdateApplicationSettingsRequest) (*model.Application, error)
 	DeleteApplication(ctx context.Context, id uuid.UUID) error
 }
 
@@ -38,9 +44,20 @@ func (s *applicationService) CreateApplication(ctx context.Context, userID uuid.
 		Name:        req.Name,
 		Description: req.Description,
 		Status:      model.ApplicationStatusActive,
+		BaseURL:     req.BaseURL,
+
+		AttachmentMaxSize:      req.AttachmentMaxSize,
+		AttachmentAllowedTypes: req.AttachmentAllowedTypes,
+		QuietHours:             req.QuietHours.ToModel(),
+		DedupeWindowSeconds:    req.DedupeWindowSeconds,
+		AutoDedupe:             req.AutoDedupe,
 		// Note: APIKey will be auto-generated by BeforeCreate hook
 	}
 
+	if locale := i18n.Normalize(req.DefaultLocale); locale != "" {
+		application.DefaultLocale = locale
+	}
+
 	if err := s.applicationRepo.Create(ctx, application); err != nil {
 		appErr := errorx.New(
 			errorx.ErrorTypeInternal,
@@ -60,10 +77,81 @@ func (s *applicationService) DeleteApplication(ctx context.Context, id uuid.UUID
 
 // GetApplicationByID implements ApplicationService.
 func (s *applicationService) GetApplicationByID(ctx context.Context, id uuid.UUID) (*model.Application, error) {
-	panic("unimplemented")
+	application, err := s.applicationRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errorx.NewAppNotFoundError(id.String())
+		}
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch application",
+		)
+		return nil, appErr
+	}
+
+	return application, nil
+}
+
+// GetUserApplication implements ApplicationService.
+// Applications owned by another user are reported as not found so their existence is not leaked.
+func (s *applicationService) GetUserApplication(ctx context.Context, userID, id uuid.UUID) (*model.Application, error) {
+	application, err := s.GetApplicationByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if application.UserID != userID {
+		return nil, errorx.NewAppNotFoundError(id.String())
+	}
+
+	return application, nil
 }
 
 // UpdateApplication implements ApplicationService.
 func (s *applicationService) UpdateApplication(ctx context.Context, application *model.Application) error {
 	panic("unimplemented")
 }
+
+// SetQuietHours sets the default quiet hours of the application's contacts, a nil request removes them
+func (s *applicationService) SetQuietHours(ctx context.Context, application *model.Application, req *dto.QuietHoursRequest) (*model.Application, error) {
+	application.QuietHours = req.ToModel()
+
+	if err := s.applicationRepo.Update(ctx, application); err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to update application",
+		)
+		return nil, appErr
+	}
+
+	return application, nil
+}
+
+// UpdateSettings changes the settings given in the request
+func (s *applicationService) UpdateSettings(ctx context.Context, application *model.Application, req dto.UpdateApplicationSettingsRequest) (*model.Application, error) {
+	if req.DedupeWindowSeconds != nil {
+		application.DedupeWindowSeconds = *req.DedupeWindowSeconds
+	}
+	if req.AutoDedupe != nil {
+		application.AutoDedupe = *req.AutoDedupe
+	}
+	if req.TrackOpens != nil {
+		application.TrackOpens = *req.TrackOpens
+	}
+	if req.TrackClicks != nil {
+		application.TrackClicks = *req.TrackClicks
+	}
+
+	if err := s.applicationRepo.Update(ctx, application); err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to update application",
+		)
+		return nil, appErr
+	}
+
+	return application, nil
+}
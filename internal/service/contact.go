@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"hermes-api/internal/dto"
+	"hermes-api/internal/model"
+	"hermes-api/internal/repository"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/i18n"
+	"hermes-api/pkg/phone"
+
+	"gorm.io/gorm"
+)
+
+// ContactService defines the interface for contact business logic
+type ContactService interface {
+	CreateContact(ctx context.Context, application *model.Application, req dto.CreateContactRequest) (*model.Contact, error)
+	GetContact(ctx context.Context, application *model.Application, externalID string) (*model.Contact, error)
+	ListContacts(ctx context.Context, application *model.Application, limit, offset int) ([]*model.Contact, error)
+	UpdateContact(ctx context.Context, application *model.Application, externalID string, req dto.UpdateContactRequest) (*model.Contact, error)
+	DeleteContact(ctx context.Context, application *model.Application, externalID string) error
+	GetPreferences(ctx context.Context, application *model.Application, externalID string) ([]model.ContactPreference, error)
+	UpdatePreferences(ctx context.Context, application *model.Application, externalID string, req dto.UpdatePreferencesRequest) ([]model.ContactPreference, error)
+}
+
+// contactService implements ContactService
+type contactService struct {
+	contactRepo repository.ContactRepository
+}
+
+// NewContactService creates a new contact service
+func NewContactService(contactRepo repository.ContactRepository) ContactService {
+	return &contactService{
+		contactRepo: contactRepo,
+	}
+}
+
+// CreateContact creates a contact identified by the application's own user ID
+func (s *contactService) CreateContact(ctx context.Context, application *model.Application, req dto.CreateContactRequest) (*model.Contact, error) {
+	// Check if a contact with the same external ID already exists
+	existing, err := s.contactRepo.GetByExternalID(ctx, application.ID, req.ExternalID)
+	if err == nil && existing != nil {
+		return nil, errorx.NewWithTemplate(errorx.ErrorTypeConflict, errorx.ErrorCodeContactAlreadyExists, req.ExternalID)
+	}
+
+	contact := &model.Contact{
+		ApplicationID: application.ID,
+		ExternalID:    req.ExternalID,
+	}
+	if err := applyContactRequest(contact, req.UpdateContactRequest); err != nil {
+		return nil, err
+	}
+
+	if err := s.contactRepo.Create(ctx, contact); err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to create contact",
+		)
+		return nil, appErr
+	}
+
+	return contact, nil
+}
+
+// GetContact retrieves a contact of the application by its external ID
+func (s *contactService) GetContact(ctx context.Context, application *model.Application, externalID string) (*model.Contact, error) {
+	contact, err := s.contactRepo.GetByExternalID(ctx, application.ID, externalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errorx.NewWithTemplate(errorx.ErrorTypeNotFound, errorx.ErrorCodeContactNotFound, externalID)
+		}
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch contact",
+		)
+		return nil, appErr
+	}
+
+	return contact, nil
+}
+
+// ListContacts retrieves the contacts of the application with pagination
+func (s *contactService) ListContacts(ctx context.Context, application *model.Application, limit, offset int) ([]*model.Contact, error) {
+	contacts, err := s.contactRepo.ListByApplication(ctx, application.ID, limit, offset)
+	if err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch contacts",
+		)
+		return nil, appErr
+	}
+
+	return contacts, nil
+}
+
+// UpdateContact replaces the addresses, locale, timezone and attributes of a contact
+func (s *contactService) UpdateContact(ctx context.Context, application *model.Application, externalID string, req dto.UpdateContactRequest) (*model.Contact, error) {
+	contact, err := s.GetContact(ctx, application, externalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyContactRequest(contact, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.contactRepo.Update(ctx, contact); err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to update contact",
+		)
+		return nil, appErr
+	}
+
+	return contact, nil
+}
+
+// DeleteContact permanently deletes a contact, notifications sent to it are kept
+func (s *contactService) DeleteContact(ctx context.Context, application *model.Application, externalID string) error {
+	contact, err := s.GetContact(ctx, application, externalID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.contactRepo.Delete(ctx, contact.ID); err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to delete contact",
+		)
+		return appErr
+	}
+
+	return nil
+}
+
+// GetPreferences retrieves the notification preferences of a contact
+func (s *contactService) GetPreferences(ctx context.Context, application *model.Application, externalID string) ([]model.ContactPreference, error) {
+	contact, err := s.GetContact(ctx, application, externalID)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences, err := s.contactRepo.ListPreferences(ctx, contact.ID)
+	if err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch preferences",
+		)
+		return nil, appErr
+	}
+
+	return preferences, nil
+}
+
+// UpdatePreferences replaces the notification preferences of a contact
+func (s *contactService) UpdatePreferences(ctx context.Context, application *model.Application, externalID string, req dto.UpdatePreferencesRequest) ([]model.ContactPreference, error) {
+	contact, err := s.GetContact(ctx, application, externalID)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences := make([]model.ContactPreference, 0, len(req.Preferences))
+	seen := make(map[[2]string]bool)
+	for i, preferenceReq := range req.Preferences {
+		preference := model.ContactPreference{
+			Category: normalizeCategory(preferenceReq.Category),
+			Channel:  model.Channel(preferenceReq.Channel),
+			Enabled:  *preferenceReq.Enabled,
+		}
+
+		scope := [2]string{preference.Category, string(preference.Channel)}
+		if seen[scope] {
+			appErr := errorx.New(errorx.ErrorTypeValidation, errorx.ErrorCodeInvalidValue, "Duplicate preference")
+			return nil, appErr.WithDetails(map[string]any{fmt.Sprintf("preferences[%d]", i): errorx.ErrorCodeInvalidValue})
+		}
+		seen[scope] = true
+
+		preferences = append(preferences, preference)
+	}
+
+	if err := s.contactRepo.ReplacePreferences(ctx, contact.ID, preferences); err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to update preferences",
+		)
+		return nil, appErr
+	}
+
+	return preferences, nil
+}
+
+// applyContactRequest copies the request fields to the contact, normalising its phone number and locale
+func applyContactRequest(contact *model.Contact, req dto.UpdateContactRequest) error {
+	contact.Phone = ""
+	if req.Phone != "" {
+		normalized, err := phone.NormalizeE164(req.Phone)
+		if err != nil {
+			appErr := errorx.New(errorx.ErrorTypeValidation, errorx.ErrorCodeInvalidValue, "Invalid request body")
+			return appErr.WithDetails(map[string]any{"Phone": errorx.ErrorCodeInvalidFormat})
+		}
+		contact.Phone = normalized
+	}
+
+	contact.Email = req.Email
+	contact.DeviceTokens = req.DeviceTokens
+	contact.Locale = i18n.Normalize(req.Locale)
+	contact.Timezone = req.Timezone
+	contact.Attributes = req.Attributes
+	contact.QuietHours = req.QuietHours.ToModel()
+	contact.DoNotTrack = req.DoNotTrack
+	return nil
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"hermes-api/internal/dto"
+	"hermes-api/internal/model"
+)
+
+// contactColumns are the CSV columns of contact imports and exports, only external_id is required
+var contactColumns = []string{"external_id", "email", "phone", "locale", "timezone", "device_tokens", "attributes"}
+
+// deviceTokenSeparator separates the device tokens of a contact in a CSV cell
+const deviceTokenSeparator = ";"
+
+// maxNDJSONLine is the longest NDJSON line accepted by contact imports
+const maxNDJSONLine = 1024 * 1024
+
+// contactRow is a decoded row of a contact import; err is set when the row could not be decoded
+type contactRow struct {
+	line int
+	req  dto.CreateContactRequest
+	err  error
+}
+
+// contactDecoder reads the rows of a contact import, it returns io.EOF after the last row
+type contactDecoder interface {
+	Next() (*contactRow, error)
+}
+
+// contactEncoder writes the contacts of an export
+type contactEncoder interface {
+	Write(contact *model.Contact) error
+	Flush() error
+}
+
+// newContactDecoder creates a decoder for the given import format
+func newContactDecoder(format model.ContactFormat, r io.Reader) (contactDecoder, error) {
+	switch format {
+	case model.ContactFormatCSV:
+		return newCSVContactDecoder(r)
+	case model.ContactFormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), maxNDJSONLine)
+		return &ndjsonContactDecoder{scanner: scanner}, nil
+	}
+	return nil, fmt.Errorf("unsupported contact format '%s'", format)
+}
+
+// newContactEncoder creates an encoder for the given export format
+func newContactEncoder(format model.ContactFormat, w io.Writer) (contactEncoder, error) {
+	switch format {
+	case model.ContactFormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(contactColumns); err != nil {
+			return nil, err
+		}
+		return &csvContactEncoder{writer: writer}, nil
+	case model.ContactFormatNDJSON:
+		return &ndjsonContactEncoder{encoder: json.NewEncoder(w)}, nil
+	}
+	return nil, fmt.Errorf("unsupported contact format '%s'", format)
+}
+
+// csvContactDecoder decodes contacts from CSV with a header row naming the columns
+type csvContactDecoder struct {
+	reader  *csv.Reader
+	columns []string
+}
+
+func newCSVContactDecoder(r io.Reader) (*csvContactDecoder, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("the file is empty")
+		}
+		return nil, fmt.Errorf("invalid header row: %w", err)
+	}
+
+	known := make(map[string]bool, len(contactColumns))
+	for _, column := range contactColumns {
+		known[column] = true
+	}
+
+	columns := make([]string, len(header))
+	hasExternalID := false
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		if !known[column] {
+			return nil, fmt.Errorf("unknown column '%s'", column)
+		}
+		hasExternalID = hasExternalID || column == "external_id"
+		columns[i] = column
+	}
+	if !hasExternalID {
+		return nil, errors.New("missing column 'external_id'")
+	}
+
+	return &csvContactDecoder{reader: reader, columns: columns}, nil
+}
+
+func (d *csvContactDecoder) Next() (*contactRow, error) {
+	record, err := d.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, io.EOF
+	}
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return &contactRow{line: parseErr.StartLine, err: parseErr.Err}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	line, _ := d.reader.FieldPos(0)
+	row := &contactRow{line: line}
+
+	for i, value := range record {
+		switch d.columns[i] {
+		case "external_id":
+			row.req.ExternalID = value
+		case "email":
+			row.req.Email = value
+		case "phone":
+			row.req.Phone = value
+		case "locale":
+			row.req.Locale = value
+		case "timezone":
+			row.req.Timezone = value
+		case "device_tokens":
+			for _, token := range strings.Split(value, deviceTokenSeparator) {
+				if token = strings.TrimSpace(token); token != "" {
+					row.req.DeviceTokens = append(row.req.DeviceTokens, token)
+				}
+			}
+		case "attributes":
+			if value != "" {
+				if err := json.Unmarshal([]byte(value), &row.req.Attributes); err != nil {
+					row.err = errors.New("attributes must be a JSON object")
+				}
+			}
+		}
+	}
+
+	return row, nil
+}
+
+// ndjsonContactDecoder decodes contacts from newline delimited JSON objects
+type ndjsonContactDecoder struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (d *ndjsonContactDecoder) Next() (*contactRow, error) {
+	for d.scanner.Scan() {
+		d.line++
+
+		content := strings.TrimSpace(d.scanner.Text())
+		if content == "" {
+			continue
+		}
+
+		row := &contactRow{line: d.line}
+		if err := json.Unmarshal([]byte(content), &row.req); err != nil {
+			row.err = errors.New("invalid JSON object")
+		}
+		return row, nil
+	}
+
+	if err := d.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// csvContactEncoder encodes contacts as CSV with the same columns as imports
+type csvContactEncoder struct {
+	writer *csv.Writer
+}
+
+func (e *csvContactEncoder) Write(contact *model.Contact) error {
+	attributes := ""
+	if len(contact.Attributes) > 0 {
+		encoded, err := json.Marshal(contact.Attributes)
+		if err != nil {
+			return err
+		}
+		attributes = string(encoded)
+	}
+
+	return e.writer.Write([]string{
+		contact.ExternalID,
+		contact.Email,
+		contact.Phone,
+		contact.Locale,
+		contact.Timezone,
+		strings.Join(contact.DeviceTokens, deviceTokenSeparator),
+		attributes,
+	})
+}
+
+func (e *csvContactEncoder) Flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// ndjsonContactEncoder encodes contacts as newline delimited JSON objects
+type ndjsonContactEncoder struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonContactEncoder) Write(contact *model.Contact) error {
+	return e.encoder.Encode(contact)
+}
+
+func (e *ndjsonContactEncoder) Flush() error {
+	return nil
+}
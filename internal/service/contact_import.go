@@ -0,0 +1,349 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"hermes-api/internal/model"
+	"hermes-api/internal/repository"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/logger"
+	"hermes-api/pkg/storage"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// contactBatchSize is the number of contacts upserted at once by imports and read at once by exports
+const contactBatchSize = 500
+
+// importErrorColumns are the columns of the downloadable error result of an import
+var importErrorColumns = []string{"line", "external_id", "field", "error"}
+
+// ContactImportService defines the interface for bulk contact import and export
+type ContactImportService interface {
+	ImportContacts(ctx context.Context, application *model.Application, format model.ContactFormat, content io.Reader) (*model.ContactImport, error)
+	GetImport(ctx context.Context, application *model.Application, id uuid.UUID) (*model.ContactImport, error)
+	OpenImportErrors(ctx context.Context, application *model.Application, id uuid.UUID) (io.ReadCloser, error)
+	ExportContacts(ctx context.Context, application *model.Application, format model.ContactFormat, w io.Writer) error
+}
+
+// contactImportService implements ContactImportService
+type contactImportService struct {
+	contactRepo       repository.ContactRepository
+	contactImportRepo repository.ContactImportRepository
+	storage           storage.Storage
+}
+
+// NewContactImportService creates a new contact import service
+func NewContactImportService(contactRepo repository.ContactRepository, contactImportRepo repository.ContactImportRepository, fileStorage storage.Storage) ContactImportService {
+	return &contactImportService{
+		contactRepo:       contactRepo,
+		contactImportRepo: contactImportRepo,
+		storage:           fileStorage,
+	}
+}
+
+// ImportContacts stores the uploaded file and starts an import job processing it in the background
+func (s *contactImportService) ImportContacts(ctx context.Context, application *model.Application, format model.ContactFormat, content io.Reader) (*model.ContactImport, error) {
+	if !format.IsValid() {
+		return nil, errorx.NewWithTemplate(errorx.ErrorTypeValidation, errorx.ErrorCodeContactFormatInvalid, format)
+	}
+
+	job := &model.ContactImport{
+		ID:            uuid.New(),
+		ApplicationID: application.ID,
+		Format:        format,
+		Status:        model.ContactImportStatusPending,
+	}
+	job.StorageKey = importKey(job, "source")
+
+	if err := s.storage.Put(ctx, job.StorageKey, content); err != nil {
+		logger.Error("Failed to store contact import", err, zap.String("application_id", application.ID.String()))
+		return nil, errorx.NewWithTemplate(errorx.ErrorTypeInternal, errorx.ErrorCodeStorageError, "failed to store import file")
+	}
+
+	if err := s.contactImportRepo.Create(ctx, job); err != nil {
+		_ = s.storage.Delete(ctx, job.StorageKey)
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to create contact import",
+		)
+		return nil, appErr
+	}
+
+	// The job outlives the request, it must not use the request context
+	started := *job
+	go s.process(&started)
+
+	return job, nil
+}
+
+// GetImport retrieves a contact import of the application by ID
+func (s *contactImportService) GetImport(ctx context.Context, application *model.Application, id uuid.UUID) (*model.ContactImport, error) {
+	job, err := s.contactImportRepo.GetByApplication(ctx, application.ID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errorx.NewWithTemplate(errorx.ErrorTypeNotFound, errorx.ErrorCodeContactImportNotFound, id.String())
+		}
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch contact import",
+		)
+		return nil, appErr
+	}
+
+	return job, nil
+}
+
+// OpenImportErrors opens the CSV result listing the rows rejected by a finished import; the caller must close it
+func (s *contactImportService) OpenImportErrors(ctx context.Context, application *model.Application, id uuid.UUID) (io.ReadCloser, error) {
+	job, err := s.GetImport(ctx, application, id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case job.Status == model.ContactImportStatusPending || job.Status == model.ContactImportStatusProcessing:
+		return nil, errorx.NewWithTemplate(errorx.ErrorTypeConflict, errorx.ErrorCodeContactImportPending, id.String())
+	case job.ErrorsKey == "":
+		// No row was rejected, the result only has its header
+		return io.NopCloser(strings.NewReader(strings.Join(importErrorColumns, ",") + "\n")), nil
+	}
+
+	content, err := s.storage.Get(ctx, job.ErrorsKey)
+	if err != nil {
+		return nil, errorx.NewWithTemplate(errorx.ErrorTypeInternal, errorx.ErrorCodeStorageError, err.Error())
+	}
+	return content, nil
+}
+
+// ExportContacts writes all contacts of the application to w, flushing after every batch so that
+// large exports are streamed to the client
+func (s *contactImportService) ExportContacts(ctx context.Context, application *model.Application, format model.ContactFormat, w io.Writer) error {
+	encoder, err := newContactEncoder(format, w)
+	if err != nil {
+		return errorx.NewWithTemplate(errorx.ErrorTypeValidation, errorx.ErrorCodeContactFormatInvalid, format)
+	}
+
+	after := ""
+	for {
+		contacts, err := s.contactRepo.ListAfter(ctx, application.ID, after, contactBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, contact := range contacts {
+			if err := encoder.Write(contact); err != nil {
+				return err
+			}
+		}
+		if err := encoder.Flush(); err != nil {
+			return err
+		}
+		if flusher, ok := w.(interface{ Flush() error }); ok {
+			if err := flusher.Flush(); err != nil {
+				return err
+			}
+		}
+
+		if len(contacts) < contactBatchSize {
+			return nil
+		}
+		after = contacts[len(contacts)-1].ExternalID
+	}
+}
+
+// process runs an import job and records its outcome
+func (s *contactImportService) process(job *model.ContactImport) {
+	ctx := context.Background()
+	fields := []zap.Field{
+		zap.String("import_id", job.ID.String()),
+		zap.String("application_id", job.ApplicationID.String()),
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("Contact import panicked", fmt.Errorf("%v", r), fields...)
+			s.finish(ctx, job, errors.New("internal error"))
+		}
+	}()
+
+	job.Status = model.ContactImportStatusProcessing
+	if err := s.contactImportRepo.Update(ctx, job); err != nil {
+		logger.Error("Failed to start contact import", err, fields...)
+		return
+	}
+
+	err := s.run(ctx, job)
+	if err != nil {
+		logger.Error("Contact import failed", err, fields...)
+	} else {
+		logger.Info("Contact import completed", append(fields,
+			zap.Int("created", job.CreatedCount),
+			zap.Int("updated", job.UpdatedCount),
+			zap.Int("failed", job.FailedCount),
+		)...)
+	}
+	s.finish(ctx, job, err)
+}
+
+// finish marks an import as completed or failed and removes its uploaded file
+func (s *contactImportService) finish(ctx context.Context, job *model.ContactImport, err error) {
+	completedAt := time.Now().UTC()
+	job.CompletedAt = &completedAt
+	job.Status = model.ContactImportStatusCompleted
+	if err != nil {
+		job.Status = model.ContactImportStatusFailed
+		job.Error = err.Error()
+	}
+
+	if err := s.contactImportRepo.Update(ctx, job); err != nil {
+		logger.Error("Failed to save contact import", err, zap.String("import_id", job.ID.String()))
+	}
+	_ = s.storage.Delete(ctx, job.StorageKey)
+}
+
+// run validates the rows of an import and upserts the valid ones in batches
+func (s *contactImportService) run(ctx context.Context, job *model.ContactImport) error {
+	source, err := s.storage.Get(ctx, job.StorageKey)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	decoder, err := newContactDecoder(job.Format, source)
+	if err != nil {
+		return err
+	}
+
+	// A rejected row counts once, with an error line for each of its invalid fields
+	var rejected [][]string
+	reject := func(row *contactRow, reasons [][2]string) {
+		job.FailedCount++
+		for _, reason := range reasons {
+			rejected = append(rejected, []string{strconv.Itoa(row.line), row.req.ExternalID, reason[0], reason[1]})
+		}
+	}
+
+	batch := make([]*model.Contact, 0, contactBatchSize)
+	inBatch := make(map[string]bool, contactBatchSize)
+
+	for {
+		row, err := decoder.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		job.TotalRows++
+
+		if row.err != nil {
+			reject(row, [][2]string{{"", row.err.Error()}})
+			continue
+		}
+
+		contact := &model.Contact{
+			ApplicationID: job.ApplicationID,
+			ExternalID:    row.req.ExternalID,
+		}
+		err = row.req.Validate()
+		if err == nil {
+			err = applyContactRequest(contact, row.req.UpdateContactRequest)
+		}
+		if err != nil {
+			reject(row, rowErrors(err))
+			continue
+		}
+
+		// A contact appearing twice must not be upserted twice in the same statement
+		if inBatch[contact.ExternalID] || len(batch) == contactBatchSize {
+			if err := s.upsert(ctx, job, batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+			inBatch = make(map[string]bool, contactBatchSize)
+		}
+		batch = append(batch, contact)
+		inBatch[contact.ExternalID] = true
+	}
+
+	if err := s.upsert(ctx, job, batch); err != nil {
+		return err
+	}
+
+	if len(rejected) > 0 {
+		return s.storeErrors(ctx, job, rejected)
+	}
+	return nil
+}
+
+// upsert saves a batch of contacts and the progress of the import
+func (s *contactImportService) upsert(ctx context.Context, job *model.ContactImport, batch []*model.Contact) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	externalIDs := make([]string, len(batch))
+	for i, contact := range batch {
+		externalIDs[i] = contact.ExternalID
+	}
+
+	existing, err := s.contactRepo.ExistingExternalIDs(ctx, job.ApplicationID, externalIDs)
+	if err != nil {
+		return err
+	}
+	if err := s.contactRepo.Upsert(ctx, batch); err != nil {
+		return err
+	}
+
+	job.UpdatedCount += len(existing)
+	job.CreatedCount += len(batch) - len(existing)
+	return s.contactImportRepo.Update(ctx, job)
+}
+
+// storeErrors writes the rejected rows of an import as CSV to the storage
+func (s *contactImportService) storeErrors(ctx context.Context, job *model.ContactImport, rejected [][]string) error {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	_ = writer.Write(importErrorColumns)
+	_ = writer.WriteAll(rejected)
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	job.ErrorsKey = importKey(job, "errors.csv")
+	return s.storage.Put(ctx, job.ErrorsKey, &buf)
+}
+
+// rowErrors returns the (field, reason) pairs a row was rejected for, sorted by field for a stable result
+func rowErrors(err error) [][2]string {
+	var appErr *errorx.AppError
+	if !errors.As(err, &appErr) || len(appErr.Details) == 0 {
+		return [][2]string{{"", err.Error()}}
+	}
+
+	reasons := make([][2]string, 0, len(appErr.Details))
+	for field, reason := range appErr.Details {
+		reasons = append(reasons, [2]string{field, fmt.Sprint(reason)})
+	}
+	sort.Slice(reasons, func(i, j int) bool { return reasons[i][0] < reasons[j][0] })
+	return reasons
+}
+
+// importKey returns the storage key of a file belonging to an import
+func importKey(job *model.ContactImport, name string) string {
+	return job.ApplicationID.String() + "/imports/" + job.ID.String() + "/" + name
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"hermes-api/internal/model"
+	"hermes-api/internal/repository"
+	"hermes-api/pkg/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// importContactRepo keeps the external IDs of the contacts upserted, in batches
+type importContactRepo struct {
+	repository.ContactRepository
+	existing map[string]bool
+	batches  [][]string
+}
+
+func (r *importContactRepo) ExistingExternalIDs(ctx context.Context, applicationID uuid.UUID, externalIDs []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	for _, externalID := range externalIDs {
+		if r.existing[externalID] {
+			existing[externalID] = true
+		}
+	}
+	return existing, nil
+}
+
+func (r *importContactRepo) Upsert(ctx context.Context, contacts []*model.Contact) error {
+	batch := make([]string, len(contacts))
+	for i, contact := range contacts {
+		batch[i] = contact.ExternalID
+		r.existing[contact.ExternalID] = true
+	}
+	r.batches = append(r.batches, batch)
+	return nil
+}
+
+// importJobRepo accepts the progress of imports
+type importJobRepo struct {
+	repository.ContactImportRepository
+}
+
+func (r *importJobRepo) Update(ctx context.Context, job *model.ContactImport) error {
+	return nil
+}
+
+// runImport runs an import of content with existing contacts, and returns the job, the contacts
+// upserted and the rows of the error result without its header
+func runImport(t *testing.T, format model.ContactFormat, content string, existing ...string) (*model.ContactImport, *importContactRepo, [][]string, error) {
+	t.Helper()
+
+	fileStorage, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	contactRepo := &importContactRepo{existing: make(map[string]bool)}
+	for _, externalID := range existing {
+		contactRepo.existing[externalID] = true
+	}
+	service := NewContactImportService(contactRepo, &importJobRepo{}, fileStorage).(*contactImportService)
+
+	job := &model.ContactImport{ID: uuid.New(), ApplicationID: uuid.New(), Format: format}
+	job.StorageKey = importKey(job, "source")
+	require.NoError(t, fileStorage.Put(context.Background(), job.StorageKey, strings.NewReader(content)))
+
+	runErr := service.run(context.Background(), job)
+	if job.ErrorsKey == "" {
+		return job, contactRepo, nil, runErr
+	}
+
+	result, err := fileStorage.Get(context.Background(), job.ErrorsKey)
+	require.NoError(t, err)
+	defer result.Close()
+	rows, err := csv.NewReader(result).ReadAll()
+	require.NoError(t, err)
+	require.NotEmpty(t, rows)
+	assert.Equal(t, importErrorColumns, rows[0])
+	return job, contactRepo, rows[1:], runErr
+}
+
+func TestContactImportRun(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      model.ContactFormat
+		content     string
+		existing    []string
+		wantTotal   int
+		wantCreated int
+		wantUpdated int
+		wantFailed  int
+		wantErrors  [][]string // Rows of the error result: line, external ID, field, error
+		wantBatches [][]string
+	}{
+		{
+			name:        "created and updated contacts",
+			format:      model.ContactFormatCSV,
+			content:     "external_id,email,phone\nu1,ada@example.com,+44 20 7946 0958\nu2,grace@example.com,\n",
+			existing:    []string{"u2"},
+			wantTotal:   2,
+			wantCreated: 1,
+			wantUpdated: 1,
+			wantBatches: [][]string{{"u1", "u2"}},
+		},
+		{
+			name:        "header with a byte order mark and any case",
+			format:      model.ContactFormatCSV,
+			content:     "\ufeffExternal_ID, Email\nu1,ada@example.com\n",
+			wantTotal:   1,
+			wantCreated: 1,
+			wantBatches: [][]string{{"u1"}},
+		},
+		{
+			name:        "missing external ID",
+			format:      model.ContactFormatCSV,
+			content:     "external_id,email\n,ada@example.com\nu2,grace@example.com\n",
+			wantTotal:   2,
+			wantCreated: 1,
+			wantFailed:  1,
+			wantErrors:  [][]string{{"2", "", "ExternalID", "REQUIRED_FIELD"}},
+			wantBatches: [][]string{{"u2"}},
+		},
+		{
+			name:       "row with several invalid fields is rejected once",
+			format:     model.ContactFormatCSV,
+			content:    "external_id,email,locale,timezone\nu1,not-an-email,not a locale,Mars/Olympus\n",
+			wantTotal:  1,
+			wantFailed: 1,
+			wantErrors: [][]string{{"2", "u1", "Email", "INVALID_VALUE"}, {"2", "u1", "Locale", "INVALID_VALUE"}, {"2", "u1", "Timezone", "INVALID_VALUE"}},
+		},
+		{
+			name:       "invalid phone number",
+			format:     model.ContactFormatCSV,
+			content:    "external_id,phone\nu1,555-1234\n",
+			wantTotal:  1,
+			wantFailed: 1,
+			wantErrors: [][]string{{"2", "u1", "Phone", "INVALID_FORMAT"}},
+		},
+		{
+			name:       "attributes that are not a JSON object",
+			format:     model.ContactFormatCSV,
+			content:    "external_id,attributes\nu1,\"[1,2]\"\n",
+			wantTotal:  1,
+			wantFailed: 1,
+			wantErrors: [][]string{{"2", "u1", "", "attributes must be a JSON object"}},
+		},
+		{
+			name:        "row with too many fields",
+			format:      model.ContactFormatCSV,
+			content:     "external_id,email\nu1,ada@example.com,extra\nu2,grace@example.com\n",
+			wantTotal:   2,
+			wantCreated: 1,
+			wantFailed:  1,
+			wantErrors:  [][]string{{"2", "", "", "wrong number of fields"}},
+			wantBatches: [][]string{{"u2"}},
+		},
+		{
+			name:       "unterminated quote",
+			format:     model.ContactFormatCSV,
+			content:    "external_id,email\nu1,\"ada@example.com\n",
+			wantTotal:  1,
+			wantFailed: 1,
+			wantErrors: [][]string{{"2", "", "", `extraneous or missing " in quoted-field`}},
+		},
+		{
+			name:        "duplicate external IDs are upserted in turn",
+			format:      model.ContactFormatCSV,
+			content:     "external_id,email\nu1,ada@example.com\nu2,grace@example.com\nu1,ada@work.example.com\n",
+			wantTotal:   3,
+			wantCreated: 2,
+			wantUpdated: 1,
+			wantBatches: [][]string{{"u1", "u2"}, {"u1"}},
+		},
+		{
+			name:        "NDJSON",
+			format:      model.ContactFormatNDJSON,
+			content:     "{\"external_id\":\"u1\",\"email\":\"ada@example.com\"}\n\n{\"external_id\":\"u2\",\"email\":\n{\"external_id\":\"u3\",\"email\":\"nope\"}\n",
+			wantTotal:   3,
+			wantCreated: 1,
+			wantFailed:  2,
+			wantErrors:  [][]string{{"3", "", "", "invalid JSON object"}, {"4", "u3", "Email", "INVALID_VALUE"}},
+			wantBatches: [][]string{{"u1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job, contactRepo, errorRows, err := runImport(t, tt.format, tt.content, tt.existing...)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantTotal, job.TotalRows, "total")
+			assert.Equal(t, tt.wantCreated, job.CreatedCount, "created")
+			assert.Equal(t, tt.wantUpdated, job.UpdatedCount, "updated")
+			assert.Equal(t, tt.wantFailed, job.FailedCount, "failed")
+			assert.Equal(t, job.TotalRows, job.CreatedCount+job.UpdatedCount+job.FailedCount, "every row is counted once")
+			assert.Equal(t, tt.wantErrors, errorRows)
+			assert.Equal(t, tt.wantBatches, contactRepo.batches)
+			if tt.wantErrors == nil {
+				assert.Empty(t, job.ErrorsKey, "no error result without rejected rows")
+			}
+		})
+	}
+}
+
+func TestContactImportRunInvalidFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "empty file", content: "", wantErr: "the file is empty"},
+		{name: "unknown column", content: "external_id,nickname\nu1,ada\n", wantErr: "unknown column 'nickname'"},
+		{name: "missing external ID column", content: "email\nada@example.com\n", wantErr: "missing column 'external_id'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job, contactRepo, _, err := runImport(t, model.ContactFormatCSV, tt.content)
+			assert.EqualError(t, err, tt.wantErr)
+			assert.Zero(t, job.TotalRows)
+			assert.Empty(t, contactRepo.batches)
+		})
+	}
+}
+
+func TestContactImportRunBatches(t *testing.T) {
+	var content strings.Builder
+	content.WriteString("external_id\n")
+	for i := 0; i < contactBatchSize*2+1; i++ {
+		content.WriteString(uuid.NewString() + "\n")
+	}
+
+	job, contactRepo, _, err := runImport(t, model.ContactFormatCSV, content.String())
+	require.NoError(t, err)
+	assert.Equal(t, contactBatchSize*2+1, job.CreatedCount)
+	require.Len(t, contactRepo.batches, 3)
+	assert.Len(t, contactRepo.batches[0], contactBatchSize)
+	assert.Len(t, contactRepo.batches[2], 1)
+}
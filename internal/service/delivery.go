@@ -0,0 +1,612 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"hermes-api/config"
+	"hermes-api/internal/model"
+	"hermes-api/internal/provider"
+	"hermes-api/internal/repository"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// DeliveryService defines the interface for delivering queued notifications
+type DeliveryService interface {
+	Deliver(ctx context.Context, id uuid.UUID) error
+}
+
+// deliveryService implements DeliveryService
+type deliveryService struct {
+	notificationRepo          repository.NotificationRepository
+	fallbacks                 fallbackQueue
+	fileService               FileService
+	preferenceCenterService   PreferenceCenterService
+	suppressionService        SuppressionService
+	frequencyCapService       FrequencyCapService
+	providerHealthService     ProviderHealthService
+	providerCredentialService ProviderCredentialService
+	trackingService           TrackingService
+	providers                 *provider.Registry
+	applicationProviders      *provider.Pool
+	config                    config.NotificationsConfig
+}
+
+// NewDeliveryService creates a new delivery service
+func NewDeliveryService(notificationRepo repository.NotificationRepository, templateRepo repository.TemplateRepository, fileService FileService, preferenceCenterService PreferenceCenterService, suppressionService SuppressionService, frequencyCapService FrequencyCapService, providerHealthService ProviderHealthService, providerCredentialService ProviderCredentialService, trackingService TrackingService, providers *provider.Registry, applicationProviders *provider.Pool, cfg config.NotificationsConfig) DeliveryService {
+	return &deliveryService{
+		notificationRepo:          notificationRepo,
+		fallbacks:                 fallbackQueue{notificationRepo: notificationRepo, templateRepo: templateRepo},
+		fileService:               fileService,
+		preferenceCenterService:   preferenceCenterService,
+		suppressionService:        suppressionService,
+		frequencyCapService:       frequencyCapService,
+		providerHealthService:     providerHealthService,
+		providerCredentialService: providerCredentialService,
+		trackingService:           trackingService,
+		providers:                 providers,
+		applicationProviders:      applicationProviders,
+		config:                    cfg,
+	}
+}
+
+// Deliver resolves the recipients of a claimed notification, renders it and hands it to the
+// provider of its channel. Delivery failures are recorded on the notification; an error is only
+// returned when the outcome could not be saved.
+func (s *deliveryService) Deliver(ctx context.Context, id uuid.UUID) error {
+	notification, err := s.notificationRepo.GetForDelivery(ctx, id)
+	if err != nil {
+		return err
+	}
+	// Only notifications claimed by this worker are delivered, never twice
+	if notification.Status != model.NotificationStatusProcessing {
+		return fmt.Errorf("%w: notification is %s, not processing", repository.ErrInvalidStatusTransition, notification.Status)
+	}
+
+	messageID, err := s.send(ctx, notification)
+	var skipped *deliverySkipped
+	var deferred *deliveryDeferred
+	if s.retryable(notification, err) {
+		notification.Retries++
+		retryAt := time.Now().UTC().Add(retryDelay(s.config.RetryDelay, notification.Retries))
+		notification.Status = model.NotificationStatusDeferred
+		notification.StatusReason = fmt.Sprintf("Retrying after failure: %s", failureReason(err))
+		notification.ScheduledAt = &retryAt
+
+		logger.WithNotification(notification.ID.String(), notification.Recipient, string(notification.Channel)).
+			Warn("Notification delivery failed, retrying", zap.Int("attempts", notification.Attempts), zap.Int("retries", notification.Retries), zap.Time("retry_at", retryAt), zap.Error(err))
+	} else if errors.As(err, &deferred) {
+		notification.Status = model.NotificationStatusDeferred
+		notification.StatusReason = deferred.reason
+		notification.ScheduledAt = &deferred.until
+
+		logger.WithNotification(notification.ID.String(), notification.Recipient, string(notification.Channel)).
+			Info("Notification deferred", zap.Time("until", deferred.until), zap.String("reason", deferred.reason))
+	} else if errors.As(err, &skipped) {
+		notification.Status = skipped.status
+		notification.StatusReason = skipped.reason
+
+		logger.WithNotification(notification.ID.String(), notification.Recipient, string(notification.Channel)).
+			Info("Notification skipped", zap.String("status", string(skipped.status)), zap.String("reason", skipped.reason))
+	} else if err != nil {
+		notification.Status = model.NotificationStatusFailed
+		notification.StatusReason = failureReason(err)
+
+		logger.WithNotification(notification.ID.String(), notification.Recipient, string(notification.Channel)).
+			Warn("Notification delivery failed", zap.String("reason", notification.StatusReason))
+	} else {
+		sentAt := time.Now().UTC()
+		notification.Status = model.NotificationStatusSent
+		notification.StatusReason = ""
+		notification.SentAt = &sentAt
+
+		logger.WithNotification(notification.ID.String(), notification.Recipient, string(notification.Channel)).
+			Info("Notification sent", zap.String("message_id", messageID))
+	}
+
+	if err := s.notificationRepo.UpdateDelivery(ctx, notification); err != nil {
+		return err
+	}
+
+	if err := s.fallbacks.queue(ctx, notification, isAddressMissing(err)); err != nil {
+		logger.WithNotification(notification.ID.String(), notification.Recipient, string(notification.Channel)).
+			Error("Failed to queue fallback notification", zap.Error(err))
+	}
+	return nil
+}
+
+// retryable reports whether a failed delivery is retried: provider failures until the notification ran
+// out of attempts, infrastructure failures regardless of its attempts, both at most MaxRetryCount times
+func (s *deliveryService) retryable(notification *model.Notification, err error) bool {
+	if s.config.MaxRetryCount > 0 && notification.Retries >= s.config.MaxRetryCount {
+		return false
+	}
+
+	var providerErr *providerFailure
+	var transientErr *transientFailure
+	switch {
+	case errors.As(err, &providerErr):
+		return notification.Attempts <= s.config.DefaultRetryCount
+	case errors.As(err, &transientErr):
+		return true
+	}
+	return false
+}
+
+// send checks that a notification may be delivered, builds its provider message and delivers it
+func (s *deliveryService) send(ctx context.Context, notification *model.Notification) (string, error) {
+	if err := s.checkFallback(ctx, notification); err != nil {
+		return "", err
+	}
+	if err := checkPreferences(notification); err != nil {
+		return "", err
+	}
+	if err := checkQuietHours(notification, time.Now()); err != nil {
+		return "", err
+	}
+
+	recipients, err := resolveRecipients(notification)
+	if err != nil {
+		return "", err
+	}
+	notification.Recipient = strings.Join(recipients, ",")
+
+	recipients, err = s.checkSuppressions(ctx, notification, recipients)
+	if err != nil {
+		return "", err
+	}
+
+	locales := []string{notification.Locale}
+	if notification.Contact != nil {
+		locales = append(locales, notification.Contact.Locale)
+	}
+
+	links, err := s.recipientLinks(notification)
+	if err != nil {
+		return "", err
+	}
+	data := templateData(notification, links)
+
+	result, err := renderTemplate(&notification.Template, &notification.Application, data, locales...)
+	if err != nil {
+		return "", err
+	}
+	result.HTMLBody, err = s.trackingService.Instrument(notification, result.HTMLBody)
+	if err != nil {
+		return "", err
+	}
+
+	message := &provider.Message{
+		NotificationID: notification.ID,
+		ApplicationID:  notification.ApplicationID,
+		Channel:        notification.Channel,
+		To:             recipients,
+		Subject:        result.Subject,
+		Body:           result.Body,
+		HTMLBody:       result.HTMLBody,
+	}
+
+	// One-click unsubscribe (RFC 8058), only contacts can be opted out
+	if unsubscribeURL, ok := links["unsubscribe_url"]; ok && notification.Channel == model.ChannelEmail {
+		message.Headers = map[string]string{
+			"List-Unsubscribe":      "<" + unsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		}
+	}
+
+	for _, attachment := range notification.Attachments {
+		content, err := s.readFile(ctx, &attachment.File)
+		if err != nil {
+			return "", err
+		}
+		message.Attachments = append(message.Attachments, provider.Attachment{
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+			ContentID:   attachment.ContentID,
+			Content:     content,
+		})
+	}
+
+	// Counted last, so that notifications failing to render do not count towards the caps
+	if err := s.checkFrequencyCaps(ctx, notification); err != nil {
+		return "", err
+	}
+
+	return s.dispatch(ctx, notification, message)
+}
+
+// dispatch hands a message to the providers of its channel in routing order, failing over to the next
+// provider when one fails. Rejected recipients are not retried with other providers; other failures are
+// returned as a providerFailure for the notification to be retried. Throttled providers and providers
+// whose circuit is open are not called; when none was called the notification is deferred until the
+// first of them is available again, without counting an attempt.
+func (s *deliveryService) dispatch(ctx context.Context, notification *model.Notification, message *provider.Message) (string, error) {
+	providers, ownProvider, err := s.route(ctx, notification, message)
+	if err != nil {
+		return "", err
+	}
+	if len(providers) == 0 {
+		return "", fmt.Errorf("no provider configured for channel '%s'", notification.Channel)
+	}
+
+	var errs []error
+	var retryAfter time.Duration
+	attempted := false
+	for _, channelProvider := range providers {
+		start := time.Now()
+		sent, err := channelProvider.Send(ctx, message)
+		latency := time.Since(start)
+
+		if wait, ok := unavailableFor(err); ok {
+			if retryAfter == 0 || wait < retryAfter {
+				retryAfter = wait
+			}
+			continue
+		}
+		if !attempted {
+			notification.Attempts++
+			attempted = true
+		}
+
+		// A rejection is the recipient's doing, the provider itself worked. The providers of applications
+		// do not score the health of the platform providers.
+		var rejected *provider.RejectedError
+		isRejected := errors.As(err, &rejected)
+		if !ownProvider {
+			s.providerHealthService.Record(ctx, notification.Channel, channelProvider.Name(), err == nil || isRejected, latency)
+		}
+		logAttempt(notification, channelProvider.Name(), sent, err, isRejected, latency)
+
+		if err == nil {
+			return sent.MessageID, nil
+		}
+		if isRejected {
+			s.suppressRejected(ctx, notification, channelProvider.Name(), rejected)
+			return "", fmt.Errorf("%s: %w", channelProvider.Name(), err)
+		}
+
+		logger.WithNotification(notification.ID.String(), notification.Recipient, string(notification.Channel)).
+			Warn("Provider failed to send notification", zap.String("provider", channelProvider.Name()), zap.Error(err))
+		errs = append(errs, fmt.Errorf("%s: %w", channelProvider.Name(), err))
+	}
+
+	switch len(errs) {
+	case 0:
+		until := time.Now().UTC().Add(retryAfter)
+		reason := fmt.Sprintf("Deferred by provider rate limits or open circuits until %s", until.Format(time.RFC3339))
+		return "", &deliveryDeferred{until: until, reason: reason}
+	case 1:
+		return "", &providerFailure{err: errs[0]}
+	}
+	return "", &providerFailure{err: fmt.Errorf("all %d providers failed, last %w", len(errs), errs[len(errs)-1])}
+}
+
+// unavailableFor returns the time a provider asked to wait before being called again, when it refused a
+// message without sending it because of its rate limit or its open circuit
+func unavailableFor(err error) (time.Duration, bool) {
+	var throttled *provider.ThrottledError
+	if errors.As(err, &throttled) {
+		return throttled.RetryAfter, true
+	}
+	var circuitOpen *provider.CircuitOpenError
+	if errors.As(err, &circuitOpen) {
+		return circuitOpen.RetryAfter, true
+	}
+	return 0, false
+}
+
+// logAttempt adds a hand-over of a notification to a provider to its attempt log, saved with the delivery outcome
+func logAttempt(notification *model.Notification, providerName string, result *provider.Result, err error, rejected bool, latency time.Duration) {
+	attempt := model.NotificationAttempt{
+		NotificationID: notification.ID,
+		Number:         notification.Attempts,
+		Provider:       providerName,
+		Outcome:        model.AttemptOutcomeSucceeded,
+		LatencyMs:      latency.Milliseconds(),
+	}
+	if result != nil {
+		attempt.ProviderMessageID = result.MessageID
+		attempt.ResponseCode = result.ResponseCode
+		attempt.Response = provider.SanitizeResponse(result.Response)
+	}
+	if err != nil {
+		attempt.Outcome = model.AttemptOutcomeFailed
+		if rejected {
+			attempt.Outcome = model.AttemptOutcomeRejected
+		}
+		attempt.Error = provider.SanitizeResponse(err.Error())
+	}
+
+	notification.AttemptLog = append(notification.AttemptLog, attempt)
+}
+
+// route returns the providers a message is handed to: the application's own provider of the channel
+// when it configured one, without falling back to the platform providers, or the platform providers in
+// routing order. The sender of the application's provider is used unless the message has one.
+func (s *deliveryService) route(ctx context.Context, notification *model.Notification, message *provider.Message) ([]provider.Provider, bool, error) {
+	credential, err := s.providerCredentialService.Resolve(ctx, notification.ApplicationID, notification.Channel)
+	if err != nil {
+		return nil, false, &transientFailure{err: err}
+	}
+	if credential == nil {
+		providers := s.providers.Order(notification.Channel, func(name string) bool {
+			return s.providerHealthService.IsHealthy(ctx, notification.Channel, name)
+		})
+		return providers, false, nil
+	}
+
+	ownProvider, err := s.applicationProviders.Get(credential, func() (map[string]string, error) {
+		return s.providerCredentialService.OpenSecrets(credential)
+	})
+	if err != nil {
+		return nil, true, fmt.Errorf("application provider '%s' is unavailable: %w", credential.Name, err)
+	}
+
+	if message.Sender == "" {
+		message.Sender = credential.Sender
+	}
+	return []provider.Provider{ownProvider}, true, nil
+}
+
+// checkFallback skips fallback notifications once a notification of their chain was delivered
+func (s *deliveryService) checkFallback(ctx context.Context, notification *model.Notification) error {
+	if notification.ParentID == nil {
+		return nil
+	}
+
+	delivered, err := s.notificationRepo.IsChainDelivered(ctx, *notification.ParentID)
+	if err != nil {
+		return &transientFailure{err: err}
+	}
+	if !delivered {
+		return nil
+	}
+
+	reason := fmt.Sprintf("Notification %s or one of its fallbacks was delivered", *notification.ParentID)
+	return &deliverySkipped{status: model.NotificationStatusSkippedFallback, reason: reason}
+}
+
+// checkSuppressions removes the suppressed addresses from the recipients, the notification is skipped
+// when all of them are suppressed
+func (s *deliveryService) checkSuppressions(ctx context.Context, notification *model.Notification, recipients []string) ([]string, error) {
+	allowed, suppressions, err := s.suppressionService.FilterSuppressed(ctx, notification.ApplicationID, notification.Channel, recipients)
+	if err != nil {
+		return nil, &transientFailure{err: err}
+	}
+
+	if len(allowed) == 0 {
+		reason := fmt.Sprintf("Recipient address is suppressed (%s)", suppressions[0].Reason)
+		return nil, &deliverySkipped{status: model.NotificationStatusSkippedSuppressed, reason: reason}
+	}
+	if len(suppressions) > 0 {
+		notification.Recipient = strings.Join(allowed, ",")
+	}
+	return allowed, nil
+}
+
+// checkFrequencyCaps skips notifications exceeding a frequency cap of their application
+func (s *deliveryService) checkFrequencyCaps(ctx context.Context, notification *model.Notification) error {
+	frequencyCap, err := s.frequencyCapService.Check(ctx, notification)
+	if err != nil {
+		return &transientFailure{err: err}
+	}
+	if frequencyCap == nil {
+		return nil
+	}
+
+	scope := string(notification.Channel)
+	if frequencyCap.Category != "" {
+		scope = fmt.Sprintf("'%s' %s", frequencyCap.Category, notification.Channel)
+	}
+	reason := fmt.Sprintf("Frequency cap exceeded: at most %d %s notifications per %s", frequencyCap.Limit, scope, formatWindow(frequencyCap.Window()))
+	return &deliverySkipped{status: model.NotificationStatusSkippedCapped, reason: reason}
+}
+
+// suppressRejected adds the addresses a provider reported as permanently rejected to the suppression list
+func (s *deliveryService) suppressRejected(ctx context.Context, notification *model.Notification, providerName string, rejected *provider.RejectedError) {
+	for _, address := range rejected.Addresses {
+		err := s.suppressionService.Suppress(ctx, notification.ApplicationID, notification.Channel, address, rejected.Reason, providerName, rejected.Description)
+		if err != nil {
+			logger.WithNotification(notification.ID.String(), address, string(notification.Channel)).
+				Error("Failed to suppress rejected address", zap.Error(err))
+		}
+	}
+}
+
+// recipientLinks signs the public links of the contact a notification is sent to
+func (s *deliveryService) recipientLinks(notification *model.Notification) (map[string]string, error) {
+	links := make(map[string]string)
+	if notification.Contact == nil {
+		return links, nil
+	}
+
+	link, err := s.preferenceCenterService.LinkFor(notification.Contact)
+	if err != nil {
+		return nil, err
+	}
+	links["preferences_url"] = link.URL
+
+	links["unsubscribe_url"], err = s.preferenceCenterService.UnsubscribeLinkFor(notification.Contact, notification.Category, notification.Channel)
+	if err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+// templateData returns the notification data with the recipient links added under the reserved
+// "hermes" key, e.g. {{ .hermes.preferences_url }} or {{ .hermes.unsubscribe_url }}
+func templateData(notification *model.Notification, links map[string]string) map[string]any {
+	data := make(map[string]any, len(notification.Data)+1)
+	for key, value := range notification.Data {
+		data[key] = value
+	}
+	data["hermes"] = links
+	return data
+}
+
+// readFile reads the content of an attachment from the file storage; storage failures are retried
+func (s *deliveryService) readFile(ctx context.Context, file *model.File) ([]byte, error) {
+	reader, err := s.fileService.OpenFile(ctx, file)
+	if err != nil {
+		return nil, &transientFailure{err: err}
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, &transientFailure{err: err}
+	}
+	return content, nil
+}
+
+// deliverySkipped is returned by the checks of the send pipeline when a notification must not be
+// delivered; the notification is recorded with the given status instead of failing
+type deliverySkipped struct {
+	status model.NotificationStatus
+	reason string
+}
+
+func (e *deliverySkipped) Error() string {
+	return e.reason
+}
+
+// deliveryDeferred is returned by the checks of the send pipeline when a notification must be
+// delivered later; the notification is deferred until the given time
+type deliveryDeferred struct {
+	until  time.Time
+	reason string
+}
+
+func (e *deliveryDeferred) Error() string {
+	return e.reason
+}
+
+// providerFailure is returned by the send pipeline when every provider of the channel failed; the
+// notification is retried until it runs out of attempts
+type providerFailure struct {
+	err error
+}
+
+func (e *providerFailure) Error() string {
+	return e.err.Error()
+}
+
+func (e *providerFailure) Unwrap() error {
+	return e.err
+}
+
+// transientFailure is returned by the send pipeline when a store it depends on failed, e.g. the database
+// or the file storage; the notification is retried without counting an attempt
+type transientFailure struct {
+	err error
+}
+
+func (e *transientFailure) Error() string {
+	return e.err.Error()
+}
+
+func (e *transientFailure) Unwrap() error {
+	return e.err
+}
+
+// retryDelay returns the delay before the given retry of a notification, doubling the base delay at
+// each retry
+func retryDelay(base time.Duration, retries int) time.Duration {
+	if base <= 0 {
+		base = 5 * time.Second
+	}
+	return base << min(max(retries-1, 0), 10)
+}
+
+// checkQuietHours defers non-urgent notifications sent during the quiet hours of their contact, or
+// of their application, to the end of the window
+func checkQuietHours(notification *model.Notification, now time.Time) error {
+	if notification.Urgent {
+		return nil
+	}
+
+	quietHours := notification.Application.QuietHours
+	timezone := ""
+	if notification.Contact != nil {
+		timezone = notification.Contact.Timezone
+		if notification.Contact.QuietHours != nil {
+			quietHours = notification.Contact.QuietHours
+		}
+	}
+	if quietHours == nil {
+		return nil
+	}
+
+	end, ok := quietHours.WindowEnd(now, notification.Channel, timezone)
+	if !ok {
+		return nil
+	}
+
+	reason := fmt.Sprintf("Deferred by quiet hours until %s", end.Format(time.RFC3339))
+	return &deliveryDeferred{until: end.UTC(), reason: reason}
+}
+
+// checkPreferences skips notifications the contact has opted out of
+func checkPreferences(notification *model.Notification) error {
+	if notification.Contact == nil || notification.Contact.Allows(notification.Category, notification.Channel) {
+		return nil
+	}
+
+	reason := fmt.Sprintf("Contact opted out of %s notifications", notification.Channel)
+	if notification.Category != "" {
+		reason = fmt.Sprintf("Contact opted out of '%s' notifications on %s", notification.Category, notification.Channel)
+	}
+	return &deliverySkipped{status: model.NotificationStatusSkippedPreference, reason: reason}
+}
+
+// resolveRecipients returns the addresses a notification is delivered to, resolving the
+// address of its contact for the notification's channel
+func resolveRecipients(notification *model.Notification) ([]string, error) {
+	if notification.Contact == nil {
+		if notification.Recipient == "" {
+			return nil, errors.New("notification has no recipient")
+		}
+		return []string{notification.Recipient}, nil
+	}
+
+	addresses := notification.Contact.Addresses(notification.Channel)
+	if len(addresses) == 0 {
+		return nil, errorx.NewWithTemplate(errorx.ErrorTypeValidation, errorx.ErrorCodeContactAddressMissing, notification.Contact.ExternalID, notification.Channel)
+	}
+	return addresses, nil
+}
+
+// isAddressMissing reports whether a delivery failed because the contact has no address for the channel
+func isAddressMissing(err error) bool {
+	var appErr *errorx.AppError
+	return errors.As(err, &appErr) && appErr.Code == errorx.ErrorCodeContactAddressMissing
+}
+
+// failureReason returns the message recorded on a notification whose delivery failed
+func failureReason(err error) string {
+	var appErr *errorx.AppError
+	if errors.As(err, &appErr) {
+		return appErr.Message
+	}
+	return err.Error()
+}
+
+// formatWindow formats a window in its largest whole unit, e.g. "24h" or "10m"
+func formatWindow(window time.Duration) string {
+	switch {
+	case window%time.Hour == 0:
+		return fmt.Sprintf("%dh", window/time.Hour)
+	case window%time.Minute == 0:
+		return fmt.Sprintf("%dm", window/time.Minute)
+	}
+	return window.String()
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"hermes-api/internal/model"
+	"hermes-api/internal/provider"
+	"hermes-api/internal/repository"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// webhookSecretName is the secret of a provider credential that signs the webhooks of the application's provider
+const webhookSecretName = "webhook_secret"
+
+// webhookPath is the path of the webhook routes under the public URL, providers must be configured to
+// post to <public_url>/api/v1/webhooks/<driver>, or <public_url>/api/v1/webhooks/<driver>/<credential_id>
+// for the provider of an application
+const webhookPath = "/api/v1/webhooks/"
+
+// messagePendingWindow is how long after an event its unknown message may still be waiting for its
+// delivery to be saved; such events are refused for the provider to post them again
+const messagePendingWindow = 15 * time.Minute
+
+// errMessagePending is returned for events of a message not saved yet, which may be sent right now
+var errMessagePending = errors.New("message of the delivery event is not saved yet")
+
+// WebhookResult reports the delivery events of a webhook request
+type WebhookResult struct {
+	Received int `json:"received"`
+	Applied  int `json:"applied"` // Events that changed a notification or suppressed an address
+}
+
+// DeliveryEventService defines the interface for the delivery events reported by provider webhooks
+type DeliveryEventService interface {
+	HandleWebhook(ctx context.Context, driver string, credentialID *uuid.UUID, request *provider.InboundRequest) (*WebhookResult, error)
+}
+
+// deliveryEventService implements DeliveryEventService
+type deliveryEventService struct {
+	notificationRepo          repository.NotificationRepository
+	fallbacks                 fallbackQueue
+	providerCredentialRepo    repository.ProviderCredentialRepository
+	providerCredentialService ProviderCredentialService
+	suppressionService        SuppressionService
+	secrets                   map[string]string
+	publicURL                 string
+}
+
+// NewDeliveryEventService creates a new delivery event service verifying the webhooks of the platform
+// providers with secrets by driver. publicURL is the base URL providers post to, some of them sign it.
+func NewDeliveryEventService(notificationRepo repository.NotificationRepository, templateRepo repository.TemplateRepository, providerCredentialRepo repository.ProviderCredentialRepository, providerCredentialService ProviderCredentialService, suppressionService SuppressionService, secrets map[string]string, publicURL string) DeliveryEventService {
+	return &deliveryEventService{
+		notificationRepo:          notificationRepo,
+		fallbacks:                 fallbackQueue{notificationRepo: notificationRepo, templateRepo: templateRepo},
+		providerCredentialRepo:    providerCredentialRepo,
+		providerCredentialService: providerCredentialService,
+		suppressionService:        suppressionService,
+		secrets:                   secrets,
+		publicURL:                 strings.TrimRight(publicURL, "/"),
+	}
+}
+
+// HandleWebhook verifies a webhook request of a provider and applies its delivery events. Requests for
+// the provider of an application are verified with the secret of its credential and only apply to the
+// application's notifications.
+func (s *deliveryEventService) HandleWebhook(ctx context.Context, driver string, credentialID *uuid.UUID, request *provider.InboundRequest) (*WebhookResult, error) {
+	applicationID, secret, err := s.webhookSecret(ctx, driver, credentialID)
+	if err != nil {
+		return nil, err
+	}
+
+	request.URL = s.webhookURL(driver, credentialID, request.RawQuery)
+	events, err := provider.ParseWebhook(driver, request, secret)
+	if errors.Is(err, provider.ErrInvalidSignature) {
+		return nil, errorx.New(errorx.ErrorTypeUnauthorized, errorx.ErrorCodeWebhookSignatureInvalid, "Invalid webhook signature")
+	}
+	if errors.Is(err, provider.ErrInvalidWebhookKey) {
+		logger.Error("Webhook verification key is misconfigured", err, zap.String("driver", driver))
+		return nil, errorx.New(errorx.ErrorTypeInternal, errorx.ErrorCodeWebhookKeyInvalid, "Webhook verification key is misconfigured")
+	}
+	if err != nil {
+		appErr := errorx.New(errorx.ErrorTypeBadRequest, errorx.ErrorCodeInvalidFormat, "Invalid webhook payload")
+		return nil, appErr
+	}
+
+	result := &WebhookResult{Received: len(events)}
+	pending := false
+	for _, event := range events {
+		applied, err := s.apply(ctx, applicationID, event)
+		if errors.Is(err, errMessagePending) {
+			pending = true
+			continue
+		}
+		if err != nil {
+			// The provider retries the request, applying its events again is harmless
+			appErr := errorx.New(
+				errorx.ErrorTypeInternal,
+				errorx.ErrorCodeDatabaseError,
+				"Failed to apply delivery event",
+			)
+			return nil, appErr
+		}
+		if applied {
+			result.Applied++
+		}
+	}
+
+	// The provider posts the request again, by then the delivery of its messages was saved
+	if pending {
+		return nil, errorx.New(errorx.ErrorTypeServiceUnavailable, errorx.ErrorCodeWebhookMessagePending, "Delivery event for a message not saved yet")
+	}
+	return result, nil
+}
+
+// webhookURL returns the URL providers are configured to post the webhooks of a driver to. It is built
+// from the public URL rather than the path of the request, which a proxy may have rewritten, so that the
+// URL signed by the provider is reproduced exactly.
+func (s *deliveryEventService) webhookURL(driver string, credentialID *uuid.UUID, rawQuery string) string {
+	webhookURL := s.publicURL + webhookPath + driver
+	if credentialID != nil {
+		webhookURL += "/" + credentialID.String()
+	}
+	if rawQuery != "" {
+		webhookURL += "?" + rawQuery
+	}
+	return webhookURL
+}
+
+// webhookSecret returns the signing secret of the webhooks of a driver, with the application the webhook
+// is restricted to when it was posted for an application's provider
+func (s *deliveryEventService) webhookSecret(ctx context.Context, driver string, credentialID *uuid.UUID) (uuid.UUID, string, error) {
+	notConfigured := errorx.NewWithTemplate(errorx.ErrorTypeNotFound, errorx.ErrorCodeWebhookNotConfigured, driver)
+	if !provider.HasWebhook(driver) {
+		return uuid.Nil, "", notConfigured
+	}
+
+	if credentialID == nil {
+		secret := s.secrets[driver]
+		if secret == "" {
+			return uuid.Nil, "", notConfigured
+		}
+		return uuid.Nil, secret, nil
+	}
+
+	credential, err := s.providerCredentialRepo.GetByID(ctx, *credentialID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && credential.Driver != driver) {
+		return uuid.Nil, "", notConfigured
+	}
+	if err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch provider credential",
+		)
+		return uuid.Nil, "", appErr
+	}
+
+	secrets, err := s.providerCredentialService.OpenSecrets(credential)
+	if err != nil {
+		return uuid.Nil, "", errorx.New(errorx.ErrorTypeInternal, errorx.ErrorCodeCredentialKeyUnavailable, "Provider credential cannot be decrypted")
+	}
+	if secrets[webhookSecretName] == "" {
+		return uuid.Nil, "", notConfigured
+	}
+	return credential.ApplicationID, secrets[webhookSecretName], nil
+}
+
+// apply moves the notification a delivery event was reported for to the event's status, runs its fallback
+// chain when it failed, and suppresses the address of hard bounces and complaints. Events of unknown
+// messages and events the notification cannot move to, e.g. reported twice, are ignored; whether the
+// event was applied is returned. Recent events of unknown messages return errMessagePending, their
+// delivery may not be saved yet.
+func (s *deliveryEventService) apply(ctx context.Context, applicationID uuid.UUID, event provider.DeliveryEvent) (bool, error) {
+	attempt, err := s.notificationRepo.GetAttemptByMessageID(ctx, event.MessageID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if time.Since(event.OccurredAt) < messagePendingWindow {
+			return false, errMessagePending
+		}
+		logger.Debug("Delivery event for unknown message", zap.String("message_id", event.MessageID), zap.String("event", string(event.Type)))
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	notification, err := s.notificationRepo.GetByID(ctx, attempt.NotificationID)
+	if err != nil {
+		return false, err
+	}
+	if applicationID != uuid.Nil && notification.ApplicationID != applicationID {
+		return false, nil
+	}
+	log := logger.WithNotification(notification.ID.String(), notification.Recipient, string(notification.Channel))
+
+	applied := false
+	if status, ok := eventStatuses[event.Type]; ok {
+		reason := event.Description
+		if reason == "" {
+			reason = fmt.Sprintf("Reported %s by %s", event.Type, attempt.Provider)
+		}
+
+		err := s.notificationRepo.UpdateStatus(ctx, notification, status, reason)
+		switch {
+		case errors.Is(err, repository.ErrInvalidStatusTransition):
+			log.Debug("Delivery event does not apply", zap.String("event", string(event.Type)), zap.String("status", string(notification.Status)))
+		case err != nil:
+			return false, err
+		default:
+			applied = true
+			log.Info("Delivery event applied", zap.String("event", string(event.Type)), zap.String("provider", attempt.Provider))
+
+			if err := s.fallbacks.reported(ctx, notification); err != nil {
+				log.Error("Failed to queue fallback notification", zap.Error(err))
+			}
+		}
+	}
+
+	if reason, ok := eventSuppressions[event.Type]; ok {
+		address := event.Address
+		if address == "" {
+			address = notification.Recipient
+		}
+		err := s.suppressionService.Suppress(ctx, notification.ApplicationID, notification.Channel, address, reason, attempt.Provider, event.Description)
+		if err != nil {
+			return false, err
+		}
+		applied = true
+	}
+
+	return applied, nil
+}
+
+// eventStatuses maps delivery events onto the status of their notification
+var eventStatuses = map[provider.EventType]model.NotificationStatus{
+	provider.EventDelivered: model.NotificationStatusDelivered,
+	provider.EventBounced:   model.NotificationStatusBounced,
+	provider.EventFailed:    model.NotificationStatusFailed,
+}
+
+// eventSuppressions maps delivery events onto the reason the address is suppressed for
+var eventSuppressions = map[provider.EventType]model.SuppressionReason{
+	provider.EventBounced:    model.SuppressionReasonBounce,
+	provider.EventComplained: model.SuppressionReasonComplaint,
+}
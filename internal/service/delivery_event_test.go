@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"testing"
+
+	"hermes-api/internal/model"
+	"hermes-api/internal/provider"
+	"hermes-api/internal/repository"
+	"hermes-api/pkg/errorx"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// eventNotificationRepo holds the notifications and successful attempts delivery events are reported for
+type eventNotificationRepo struct {
+	repository.NotificationRepository
+	notifications map[uuid.UUID]*model.Notification
+	attempts      map[string]*model.NotificationAttempt
+}
+
+func (r *eventNotificationRepo) GetAttemptByMessageID(ctx context.Context, messageID string) (*model.NotificationAttempt, error) {
+	attempt, ok := r.attempts[messageID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return attempt, nil
+}
+
+func (r *eventNotificationRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.Notification, error) {
+	notification, ok := r.notifications[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return notification, nil
+}
+
+func (r *eventNotificationRepo) UpdateStatus(ctx context.Context, notification *model.Notification, status model.NotificationStatus, reason string) error {
+	if !notification.Status.CanTransitionTo(status) {
+		return fmt.Errorf("%w to '%s'", repository.ErrInvalidStatusTransition, status)
+	}
+	notification.Status = status
+	notification.StatusReason = reason
+	return nil
+}
+
+// eventCredentialRepo holds the provider credentials of applications
+type eventCredentialRepo struct {
+	repository.ProviderCredentialRepository
+	credentials map[uuid.UUID]*model.ProviderCredential
+}
+
+func (r *eventCredentialRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.ProviderCredential, error) {
+	credential, ok := r.credentials[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return credential, nil
+}
+
+// eventCredentialService opens the secrets of credentials without encryption
+type eventCredentialService struct {
+	ProviderCredentialService
+	secrets map[uuid.UUID]map[string]string
+}
+
+func (s *eventCredentialService) OpenSecrets(credential *model.ProviderCredential) (map[string]string, error) {
+	return s.secrets[credential.ID], nil
+}
+
+// recordingSuppressionService records the suppressed addresses
+type recordingSuppressionService struct {
+	SuppressionService
+	suppressed []string
+}
+
+func (s *recordingSuppressionService) Suppress(ctx context.Context, applicationID uuid.UUID, channel model.Channel, address string, reason model.SuppressionReason, source, description string) error {
+	s.suppressed = append(s.suppressed, fmt.Sprintf("%s:%s:%s", applicationID, address, reason))
+	return nil
+}
+
+// signTwilio signs a status callback posted to webhookURL the way Twilio does
+func signTwilio(webhookURL, authToken string, form url.Values) string {
+	names := make([]string, 0, len(form))
+	for name := range form {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(webhookURL))
+	for _, name := range names {
+		mac.Write([]byte(name + form.Get(name)))
+	}
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestHandleWebhook(t *testing.T) {
+	application := uuid.New()
+	otherApplication := uuid.New()
+	credential := &model.ProviderCredential{ID: uuid.New(), ApplicationID: application, Driver: "twilio"}
+	emailCredential := &model.ProviderCredential{ID: uuid.New(), ApplicationID: application, Driver: "sendgrid"}
+
+	// The public URL includes the path prefix a proxy strips before the request reaches the service
+	const publicURL = "https://hermes.example.com/notify/"
+	platformURL := "https://hermes.example.com/notify/api/v1/webhooks/twilio"
+	credentialURL := platformURL + "/" + credential.ID.String()
+
+	tests := []struct {
+		name         string
+		credentialID *uuid.UUID
+		signedURL    string // The URL the provider signs
+		rawQuery     string
+		authToken    string
+		owner        uuid.UUID // The application of the notification the event is reported for
+		status       string
+		errorCode    string
+		messageID    string
+		wantErr      errorx.ErrorType
+		wantApplied  int
+		wantStatus   model.NotificationStatus
+		wantSuppress model.SuppressionReason // Empty when the address must not be suppressed
+	}{
+		{
+			name:        "delivered",
+			signedURL:   platformURL,
+			authToken:   "platform-token",
+			status:      "delivered",
+			wantApplied: 1,
+			wantStatus:  model.NotificationStatusDelivered,
+		},
+		{
+			name:        "query string is signed",
+			signedURL:   platformURL + "?region=eu&retry=1",
+			rawQuery:    "region=eu&retry=1",
+			authToken:   "platform-token",
+			status:      "delivered",
+			wantApplied: 1,
+			wantStatus:  model.NotificationStatusDelivered,
+		},
+		{
+			name:       "query string missing from the signed URL",
+			signedURL:  platformURL,
+			rawQuery:   "region=eu",
+			authToken:  "platform-token",
+			status:     "delivered",
+			wantErr:    errorx.ErrorTypeUnauthorized,
+			wantStatus: model.NotificationStatusSent,
+		},
+		{
+			name:       "signed without the proxy prefix",
+			signedURL:  "https://hermes.example.com/api/v1/webhooks/twilio",
+			authToken:  "platform-token",
+			status:     "delivered",
+			wantErr:    errorx.ErrorTypeUnauthorized,
+			wantStatus: model.NotificationStatusSent,
+		},
+		{
+			name:       "wrong secret",
+			signedURL:  platformURL,
+			authToken:  "application-token",
+			status:     "delivered",
+			wantErr:    errorx.ErrorTypeUnauthorized,
+			wantStatus: model.NotificationStatusSent,
+		},
+		{
+			name:         "unreachable number bounces and is suppressed",
+			signedURL:    platformURL,
+			authToken:    "platform-token",
+			status:       "undelivered",
+			errorCode:    "30005",
+			wantApplied:  1,
+			wantStatus:   model.NotificationStatusBounced,
+			wantSuppress: model.SuppressionReasonBounce,
+		},
+		{
+			name:         "opt-out is suppressed without changing the status",
+			signedURL:    platformURL,
+			authToken:    "platform-token",
+			status:       "failed",
+			errorCode:    "21610",
+			wantApplied:  1,
+			wantStatus:   model.NotificationStatusSent,
+			wantSuppress: model.SuppressionReasonComplaint,
+		},
+		{
+			name:        "failure",
+			signedURL:   platformURL,
+			authToken:   "platform-token",
+			status:      "failed",
+			errorCode:   "30001",
+			wantApplied: 1,
+			wantStatus:  model.NotificationStatusFailed,
+		},
+		{
+			name:         "application credential",
+			credentialID: &credential.ID,
+			signedURL:    credentialURL,
+			authToken:    "application-token",
+			status:       "delivered",
+			wantApplied:  1,
+			wantStatus:   model.NotificationStatusDelivered,
+		},
+		{
+			name:         "application credential signed with the platform secret",
+			credentialID: &credential.ID,
+			signedURL:    credentialURL,
+			authToken:    "platform-token",
+			status:       "delivered",
+			wantErr:      errorx.ErrorTypeUnauthorized,
+			wantStatus:   model.NotificationStatusSent,
+		},
+		{
+			name:         "event of another application is ignored",
+			credentialID: &credential.ID,
+			signedURL:    credentialURL,
+			authToken:    "application-token",
+			owner:        otherApplication,
+			status:       "undelivered",
+			errorCode:    "30005",
+			wantStatus:   model.NotificationStatusSent,
+		},
+		{
+			name:         "credential of another driver",
+			credentialID: &emailCredential.ID,
+			signedURL:    platformURL + "/" + emailCredential.ID.String(),
+			authToken:    "application-token",
+			status:       "delivered",
+			wantErr:      errorx.ErrorTypeNotFound,
+			wantStatus:   model.NotificationStatusSent,
+		},
+		{
+			name:         "unknown credential",
+			credentialID: func() *uuid.UUID { id := uuid.New(); return &id }(),
+			signedURL:    platformURL,
+			authToken:    "application-token",
+			status:       "delivered",
+			wantErr:      errorx.ErrorTypeNotFound,
+			wantStatus:   model.NotificationStatusSent,
+		},
+		{
+			name:       "recent event of an unknown message",
+			signedURL:  platformURL,
+			authToken:  "platform-token",
+			status:     "delivered",
+			messageID:  "SM-unknown",
+			wantErr:    errorx.ErrorTypeServiceUnavailable,
+			wantStatus: model.NotificationStatusSent,
+		},
+		{
+			name:       "status without a delivery event",
+			signedURL:  platformURL,
+			authToken:  "platform-token",
+			status:     "sent",
+			wantStatus: model.NotificationStatusSent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner := tt.owner
+			if owner == uuid.Nil {
+				owner = application
+			}
+			notification := &model.Notification{
+				ID:            uuid.New(),
+				ApplicationID: owner,
+				Channel:       model.ChannelSMS,
+				Recipient:     "+15551234567",
+				Status:        model.NotificationStatusSent,
+			}
+			notificationRepo := &eventNotificationRepo{
+				notifications: map[uuid.UUID]*model.Notification{notification.ID: notification},
+				attempts: map[string]*model.NotificationAttempt{
+					"SM123": {NotificationID: notification.ID, Provider: "twilio", ProviderMessageID: "SM123"},
+				},
+			}
+			suppressions := &recordingSuppressionService{}
+			service := NewDeliveryEventService(
+				notificationRepo,
+				nil,
+				&eventCredentialRepo{credentials: map[uuid.UUID]*model.ProviderCredential{
+					credential.ID:      credential,
+					emailCredential.ID: emailCredential,
+				}},
+				&eventCredentialService{secrets: map[uuid.UUID]map[string]string{
+					credential.ID:      {webhookSecretName: "application-token"},
+					emailCredential.ID: {webhookSecretName: "application-token"},
+				}},
+				suppressions,
+				map[string]string{"twilio": "platform-token"},
+				publicURL,
+			)
+
+			messageID := tt.messageID
+			if messageID == "" {
+				messageID = "SM123"
+			}
+			form := url.Values{"MessageSid": {messageID}, "MessageStatus": {tt.status}, "To": {"+15551234567"}}
+			if tt.errorCode != "" {
+				form.Set("ErrorCode", tt.errorCode)
+			}
+			header := http.Header{}
+			header.Set("X-Twilio-Signature", signTwilio(tt.signedURL, tt.authToken, form))
+
+			result, err := service.HandleWebhook(context.Background(), "twilio", tt.credentialID, &provider.InboundRequest{
+				RawQuery: tt.rawQuery,
+				Header:   header,
+				Body:     []byte(form.Encode()),
+			})
+
+			if tt.wantErr != "" {
+				var appErr *errorx.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, tt.wantErr, appErr.Type)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantApplied, result.Applied)
+			}
+			assert.Equal(t, tt.wantStatus, notification.Status)
+
+			if tt.wantSuppress != "" {
+				assert.Equal(t, []string{owner.String() + ":+15551234567:" + string(tt.wantSuppress)}, suppressions.suppressed)
+			} else {
+				assert.Empty(t, suppressions.suppressed)
+			}
+		})
+	}
+
+	t.Run("driver without a secret", func(t *testing.T) {
+		service := NewDeliveryEventService(&eventNotificationRepo{}, nil, &eventCredentialRepo{}, &eventCredentialService{}, &recordingSuppressionService{}, map[string]string{}, publicURL)
+		_, err := service.HandleWebhook(context.Background(), "twilio", nil, &provider.InboundRequest{Header: http.Header{}})
+
+		var appErr *errorx.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, errorx.ErrorTypeNotFound, appErr.Type)
+	})
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"hermes-api/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckQuietHours(t *testing.T) {
+	// 21:30 UTC is 22:30 in Berlin and 16:30 in New York
+	now := time.Date(2024, 3, 1, 21, 30, 0, 0, time.UTC)
+
+	evening := &model.QuietHours{Start: "22:00", End: "07:00"}
+	afternoon := &model.QuietHours{Start: "16:00", End: "18:00", Timezone: "America/New_York"}
+
+	tests := []struct {
+		name        string
+		application *model.QuietHours
+		contact     *model.Contact
+		urgent      bool
+		wantUntil   time.Time // Zero when the notification is not deferred
+	}{
+		{name: "no quiet hours"},
+		{
+			name:        "application quiet hours in UTC",
+			application: &model.QuietHours{Start: "21:00", End: "07:00"},
+			wantUntil:   time.Date(2024, 3, 2, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "application quiet hours in the contact's timezone",
+			application: evening,
+			contact:     &model.Contact{Timezone: "Europe/Berlin"},
+			wantUntil:   time.Date(2024, 3, 2, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "application quiet hours without the contact's timezone",
+			application: evening,
+			contact:     &model.Contact{},
+		},
+		{
+			name:        "contact quiet hours take precedence",
+			application: evening,
+			contact:     &model.Contact{Timezone: "Europe/Berlin", QuietHours: &model.QuietHours{Start: "23:00", End: "06:00"}},
+		},
+		{
+			name:      "contact quiet hours without application quiet hours",
+			contact:   &model.Contact{Timezone: "Europe/Berlin", QuietHours: &model.QuietHours{Start: "22:15", End: "06:00"}},
+			wantUntil: time.Date(2024, 3, 2, 5, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "quiet hours timezone takes precedence over the contact's",
+			contact:   &model.Contact{Timezone: "Europe/Berlin", QuietHours: afternoon},
+			wantUntil: time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "urgent notifications are not deferred",
+			application: evening,
+			contact:     &model.Contact{Timezone: "Europe/Berlin"},
+			urgent:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notification := &model.Notification{
+				Channel:     model.ChannelEmail,
+				Urgent:      tt.urgent,
+				Application: model.Application{QuietHours: tt.application},
+				Contact:     tt.contact,
+			}
+
+			err := checkQuietHours(notification, now)
+			if tt.wantUntil.IsZero() {
+				assert.NoError(t, err)
+				return
+			}
+
+			var deferred *deliveryDeferred
+			require.ErrorAs(t, err, &deferred)
+			assert.Equal(t, tt.wantUntil, deferred.until)
+		})
+	}
+}
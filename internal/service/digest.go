@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"hermes-api/internal/dto"
+	"hermes-api/internal/model"
+	"hermes-api/internal/repository"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// DigestService defines the interface for batching notifications into digests
+type DigestService interface {
+	CreateDigestRule(ctx context.Context, application *model.Application, req dto.CreateDigestRuleRequest) (*model.DigestRule, error)
+	ListDigestRules(ctx context.Context, application *model.Application) ([]*model.DigestRule, error)
+	DeleteDigestRule(ctx context.Context, application *model.Application, id uuid.UUID) error
+	MatchRule(ctx context.Context, notification *model.Notification) (*model.DigestRule, error)
+	Collect(ctx context.Context, rule *model.DigestRule, notification *model.Notification) error
+	FlushDue(ctx context.Context, limit int) (int, error)
+}
+
+// digestService implements DigestService
+type digestService struct {
+	digestRepo   repository.DigestRepository
+	templateRepo repository.TemplateRepository
+}
+
+// NewDigestService creates a new digest service
+func NewDigestService(digestRepo repository.DigestRepository, templateRepo repository.TemplateRepository) DigestService {
+	return &digestService{
+		digestRepo:   digestRepo,
+		templateRepo: templateRepo,
+	}
+}
+
+// CreateDigestRule adds a digest rule to the application
+func (s *digestService) CreateDigestRule(ctx context.Context, application *model.Application, req dto.CreateDigestRuleRequest) (*model.DigestRule, error) {
+	digestTemplate, err := s.getTemplate(ctx, application, req.DigestTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := &model.DigestRule{
+		ApplicationID:    application.ID,
+		Category:         normalizeCategory(req.Category),
+		DigestTemplateID: digestTemplate.ID,
+		WindowSeconds:    req.WindowSeconds,
+		MaxItems:         req.MaxItems,
+	}
+
+	if req.Template != "" {
+		template, err := s.getTemplate(ctx, application, req.Template)
+		if err != nil {
+			return nil, err
+		}
+		if template.ID == digestTemplate.ID {
+			appErr := errorx.New(errorx.ErrorTypeValidation, errorx.ErrorCodeInvalidValue, "Invalid request body")
+			return nil, appErr.WithDetails(map[string]any{"DigestTemplate": errorx.ErrorCodeInvalidValue})
+		}
+		rule.TemplateID = &template.ID
+	}
+
+	if err := s.digestRepo.Create(ctx, rule); err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to create digest rule",
+		)
+		return nil, appErr
+	}
+
+	return rule, nil
+}
+
+// ListDigestRules retrieves the digest rules of the application
+func (s *digestService) ListDigestRules(ctx context.Context, application *model.Application) ([]*model.DigestRule, error) {
+	rules, err := s.digestRepo.ListRulesByApplication(ctx, application.ID)
+	if err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch digest rules",
+		)
+		return nil, appErr
+	}
+
+	return rules, nil
+}
+
+// DeleteDigestRule removes a digest rule of the application, sending its open digests first so that
+// the notifications they collected are delivered
+func (s *digestService) DeleteDigestRule(ctx context.Context, application *model.Application, id uuid.UUID) error {
+	rule, err := s.digestRepo.GetRuleByApplication(ctx, application.ID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errorx.NewWithTemplate(errorx.ErrorTypeNotFound, errorx.ErrorCodeDigestRuleNotFound, id.String())
+		}
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch digest rule",
+		)
+		return appErr
+	}
+
+	if err := s.digestRepo.DeleteRule(ctx, rule.ID, buildDigestNotification); err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to delete digest rule",
+		)
+		return appErr
+	}
+
+	return nil
+}
+
+// MatchRule returns the digest rule a notification is collected by, or nil when it is delivered on its
+// own. Only notifications sent to a contact are batched; urgent and critical ones never are, nor are
+// the ones with a fallback chain, which follows their own delivery.
+func (s *digestService) MatchRule(ctx context.Context, notification *model.Notification) (*model.DigestRule, error) {
+	if notification.ContactID == nil || notification.Urgent || notification.Priority == model.NotificationPriorityCritical || len(notification.FallbackChain) > 0 {
+		return nil, nil
+	}
+
+	rule, err := s.digestRepo.FindMatchingRule(ctx, notification.ApplicationID, notification.TemplateID, notification.Category)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// Collect adds a queued notification to the open digest of the rule for its contact, and flushes the
+// digest right away once it holds the maximum number of items of the rule
+func (s *digestService) Collect(ctx context.Context, rule *model.DigestRule, notification *model.Notification) error {
+	digest, err := s.digestRepo.AddItem(ctx, rule, notification)
+	if err != nil || digest == nil {
+		return err
+	}
+
+	if rule.MaxItems > 0 && digest.ItemCount >= rule.MaxItems {
+		return s.digestRepo.Flush(ctx, digest.ID, buildDigestNotification)
+	}
+	return nil
+}
+
+// FlushDue queues the notifications of up to limit digests whose window has ended, and returns the
+// number of digests processed
+func (s *digestService) FlushDue(ctx context.Context, limit int) (int, error) {
+	digests, err := s.digestRepo.ListDue(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, digest := range digests {
+		if err := s.digestRepo.Flush(ctx, digest.ID, buildDigestNotification); err != nil {
+			// A failing digest must not hold back the others, it is retried on the next poll
+			logger.Error("Failed to flush digest", err, zap.String("digest_id", digest.ID.String()))
+		}
+	}
+
+	return len(digests), nil
+}
+
+// getTemplate retrieves a template of the application by name
+func (s *digestService) getTemplate(ctx context.Context, application *model.Application, name string) (*model.Template, error) {
+	template, err := s.templateRepo.GetByName(ctx, application.ID, name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errorx.NewWithTemplate(errorx.ErrorTypeNotFound, errorx.ErrorCodeTemplateNotFound, name)
+		}
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch template",
+		)
+		return nil, appErr
+	}
+
+	return template, nil
+}
+
+// buildDigestNotification builds the notification a digest is delivered as, its template receives the
+// collected notifications as "items" with their template, category, data and creation time
+func buildDigestNotification(digest *model.Digest, items []*model.Notification) (*model.Notification, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("digest %s has no items", digest.ID)
+	}
+
+	data := make([]map[string]any, len(items))
+	for i, item := range items {
+		data[i] = map[string]any{
+			"id":         item.ID.String(),
+			"template":   item.Template.Name,
+			"category":   item.Category,
+			"data":       item.Data,
+			"created_at": item.CreatedAt,
+		}
+	}
+
+	// The digest is written in the locale of the latest item
+	latest := items[len(items)-1]
+	template := digest.Rule.DigestTemplate
+
+	return &model.Notification{
+		ApplicationID: digest.ApplicationID,
+		TemplateID:    template.ID,
+		ContactID:     &digest.ContactID,
+		Channel:       template.Channel,
+		Category:      template.Category,
+		Locale:        latest.Locale,
+		Data:          map[string]any{"items": data, "count": len(items)},
+		DedupeKey:     "digest:" + digest.ID.String(),
+		Status:        model.NotificationStatusQueued,
+		StatusHistory: []model.NotificationStatusChange{{Status: model.NotificationStatusQueued}},
+	}, nil
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"hermes-api/internal/model"
+	"hermes-api/internal/repository"
+	"hermes-api/pkg/errorx"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// memoryDigestRepo holds digest rules and the items of open digests, flushing builds the digest
+// notification the way the database does
+type memoryDigestRepo struct {
+	repository.DigestRepository
+	rules    map[uuid.UUID]*model.DigestRule
+	digests  map[uuid.UUID]*model.Digest
+	items    map[uuid.UUID][]*model.Notification
+	failing  map[uuid.UUID]bool // Digests whose flush fails
+	sent     []*model.Notification
+	attempts []uuid.UUID // Digests a flush was attempted for
+}
+
+func newMemoryDigestRepo(rules ...*model.DigestRule) *memoryDigestRepo {
+	repo := &memoryDigestRepo{
+		rules:   make(map[uuid.UUID]*model.DigestRule),
+		digests: make(map[uuid.UUID]*model.Digest),
+		items:   make(map[uuid.UUID][]*model.Notification),
+		failing: make(map[uuid.UUID]bool),
+	}
+	for _, rule := range rules {
+		repo.rules[rule.ID] = rule
+	}
+	return repo
+}
+
+func (r *memoryDigestRepo) GetRuleByApplication(ctx context.Context, applicationID, id uuid.UUID) (*model.DigestRule, error) {
+	rule, ok := r.rules[id]
+	if !ok || rule.ApplicationID != applicationID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return rule, nil
+}
+
+func (r *memoryDigestRepo) ListDue(ctx context.Context, limit int) ([]*model.Digest, error) {
+	var due []*model.Digest
+	for _, digest := range r.digests {
+		if digest.Status == model.DigestStatusOpen && len(due) < limit {
+			due = append(due, digest)
+		}
+	}
+	return due, nil
+}
+
+func (r *memoryDigestRepo) AddItem(ctx context.Context, rule *model.DigestRule, notification *model.Notification) (*model.Digest, error) {
+	if _, ok := r.rules[rule.ID]; !ok {
+		return nil, nil
+	}
+	for _, digest := range r.digests {
+		if digest.RuleID == rule.ID && digest.ContactID == *notification.ContactID && digest.Status == model.DigestStatusOpen {
+			digest.ItemCount++
+			r.items[digest.ID] = append(r.items[digest.ID], notification)
+			return digest, nil
+		}
+	}
+	digest := &model.Digest{ID: uuid.New(), ApplicationID: rule.ApplicationID, RuleID: rule.ID, Rule: *rule, ContactID: *notification.ContactID, Status: model.DigestStatusOpen, ItemCount: 1}
+	r.digests[digest.ID] = digest
+	r.items[digest.ID] = []*model.Notification{notification}
+	return digest, nil
+}
+
+func (r *memoryDigestRepo) Flush(ctx context.Context, id uuid.UUID, build repository.DigestBuilder) error {
+	r.attempts = append(r.attempts, id)
+	digest := r.digests[id]
+	if r.failing[id] {
+		return errors.New("connection reset")
+	}
+	if digest.Status != model.DigestStatusOpen {
+		return nil
+	}
+	notification, err := build(digest, r.items[id])
+	if err != nil {
+		return err
+	}
+	notification.ID = uuid.New()
+	r.sent = append(r.sent, notification)
+	digest.Status = model.DigestStatusFlushed
+	digest.NotificationID = &notification.ID
+	return nil
+}
+
+func (r *memoryDigestRepo) DeleteRule(ctx context.Context, id uuid.UUID, build repository.DigestBuilder) error {
+	for digestID, digest := range r.digests {
+		if digest.RuleID == id {
+			if err := r.Flush(ctx, digestID, build); err != nil {
+				return err
+			}
+		}
+	}
+	delete(r.rules, id)
+	return nil
+}
+
+func TestBuildDigestNotification(t *testing.T) {
+	contact := uuid.New()
+	digestTemplate := model.Template{ID: uuid.New(), Name: "daily-digest", Channel: model.ChannelEmail, Category: "digests"}
+	digest := &model.Digest{ID: uuid.New(), ApplicationID: uuid.New(), ContactID: contact, Rule: model.DigestRule{DigestTemplate: digestTemplate}}
+	created := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	items := []*model.Notification{
+		{ID: uuid.New(), Template: model.Template{Name: "comment"}, Category: "social", Locale: "en", Data: map[string]any{"author": "Ada"}, CreatedAt: created},
+		{ID: uuid.New(), Template: model.Template{Name: "like"}, Category: "social", Locale: "fr", Data: map[string]any{"count": 3}, CreatedAt: created.Add(time.Hour)},
+	}
+
+	notification, err := buildDigestNotification(digest, items)
+	require.NoError(t, err)
+	assert.Equal(t, digest.ApplicationID, notification.ApplicationID)
+	assert.Equal(t, digestTemplate.ID, notification.TemplateID)
+	assert.Equal(t, model.ChannelEmail, notification.Channel)
+	assert.Equal(t, "digests", notification.Category)
+	assert.Equal(t, &contact, notification.ContactID)
+	assert.Equal(t, "fr", notification.Locale, "digests are written in the locale of the latest item")
+	assert.Equal(t, "digest:"+digest.ID.String(), notification.DedupeKey)
+	assert.Equal(t, model.NotificationStatusQueued, notification.Status)
+	assert.Equal(t, 2, notification.Data["count"])
+	assert.Equal(t, []map[string]any{
+		{"id": items[0].ID.String(), "template": "comment", "category": "social", "data": map[string]any{"author": "Ada"}, "created_at": created},
+		{"id": items[1].ID.String(), "template": "like", "category": "social", "data": map[string]any{"count": 3}, "created_at": created.Add(time.Hour)},
+	}, notification.Data["items"])
+
+	t.Run("empty digest", func(t *testing.T) {
+		_, err := buildDigestNotification(digest, nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestDigestCollect(t *testing.T) {
+	application := uuid.New()
+	contact := uuid.New()
+	rule := &model.DigestRule{ID: uuid.New(), ApplicationID: application, MaxItems: 3, DigestTemplate: model.Template{ID: uuid.New(), Channel: model.ChannelEmail}}
+	repo := newMemoryDigestRepo(rule)
+	service := NewDigestService(repo, nil)
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		notification := &model.Notification{ID: uuid.New(), ApplicationID: application, ContactID: &contact, Status: model.NotificationStatusQueued}
+		require.NoError(t, service.Collect(ctx, rule, notification))
+		if i < 2 {
+			assert.Empty(t, repo.sent, "the digest waits for its window before %d items", rule.MaxItems)
+		}
+	}
+
+	// The third item sends the digest, the fourth opens a new one
+	require.Len(t, repo.sent, 1)
+	assert.Equal(t, 3, repo.sent[0].Data["count"])
+	open, err := repo.ListDue(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, open, 1)
+	assert.Equal(t, 1, open[0].ItemCount)
+
+	t.Run("deleted rule", func(t *testing.T) {
+		deleted := &model.DigestRule{ID: uuid.New(), ApplicationID: application, MaxItems: 1}
+		notification := &model.Notification{ID: uuid.New(), ApplicationID: application, ContactID: &contact}
+		require.NoError(t, service.Collect(ctx, deleted, notification), "the notification is delivered on its own")
+		assert.Len(t, repo.sent, 1)
+	})
+}
+
+func TestDigestFlushDue(t *testing.T) {
+	rule := &model.DigestRule{ID: uuid.New(), DigestTemplate: model.Template{ID: uuid.New(), Channel: model.ChannelEmail}}
+	repo := newMemoryDigestRepo(rule)
+	service := NewDigestService(repo, nil)
+	ctx := context.Background()
+
+	var digests []*model.Digest
+	for i := 0; i < 3; i++ {
+		contact := uuid.New()
+		digest, err := repo.AddItem(ctx, rule, &model.Notification{ID: uuid.New(), ContactID: &contact})
+		require.NoError(t, err)
+		digests = append(digests, digest)
+	}
+	repo.failing[digests[0].ID] = true
+
+	flushed, err := service.FlushDue(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 3, flushed)
+	assert.Len(t, repo.attempts, 3, "a failing digest does not hold back the others")
+	assert.Len(t, repo.sent, 2)
+	assert.Equal(t, model.DigestStatusOpen, digests[0].Status, "the failed digest is retried on the next poll")
+
+	delete(repo.failing, digests[0].ID)
+	flushed, err = service.FlushDue(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, flushed)
+	assert.Len(t, repo.sent, 3)
+}
+
+func TestDeleteDigestRule(t *testing.T) {
+	application := &model.Application{ID: uuid.New()}
+	contact := uuid.New()
+	ctx := context.Background()
+
+	t.Run("open digests are sent", func(t *testing.T) {
+		rule := &model.DigestRule{ID: uuid.New(), ApplicationID: application.ID, DigestTemplate: model.Template{ID: uuid.New(), Channel: model.ChannelEmail}}
+		repo := newMemoryDigestRepo(rule)
+		service := NewDigestService(repo, nil)
+		for i := 0; i < 2; i++ {
+			_, err := repo.AddItem(ctx, rule, &model.Notification{ID: uuid.New(), ContactID: &contact})
+			require.NoError(t, err)
+		}
+
+		require.NoError(t, service.DeleteDigestRule(ctx, application, rule.ID))
+		assert.NotContains(t, repo.rules, rule.ID)
+		require.Len(t, repo.sent, 1, "the collected notifications are delivered")
+		assert.Equal(t, 2, repo.sent[0].Data["count"])
+	})
+
+	t.Run("rule of another application", func(t *testing.T) {
+		rule := &model.DigestRule{ID: uuid.New(), ApplicationID: uuid.New()}
+		repo := newMemoryDigestRepo(rule)
+		err := NewDigestService(repo, nil).DeleteDigestRule(ctx, application, rule.ID)
+
+		var appErr *errorx.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, errorx.ErrorTypeNotFound, appErr.Type)
+		assert.Contains(t, repo.rules, rule.ID)
+	})
+
+	t.Run("digest that cannot be sent", func(t *testing.T) {
+		rule := &model.DigestRule{ID: uuid.New(), ApplicationID: application.ID}
+		repo := newMemoryDigestRepo(rule)
+		digest, err := repo.AddItem(ctx, rule, &model.Notification{ID: uuid.New(), ContactID: &contact})
+		require.NoError(t, err)
+		repo.failing[digest.ID] = true
+
+		err = NewDigestService(repo, nil).DeleteDigestRule(ctx, application, rule.ID)
+		var appErr *errorx.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, errorx.ErrorTypeInternal, appErr.Type)
+		assert.Contains(t, repo.rules, rule.ID, "the rule is kept with its digest")
+	})
+}
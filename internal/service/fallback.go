@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"hermes-api/internal/model"
+	"hermes-api/internal/repository"
+	"hermes-api/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// fallbackQueue queues the notifications of fallback chains, after a delivery or when a provider
+// reports that a sent notification failed
+type fallbackQueue struct {
+	notificationRepo repository.NotificationRepository
+	templateRepo     repository.TemplateRepository
+}
+
+// queue queues the next notification of the fallback chain when its condition is met by the outcome
+// of the delivery. A notification sent while the step waits for delivery is followed by a fallback
+// deferred by the step's delay, which is skipped if any notification of the chain was delivered by then.
+func (q *fallbackQueue) queue(ctx context.Context, notification *model.Notification, noAddress bool) error {
+	if len(notification.FallbackChain) == 0 || notification.ContactID == nil {
+		return nil
+	}
+	step := notification.FallbackChain[0]
+	if !step.Matches(notification.Status, noAddress) {
+		return nil
+	}
+
+	template, err := q.templateRepo.GetByName(ctx, notification.ApplicationID, step.Template)
+	if err != nil {
+		return fmt.Errorf("fallback template '%s': %w", step.Template, err)
+	}
+
+	root := notification.ID
+	if notification.ParentID != nil {
+		root = *notification.ParentID
+	}
+
+	fallback := &model.Notification{
+		ApplicationID: notification.ApplicationID,
+		TemplateID:    template.ID,
+		ContactID:     notification.ContactID,
+		Channel:       template.Channel,
+		Category:      template.Category,
+		Locale:        notification.Locale,
+		Data:          notification.Data,
+		Metadata:      notification.Metadata,
+		Urgent:        notification.Urgent,
+		Priority:      notification.Priority,
+		ParentID:      &root,
+		FallbackOf:    &notification.ID,
+		FallbackStep:  notification.FallbackStep + 1,
+		FallbackChain: notification.FallbackChain[1:],
+		Status:        model.NotificationStatusQueued,
+	}
+
+	reason := fmt.Sprintf("Fallback of notification %s (%s)", notification.ID, step.Condition)
+	if notification.Status == model.NotificationStatusSent {
+		scheduledAt := time.Now().UTC().Add(step.After())
+		fallback.Status = model.NotificationStatusDeferred
+		fallback.ScheduledAt = &scheduledAt
+		reason = fmt.Sprintf("Fallback of notification %s unless delivered by %s", notification.ID, scheduledAt.Format(time.RFC3339))
+	}
+	fallback.StatusHistory = []model.NotificationStatusChange{{Status: fallback.Status, Reason: reason}}
+
+	// A notification has a single fallback, e.g. a failure reported twice does not queue another one
+	err = q.notificationRepo.Create(ctx, fallback)
+	if repository.IsUniqueViolation(err) {
+		logger.Debug("Fallback notification already queued", zap.String("notification_id", notification.ID.String()))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.WithNotification(notification.ID.String(), notification.Recipient, string(notification.Channel)).
+		Info("Fallback notification queued",
+			zap.String("fallback_id", fallback.ID.String()),
+			zap.String("fallback_channel", string(fallback.Channel)),
+			zap.String("condition", string(step.Condition)),
+		)
+	return nil
+}
+
+// reported runs the fallback chain of a sent notification its provider reported as failed or bounced.
+// The fallback of a not_delivered step was deferred when the notification was sent, it is sent right
+// away instead of queuing another one.
+func (q *fallbackQueue) reported(ctx context.Context, notification *model.Notification) error {
+	if len(notification.FallbackChain) == 0 || notification.ContactID == nil {
+		return nil
+	}
+	step := notification.FallbackChain[0]
+	if !step.Matches(notification.Status, false) {
+		return nil
+	}
+
+	if step.Condition == model.FallbackConditionNotDelivered {
+		return q.notificationRepo.ExpediteFallback(ctx, notification)
+	}
+	return q.queue(ctx, notification, false)
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hermes-api/internal/model"
+	"hermes-api/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fallbackNotificationRepo records the fallbacks created and expedited
+type fallbackNotificationRepo struct {
+	repository.NotificationRepository
+	created   []*model.Notification
+	createErr error
+	expedited []uuid.UUID
+}
+
+func (r *fallbackNotificationRepo) Create(ctx context.Context, notification *model.Notification) error {
+	if r.createErr != nil {
+		return r.createErr
+	}
+	r.created = append(r.created, notification)
+	return nil
+}
+
+func (r *fallbackNotificationRepo) ExpediteFallback(ctx context.Context, notification *model.Notification) error {
+	r.expedited = append(r.expedited, notification.ID)
+	return nil
+}
+
+// fallbackTemplateRepo returns an SMS template for any name
+type fallbackTemplateRepo struct {
+	repository.TemplateRepository
+}
+
+func (r *fallbackTemplateRepo) GetByName(ctx context.Context, applicationID uuid.UUID, name string) (*model.Template, error) {
+	return &model.Template{ID: uuid.New(), ApplicationID: applicationID, Name: name, Channel: model.ChannelSMS}, nil
+}
+
+func TestFallbackQueue(t *testing.T) {
+	root := uuid.New()
+	contact := uuid.New()
+	chain := []model.FallbackStep{
+		{Template: "sms", Condition: model.FallbackConditionFailed},
+		{Template: "push", Condition: model.FallbackConditionNotDelivered, AfterSeconds: 600},
+	}
+
+	tests := []struct {
+		name         string
+		notification model.Notification
+		reported     bool // Reported by the provider rather than the outcome of the delivery
+		createErr    error
+		wantStep     int // Step of the queued fallback, 0 when none is queued
+		wantStatus   model.NotificationStatus
+		wantExpedite bool
+	}{
+		{
+			name:         "failed notification",
+			notification: model.Notification{ID: root, Status: model.NotificationStatusFailed, FallbackChain: chain},
+			wantStep:     1,
+			wantStatus:   model.NotificationStatusQueued,
+		},
+		{
+			name:         "sent notification does not match a failed step",
+			notification: model.Notification{ID: root, Status: model.NotificationStatusSent, FallbackChain: chain},
+		},
+		{
+			name:         "sent notification waits for delivery",
+			notification: model.Notification{ID: uuid.New(), ParentID: &root, FallbackStep: 1, Status: model.NotificationStatusSent, FallbackChain: chain[1:]},
+			wantStep:     2,
+			wantStatus:   model.NotificationStatusDeferred,
+		},
+		{
+			name:         "reported bounce queues the fallback",
+			notification: model.Notification{ID: root, Status: model.NotificationStatusBounced, FallbackChain: chain},
+			reported:     true,
+			wantStep:     1,
+			wantStatus:   model.NotificationStatusQueued,
+		},
+		{
+			name:         "reported failure expedites the deferred fallback",
+			notification: model.Notification{ID: uuid.New(), ParentID: &root, FallbackStep: 1, Status: model.NotificationStatusFailed, FallbackChain: chain[1:]},
+			reported:     true,
+			wantExpedite: true,
+		},
+		{
+			name:         "fallback already queued",
+			notification: model.Notification{ID: root, Status: model.NotificationStatusFailed, FallbackChain: chain},
+			createErr:    &pgconn.PgError{Code: "23505"},
+		},
+		{
+			name:         "end of the chain",
+			notification: model.Notification{ID: root, Status: model.NotificationStatusFailed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fallbackNotificationRepo{createErr: tt.createErr}
+			queue := &fallbackQueue{notificationRepo: repo, templateRepo: &fallbackTemplateRepo{}}
+
+			notification := tt.notification
+			notification.ApplicationID = uuid.New()
+			notification.ContactID = &contact
+
+			var err error
+			if tt.reported {
+				err = queue.reported(context.Background(), &notification)
+			} else {
+				err = queue.queue(context.Background(), &notification, false)
+			}
+			require.NoError(t, err)
+
+			if tt.wantExpedite {
+				assert.Equal(t, []uuid.UUID{notification.ID}, repo.expedited)
+			} else {
+				assert.Empty(t, repo.expedited)
+			}
+
+			if tt.wantStep == 0 {
+				assert.Empty(t, repo.created)
+				return
+			}
+			require.Len(t, repo.created, 1)
+			fallback := repo.created[0]
+			assert.Equal(t, &notification.ID, fallback.FallbackOf)
+			assert.Equal(t, tt.wantStep, fallback.FallbackStep)
+			assert.Equal(t, &root, fallback.ParentID, "fallbacks are linked to the notification the chain started from")
+			assert.Empty(t, fallback.DedupeKey, "the dedupe key is left to the caller")
+			assert.Equal(t, notification.FallbackChain[1:], fallback.FallbackChain)
+			assert.Equal(t, tt.wantStatus, fallback.Status)
+			if tt.wantStatus == model.NotificationStatusDeferred {
+				require.NotNil(t, fallback.ScheduledAt)
+				assert.WithinDuration(t, time.Now().Add(10*time.Minute), *fallback.ScheduledAt, time.Minute)
+			}
+		})
+	}
+
+	t.Run("other errors are returned", func(t *testing.T) {
+		repo := &fallbackNotificationRepo{createErr: &pgconn.PgError{Code: "40001"}}
+		queue := &fallbackQueue{notificationRepo: repo, templateRepo: &fallbackTemplateRepo{}}
+		notification := &model.Notification{ID: root, ContactID: &contact, Status: model.NotificationStatusFailed, FallbackChain: chain}
+		assert.Error(t, queue.queue(context.Background(), notification, false))
+	})
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"hermes-api/config"
+	"hermes-api/internal/dto"
+	"hermes-api/internal/model"
+	"hermes-api/internal/repository"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/logger"
+	"hermes-api/pkg/storage"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// FileService defines the interface for file and attachment business logic
+type FileService interface {
+	UploadFile(ctx context.Context, application *model.Application, filename, contentType string, content io.Reader) (*model.File, error)
+	GetFileByID(ctx context.Context, application *model.Application, id uuid.UUID) (*model.File, error)
+	OpenFile(ctx context.Context, file *model.File) (io.ReadCloser, error)
+	PrepareAttachments(ctx context.Context, application *model.Application, reqs []dto.AttachmentRequest) ([]model.NotificationAttachment, error)
+	DiscardAttachments(ctx context.Context, application *model.Application, reqs []dto.AttachmentRequest, attachments []model.NotificationAttachment)
+}
+
+// attachmentLimits holds the effective attachment limits of an application
+type attachmentLimits struct {
+	maxSize      int64
+	maxCount     int
+	allowedTypes []string
+}
+
+// fileService implements FileService
+type fileService struct {
+	fileRepo repository.FileRepository
+	storage  storage.Storage
+	defaults config.AttachmentsConfig
+}
+
+// NewFileService creates a new file service
+func NewFileService(fileRepo repository.FileRepository, fileStorage storage.Storage, defaults config.AttachmentsConfig) FileService {
+	return &fileService{
+		fileRepo: fileRepo,
+		storage:  fileStorage,
+		defaults: defaults,
+	}
+}
+
+// UploadFile validates and stores an uploaded file so it can be referenced by notifications
+func (s *fileService) UploadFile(ctx context.Context, application *model.Application, filename, contentType string, content io.Reader) (*model.File, error) {
+	limits := s.limits(application)
+
+	// Read one byte more than allowed to detect oversized uploads without buffering them entirely
+	data, err := io.ReadAll(io.LimitReader(content, limits.maxSize+1))
+	if err != nil {
+		appErr := errorx.New(errorx.ErrorTypeBadRequest, errorx.ErrorCodeInvalidFormat, "Failed to read file")
+		return nil, appErr
+	}
+	if int64(len(data)) > limits.maxSize {
+		return nil, errorx.NewWithTemplate(errorx.ErrorTypeValidation, errorx.ErrorCodeAttachmentTooLarge, limits.maxSize)
+	}
+
+	return s.store(ctx, application, limits, filename, contentType, data)
+}
+
+// GetFileByID retrieves a file of the application by ID
+func (s *fileService) GetFileByID(ctx context.Context, application *model.Application, id uuid.UUID) (*model.File, error) {
+	file, err := s.fileRepo.GetByApplication(ctx, application.ID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errorx.NewWithTemplate(errorx.ErrorTypeNotFound, errorx.ErrorCodeFileNotFound, id.String())
+		}
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch file",
+		)
+		return nil, appErr
+	}
+
+	return file, nil
+}
+
+// OpenFile opens the content of a stored file; the caller must close it
+func (s *fileService) OpenFile(ctx context.Context, file *model.File) (io.ReadCloser, error) {
+	content, err := s.storage.Get(ctx, file.StorageKey)
+	if err != nil {
+		return nil, errorx.NewWithTemplate(errorx.ErrorTypeInternal, errorx.ErrorCodeStorageError, err.Error())
+	}
+	return content, nil
+}
+
+// PrepareAttachments resolves the attachments of a send request into stored files, enforcing the
+// application's count, total size and MIME type limits. Inline base64 content is stored first so
+// that every attachment is delivered from the file storage.
+func (s *fileService) PrepareAttachments(ctx context.Context, application *model.Application, reqs []dto.AttachmentRequest) ([]model.NotificationAttachment, error) {
+	if len(reqs) == 0 {
+		return nil, nil
+	}
+
+	limits := s.limits(application)
+	if len(reqs) > limits.maxCount {
+		return nil, errorx.NewWithTemplate(errorx.ErrorTypeValidation, errorx.ErrorCodeAttachmentLimitExceeded, limits.maxCount)
+	}
+
+	// Check all attachments before storing anything
+	var total int64
+	decoded := make([][]byte, len(reqs))
+	referenced := make([]*model.File, len(reqs))
+	contentIDs := make(map[string]bool)
+
+	for i, req := range reqs {
+		if req.ContentID != "" {
+			if contentIDs[req.ContentID] {
+				appErr := errorx.New(errorx.ErrorTypeValidation, errorx.ErrorCodeInvalidValue, "Duplicate attachment content ID")
+				return nil, appErr.WithDetails(map[string]any{fmt.Sprintf("attachments[%d].content_id", i): errorx.ErrorCodeInvalidValue})
+			}
+			contentIDs[req.ContentID] = true
+		}
+
+		if req.FileID != "" {
+			file, err := s.GetFileByID(ctx, application, uuid.MustParse(req.FileID))
+			if err != nil {
+				return nil, err
+			}
+			if !typeAllowed(file.ContentType, limits.allowedTypes) {
+				return nil, errorx.NewWithTemplate(errorx.ErrorTypeValidation, errorx.ErrorCodeAttachmentTypeNotAllowed, file.ContentType)
+			}
+			referenced[i] = file
+			total += file.Size
+			continue
+		}
+
+		data, err := base64.StdEncoding.DecodeString(req.Content)
+		if err != nil {
+			appErr := errorx.New(errorx.ErrorTypeValidation, errorx.ErrorCodeInvalidFormat, "Invalid attachment content")
+			return nil, appErr.WithDetails(map[string]any{fmt.Sprintf("attachments[%d].content", i): errorx.ErrorCodeInvalidFormat})
+		}
+		decoded[i] = data
+		total += int64(len(data))
+	}
+
+	if total > limits.maxSize {
+		return nil, errorx.NewWithTemplate(errorx.ErrorTypeValidation, errorx.ErrorCodeAttachmentTooLarge, limits.maxSize)
+	}
+
+	attachments := make([]model.NotificationAttachment, 0, len(reqs))
+	for i, req := range reqs {
+		file := referenced[i]
+		if file == nil {
+			var err error
+			file, err = s.store(ctx, application, limits, req.Filename, req.ContentType, decoded[i])
+			if err != nil {
+				s.DiscardAttachments(ctx, application, reqs, attachments)
+				return nil, err
+			}
+		}
+
+		attachment := model.NotificationAttachment{
+			FileID:      file.ID,
+			Filename:    file.Filename,
+			ContentType: file.ContentType,
+			Size:        file.Size,
+			Disposition: model.AttachmentDispositionAttachment,
+		}
+		if req.Filename != "" {
+			attachment.Filename = sanitizeFilename(req.Filename)
+		}
+		if req.ContentID != "" {
+			attachment.Disposition = model.AttachmentDispositionInline
+			attachment.ContentID = req.ContentID
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, nil
+}
+
+// DiscardAttachments removes the files PrepareAttachments stored from the inline content of reqs, e.g.
+// when the notification they were prepared for is dropped as a duplicate. Files referenced by ID are kept.
+func (s *fileService) DiscardAttachments(ctx context.Context, application *model.Application, reqs []dto.AttachmentRequest, attachments []model.NotificationAttachment) {
+	for i, attachment := range attachments {
+		if reqs[i].FileID != "" {
+			continue
+		}
+
+		if err := s.fileRepo.Delete(ctx, attachment.FileID); err != nil {
+			logger.Error("Failed to delete discarded file", err, zap.String("file_id", attachment.FileID.String()))
+			continue
+		}
+		_ = s.storage.Delete(ctx, fileKey(application.ID, attachment.FileID))
+	}
+}
+
+// store checks the content type of a file and saves it to the storage and the database
+func (s *fileService) store(ctx context.Context, application *model.Application, limits attachmentLimits, filename, contentType string, data []byte) (*model.File, error) {
+	filename = sanitizeFilename(filename)
+	contentType = detectContentType(filename, contentType, data)
+	if !typeAllowed(contentType, limits.allowedTypes) {
+		return nil, errorx.NewWithTemplate(errorx.ErrorTypeValidation, errorx.ErrorCodeAttachmentTypeNotAllowed, contentType)
+	}
+
+	checksum := sha256.Sum256(data)
+	file := &model.File{
+		ID:            uuid.New(),
+		ApplicationID: application.ID,
+		Filename:      filename,
+		ContentType:   contentType,
+		Size:          int64(len(data)),
+		Checksum:      hex.EncodeToString(checksum[:]),
+	}
+	file.StorageKey = fileKey(application.ID, file.ID)
+
+	if err := s.storage.Put(ctx, file.StorageKey, bytes.NewReader(data)); err != nil {
+		logger.Error("Failed to store file", err, zap.String("application_id", application.ID.String()))
+		return nil, errorx.NewWithTemplate(errorx.ErrorTypeInternal, errorx.ErrorCodeStorageError, "failed to store file")
+	}
+
+	if err := s.fileRepo.Create(ctx, file); err != nil {
+		// Do not leave orphaned content behind
+		_ = s.storage.Delete(ctx, file.StorageKey)
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to create file",
+		)
+		return nil, appErr
+	}
+
+	return file, nil
+}
+
+// fileKey returns the storage key of a file of an application
+func fileKey(applicationID, id uuid.UUID) string {
+	return applicationID.String() + "/" + id.String()
+}
+
+// limits returns the attachment limits of the application, falling back to the platform defaults
+func (s *fileService) limits(application *model.Application) attachmentLimits {
+	limits := attachmentLimits{
+		maxSize:      s.defaults.MaxSize,
+		maxCount:     s.defaults.MaxCount,
+		allowedTypes: s.defaults.AllowedTypes,
+	}
+
+	if application.AttachmentMaxSize > 0 {
+		limits.maxSize = application.AttachmentMaxSize
+	}
+	if len(application.AttachmentAllowedTypes) > 0 {
+		limits.allowedTypes = application.AttachmentAllowedTypes
+	}
+	return limits
+}
+
+// detectContentType returns the declared content type, or guesses it from the file extension and content
+func detectContentType(filename, declared string, data []byte) string {
+	if declared != "" {
+		if mediaType, _, err := mime.ParseMediaType(declared); err == nil {
+			return mediaType
+		}
+	}
+
+	if byExtension := mime.TypeByExtension(filepath.Ext(filename)); byExtension != "" {
+		if mediaType, _, err := mime.ParseMediaType(byExtension); err == nil {
+			return mediaType
+		}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	return mediaType
+}
+
+// typeAllowed reports whether a media type matches one of the allowed types; "image/*" matches any image type
+func typeAllowed(contentType string, allowed []string) bool {
+	for _, pattern := range allowed {
+		if pattern == "*/*" || strings.EqualFold(pattern, contentType) {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeFilename strips any directory part and control characters from a client supplied filename
+func sanitizeFilename(filename string) string {
+	filename = filepath.Base(strings.ReplaceAll(filename, "\\", "/"))
+	filename = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, filename)
+
+	if filename == "" || filename == "." || filename == "/" {
+		return "attachment"
+	}
+	return filename
+}
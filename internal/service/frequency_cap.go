@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"hermes-api/internal/dto"
+	"hermes-api/internal/model"
+	"hermes-api/internal/repository"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/ratelimit"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FrequencyCapService defines the interface for the frequency caps of applications
+type FrequencyCapService interface {
+	CreateFrequencyCap(ctx context.Context, application *model.Application, req dto.CreateFrequencyCapRequest) (*model.FrequencyCap, error)
+	ListFrequencyCaps(ctx context.Context, application *model.Application) ([]*model.FrequencyCap, error)
+	DeleteFrequencyCap(ctx context.Context, application *model.Application, id uuid.UUID) error
+	Check(ctx context.Context, notification *model.Notification) (*model.FrequencyCap, error)
+}
+
+// frequencyCapService implements FrequencyCapService
+type frequencyCapService struct {
+	frequencyCapRepo repository.FrequencyCapRepository
+	window           ratelimit.SlidingWindow
+}
+
+// NewFrequencyCapService creates a new frequency cap service counting notifications in window
+func NewFrequencyCapService(frequencyCapRepo repository.FrequencyCapRepository, window ratelimit.SlidingWindow) FrequencyCapService {
+	return &frequencyCapService{
+		frequencyCapRepo: frequencyCapRepo,
+		window:           window,
+	}
+}
+
+// CreateFrequencyCap adds a frequency cap to the application
+func (s *frequencyCapService) CreateFrequencyCap(ctx context.Context, application *model.Application, req dto.CreateFrequencyCapRequest) (*model.FrequencyCap, error) {
+	frequencyCap := &model.FrequencyCap{
+		ApplicationID: application.ID,
+		Category:      normalizeCategory(req.Category),
+		Channel:       model.Channel(req.Channel),
+		Limit:         req.Limit,
+		WindowSeconds: req.WindowSeconds,
+	}
+
+	if err := s.frequencyCapRepo.Create(ctx, frequencyCap); err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to create frequency cap",
+		)
+		return nil, appErr
+	}
+
+	return frequencyCap, nil
+}
+
+// ListFrequencyCaps retrieves the frequency caps of the application
+func (s *frequencyCapService) ListFrequencyCaps(ctx context.Context, application *model.Application) ([]*model.FrequencyCap, error) {
+	frequencyCaps, err := s.frequencyCapRepo.ListByApplication(ctx, application.ID)
+	if err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch frequency caps",
+		)
+		return nil, appErr
+	}
+
+	return frequencyCaps, nil
+}
+
+// DeleteFrequencyCap removes a frequency cap of the application
+func (s *frequencyCapService) DeleteFrequencyCap(ctx context.Context, application *model.Application, id uuid.UUID) error {
+	frequencyCap, err := s.frequencyCapRepo.GetByApplication(ctx, application.ID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errorx.NewWithTemplate(errorx.ErrorTypeNotFound, errorx.ErrorCodeFrequencyCapNotFound, id.String())
+		}
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch frequency cap",
+		)
+		return appErr
+	}
+
+	if err := s.frequencyCapRepo.Delete(ctx, frequencyCap.ID); err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to delete frequency cap",
+		)
+		return appErr
+	}
+
+	return nil
+}
+
+// Check counts a notification against the caps of its application matching its category and channel,
+// and returns the first cap it exceeds. The notification is counted by every cap only when none is exceeded,
+// so a notification stopped by one cap uses none of the others. A notification is counted once per cap,
+// checking it again when it is retried or deferred does not count it twice.
+func (s *frequencyCapService) Check(ctx context.Context, notification *model.Notification) (*model.FrequencyCap, error) {
+	frequencyCaps, err := s.frequencyCapRepo.ListByApplication(ctx, notification.ApplicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	recipient := notification.Recipient
+	if notification.ContactID != nil {
+		recipient = notification.ContactID.String()
+	}
+
+	var matching []*model.FrequencyCap
+	var limits []ratelimit.Limit
+	for _, frequencyCap := range frequencyCaps {
+		if !frequencyCap.Matches(notification.Category, notification.Channel) {
+			continue
+		}
+		matching = append(matching, frequencyCap)
+		limits = append(limits, ratelimit.Limit{
+			Key:    frequencyCap.ID.String() + ":" + recipient,
+			Limit:  frequencyCap.Limit,
+			Window: frequencyCap.Window(),
+		})
+	}
+	if len(limits) == 0 {
+		return nil, nil
+	}
+
+	reached, err := s.window.Allow(ctx, notification.ID.String(), limits)
+	if err != nil {
+		return nil, err
+	}
+	if reached < 0 {
+		return nil, nil
+	}
+	return matching[reached], nil
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"hermes-api/internal/model"
+	"hermes-api/internal/repository"
+	"hermes-api/pkg/ratelimit"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capRepo returns the frequency caps of every application
+type capRepo struct {
+	repository.FrequencyCapRepository
+	caps []*model.FrequencyCap
+}
+
+func (r *capRepo) ListByApplication(ctx context.Context, applicationID uuid.UUID) ([]*model.FrequencyCap, error) {
+	return r.caps, nil
+}
+
+// fakeWindow is a sliding window kept in memory with a clock the test moves
+type fakeWindow struct {
+	now    time.Time
+	events map[string]map[string]time.Time // Time of the events recorded under each key
+	calls  int
+	err    error
+}
+
+func newFakeWindow() *fakeWindow {
+	return &fakeWindow{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), events: make(map[string]map[string]time.Time)}
+}
+
+func (w *fakeWindow) Allow(ctx context.Context, event string, limits []ratelimit.Limit) (int, error) {
+	w.calls++
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	for i, limit := range limits {
+		for recorded, at := range w.events[limit.Key] {
+			if !at.After(w.now.Add(-limit.Window)) {
+				delete(w.events[limit.Key], recorded)
+			}
+		}
+		if _, ok := w.events[limit.Key][event]; !ok && len(w.events[limit.Key]) >= limit.Limit {
+			return i, nil
+		}
+	}
+	for _, limit := range limits {
+		if w.events[limit.Key] == nil {
+			w.events[limit.Key] = make(map[string]time.Time)
+		}
+		if _, ok := w.events[limit.Key][event]; !ok {
+			w.events[limit.Key][event] = w.now
+		}
+	}
+	return -1, nil
+}
+
+// count returns the number of events recorded under the key of a cap for recipient
+func (w *fakeWindow) count(frequencyCap *model.FrequencyCap, recipient string) int {
+	return len(w.events[frequencyCap.ID.String()+":"+recipient])
+}
+
+func TestFrequencyCapCheck(t *testing.T) {
+	daily := &model.FrequencyCap{ID: uuid.New(), Category: "marketing", Limit: 3, WindowSeconds: 86400}
+	hourly := &model.FrequencyCap{ID: uuid.New(), Limit: 1, WindowSeconds: 3600}
+	pushOnly := &model.FrequencyCap{ID: uuid.New(), Channel: model.ChannelPush, Limit: 1, WindowSeconds: 60}
+	caps := []*model.FrequencyCap{daily, hourly, pushOnly}
+
+	// send is a notification checked after elapsed, with the cap it must exceed
+	type send struct {
+		category string
+		id       uuid.UUID // A new notification when nil
+		elapsed  time.Duration
+		want     *model.FrequencyCap
+	}
+
+	first := uuid.New()
+	tests := []struct {
+		name      string
+		sends     []send
+		wantDaily int // Notifications counted by the daily and hourly caps once sent
+		wantHour  int
+	}{
+		{
+			name:      "counted by every matching cap",
+			sends:     []send{{category: "marketing"}},
+			wantDaily: 1,
+			wantHour:  1,
+		},
+		{
+			name:      "caps of other categories are not checked",
+			sends:     []send{{category: "receipts"}},
+			wantDaily: 0,
+			wantHour:  1,
+		},
+		{
+			name: "stopped by a cap without counting for the others",
+			sends: []send{
+				{category: "marketing"},
+				{category: "marketing", want: hourly},
+				{category: "marketing", want: hourly},
+			},
+			wantDaily: 1,
+			wantHour:  1,
+		},
+		{
+			name: "first cap reached is reported",
+			sends: []send{
+				{category: "marketing"},
+				{category: "marketing", elapsed: time.Hour},
+				{category: "marketing", elapsed: time.Hour},
+				{category: "marketing", elapsed: time.Hour, want: daily},
+			},
+			wantDaily: 3,
+			wantHour:  1,
+		},
+		{
+			name: "retried notification is counted once",
+			sends: []send{
+				{category: "marketing", id: first},
+				{category: "marketing", id: first, elapsed: time.Minute},
+				{category: "marketing", id: first, elapsed: time.Minute},
+			},
+			wantDaily: 1,
+			wantHour:  1,
+		},
+		{
+			name: "window slides",
+			sends: []send{
+				{category: "marketing"},
+				{category: "marketing", elapsed: 59 * time.Minute, want: hourly},
+				{category: "marketing", elapsed: time.Minute},
+			},
+			wantDaily: 2,
+			wantHour:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window := newFakeWindow()
+			service := NewFrequencyCapService(&capRepo{caps: caps}, window)
+
+			for i, step := range tt.sends {
+				window.now = window.now.Add(step.elapsed)
+				id := step.id
+				if id == uuid.Nil {
+					id = uuid.New()
+				}
+				notification := &model.Notification{ID: id, Category: step.category, Channel: model.ChannelEmail, Recipient: "ada@example.com"}
+
+				got, err := service.Check(context.Background(), notification)
+				require.NoError(t, err, "send %d", i)
+				assert.Equal(t, step.want, got, "send %d", i)
+			}
+
+			assert.Equal(t, tt.wantDaily, window.count(daily, "ada@example.com"), "daily")
+			assert.Equal(t, tt.wantHour, window.count(hourly, "ada@example.com"), "hourly")
+			assert.Zero(t, window.count(pushOnly, "ada@example.com"), "caps of other channels are not counted")
+		})
+	}
+
+	t.Run("contacts are counted across addresses", func(t *testing.T) {
+		window := newFakeWindow()
+		service := NewFrequencyCapService(&capRepo{caps: caps}, window)
+		contact := uuid.New()
+
+		got, err := service.Check(context.Background(), &model.Notification{ID: uuid.New(), ContactID: &contact, Channel: model.ChannelEmail, Recipient: "ada@example.com"})
+		require.NoError(t, err)
+		assert.Nil(t, got)
+
+		got, err = service.Check(context.Background(), &model.Notification{ID: uuid.New(), ContactID: &contact, Channel: model.ChannelEmail, Recipient: "ada@work.example.com"})
+		require.NoError(t, err)
+		assert.Equal(t, hourly, got)
+		assert.Equal(t, 1, window.count(hourly, contact.String()))
+	})
+
+	t.Run("no matching caps", func(t *testing.T) {
+		window := newFakeWindow()
+		service := NewFrequencyCapService(&capRepo{caps: []*model.FrequencyCap{pushOnly}}, window)
+
+		got, err := service.Check(context.Background(), &model.Notification{ID: uuid.New(), Channel: model.ChannelSMS, Recipient: "+15551234567"})
+		require.NoError(t, err)
+		assert.Nil(t, got)
+		assert.Zero(t, window.calls, "the window is not used without caps")
+	})
+
+	t.Run("window errors are returned", func(t *testing.T) {
+		window := newFakeWindow()
+		window.err = errors.New("connection refused")
+		service := NewFrequencyCapService(&capRepo{caps: caps}, window)
+
+		_, err := service.Check(context.Background(), &model.Notification{ID: uuid.New(), Channel: model.ChannelEmail, Recipient: "ada@example.com"})
+		assert.ErrorIs(t, err, window.err)
+	})
+}
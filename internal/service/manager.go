@@ -2,7 +2,15 @@
 package service
 
 import (
+	"hermes-api/config"
 	"hermes-api/internal/repository"
+	"hermes-api/pkg/envelope"
+	"hermes-api/pkg/logger"
+	"hermes-api/pkg/ratelimit"
+	"hermes-api/pkg/signer"
+	"hermes-api/pkg/storage"
+
+	"github.com/redis/go-redis/v9"
 )
 
 // ServiceManager manages all services
@@ -10,21 +18,88 @@ type ServiceManager interface {
 	User() UserService
 	Auth() AuthService
 	Application() ApplicationService
+	Template() TemplateService
+	Notification() NotificationService
+	File() FileService
+	Contact() ContactService
+	ContactImport() ContactImportService
+	PreferenceCenter() PreferenceCenterService
+	Suppression() SuppressionService
+	FrequencyCap() FrequencyCapService
+	Digest() DigestService
+	ProviderHealth() ProviderHealthService
+	ProviderCredential() ProviderCredentialService
+	DeliveryEvent() DeliveryEventService
+	Tracking() TrackingService
+	Analytics() AnalyticsService
 }
 
 // serviceManager implements ServiceManager
 type serviceManager struct {
-	userService        UserService
-	authService        AuthService
-	applicationService ApplicationService
+	userService               UserService
+	authService               AuthService
+	applicationService        ApplicationService
+	templateService           TemplateService
+	notificationService       NotificationService
+	fileService               FileService
+	contactService            ContactService
+	contactImportService      ContactImportService
+	preferenceCenterService   PreferenceCenterService
+	suppressionService        SuppressionService
+	frequencyCapService       FrequencyCapService
+	digestService             DigestService
+	providerHealthService     ProviderHealthService
+	providerCredentialService ProviderCredentialService
+	deliveryEventService      DeliveryEventService
+	trackingService           TrackingService
+	analyticsService          AnalyticsService
 }
 
-// NewServiceManager creates a new service manager using a RepositoryManager
-func NewServiceManager(repoManager repository.RepositoryManager, jwtSecret string) ServiceManager {
+// NewServiceManager creates a new service manager using a RepositoryManager, shared counters are kept
+// in Redis when redisClient is not nil
+func NewServiceManager(repoManager repository.RepositoryManager, cfg *config.Config, fileStorage storage.Storage, redisClient *redis.Client) ServiceManager {
+	fileService := NewFileService(repoManager.File(), fileStorage, cfg.Attachments)
+	contactService := NewContactService(repoManager.Contact())
+	linkSigner := signer.New(cfg.Security.LinkSecret)
+	preferenceCenterService := NewPreferenceCenterService(repoManager.Application(), repoManager.Contact(), repoManager.Template(),
+		contactService, linkSigner, cfg.Server.PublicURL, cfg.Security.PreferenceLinkTTL)
+
+	var frequencyWindow ratelimit.SlidingWindow = repoManager.FrequencyCap()
+	if redisClient != nil {
+		frequencyWindow = ratelimit.NewRedisSlidingWindow(redisClient, "hermes:frequency:")
+	}
+
+	digestService := NewDigestService(repoManager.Digest(), repoManager.Template())
+
+	// Provider credentials are unavailable rather than stored unencrypted when the keys are misconfigured
+	credentialKeyring, err := envelope.NewKeyring(cfg.Security.CredentialKeyID, cfg.Security.CredentialKeys)
+	if err != nil {
+		logger.Error("Invalid credential keys, provider credentials are unavailable", err)
+	}
+
+	suppressionService := NewSuppressionService(repoManager.Suppression())
+	providerCredentialService := NewProviderCredentialService(repoManager.ProviderCredential(), credentialKeyring)
+
 	return &serviceManager{
-		userService:        NewUserService(repoManager.User()),
-		authService:        NewAuthService(repoManager.User(), jwtSecret),
-		applicationService: NewApplicationService(repoManager.Application()),
+		userService:               NewUserService(repoManager.User()),
+		authService:               NewAuthService(repoManager.User(), cfg.Security.JWTSecret),
+		applicationService:        NewApplicationService(repoManager.Application()),
+		templateService:           NewTemplateService(repoManager.Template()),
+		notificationService:       NewNotificationService(repoManager.Notification(), repoManager.Template(), repoManager.TrackingEvent(), contactService, fileService, digestService, cfg.Notifications.DedupeWindow),
+		fileService:               fileService,
+		contactService:            contactService,
+		contactImportService:      NewContactImportService(repoManager.Contact(), repoManager.ContactImport(), fileStorage),
+		preferenceCenterService:   preferenceCenterService,
+		suppressionService:        suppressionService,
+		frequencyCapService:       NewFrequencyCapService(repoManager.FrequencyCap(), frequencyWindow),
+		digestService:             digestService,
+		providerHealthService:     NewProviderHealthService(repoManager.ProviderStat(), cfg.Providers, cfg.ProviderHealth),
+		providerCredentialService: providerCredentialService,
+		deliveryEventService: NewDeliveryEventService(repoManager.Notification(), repoManager.Template(), repoManager.ProviderCredential(), providerCredentialService,
+			suppressionService, cfg.Webhooks.Secrets, cfg.Server.PublicURL),
+		trackingService: NewTrackingService(repoManager.Notification(), repoManager.Contact(), repoManager.TrackingEvent(),
+			linkSigner, cfg.Server.PublicURL, cfg.Tracking),
+		analyticsService: NewAnalyticsService(repoManager.DeliveryRollup(), repoManager.Template(), repoManager.Application(), cfg.Analytics),
 	}
 }
 
@@ -42,3 +117,73 @@ func (sm *serviceManager) Auth() AuthService {
 func (sm *serviceManager) Application() ApplicationService {
 	return sm.applicationService
 }
+
+// Template returns the template service
+func (sm *serviceManager) Template() TemplateService {
+	return sm.templateService
+}
+
+// Notification returns the notification service
+func (sm *serviceManager) Notification() NotificationService {
+	return sm.notificationService
+}
+
+// File returns the file service
+func (sm *serviceManager) File() FileService {
+	return sm.fileService
+}
+
+// Contact returns the contact service
+func (sm *serviceManager) Contact() ContactService {
+	return sm.contactService
+}
+
+// ContactImport returns the contact import service
+func (sm *serviceManager) ContactImport() ContactImportService {
+	return sm.contactImportService
+}
+
+// PreferenceCenter returns the preference center service
+func (sm *serviceManager) PreferenceCenter() PreferenceCenterService {
+	return sm.preferenceCenterService
+}
+
+// Suppression returns the suppression service
+func (sm *serviceManager) Suppression() SuppressionService {
+	return sm.suppressionService
+}
+
+// FrequencyCap returns the frequency cap service
+func (sm *serviceManager) FrequencyCap() FrequencyCapService {
+	return sm.frequencyCapService
+}
+
+// Digest returns the digest service
+func (sm *serviceManager) Digest() DigestService {
+	return sm.digestService
+}
+
+// ProviderHealth returns the provider health service
+func (sm *serviceManager) ProviderHealth() ProviderHealthService {
+	return sm.providerHealthService
+}
+
+// ProviderCredential returns the provider credential service
+func (sm *serviceManager) ProviderCredential() ProviderCredentialService {
+	return sm.providerCredentialService
+}
+
+// DeliveryEvent returns the delivery event service
+func (sm *serviceManager) DeliveryEvent() DeliveryEventService {
+	return sm.deliveryEventService
+}
+
+// Tracking returns the tracking service
+func (sm *serviceManager) Tracking() TrackingService {
+	return sm.trackingService
+}
+
+// Analytics returns the analytics service
+func (sm *serviceManager) Analytics() AnalyticsService {
+	return sm.analyticsService
+}
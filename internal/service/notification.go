@@ -0,0 +1,470 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"hermes-api/internal/dto"
+	"hermes-api/internal/model"
+	"hermes-api/internal/repository"
+	"hermes-api/internal/validation"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/i18n"
+	"hermes-api/pkg/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// NotificationService defines the interface for notification business logic
+type NotificationService interface {
+	SendNotification(ctx context.Context, application *model.Application, req dto.SendNotificationRequest) (*model.Notification, error)
+	GetNotification(ctx context.Context, application *model.Application, id uuid.UUID) (*model.Notification, error)
+	ListAttempts(ctx context.Context, application *model.Application, id uuid.UUID) ([]*model.NotificationAttempt, error)
+	ListTrackingEvents(ctx context.Context, application *model.Application, id uuid.UUID) ([]*model.TrackingEvent, error)
+	ListNotifications(ctx context.Context, userID uuid.UUID, query dto.ListNotificationsQuery) (*NotificationPage, error)
+	GetUserNotification(ctx context.Context, userID, id uuid.UUID) (*model.Notification, error)
+	ListUserAttempts(ctx context.Context, userID, id uuid.UUID) ([]*model.NotificationAttempt, error)
+}
+
+// NotificationPage is a page of the notification list with what is needed to request the next one
+type NotificationPage struct {
+	Notifications []*model.Notification
+	Limit         int
+	HasNext       bool
+	NextCursor    string
+	SortBy        string
+	SortOrder     string
+	Filters       map[string]any // The filters applied, as given in the query
+}
+
+// notificationCursor is the opaque cursor of the notification list, it is only valid for the sort it was issued for
+type notificationCursor struct {
+	Sort string `json:"s"`
+	repository.NotificationCursor
+}
+
+// defaultNotificationPageSize is the page size of the notification list when the query sets none
+const defaultNotificationPageSize = 20
+
+// notificationService implements NotificationService
+type notificationService struct {
+	notificationRepo  repository.NotificationRepository
+	templateRepo      repository.TemplateRepository
+	trackingEventRepo repository.TrackingEventRepository
+	contactService    ContactService
+	fileService       FileService
+	digestService     DigestService
+	schemas           *validation.SchemaCache // Data schemas of the templates by template version
+	dedupeWindow      time.Duration
+}
+
+// NewNotificationService creates a new notification service
+func NewNotificationService(notificationRepo repository.NotificationRepository, templateRepo repository.TemplateRepository, trackingEventRepo repository.TrackingEventRepository, contactService ContactService, fileService FileService, digestService DigestService, dedupeWindow time.Duration) NotificationService {
+	return &notificationService{
+		notificationRepo:  notificationRepo,
+		templateRepo:      templateRepo,
+		trackingEventRepo: trackingEventRepo,
+		contactService:    contactService,
+		fileService:       fileService,
+		digestService:     digestService,
+		schemas:           validation.NewSchemaCache(),
+		dedupeWindow:      dedupeWindow,
+	}
+}
+
+// SendNotification validates a send request against its template and queues the notification
+func (s *notificationService) SendNotification(ctx context.Context, application *model.Application, req dto.SendNotificationRequest) (*model.Notification, error) {
+	template, err := s.templateRepo.GetByName(ctx, application.ID, req.Template)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errorx.NewWithTemplate(errorx.ErrorTypeNotFound, errorx.ErrorCodeTemplateNotFound, req.Template)
+		}
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch template",
+		)
+		return nil, appErr
+	}
+
+	data := req.Data
+	if data == nil {
+		data = map[string]any{}
+	}
+
+	// Validate the data payload against the template's schema
+	if template.DataSchema != nil {
+		schema, err := s.schemas.Compile(template.ID.String(), template.UpdatedAt, template.DataSchema)
+		if err != nil {
+			appErr := errorx.New(
+				errorx.ErrorTypeInternal,
+				errorx.ErrorCodeTemplateInvalid,
+				"Template data schema is invalid",
+			)
+			return nil, appErr
+		}
+
+		if err := validation.MapSchemaErrors(schema.Validate("data", data)); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(req.Attachments) > 0 && template.Channel != model.ChannelEmail {
+		return nil, errorx.NewWithTemplate(errorx.ErrorTypeValidation, errorx.ErrorCodeAttachmentsNotSupported, template.Channel)
+	}
+
+	// Only notifications sent to contacts can fall back to other channels
+	var fallbackChain []model.FallbackStep
+	if req.ContactID != "" {
+		fallbackChain = template.Fallbacks
+		if len(req.Fallbacks) > 0 {
+			fallbackChain = dto.FallbackChain(req.Fallbacks)
+
+			details, err := validateFallbackChain(ctx, s.templateRepo, application.ID, template.Name, fallbackChain)
+			if err != nil {
+				return nil, err
+			}
+			if len(details) > 0 {
+				appErr := errorx.New(errorx.ErrorTypeValidation, errorx.ErrorCodeInvalidValue, "Invalid request body")
+				return nil, appErr.WithDetails(details)
+			}
+		}
+	}
+
+	// The address of a contact is resolved by the worker, it only has to exist for now. A contact
+	// without an address is accepted when the fallback chain can send to another channel.
+	var contact *model.Contact
+	if req.ContactID != "" {
+		contact, err = s.contactService.GetContact(ctx, application, req.ContactID)
+		if err != nil {
+			return nil, err
+		}
+		if len(contact.Addresses(template.Channel)) == 0 && len(fallbackChain) == 0 {
+			return nil, errorx.NewWithTemplate(errorx.ErrorTypeValidation, errorx.ErrorCodeContactAddressMissing, contact.ExternalID, template.Channel)
+		}
+	}
+
+	attachments, err := s.fileService.PrepareAttachments(ctx, application, req.Attachments)
+	if err != nil {
+		return nil, err
+	}
+
+	notification := &model.Notification{
+		ApplicationID: application.ID,
+		TemplateID:    template.ID,
+		Channel:       template.Channel,
+		Category:      template.Category,
+		Recipient:     req.Recipient,
+		Locale:        i18n.Normalize(req.Locale),
+		Data:          data,
+		Metadata:      req.Metadata,
+		Urgent:        req.Urgent,
+		Priority:      model.NotificationPriority(req.Priority),
+		Status:        model.NotificationStatusQueued,
+		StatusHistory: []model.NotificationStatusChange{{Status: model.NotificationStatusQueued}},
+		Attachments:   attachments,
+		FallbackChain: fallbackChain,
+	}
+	if contact != nil {
+		notification.ContactID = &contact.ID
+	}
+
+	notification.DedupeKey = req.DedupeKey
+	if notification.DedupeKey == "" && application.AutoDedupe {
+		notification.DedupeKey, err = deriveDedupeKey(notification)
+		if err != nil {
+			s.fileService.DiscardAttachments(ctx, application, req.Attachments, attachments)
+			return nil, err
+		}
+	}
+
+	window := s.dedupeWindow
+	if application.DedupeWindowSeconds > 0 {
+		window = time.Duration(application.DedupeWindowSeconds) * time.Second
+	}
+
+	original, err := s.notificationRepo.CreateDeduplicated(ctx, notification, window)
+	if err != nil {
+		s.fileService.DiscardAttachments(ctx, application, req.Attachments, attachments)
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to create notification",
+		)
+		return nil, appErr
+	}
+
+	if original == nil {
+		s.collectDigest(ctx, notification)
+	} else {
+		// The dropped duplicate does not deliver the files stored for it
+		s.fileService.DiscardAttachments(ctx, application, req.Attachments, attachments)
+	}
+
+	return notification, nil
+}
+
+// collectDigest adds a queued notification to a digest when a digest rule matches it. The notification
+// is already queued, so on failure it is delivered on its own rather than failing the request.
+func (s *notificationService) collectDigest(ctx context.Context, notification *model.Notification) {
+	rule, err := s.digestService.MatchRule(ctx, notification)
+	if err == nil && rule != nil {
+		err = s.digestService.Collect(ctx, rule, notification)
+	}
+	if err != nil {
+		logger.WithNotification(notification.ID.String(), notification.Recipient, string(notification.Channel)).
+			Warn("Failed to add notification to digest, delivering it on its own", zap.Error(err))
+	}
+}
+
+// GetNotification retrieves a notification of the application with its status history and delivery attempts
+func (s *notificationService) GetNotification(ctx context.Context, application *model.Application, id uuid.UUID) (*model.Notification, error) {
+	notification, err := s.notificationRepo.GetByApplication(ctx, application.ID, id)
+	return notificationFound(notification, err, id)
+}
+
+// GetUserNotification retrieves a notification of any application of the user with its status history
+// and delivery attempts
+func (s *notificationService) GetUserNotification(ctx context.Context, userID, id uuid.UUID) (*model.Notification, error) {
+	notification, err := s.notificationRepo.GetByUser(ctx, userID, id)
+	return notificationFound(notification, err, id)
+}
+
+// notificationFound maps the outcome of fetching the notification with the given ID onto the service errors
+func notificationFound(notification *model.Notification, err error, id uuid.UUID) (*model.Notification, error) {
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errorx.NewWithTemplate(errorx.ErrorTypeNotFound, errorx.ErrorCodeNotificationNotFound, id.String())
+		}
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch notification",
+		)
+		return nil, appErr
+	}
+
+	return notification, nil
+}
+
+// ListAttempts retrieves the delivery attempts of a notification of the application
+func (s *notificationService) ListAttempts(ctx context.Context, application *model.Application, id uuid.UUID) ([]*model.NotificationAttempt, error) {
+	notification, err := s.GetNotification(ctx, application, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.listAttempts(ctx, notification)
+}
+
+// ListUserAttempts retrieves the delivery attempts of a notification of any application of the user
+func (s *notificationService) ListUserAttempts(ctx context.Context, userID, id uuid.UUID) ([]*model.NotificationAttempt, error) {
+	notification, err := s.GetUserNotification(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.listAttempts(ctx, notification)
+}
+
+// listAttempts retrieves the delivery attempts of a notification, oldest first
+func (s *notificationService) listAttempts(ctx context.Context, notification *model.Notification) ([]*model.NotificationAttempt, error) {
+	attempts, err := s.notificationRepo.ListAttempts(ctx, notification.ID)
+	if err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch notification attempts",
+		)
+		return nil, appErr
+	}
+
+	return attempts, nil
+}
+
+// ListTrackingEvents retrieves the opens and clicks of a notification of the application, oldest first
+func (s *notificationService) ListTrackingEvents(ctx context.Context, application *model.Application, id uuid.UUID) ([]*model.TrackingEvent, error) {
+	notification, err := s.GetNotification(ctx, application, id)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.trackingEventRepo.ListByNotification(ctx, notification.ID)
+	if err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch notification tracking events",
+		)
+		return nil, appErr
+	}
+
+	return events, nil
+}
+
+// ListNotifications retrieves a page of the notifications of the user's applications matching the query
+func (s *notificationService) ListNotifications(ctx context.Context, userID uuid.UUID, query dto.ListNotificationsQuery) (*NotificationPage, error) {
+	page := &NotificationPage{
+		Limit:     query.Limit,
+		SortBy:    query.SortBy,
+		SortOrder: query.SortOrder,
+		Filters:   make(map[string]any),
+	}
+	if page.Limit == 0 {
+		page.Limit = defaultNotificationPageSize
+	}
+	if page.SortBy == "" {
+		page.SortBy = "created_at"
+	}
+	if page.SortOrder == "" {
+		page.SortOrder = "desc"
+	}
+
+	filter := repository.NotificationFilter{
+		UserID:     userID,
+		Recipient:  strings.TrimSpace(query.Recipient),
+		ExternalID: query.ExternalID,
+		Channel:    model.Channel(query.Channel),
+		Template:   query.Template,
+		SortBy:     page.SortBy,
+		Descending: page.SortOrder == "desc",
+		Limit:      page.Limit + 1,
+	}
+
+	if query.ApplicationID != "" {
+		applicationID, err := uuid.Parse(query.ApplicationID)
+		if err != nil {
+			return nil, errorx.NewValidationError("application_id", query.ApplicationID)
+		}
+		filter.ApplicationID = &applicationID
+		page.Filters["application_id"] = query.ApplicationID
+	}
+	if query.Recipient != "" {
+		page.Filters["recipient"] = query.Recipient
+	}
+	if query.ContactID != "" {
+		contactID, err := uuid.Parse(query.ContactID)
+		if err != nil {
+			return nil, errorx.NewValidationError("contact_id", query.ContactID)
+		}
+		filter.ContactID = &contactID
+		page.Filters["contact_id"] = query.ContactID
+	}
+	if query.ExternalID != "" {
+		page.Filters["external_id"] = query.ExternalID
+	}
+	if len(query.Status) > 0 {
+		for _, status := range query.Status {
+			filter.Statuses = append(filter.Statuses, model.NotificationStatus(status))
+		}
+		page.Filters["status"] = query.Status
+	}
+	if query.Channel != "" {
+		page.Filters["channel"] = query.Channel
+	}
+	if query.Template != "" {
+		page.Filters["template"] = query.Template
+	}
+	for field, value := range map[string]string{"from": query.From, "to": query.To} {
+		if value == "" {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, errorx.NewValidationError(field, value)
+		}
+		if field == "from" {
+			filter.From = &at
+		} else {
+			filter.To = &at
+		}
+		page.Filters[field] = value
+	}
+	if len(query.Metadata) > 0 {
+		filter.Metadata = make(map[string]string, len(query.Metadata))
+		for _, pair := range query.Metadata {
+			key, value, _ := strings.Cut(pair, ":")
+			if key == "" {
+				return nil, errorx.NewValidationError("metadata", pair)
+			}
+			filter.Metadata[key] = value
+		}
+		page.Filters["metadata"] = filter.Metadata
+	}
+
+	sort := page.SortBy + ":" + page.SortOrder
+	if query.Cursor != "" {
+		cursor, err := decodeNotificationCursor(query.Cursor)
+		if err != nil || cursor.Sort != sort {
+			return nil, errorx.NewValidationError("cursor", query.Cursor)
+		}
+		filter.After = &cursor.NotificationCursor
+	}
+
+	notifications, err := s.notificationRepo.Search(ctx, filter)
+	if err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch notifications",
+		)
+		return nil, appErr
+	}
+
+	page.HasNext = len(notifications) > page.Limit
+	if page.HasNext {
+		notifications = notifications[:page.Limit]
+		last := notifications[len(notifications)-1]
+		position := repository.NotificationCursor{Value: last.CreatedAt, ID: last.ID}
+		if page.SortBy == "updated_at" {
+			position.Value = last.UpdatedAt
+		}
+		page.NextCursor = encodeNotificationCursor(notificationCursor{Sort: sort, NotificationCursor: position})
+	}
+	page.Notifications = notifications
+
+	return page, nil
+}
+
+// encodeNotificationCursor encodes a cursor of the notification list
+func encodeNotificationCursor(cursor notificationCursor) string {
+	encoded, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// decodeNotificationCursor decodes a cursor issued by encodeNotificationCursor
+func decodeNotificationCursor(value string) (*notificationCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	var cursor notificationCursor
+	if err := json.Unmarshal(decoded, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// deriveDedupeKey derives the dedupe key of a notification sent without one from its template,
+// recipient, locale and data, so that an event fired twice upstream is delivered once
+func deriveDedupeKey(notification *model.Notification) (string, error) {
+	content, err := json.Marshal(struct {
+		TemplateID uuid.UUID      `json:"t"`
+		ContactID  *uuid.UUID     `json:"c"`
+		Recipient  string         `json:"r"`
+		Locale     string         `json:"l"`
+		Data       map[string]any `json:"d"` // Map keys are sorted by encoding/json
+	}{notification.TemplateID, notification.ContactID, notification.Recipient, notification.Locale, notification.Data})
+	if err != nil {
+		return "", errorx.New(errorx.ErrorTypeValidation, errorx.ErrorCodeInvalidValue, "Notification data cannot be encoded")
+	}
+
+	sum := sha256.Sum256(content)
+	return "auto:" + hex.EncodeToString(sum[:]), nil
+}
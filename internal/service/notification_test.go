@@ -0,0 +1,409 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"math"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"hermes-api/internal/dto"
+	"hermes-api/internal/model"
+	"hermes-api/internal/repository"
+	"hermes-api/pkg/errorx"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationCursor(t *testing.T) {
+	cursor := notificationCursor{
+		Sort: "created_at:desc",
+		NotificationCursor: repository.NotificationCursor{
+			Value: time.Date(2024, 3, 1, 12, 0, 0, 123456000, time.UTC),
+			ID:    uuid.MustParse("7c9e6679-7425-40de-944b-e07fc1f90ae7"),
+		},
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		decoded, err := decodeNotificationCursor(encodeNotificationCursor(cursor))
+		require.NoError(t, err)
+		assert.Equal(t, cursor.Sort, decoded.Sort)
+		assert.Equal(t, cursor.ID, decoded.ID)
+		assert.True(t, decoded.Value.Equal(cursor.Value), "value %s, want %s", decoded.Value, cursor.Value)
+	})
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "not base64", value: "not a cursor!"},
+		{name: "padded base64", value: base64.URLEncoding.EncodeToString([]byte(`{"s":"created_at:desc"}`))},
+		{name: "not JSON", value: base64.RawURLEncoding.EncodeToString([]byte("created_at:desc"))},
+		{name: "invalid ID", value: base64.RawURLEncoding.EncodeToString([]byte(`{"s":"created_at:desc","i":"42"}`))},
+		{name: "invalid time", value: base64.RawURLEncoding.EncodeToString([]byte(`{"s":"created_at:desc","v":"yesterday"}`))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeNotificationCursor(tt.value)
+			assert.Error(t, err)
+		})
+	}
+}
+
+// keysetNotificationRepo searches an in-memory list of notifications the way the database does:
+// sorted by the sort column then the ID, starting after the cursor
+type keysetNotificationRepo struct {
+	repository.NotificationRepository
+	notifications []*model.Notification
+}
+
+func (r *keysetNotificationRepo) Search(ctx context.Context, filter repository.NotificationFilter) ([]*model.Notification, error) {
+	value := func(n *model.Notification) time.Time {
+		if filter.SortBy == "updated_at" {
+			return n.UpdatedAt
+		}
+		return n.CreatedAt
+	}
+	// before reports whether a sorts before b in ascending order
+	before := func(a time.Time, aID uuid.UUID, b time.Time, bID uuid.UUID) bool {
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return aID.String() < bID.String()
+	}
+
+	sorted := append([]*model.Notification(nil), r.notifications...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if filter.Descending {
+			i, j = j, i
+		}
+		return before(value(sorted[i]), sorted[i].ID, value(sorted[j]), sorted[j].ID)
+	})
+
+	var page []*model.Notification
+	for _, n := range sorted {
+		if filter.After != nil {
+			beyond := before(filter.After.Value, filter.After.ID, value(n), n.ID)
+			if filter.Descending {
+				beyond = before(value(n), n.ID, filter.After.Value, filter.After.ID)
+			}
+			if !beyond {
+				continue
+			}
+		}
+		if len(page) == filter.Limit {
+			break
+		}
+		page = append(page, n)
+	}
+	return page, nil
+}
+
+func TestListNotificationsKeysetPages(t *testing.T) {
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	// Notifications sharing a timestamp must neither be skipped nor repeated across pages
+	offsets := []int{0, 1, 1, 1, 2, 3, 3, 4}
+	notifications := make([]*model.Notification, len(offsets))
+	for i, offset := range offsets {
+		notifications[i] = &model.Notification{
+			ID:        uuid.New(),
+			CreatedAt: base.Add(time.Duration(offset) * time.Minute),
+			UpdatedAt: base.Add(time.Duration(len(offsets)-i) * time.Minute),
+		}
+	}
+
+	repo := &keysetNotificationRepo{notifications: notifications}
+	service := &notificationService{notificationRepo: repo}
+
+	tests := []struct {
+		name      string
+		sortBy    string
+		sortOrder string
+		limit     int
+	}{
+		{name: "newest first", limit: 3},
+		{name: "oldest first", sortOrder: "asc", limit: 3},
+		{name: "one per page", sortOrder: "asc", limit: 1},
+		{name: "single page", limit: 20},
+		{name: "exact pages", sortOrder: "desc", limit: 4},
+		{name: "by update", sortBy: "updated_at", sortOrder: "asc", limit: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := dto.ListNotificationsQuery{SortBy: tt.sortBy, SortOrder: tt.sortOrder, Limit: tt.limit}
+			want, _ := repo.Search(context.Background(), repository.NotificationFilter{
+				SortBy:     tt.sortBy,
+				Descending: tt.sortOrder != "asc",
+				Limit:      len(notifications),
+			})
+
+			var got []*model.Notification
+			for pages := 0; ; pages++ {
+				require.LessOrEqual(t, pages, len(notifications), "the last page must be reached")
+
+				page, err := service.ListNotifications(context.Background(), uuid.New(), query)
+				require.NoError(t, err)
+				require.LessOrEqual(t, len(page.Notifications), tt.limit)
+				got = append(got, page.Notifications...)
+
+				require.Equal(t, page.HasNext, page.NextCursor != "", "has next with cursor %q", page.NextCursor)
+				if !page.HasNext {
+					break
+				}
+				query.Cursor = page.NextCursor
+			}
+
+			assert.Equal(t, want, got, "pages must return every notification once, in sort order")
+		})
+	}
+}
+
+func TestListNotificationsRejectsCursor(t *testing.T) {
+	service := &notificationService{notificationRepo: &keysetNotificationRepo{}}
+	issued := encodeNotificationCursor(notificationCursor{
+		Sort:               "created_at:desc",
+		NotificationCursor: repository.NotificationCursor{Value: time.Now(), ID: uuid.New()},
+	})
+
+	tests := []struct {
+		name    string
+		query   dto.ListNotificationsQuery
+		wantErr bool
+	}{
+		{name: "issued for the sort", query: dto.ListNotificationsQuery{Cursor: issued}},
+		{name: "issued for another order", query: dto.ListNotificationsQuery{SortOrder: "asc", Cursor: issued}, wantErr: true},
+		{name: "issued for another column", query: dto.ListNotificationsQuery{SortBy: "updated_at", Cursor: issued}, wantErr: true},
+		{name: "malformed", query: dto.ListNotificationsQuery{Cursor: "garbage"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.ListNotifications(context.Background(), uuid.New(), tt.query)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+
+			var appErr *errorx.AppError
+			require.ErrorAs(t, err, &appErr)
+			assert.Equal(t, errorx.ErrorTypeValidation, appErr.Type)
+		})
+	}
+}
+
+func TestDeriveDedupeKey(t *testing.T) {
+	contact := uuid.New()
+	base := model.Notification{
+		TemplateID: uuid.MustParse("7c9e6679-7425-40de-944b-e07fc1f90ae7"),
+		ContactID:  &contact,
+		Recipient:  "user@example.com",
+		Locale:     "en",
+		Data:       map[string]any{"order": 42, "items": []any{"book", "pen"}},
+	}
+	key, err := deriveDedupeKey(&base)
+	require.NoError(t, err)
+	assert.Regexp(t, `^auto:[0-9a-f]{64}$`, key)
+
+	tests := []struct {
+		name     string
+		change   func(n *model.Notification)
+		wantSame bool
+	}{
+		{name: "data built in another order", change: func(n *model.Notification) {
+			n.Data = map[string]any{"items": []any{"book", "pen"}, "order": 42}
+		}, wantSame: true},
+		{name: "metadata", change: func(n *model.Notification) { n.Metadata = map[string]string{"source": "retry"} }, wantSame: true},
+		{name: "priority", change: func(n *model.Notification) { n.Priority = model.NotificationPriority("high") }, wantSame: true},
+		{name: "attachments", change: func(n *model.Notification) {
+			n.Attachments = []model.NotificationAttachment{{Filename: "invoice.pdf"}}
+		}, wantSame: true},
+		{name: "template", change: func(n *model.Notification) { n.TemplateID = uuid.New() }},
+		{name: "contact", change: func(n *model.Notification) { other := uuid.New(); n.ContactID = &other }},
+		{name: "no contact", change: func(n *model.Notification) { n.ContactID = nil }},
+		{name: "recipient", change: func(n *model.Notification) { n.Recipient = "other@example.com" }},
+		{name: "locale", change: func(n *model.Notification) { n.Locale = "fr" }},
+		{name: "data", change: func(n *model.Notification) { n.Data = map[string]any{"order": 43, "items": []any{"book", "pen"}} }},
+		{name: "order of a list", change: func(n *model.Notification) { n.Data = map[string]any{"order": 42, "items": []any{"pen", "book"}} }},
+		{name: "empty data", change: func(n *model.Notification) { n.Data = map[string]any{} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notification := base
+			tt.change(&notification)
+
+			derived, err := deriveDedupeKey(&notification)
+			require.NoError(t, err)
+			if tt.wantSame {
+				assert.Equal(t, key, derived)
+			} else {
+				assert.NotEqual(t, key, derived)
+			}
+		})
+	}
+
+	t.Run("data that cannot be encoded", func(t *testing.T) {
+		notification := base
+		notification.Data = map[string]any{"amount": math.Inf(1)}
+		_, err := deriveDedupeKey(&notification)
+
+		var appErr *errorx.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, errorx.ErrorTypeValidation, appErr.Type)
+	})
+}
+
+// dedupeNotificationRepo records the notifications created, the ones with a dedupe key seen before are duplicates
+type dedupeNotificationRepo struct {
+	repository.NotificationRepository
+	originals map[string]*model.Notification
+	created   []*model.Notification
+	windows   []time.Duration
+}
+
+func (r *dedupeNotificationRepo) CreateDeduplicated(ctx context.Context, notification *model.Notification, window time.Duration) (*model.Notification, error) {
+	notification.ID = uuid.New()
+	r.created = append(r.created, notification)
+	r.windows = append(r.windows, window)
+	if notification.DedupeKey == "" {
+		return nil, nil
+	}
+	if original, ok := r.originals[notification.DedupeKey]; ok {
+		notification.Status = model.NotificationStatusDeduplicated
+		return original, nil
+	}
+	r.originals[notification.DedupeKey] = notification
+	return nil, nil
+}
+
+// fixedTemplateRepo returns the same template for any name
+type fixedTemplateRepo struct {
+	repository.TemplateRepository
+	template *model.Template
+}
+
+func (r *fixedTemplateRepo) GetByName(ctx context.Context, applicationID uuid.UUID, name string) (*model.Template, error) {
+	return r.template, nil
+}
+
+// recordingFileService records the requests whose prepared attachments are discarded
+type recordingFileService struct {
+	FileService
+	discarded int
+}
+
+func (s *recordingFileService) PrepareAttachments(ctx context.Context, application *model.Application, reqs []dto.AttachmentRequest) ([]model.NotificationAttachment, error) {
+	return nil, nil
+}
+
+func (s *recordingFileService) DiscardAttachments(ctx context.Context, application *model.Application, reqs []dto.AttachmentRequest, attachments []model.NotificationAttachment) {
+	s.discarded++
+}
+
+// recordingDigestService records the notifications matched against digest rules, none matches
+type recordingDigestService struct {
+	DigestService
+	matched []uuid.UUID
+}
+
+func (s *recordingDigestService) MatchRule(ctx context.Context, notification *model.Notification) (*model.DigestRule, error) {
+	s.matched = append(s.matched, notification.ID)
+	return nil, nil
+}
+
+func TestSendNotificationDedupe(t *testing.T) {
+	request := dto.SendNotificationRequest{Template: "order-shipped", Recipient: "+15551234567", Data: map[string]any{"order": 42}}
+
+	tests := []struct {
+		name        string
+		application model.Application
+		requests    []dto.SendNotificationRequest
+		wantAuto    bool // Whether the dedupe key is derived
+		wantCreated []model.NotificationStatus
+		wantWindow  time.Duration
+	}{
+		{
+			name:        "dedupe is opt-in",
+			requests:    []dto.SendNotificationRequest{request, request},
+			wantCreated: []model.NotificationStatus{model.NotificationStatusQueued, model.NotificationStatusQueued},
+			wantWindow:  time.Hour,
+		},
+		{
+			name:        "derived key",
+			application: model.Application{AutoDedupe: true},
+			requests:    []dto.SendNotificationRequest{request, request},
+			wantAuto:    true,
+			wantCreated: []model.NotificationStatus{model.NotificationStatusQueued, model.NotificationStatusDeduplicated},
+			wantWindow:  time.Hour,
+		},
+		{
+			name:        "derived key of other data",
+			application: model.Application{AutoDedupe: true},
+			requests: []dto.SendNotificationRequest{request, func() dto.SendNotificationRequest {
+				other := request
+				other.Data = map[string]any{"order": 43}
+				return other
+			}()},
+			wantAuto:    true,
+			wantCreated: []model.NotificationStatus{model.NotificationStatusQueued, model.NotificationStatusQueued},
+			wantWindow:  time.Hour,
+		},
+		{
+			name:        "explicit key without auto dedupe",
+			application: model.Application{DedupeWindowSeconds: 60},
+			requests: []dto.SendNotificationRequest{
+				func() dto.SendNotificationRequest { r := request; r.DedupeKey = "order-42"; return r }(),
+				func() dto.SendNotificationRequest {
+					r := request
+					r.DedupeKey = "order-42"
+					r.Data = map[string]any{"order": 43}
+					return r
+				}(),
+			},
+			wantCreated: []model.NotificationStatus{model.NotificationStatusQueued, model.NotificationStatusDeduplicated},
+			wantWindow:  time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &dedupeNotificationRepo{originals: make(map[string]*model.Notification)}
+			files := &recordingFileService{}
+			digests := &recordingDigestService{}
+			application := tt.application
+			application.ID = uuid.New()
+			template := &model.Template{ID: uuid.New(), ApplicationID: application.ID, Name: "order-shipped", Channel: model.ChannelSMS}
+			service := NewNotificationService(repo, &fixedTemplateRepo{template: template}, nil, nil, files, digests, time.Hour)
+
+			for _, req := range tt.requests {
+				_, err := service.SendNotification(context.Background(), &application, req)
+				require.NoError(t, err)
+			}
+
+			require.Len(t, repo.created, len(tt.wantCreated))
+			var duplicates int
+			for i, notification := range repo.created {
+				assert.Equal(t, tt.wantCreated[i], notification.Status)
+				assert.Equal(t, tt.wantWindow, repo.windows[i])
+				if tt.wantAuto {
+					assert.True(t, strings.HasPrefix(notification.DedupeKey, "auto:"), notification.DedupeKey)
+				} else {
+					assert.Equal(t, tt.requests[i].DedupeKey, notification.DedupeKey)
+				}
+				if notification.Status == model.NotificationStatusDeduplicated {
+					duplicates++
+					assert.NotContains(t, digests.matched, notification.ID, "duplicates are not collected into digests")
+				} else {
+					assert.Contains(t, digests.matched, notification.ID)
+				}
+			}
+			assert.Equal(t, duplicates, files.discarded, "the files of dropped duplicates are discarded")
+		})
+	}
+}
@@ -0,0 +1,351 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"hermes-api/internal/model"
+	"hermes-api/internal/repository"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/signer"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// preferencesPurpose is the purpose of the signed tokens of preference center links
+	preferencesPurpose = "preferences"
+	// unsubscribePurpose is the purpose of the signed tokens of unsubscribe links
+	unsubscribePurpose = "unsubscribe"
+)
+
+// contactClaims identifies the contact a signed link was issued for
+type contactClaims struct {
+	ApplicationID uuid.UUID `json:"a"`
+	ContactID     uuid.UUID `json:"c"`
+}
+
+// unsubscribeClaims identifies the contact, category and channel an unsubscribe link was issued for
+type unsubscribeClaims struct {
+	contactClaims
+	Category string        `json:"k,omitempty"`
+	Channel  model.Channel `json:"h"`
+}
+
+// PreferenceLink is a signed link to the hosted preference center of a contact
+type PreferenceLink struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PreferenceCenter is the content of the hosted preference center of a contact
+type PreferenceCenter struct {
+	Application string
+	Categories  []PreferenceCategory
+}
+
+// PreferenceCategory lists the channels of a category a contact can opt in or out of
+type PreferenceCategory struct {
+	Name     string
+	Channels []PreferenceChoice
+}
+
+// PreferenceChoice is the current preference of a contact for a category on a channel
+type PreferenceChoice struct {
+	Channel model.Channel
+	Enabled bool
+}
+
+// Unsubscription describes what an unsubscribe link opts a contact out of
+type Unsubscription struct {
+	Application    string
+	Category       string
+	Channel        model.Channel
+	PreferencesURL string
+}
+
+// PreferenceCenterService defines the interface for the hosted preference center
+type PreferenceCenterService interface {
+	CreateLink(ctx context.Context, application *model.Application, externalID string) (*PreferenceLink, error)
+	LinkFor(contact *model.Contact) (*PreferenceLink, error)
+	GetPreferenceCenter(ctx context.Context, token string) (*PreferenceCenter, error)
+	SavePreferenceCenter(ctx context.Context, token string, enabled map[string]bool) (*PreferenceCenter, error)
+	UnsubscribeLinkFor(contact *model.Contact, category string, channel model.Channel) (string, error)
+	GetUnsubscription(ctx context.Context, token string) (*Unsubscription, error)
+	Unsubscribe(ctx context.Context, token string) (*Unsubscription, error)
+}
+
+// preferenceCenterService implements PreferenceCenterService
+type preferenceCenterService struct {
+	applicationRepo repository.ApplicationRepository
+	contactRepo     repository.ContactRepository
+	templateRepo    repository.TemplateRepository
+	contactService  ContactService
+	signer          *signer.Signer
+	publicURL       string
+	linkTTL         time.Duration
+}
+
+// NewPreferenceCenterService creates a new preference center service
+func NewPreferenceCenterService(applicationRepo repository.ApplicationRepository, contactRepo repository.ContactRepository, templateRepo repository.TemplateRepository, contactService ContactService, linkSigner *signer.Signer, publicURL string, linkTTL time.Duration) PreferenceCenterService {
+	return &preferenceCenterService{
+		applicationRepo: applicationRepo,
+		contactRepo:     contactRepo,
+		templateRepo:    templateRepo,
+		contactService:  contactService,
+		signer:          linkSigner,
+		publicURL:       strings.TrimRight(publicURL, "/"),
+		linkTTL:         linkTTL,
+	}
+}
+
+// CreateLink issues a preference center link for a contact of the application
+func (s *preferenceCenterService) CreateLink(ctx context.Context, application *model.Application, externalID string) (*PreferenceLink, error) {
+	contact, err := s.contactService.GetContact(ctx, application, externalID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.LinkFor(contact)
+}
+
+// LinkFor signs a preference center link for a contact
+func (s *preferenceCenterService) LinkFor(contact *model.Contact) (*PreferenceLink, error) {
+	expiresAt := time.Now().Add(s.linkTTL).UTC().Truncate(time.Second)
+	token, err := s.signer.Sign(preferencesPurpose, contactClaims{ApplicationID: contact.ApplicationID, ContactID: contact.ID}, expiresAt)
+	if err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeUnknownError,
+			"Failed to sign preference link",
+		)
+		return nil, appErr
+	}
+
+	return &PreferenceLink{
+		URL:       s.publicURL + "/preferences/" + token,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// GetPreferenceCenter returns the preference center of the contact a link was issued for
+func (s *preferenceCenterService) GetPreferenceCenter(ctx context.Context, token string) (*PreferenceCenter, error) {
+	application, contact, err := s.verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.preferenceCenter(ctx, application, contact)
+}
+
+// SavePreferenceCenter saves the choices submitted from the preference center; enabled is keyed by
+// "<category>:<channel>" and only lists the enabled choices. Preferences not shown on the page are kept.
+func (s *preferenceCenterService) SavePreferenceCenter(ctx context.Context, token string, enabled map[string]bool) (*PreferenceCenter, error) {
+	application, contact, err := s.verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	center, err := s.preferenceCenter(ctx, application, contact)
+	if err != nil {
+		return nil, err
+	}
+
+	shown := make(map[[2]string]bool)
+	var preferences []model.ContactPreference
+	for i, category := range center.Categories {
+		for j, choice := range category.Channels {
+			choice.Enabled = enabled[category.Name+":"+string(choice.Channel)]
+			center.Categories[i].Channels[j] = choice
+
+			shown[[2]string{category.Name, string(choice.Channel)}] = true
+			preferences = append(preferences, model.ContactPreference{
+				Category: category.Name,
+				Channel:  choice.Channel,
+				Enabled:  choice.Enabled,
+			})
+		}
+	}
+
+	for _, preference := range contact.Preferences {
+		if !shown[[2]string{preference.Category, string(preference.Channel)}] {
+			preferences = append(preferences, model.ContactPreference{
+				Category: preference.Category,
+				Channel:  preference.Channel,
+				Enabled:  preference.Enabled,
+			})
+		}
+	}
+
+	if err := s.contactRepo.ReplacePreferences(ctx, contact.ID, preferences); err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to update preferences",
+		)
+		return nil, appErr
+	}
+
+	return center, nil
+}
+
+// UnsubscribeLinkFor signs a link opting a contact out of a category on a channel, or out of the whole
+// channel when category is empty. Unsubscribe links never expire, they must keep working in old messages.
+func (s *preferenceCenterService) UnsubscribeLinkFor(contact *model.Contact, category string, channel model.Channel) (string, error) {
+	claims := unsubscribeClaims{
+		contactClaims: contactClaims{ApplicationID: contact.ApplicationID, ContactID: contact.ID},
+		Category:      category,
+		Channel:       channel,
+	}
+
+	token, err := s.signer.Sign(unsubscribePurpose, claims, time.Time{})
+	if err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeUnknownError,
+			"Failed to sign unsubscribe link",
+		)
+		return "", appErr
+	}
+
+	return s.publicURL + "/unsubscribe/" + token, nil
+}
+
+// GetUnsubscription describes what an unsubscribe link opts out of without changing any preference
+func (s *preferenceCenterService) GetUnsubscription(ctx context.Context, token string) (*Unsubscription, error) {
+	_, unsubscription, err := s.unsubscription(ctx, token)
+	return unsubscription, err
+}
+
+// Unsubscribe opts the contact of an unsubscribe link out of its category and channel. It is idempotent,
+// following the link again keeps the contact opted out.
+func (s *preferenceCenterService) Unsubscribe(ctx context.Context, token string) (*Unsubscription, error) {
+	contact, unsubscription, err := s.unsubscription(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	preference := &model.ContactPreference{
+		ContactID: contact.ID,
+		Category:  unsubscription.Category,
+		Channel:   unsubscription.Channel,
+		Enabled:   false,
+	}
+	if err := s.contactRepo.SetPreference(ctx, preference); err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to update preferences",
+		)
+		return nil, appErr
+	}
+
+	return unsubscription, nil
+}
+
+// unsubscription checks an unsubscribe token and loads the contact it was issued for
+func (s *preferenceCenterService) unsubscription(ctx context.Context, token string) (*model.Contact, *Unsubscription, error) {
+	var claims unsubscribeClaims
+	if err := s.verifyToken(unsubscribePurpose, token, &claims); err != nil {
+		return nil, nil, err
+	}
+
+	application, contact, err := s.load(ctx, claims.contactClaims)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	link, err := s.LinkFor(contact)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return contact, &Unsubscription{
+		Application:    application.Name,
+		Category:       claims.Category,
+		Channel:        claims.Channel,
+		PreferencesURL: link.URL,
+	}, nil
+}
+
+// preferenceCenter lists the categories of the application's templates with the contact's current choices
+func (s *preferenceCenterService) preferenceCenter(ctx context.Context, application *model.Application, contact *model.Contact) (*PreferenceCenter, error) {
+	categories, err := s.templateRepo.ListCategories(ctx, application.ID)
+	if err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch categories",
+		)
+		return nil, appErr
+	}
+
+	center := &PreferenceCenter{Application: application.Name}
+	for _, category := range categories {
+		if n := len(center.Categories); n == 0 || center.Categories[n-1].Name != category.Category {
+			center.Categories = append(center.Categories, PreferenceCategory{Name: category.Category})
+		}
+
+		current := &center.Categories[len(center.Categories)-1]
+		current.Channels = append(current.Channels, PreferenceChoice{
+			Channel: category.Channel,
+			Enabled: contact.Allows(category.Category, category.Channel),
+		})
+	}
+
+	return center, nil
+}
+
+// verify checks a preference center token and loads its application and contact with its preferences
+func (s *preferenceCenterService) verify(ctx context.Context, token string) (*model.Application, *model.Contact, error) {
+	var claims contactClaims
+	if err := s.verifyToken(preferencesPurpose, token, &claims); err != nil {
+		return nil, nil, err
+	}
+
+	application, contact, err := s.load(ctx, claims)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	contact.Preferences, err = s.contactRepo.ListPreferences(ctx, contact.ID)
+	if err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch preferences",
+		)
+		return nil, nil, appErr
+	}
+
+	return application, contact, nil
+}
+
+// verifyToken checks a token signed for purpose and decodes its claims
+func (s *preferenceCenterService) verifyToken(purpose, token string, claims any) error {
+	if err := s.signer.Verify(purpose, token, claims); err != nil {
+		if errors.Is(err, signer.ErrExpiredToken) {
+			return errorx.NewWithTemplate(errorx.ErrorTypeForbidden, errorx.ErrorCodeLinkExpired)
+		}
+		return errorx.NewWithTemplate(errorx.ErrorTypeForbidden, errorx.ErrorCodeLinkInvalid)
+	}
+	return nil
+}
+
+// load retrieves the application and contact a link was issued for
+func (s *preferenceCenterService) load(ctx context.Context, claims contactClaims) (*model.Application, *model.Contact, error) {
+	// Contacts deleted since the link was issued make the link invalid
+	contact, err := s.contactRepo.GetByID(ctx, claims.ContactID)
+	if err != nil || contact.ApplicationID != claims.ApplicationID {
+		return nil, nil, errorx.NewWithTemplate(errorx.ErrorTypeForbidden, errorx.ErrorCodeLinkInvalid)
+	}
+
+	application, err := s.applicationRepo.GetByID(ctx, claims.ApplicationID)
+	if err != nil {
+		return nil, nil, errorx.NewWithTemplate(errorx.ErrorTypeForbidden, errorx.ErrorCodeLinkInvalid)
+	}
+
+	return application, contact, nil
+}
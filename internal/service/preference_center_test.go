@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"hermes-api/internal/model"
+	"hermes-api/internal/repository"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/signer"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const preferencePublicURL = "https://hermes.example.com"
+
+// preferenceApplicationRepo holds the applications links are issued for
+type preferenceApplicationRepo struct {
+	repository.ApplicationRepository
+	applications map[uuid.UUID]*model.Application
+}
+
+func (r *preferenceApplicationRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.Application, error) {
+	application, ok := r.applications[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return application, nil
+}
+
+// preferenceContactRepo holds contacts and their preferences, loaded copies leave the stored contacts unchanged
+type preferenceContactRepo struct {
+	repository.ContactRepository
+	contacts    map[uuid.UUID]*model.Contact
+	preferences map[uuid.UUID][]model.ContactPreference
+}
+
+func (r *preferenceContactRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.Contact, error) {
+	contact, ok := r.contacts[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	loaded := *contact
+	return &loaded, nil
+}
+
+func (r *preferenceContactRepo) ListPreferences(ctx context.Context, contactID uuid.UUID) ([]model.ContactPreference, error) {
+	return r.preferences[contactID], nil
+}
+
+func (r *preferenceContactRepo) ReplacePreferences(ctx context.Context, contactID uuid.UUID, preferences []model.ContactPreference) error {
+	r.preferences[contactID] = preferences
+	return nil
+}
+
+func (r *preferenceContactRepo) SetPreference(ctx context.Context, preference *model.ContactPreference) error {
+	preferences := r.preferences[preference.ContactID]
+	for i, current := range preferences {
+		if current.Category == preference.Category && current.Channel == preference.Channel {
+			preferences[i].Enabled = preference.Enabled
+			return nil
+		}
+	}
+	r.preferences[preference.ContactID] = append(preferences, *preference)
+	return nil
+}
+
+// preferenceTemplateRepo lists the same categories for every application
+type preferenceTemplateRepo struct {
+	repository.TemplateRepository
+	categories []repository.TemplateCategory
+}
+
+func (r *preferenceTemplateRepo) ListCategories(ctx context.Context, applicationID uuid.UUID) ([]repository.TemplateCategory, error) {
+	return r.categories, nil
+}
+
+// newTestPreferenceCenterService creates a preference center holding the given applications and contacts
+func newTestPreferenceCenterService(applications []*model.Application, contacts ...*model.Contact) (*preferenceCenterService, *preferenceContactRepo) {
+	applicationRepo := &preferenceApplicationRepo{applications: make(map[uuid.UUID]*model.Application)}
+	for _, application := range applications {
+		applicationRepo.applications[application.ID] = application
+	}
+	contactRepo := &preferenceContactRepo{contacts: make(map[uuid.UUID]*model.Contact), preferences: make(map[uuid.UUID][]model.ContactPreference)}
+	for _, contact := range contacts {
+		contactRepo.contacts[contact.ID] = contact
+	}
+	templateRepo := &preferenceTemplateRepo{categories: []repository.TemplateCategory{
+		{Category: "newsletter", Channel: model.ChannelEmail},
+		{Category: "product-updates", Channel: model.ChannelEmail},
+		{Category: "product-updates", Channel: model.ChannelSMS},
+	}}
+	service := NewPreferenceCenterService(applicationRepo, contactRepo, templateRepo, nil, signer.New("link-secret"), preferencePublicURL+"/", 24*time.Hour)
+	return service.(*preferenceCenterService), contactRepo
+}
+
+// assertLinkError checks that err is the forbidden error of an invalid or expired link
+func assertLinkError(t *testing.T, err error, code errorx.ErrorCode) {
+	t.Helper()
+	var appErr *errorx.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errorx.ErrorTypeForbidden, appErr.Type)
+	assert.Equal(t, code, appErr.Code)
+}
+
+func TestPreferenceCenterVerify(t *testing.T) {
+	application := &model.Application{ID: uuid.New(), Name: "Acme"}
+	otherApplication := &model.Application{ID: uuid.New(), Name: "Globex"}
+	contact := &model.Contact{ID: uuid.New(), ApplicationID: application.ID, ExternalID: "user-1"}
+	deleted := &model.Contact{ID: uuid.New(), ApplicationID: application.ID, ExternalID: "user-2"}
+	service, _ := newTestPreferenceCenterService([]*model.Application{application, otherApplication}, contact)
+
+	link, err := service.LinkFor(contact)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(link.URL, preferencePublicURL+"/preferences/"))
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), link.ExpiresAt, time.Minute)
+	token := strings.TrimPrefix(link.URL, preferencePublicURL+"/preferences/")
+
+	// sign returns a token signed with secret for purpose
+	sign := func(secret, purpose string, claims any, expiresAt time.Time) string {
+		token, err := signer.New(secret).Sign(purpose, claims, expiresAt)
+		require.NoError(t, err)
+		return token
+	}
+	// tamper changes the first character of s, the last one may only hold padding bits
+	tamper := func(s string) string {
+		if strings.HasPrefix(s, "A") {
+			return "B" + s[1:]
+		}
+		return "A" + s[1:]
+	}
+	claims := contactClaims{ApplicationID: application.ID, ContactID: contact.ID}
+	body, signature, _ := strings.Cut(token, ".")
+
+	tests := []struct {
+		name     string
+		token    string
+		wantCode errorx.ErrorCode // Empty when the token is valid
+	}{
+		{name: "valid", token: token},
+		{name: "expired", token: sign("link-secret", preferencesPurpose, claims, time.Now().Add(-time.Minute)), wantCode: errorx.ErrorCodeLinkExpired},
+		{name: "other secret", token: sign("other-secret", preferencesPurpose, claims, time.Now().Add(time.Hour)), wantCode: errorx.ErrorCodeLinkInvalid},
+		{name: "unsubscribe token", token: sign("link-secret", unsubscribePurpose, unsubscribeClaims{contactClaims: claims, Channel: model.ChannelEmail}, time.Time{}), wantCode: errorx.ErrorCodeLinkInvalid},
+		{name: "tracking token", token: sign("link-secret", clickPurpose, claims, time.Time{}), wantCode: errorx.ErrorCodeLinkInvalid},
+		{name: "tampered body", token: tamper(body) + "." + signature, wantCode: errorx.ErrorCodeLinkInvalid},
+		{name: "tampered signature", token: body + "." + tamper(signature), wantCode: errorx.ErrorCodeLinkInvalid},
+		{name: "missing signature", token: body, wantCode: errorx.ErrorCodeLinkInvalid},
+		{name: "empty", token: "", wantCode: errorx.ErrorCodeLinkInvalid},
+		{
+			name:     "contact claimed by another application",
+			token:    sign("link-secret", preferencesPurpose, contactClaims{ApplicationID: otherApplication.ID, ContactID: contact.ID}, time.Now().Add(time.Hour)),
+			wantCode: errorx.ErrorCodeLinkInvalid,
+		},
+		{
+			name:     "deleted contact",
+			token:    sign("link-secret", preferencesPurpose, contactClaims{ApplicationID: application.ID, ContactID: deleted.ID}, time.Now().Add(time.Hour)),
+			wantCode: errorx.ErrorCodeLinkInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			center, err := service.GetPreferenceCenter(context.Background(), tt.token)
+			if tt.wantCode != "" {
+				assertLinkError(t, err, tt.wantCode)
+				_, err = service.SavePreferenceCenter(context.Background(), tt.token, nil)
+				assertLinkError(t, err, tt.wantCode)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "Acme", center.Application)
+		})
+	}
+}
+
+func TestSavePreferenceCenter(t *testing.T) {
+	application := &model.Application{ID: uuid.New(), Name: "Acme"}
+	contact := &model.Contact{ID: uuid.New(), ApplicationID: application.ID, ExternalID: "user-1"}
+	service, contactRepo := newTestPreferenceCenterService([]*model.Application{application}, contact)
+	contactRepo.preferences[contact.ID] = []model.ContactPreference{
+		{Category: "newsletter", Channel: model.ChannelEmail, Enabled: false},
+		{Category: "billing", Channel: model.ChannelSMS, Enabled: false}, // Not shown on the page
+		{Channel: model.ChannelPush, Enabled: false},
+	}
+
+	link, err := service.LinkFor(contact)
+	require.NoError(t, err)
+	token := strings.TrimPrefix(link.URL, preferencePublicURL+"/preferences/")
+
+	center, err := service.GetPreferenceCenter(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, []PreferenceCategory{
+		{Name: "newsletter", Channels: []PreferenceChoice{{Channel: model.ChannelEmail, Enabled: false}}},
+		{Name: "product-updates", Channels: []PreferenceChoice{{Channel: model.ChannelEmail, Enabled: true}, {Channel: model.ChannelSMS, Enabled: true}}},
+	}, center.Categories)
+
+	// Only the checked boxes are submitted
+	center, err = service.SavePreferenceCenter(context.Background(), token, map[string]bool{"newsletter:email": true, "product-updates:email": true})
+	require.NoError(t, err)
+	assert.Equal(t, []PreferenceCategory{
+		{Name: "newsletter", Channels: []PreferenceChoice{{Channel: model.ChannelEmail, Enabled: true}}},
+		{Name: "product-updates", Channels: []PreferenceChoice{{Channel: model.ChannelEmail, Enabled: true}, {Channel: model.ChannelSMS, Enabled: false}}},
+	}, center.Categories)
+	assert.ElementsMatch(t, []model.ContactPreference{
+		{Category: "newsletter", Channel: model.ChannelEmail, Enabled: true},
+		{Category: "product-updates", Channel: model.ChannelEmail, Enabled: true},
+		{Category: "product-updates", Channel: model.ChannelSMS, Enabled: false},
+		{Category: "billing", Channel: model.ChannelSMS, Enabled: false},
+		{Channel: model.ChannelPush, Enabled: false},
+	}, contactRepo.preferences[contact.ID], "preferences not shown on the page are kept")
+}
+
+func TestUnsubscribe(t *testing.T) {
+	application := &model.Application{ID: uuid.New(), Name: "Acme"}
+	otherApplication := &model.Application{ID: uuid.New(), Name: "Globex"}
+	contact := &model.Contact{ID: uuid.New(), ApplicationID: application.ID, ExternalID: "user-1"}
+	ctx := context.Background()
+
+	// sign returns an unsubscribe token signed with secret
+	sign := func(secret, purpose string, claims unsubscribeClaims, expiresAt time.Time) string {
+		token, err := signer.New(secret).Sign(purpose, claims, expiresAt)
+		require.NoError(t, err)
+		return token
+	}
+	claims := unsubscribeClaims{contactClaims: contactClaims{ApplicationID: application.ID, ContactID: contact.ID}, Channel: model.ChannelEmail}
+
+	tests := []struct {
+		name     string
+		category string
+		channel  model.Channel
+		token    string // Token of the link, the one signed by UnsubscribeLinkFor when empty
+		wantCode errorx.ErrorCode
+		want     []model.ContactPreference
+	}{
+		{
+			name:     "category on a channel",
+			category: "newsletter",
+			channel:  model.ChannelEmail,
+			want:     []model.ContactPreference{{Category: "newsletter", Channel: model.ChannelEmail}},
+		},
+		{
+			name:    "whole channel",
+			channel: model.ChannelSMS,
+			want:    []model.ContactPreference{{Channel: model.ChannelSMS}},
+		},
+		{
+			name:  "links never expire",
+			token: sign("link-secret", unsubscribePurpose, claims, time.Time{}),
+			want:  []model.ContactPreference{{Channel: model.ChannelEmail}},
+		},
+		{
+			name:     "expiring token",
+			token:    sign("link-secret", unsubscribePurpose, claims, time.Now().Add(-time.Minute)),
+			wantCode: errorx.ErrorCodeLinkExpired,
+		},
+		{
+			name:     "preference center token",
+			token:    sign("link-secret", preferencesPurpose, claims, time.Now().Add(time.Hour)),
+			wantCode: errorx.ErrorCodeLinkInvalid,
+		},
+		{
+			name:     "other secret",
+			token:    sign("other-secret", unsubscribePurpose, claims, time.Time{}),
+			wantCode: errorx.ErrorCodeLinkInvalid,
+		},
+		{
+			name: "contact claimed by another application",
+			token: sign("link-secret", unsubscribePurpose, unsubscribeClaims{
+				contactClaims: contactClaims{ApplicationID: otherApplication.ID, ContactID: contact.ID},
+				Channel:       model.ChannelEmail,
+			}, time.Time{}),
+			wantCode: errorx.ErrorCodeLinkInvalid,
+		},
+		{
+			name:     "malformed",
+			token:    "not-a-token",
+			wantCode: errorx.ErrorCodeLinkInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, contactRepo := newTestPreferenceCenterService([]*model.Application{application, otherApplication}, contact)
+
+			token := tt.token
+			if token == "" {
+				link, err := service.UnsubscribeLinkFor(contact, tt.category, tt.channel)
+				require.NoError(t, err)
+				require.True(t, strings.HasPrefix(link, preferencePublicURL+"/unsubscribe/"))
+				token = strings.TrimPrefix(link, preferencePublicURL+"/unsubscribe/")
+			}
+
+			// Showing the confirmation page changes nothing
+			unsubscription, err := service.GetUnsubscription(ctx, token)
+			if tt.wantCode != "" {
+				assertLinkError(t, err, tt.wantCode)
+				_, err = service.Unsubscribe(ctx, token)
+				assertLinkError(t, err, tt.wantCode)
+				assert.Empty(t, contactRepo.preferences)
+				return
+			}
+			require.NoError(t, err)
+			assert.Empty(t, contactRepo.preferences)
+			assert.Equal(t, "Acme", unsubscription.Application)
+			assert.True(t, strings.HasPrefix(unsubscription.PreferencesURL, preferencePublicURL+"/preferences/"))
+
+			// Following the link again keeps the contact opted out
+			for i := 0; i < 2; i++ {
+				unsubscription, err = service.Unsubscribe(ctx, token)
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.want[0].Category, unsubscription.Category)
+			assert.Equal(t, tt.want[0].Channel, unsubscription.Channel)
+			for i := range tt.want {
+				tt.want[i].ContactID = contact.ID
+			}
+			assert.Equal(t, tt.want, contactRepo.preferences[contact.ID])
+		})
+	}
+}
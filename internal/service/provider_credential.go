@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"hermes-api/internal/dto"
+	"hermes-api/internal/model"
+	"hermes-api/internal/provider"
+	"hermes-api/internal/repository"
+	"hermes-api/pkg/envelope"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// credentialRotationBatch is the number of provider credentials re-encrypted per query during a key rotation
+const credentialRotationBatch = 100
+
+// ProviderCredentialService defines the interface for the provider credentials of applications
+type ProviderCredentialService interface {
+	SetProviderCredential(ctx context.Context, application *model.Application, channel model.Channel, req dto.SetProviderCredentialRequest) (*model.ProviderCredential, error)
+	ListProviderCredentials(ctx context.Context, application *model.Application) ([]*model.ProviderCredential, error)
+	DeleteProviderCredential(ctx context.Context, application *model.Application, channel model.Channel) error
+	Resolve(ctx context.Context, applicationID uuid.UUID, channel model.Channel) (*model.ProviderCredential, error)
+	OpenSecrets(credential *model.ProviderCredential) (map[string]string, error)
+	RotateKeys(ctx context.Context) (string, int, error)
+}
+
+// providerCredentialService implements ProviderCredentialService
+type providerCredentialService struct {
+	providerCredentialRepo repository.ProviderCredentialRepository
+	keyring                *envelope.Keyring
+}
+
+// NewProviderCredentialService creates a new provider credential service sealing secrets with keyring.
+// Without a keyring, e.g. when the credential keys are misconfigured, credentials cannot be stored or read.
+func NewProviderCredentialService(providerCredentialRepo repository.ProviderCredentialRepository, keyring *envelope.Keyring) ProviderCredentialService {
+	return &providerCredentialService{
+		providerCredentialRepo: providerCredentialRepo,
+		keyring:                keyring,
+	}
+}
+
+// SetProviderCredential configures the provider of a channel of the application, replacing the current one
+func (s *providerCredentialService) SetProviderCredential(ctx context.Context, application *model.Application, channel model.Channel, req dto.SetProviderCredentialRequest) (*model.ProviderCredential, error) {
+	if s.keyring == nil {
+		return nil, errorx.New(errorx.ErrorTypeInternal, errorx.ErrorCodeCredentialKeyUnavailable, "Provider credentials cannot be encrypted")
+	}
+	if !provider.SupportsChannel(req.Driver, channel) {
+		appErr := errorx.New(errorx.ErrorTypeValidation, errorx.ErrorCodeInvalidValue, "Invalid request body")
+		return nil, appErr.WithDetails(map[string]any{"Driver": errorx.ErrorCodeInvalidValue})
+	}
+
+	secrets := req.Secrets
+	if secrets == nil {
+		secrets = map[string]string{}
+	}
+
+	// Building the provider checks its settings and secrets
+	_, err := provider.New(req.Driver, req.Name, provider.Credentials{Sender: req.Sender, Settings: req.Settings, Secrets: secrets})
+	if err != nil {
+		return nil, errorx.New(errorx.ErrorTypeValidation, errorx.ErrorCodeInvalidValue, err.Error())
+	}
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, errorx.New(errorx.ErrorTypeValidation, errorx.ErrorCodeInvalidValue, "Secrets cannot be encoded")
+	}
+	secretNames := make([]string, 0, len(secrets))
+	for name := range secrets {
+		secretNames = append(secretNames, name)
+	}
+	sort.Strings(secretNames)
+
+	// The secrets are bound to the credential, which keeps its ID when it is replaced
+	credentialID := uuid.New()
+	existing, err := s.providerCredentialRepo.GetForChannel(ctx, application.ID, channel)
+	switch {
+	case err == nil:
+		credentialID = existing.ID
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch provider credential",
+		)
+		return nil, appErr
+	}
+
+	sealed, err := s.keyring.Seal(plaintext, credentialAAD(credentialID, application.ID))
+	if err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeCredentialKeyUnavailable,
+			"Failed to encrypt provider credential",
+		)
+		return nil, appErr
+	}
+
+	credential := &model.ProviderCredential{
+		ID:               credentialID,
+		ApplicationID:    application.ID,
+		Channel:          channel,
+		Driver:           req.Driver,
+		Name:             req.Name,
+		Sender:           req.Sender,
+		Settings:         req.Settings,
+		SecretNames:      secretNames,
+		KeyID:            sealed.KeyID,
+		EncryptedKey:     sealed.EncryptedKey,
+		EncryptedSecrets: sealed.Ciphertext,
+	}
+	if req.RateLimit != nil {
+		credential.RateLimit = &model.RateLimit{
+			Limit:      req.RateLimit.Limit,
+			PerSeconds: req.RateLimit.PerSeconds,
+			Burst:      req.RateLimit.Burst,
+			PerSender:  req.RateLimit.PerSender,
+		}
+	}
+
+	err = s.providerCredentialRepo.Upsert(ctx, credential)
+	if errors.Is(err, repository.ErrProviderCredentialReplaced) {
+		return nil, errorx.NewWithTemplate(errorx.ErrorTypeConflict, errorx.ErrorCodeProviderCredentialConflict, channel)
+	}
+	if err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to save provider credential",
+		)
+		return nil, appErr
+	}
+
+	return credential, nil
+}
+
+// ListProviderCredentials retrieves the provider credentials of the application, without their secrets
+func (s *providerCredentialService) ListProviderCredentials(ctx context.Context, application *model.Application) ([]*model.ProviderCredential, error) {
+	credentials, err := s.providerCredentialRepo.ListByApplication(ctx, application.ID)
+	if err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch provider credentials",
+		)
+		return nil, appErr
+	}
+
+	return credentials, nil
+}
+
+// DeleteProviderCredential removes the provider of a channel of the application, the channel is delivered
+// by the platform providers again
+func (s *providerCredentialService) DeleteProviderCredential(ctx context.Context, application *model.Application, channel model.Channel) error {
+	deleted, err := s.providerCredentialRepo.DeleteForChannel(ctx, application.ID, channel)
+	if err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to delete provider credential",
+		)
+		return appErr
+	}
+	if !deleted {
+		return errorx.NewWithTemplate(errorx.ErrorTypeNotFound, errorx.ErrorCodeProviderCredentialNotFound, channel)
+	}
+
+	return nil
+}
+
+// Resolve returns the provider credential of an application for a channel, or nil when the application
+// uses the platform providers
+func (s *providerCredentialService) Resolve(ctx context.Context, applicationID uuid.UUID, channel model.Channel) (*model.ProviderCredential, error) {
+	credential, err := s.providerCredentialRepo.GetForChannel(ctx, applicationID, channel)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return credential, err
+}
+
+// OpenSecrets decrypts the secrets of a provider credential
+func (s *providerCredentialService) OpenSecrets(credential *model.ProviderCredential) (map[string]string, error) {
+	if s.keyring == nil {
+		return nil, envelope.ErrUnknownKey
+	}
+
+	plaintext, err := s.keyring.Open(&envelope.Sealed{
+		KeyID:        credential.KeyID,
+		EncryptedKey: credential.EncryptedKey,
+		Ciphertext:   credential.EncryptedSecrets,
+	}, credentialAAD(credential.ID, credential.ApplicationID))
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets map[string]string
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+// RotateKeys re-encrypts the data keys of the provider credentials sealed with a previous key with the
+// active key, and returns the active key ID with the number of credentials rotated. The secrets themselves are not re-encrypted.
+// Credentials that cannot be rotated, e.g. sealed with a key removed from the configuration, are logged
+// and reported once the others are rotated.
+func (s *providerCredentialService) RotateKeys(ctx context.Context) (string, int, error) {
+	if s.keyring == nil {
+		return "", 0, errorx.New(errorx.ErrorTypeInternal, errorx.ErrorCodeCredentialKeyUnavailable, "Provider credentials cannot be encrypted")
+	}
+
+	activeKeyID := s.keyring.ActiveKeyID()
+	rotated, failed := 0, 0
+	after := uuid.Nil
+	for {
+		credentials, err := s.providerCredentialRepo.ListSealedWithOtherKey(ctx, activeKeyID, after, credentialRotationBatch)
+		if err != nil {
+			appErr := errorx.New(
+				errorx.ErrorTypeInternal,
+				errorx.ErrorCodeDatabaseError,
+				"Failed to fetch provider credentials",
+			)
+			return activeKeyID, rotated, appErr
+		}
+
+		for _, credential := range credentials {
+			after = credential.ID
+			previousKeyID := credential.KeyID
+
+			sealed, err := s.keyring.Rewrap(&envelope.Sealed{
+				KeyID:        credential.KeyID,
+				EncryptedKey: credential.EncryptedKey,
+				Ciphertext:   credential.EncryptedSecrets,
+			}, credentialAAD(credential.ID, credential.ApplicationID))
+			if err != nil {
+				logger.Warn("Failed to rotate provider credential",
+					zap.String("credential_id", credential.ID.String()),
+					zap.String("key_id", previousKeyID),
+					zap.Error(err),
+				)
+				failed++
+				continue
+			}
+
+			credential.KeyID = sealed.KeyID
+			credential.EncryptedKey = sealed.EncryptedKey
+			updated, err := s.providerCredentialRepo.UpdateKey(ctx, credential, previousKeyID)
+			if err != nil {
+				appErr := errorx.New(
+					errorx.ErrorTypeInternal,
+					errorx.ErrorCodeDatabaseError,
+					"Failed to save provider credential",
+				)
+				return activeKeyID, rotated, appErr
+			}
+			// A credential replaced meanwhile was sealed with the active key
+			if updated {
+				rotated++
+			}
+		}
+
+		if len(credentials) < credentialRotationBatch {
+			break
+		}
+	}
+
+	if failed > 0 {
+		appErr := errorx.New(errorx.ErrorTypeInternal, errorx.ErrorCodeCredentialKeyUnavailable, "Some provider credentials could not be rotated")
+		return activeKeyID, rotated, appErr.WithDetails(map[string]any{"rotated": rotated, "failed": failed})
+	}
+	return activeKeyID, rotated, nil
+}
+
+// credentialAAD returns the additional data binding the secrets of a provider credential to the
+// credential and its application, so that they cannot be opened once copied to another credential
+func credentialAAD(credentialID, applicationID uuid.UUID) []byte {
+	return []byte("provider_credential:" + credentialID.String() + ":" + applicationID.String())
+}
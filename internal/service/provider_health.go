@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"hermes-api/config"
+	"hermes-api/internal/model"
+	"hermes-api/internal/repository"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/logger"
+)
+
+// ProviderHealthService defines the interface for scoring the health of delivery providers
+type ProviderHealthService interface {
+	Record(ctx context.Context, channel model.Channel, provider string, success bool, latency time.Duration)
+	IsHealthy(ctx context.Context, channel model.Channel, provider string) bool
+	Refresh(ctx context.Context) error
+	Flush(ctx context.Context) error
+	ListProviderHealth(ctx context.Context) ([]*model.ProviderHealth, error)
+}
+
+// providerKey identifies a provider within its channel
+type providerKey struct {
+	channel  model.Channel
+	provider string
+}
+
+// bucketKey identifies the bucket of a provider's sends during a minute
+type bucketKey struct {
+	providerKey
+	start time.Time
+}
+
+// providerHealthService implements ProviderHealthService
+type providerHealthService struct {
+	providerStatRepo repository.ProviderStatRepository
+	providers        []config.ProviderConfig
+	config           config.ProviderHealthConfig
+
+	mu    sync.RWMutex
+	stats map[providerKey]*model.ProviderStat
+
+	// Sends counted since the last flush, written to the database by Refresh
+	pendingMu sync.Mutex
+	pending   map[bucketKey]*model.ProviderStat
+}
+
+// NewProviderHealthService creates a new provider health service for the configured providers
+func NewProviderHealthService(providerStatRepo repository.ProviderStatRepository, providers []config.ProviderConfig, cfg config.ProviderHealthConfig) ProviderHealthService {
+	return &providerHealthService{
+		providerStatRepo: providerStatRepo,
+		providers:        providers,
+		config:           cfg,
+		pending:          make(map[bucketKey]*model.ProviderStat),
+	}
+}
+
+// Record counts a send of a provider in memory, in the bucket of the current minute. The counts are
+// written by the next Refresh so that deliveries never wait on the database for statistics.
+func (s *providerHealthService) Record(ctx context.Context, channel model.Channel, provider string, success bool, latency time.Duration) {
+	key := bucketKey{providerKey{channel, provider}, time.Now().UTC().Truncate(time.Minute)}
+
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+
+	stat, ok := s.pending[key]
+	if !ok {
+		stat = &model.ProviderStat{Channel: channel, Provider: provider, BucketStart: key.start}
+		s.pending[key] = stat
+	}
+	if success {
+		stat.Successes++
+	} else {
+		stat.Failures++
+	}
+	stat.LatencyMs += latency.Milliseconds()
+}
+
+// Flush writes the sends counted since the last flush. On failure they are kept for the next flush,
+// unless their bucket left the rolling window by then.
+func (s *providerHealthService) Flush(ctx context.Context) error {
+	s.pendingMu.Lock()
+	pending := s.pending
+	s.pending = make(map[bucketKey]*model.ProviderStat)
+	s.pendingMu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+	stats := make([]*model.ProviderStat, 0, len(pending))
+	for _, stat := range pending {
+		stats = append(stats, stat)
+	}
+
+	err := s.providerStatRepo.Add(ctx, stats)
+	if err == nil {
+		return nil
+	}
+
+	since := time.Now().UTC().Add(-s.config.Window).Truncate(time.Minute)
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+	for key, stat := range pending {
+		if key.start.Before(since) {
+			continue
+		}
+		if current, ok := s.pending[key]; ok {
+			current.Successes += stat.Successes
+			current.Failures += stat.Failures
+			current.LatencyMs += stat.LatencyMs
+		} else {
+			s.pending[key] = stat
+		}
+	}
+	return err
+}
+
+// IsHealthy reports whether a provider met the health thresholds over the rolling window, as of the
+// last refresh; providers are healthy while no figures were loaded
+func (s *providerHealthService) IsHealthy(ctx context.Context, channel model.Channel, provider string) bool {
+	s.mu.RLock()
+	stat, ok := s.stats[providerKey{channel, provider}]
+	s.mu.RUnlock()
+
+	if !ok {
+		return true
+	}
+	_, _, healthy := s.score(stat)
+	return healthy
+}
+
+// Refresh writes the sends counted by this worker, drops the sends older than the rolling window and
+// reloads the figures IsHealthy routes with. It is called by the workers every refresh interval; on
+// failure the previous figures are kept.
+func (s *providerHealthService) Refresh(ctx context.Context) error {
+	if err := s.Flush(ctx); err != nil {
+		logger.Error("Failed to write provider sends, they are kept for the next refresh", err)
+	}
+
+	since := time.Now().Add(-s.config.Window)
+	if err := s.providerStatRepo.Prune(ctx, since.Truncate(time.Minute)); err != nil {
+		return err
+	}
+
+	stats, err := s.summarize(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.stats = stats
+	s.mu.Unlock()
+	return nil
+}
+
+// ListProviderHealth returns the current health of every configured provider
+func (s *providerHealthService) ListProviderHealth(ctx context.Context) ([]*model.ProviderHealth, error) {
+	stats, err := s.summarize(ctx)
+	if err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch provider health",
+		)
+		return nil, appErr
+	}
+
+	healths := make([]*model.ProviderHealth, 0, len(s.providers))
+	for _, provider := range s.providers {
+		health := &model.ProviderHealth{
+			Channel:     model.Channel(provider.Channel),
+			Provider:    provider.Name,
+			Priority:    provider.Priority,
+			Weight:      provider.Weight,
+			SuccessRate: 1,
+			Healthy:     true,
+		}
+		if stat, ok := stats[providerKey{health.Channel, health.Provider}]; ok {
+			health.Successes = stat.Successes
+			health.Failures = stat.Failures
+			health.SuccessRate, health.AvgLatencyMs, health.Healthy = s.score(stat)
+		}
+		healths = append(healths, health)
+	}
+
+	slices.SortStableFunc(healths, func(a, b *model.ProviderHealth) int {
+		if a.Channel != b.Channel {
+			if a.Channel < b.Channel {
+				return -1
+			}
+			return 1
+		}
+		return a.Priority - b.Priority
+	})
+	return healths, nil
+}
+
+// summarize loads the stats of the providers over the rolling window
+func (s *providerHealthService) summarize(ctx context.Context) (map[providerKey]*model.ProviderStat, error) {
+	stats, err := s.providerStatRepo.Summarize(ctx, time.Now().Add(-s.config.Window))
+	if err != nil {
+		return nil, err
+	}
+
+	byProvider := make(map[providerKey]*model.ProviderStat, len(stats))
+	for _, stat := range stats {
+		byProvider[providerKey{stat.Channel, stat.Provider}] = stat
+	}
+	return byProvider, nil
+}
+
+// score returns the success rate and average latency of a provider, and whether they meet the
+// thresholds; providers with too few sends to judge are healthy
+func (s *providerHealthService) score(stat *model.ProviderStat) (float64, float64, bool) {
+	total := stat.Successes + stat.Failures
+	if total == 0 {
+		return 1, 0, true
+	}
+
+	successRate := float64(stat.Successes) / float64(total)
+	avgLatencyMs := float64(stat.LatencyMs) / float64(total)
+	if total < s.config.MinSamples {
+		return successRate, avgLatencyMs, true
+	}
+
+	healthy := successRate >= s.config.MinSuccessRate &&
+		(s.config.MaxLatency <= 0 || avgLatencyMs <= float64(s.config.MaxLatency.Milliseconds()))
+	return successRate, avgLatencyMs, healthy
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"hermes-api/config"
+	"hermes-api/internal/model"
+	"hermes-api/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryProviderStatRepo keeps provider stats in memory, failing writes while addErr is set
+type memoryProviderStatRepo struct {
+	repository.ProviderStatRepository
+	mu     sync.Mutex
+	stats  map[bucketKey]model.ProviderStat
+	writes int
+	addErr error
+}
+
+func (r *memoryProviderStatRepo) Add(ctx context.Context, stats []*model.ProviderStat) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.writes++
+	if r.addErr != nil {
+		return r.addErr
+	}
+	for _, stat := range stats {
+		key := bucketKey{providerKey{stat.Channel, stat.Provider}, stat.BucketStart}
+		bucket := r.stats[key]
+		bucket.Channel, bucket.Provider, bucket.BucketStart = stat.Channel, stat.Provider, stat.BucketStart
+		bucket.Successes += stat.Successes
+		bucket.Failures += stat.Failures
+		bucket.LatencyMs += stat.LatencyMs
+		r.stats[key] = bucket
+	}
+	return nil
+}
+
+func (r *memoryProviderStatRepo) Summarize(ctx context.Context, since time.Time) ([]*model.ProviderStat, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	byProvider := make(map[providerKey]*model.ProviderStat)
+	for key, bucket := range r.stats {
+		stat, ok := byProvider[key.providerKey]
+		if !ok {
+			stat = &model.ProviderStat{Channel: bucket.Channel, Provider: bucket.Provider}
+			byProvider[key.providerKey] = stat
+		}
+		stat.Successes += bucket.Successes
+		stat.Failures += bucket.Failures
+		stat.LatencyMs += bucket.LatencyMs
+	}
+
+	stats := make([]*model.ProviderStat, 0, len(byProvider))
+	for _, stat := range byProvider {
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
+func (r *memoryProviderStatRepo) Prune(ctx context.Context, before time.Time) error {
+	return nil
+}
+
+// summary returns the counts written for a provider
+func (r *memoryProviderStatRepo) summary(provider string) (successes, failures, latencyMs int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, bucket := range r.stats {
+		if key.provider == provider {
+			successes += bucket.Successes
+			failures += bucket.Failures
+			latencyMs += bucket.LatencyMs
+		}
+	}
+	return successes, failures, latencyMs
+}
+
+func TestProviderHealthRecord(t *testing.T) {
+	repo := &memoryProviderStatRepo{stats: make(map[bucketKey]model.ProviderStat)}
+	cfg := config.ProviderHealthConfig{Window: 5 * time.Minute, MinSamples: 4, MinSuccessRate: 0.8}
+	service := NewProviderHealthService(repo, nil, cfg).(*providerHealthService)
+	ctx := context.Background()
+
+	// Sends are counted in memory, deliveries never wait for a write
+	for i := 0; i < 3; i++ {
+		service.Record(ctx, model.ChannelEmail, "sendgrid", false, 200*time.Millisecond)
+	}
+	service.Record(ctx, model.ChannelEmail, "sendgrid", true, 100*time.Millisecond)
+	service.Record(ctx, model.ChannelSMS, "twilio", true, 50*time.Millisecond)
+	assert.Zero(t, repo.writes)
+	assert.True(t, service.IsHealthy(ctx, model.ChannelEmail, "sendgrid"), "figures are only loaded by a refresh")
+
+	// The refresh writes the counts in a single batch and routes with them
+	require.NoError(t, service.Refresh(ctx))
+	assert.Equal(t, 1, repo.writes)
+	successes, failures, latencyMs := repo.summary("sendgrid")
+	assert.Equal(t, []int64{1, 3, 700}, []int64{successes, failures, latencyMs})
+	assert.False(t, service.IsHealthy(ctx, model.ChannelEmail, "sendgrid"))
+	assert.True(t, service.IsHealthy(ctx, model.ChannelSMS, "twilio"))
+
+	// Counts are written once
+	require.NoError(t, service.Refresh(ctx))
+	successes, failures, _ = repo.summary("sendgrid")
+	assert.Equal(t, []int64{1, 3}, []int64{successes, failures})
+	assert.Equal(t, 1, repo.writes, "nothing is written without new sends")
+}
+
+func TestProviderHealthFlushFailure(t *testing.T) {
+	repo := &memoryProviderStatRepo{stats: make(map[bucketKey]model.ProviderStat), addErr: errors.New("connection refused")}
+	cfg := config.ProviderHealthConfig{Window: 5 * time.Minute}
+	service := NewProviderHealthService(repo, nil, cfg).(*providerHealthService)
+	ctx := context.Background()
+
+	service.Record(ctx, model.ChannelEmail, "sendgrid", true, 100*time.Millisecond)
+	assert.Error(t, service.Flush(ctx))
+	require.NoError(t, service.Refresh(ctx), "a failed write does not fail the refresh")
+
+	// Sends counted meanwhile are merged with the ones kept from the failed writes
+	service.Record(ctx, model.ChannelEmail, "sendgrid", false, 300*time.Millisecond)
+	repo.addErr = nil
+	require.NoError(t, service.Flush(ctx))
+
+	successes, failures, latencyMs := repo.summary("sendgrid")
+	assert.Equal(t, []int64{1, 1, 400}, []int64{successes, failures, latencyMs})
+
+	t.Run("buckets out of the window are dropped", func(t *testing.T) {
+		repo := &memoryProviderStatRepo{stats: make(map[bucketKey]model.ProviderStat), addErr: errors.New("connection refused")}
+		service := NewProviderHealthService(repo, nil, cfg).(*providerHealthService)
+
+		old := bucketKey{providerKey{model.ChannelEmail, "sendgrid"}, time.Now().UTC().Add(-10 * time.Minute).Truncate(time.Minute)}
+		service.pending[old] = &model.ProviderStat{Channel: model.ChannelEmail, Provider: "sendgrid", BucketStart: old.start, Successes: 1}
+		service.Record(ctx, model.ChannelEmail, "sendgrid", true, 0)
+
+		assert.Error(t, service.Flush(ctx))
+		assert.Len(t, service.pending, 1)
+		assert.NotContains(t, service.pending, old)
+	})
+}
+
+func TestProviderHealthRecordConcurrently(t *testing.T) {
+	repo := &memoryProviderStatRepo{stats: make(map[bucketKey]model.ProviderStat)}
+	service := NewProviderHealthService(repo, nil, config.ProviderHealthConfig{Window: 5 * time.Minute})
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				service.Record(ctx, model.ChannelEmail, "sendgrid", true, time.Millisecond)
+				if j%25 == 0 {
+					_ = service.Flush(ctx)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, service.Flush(ctx))
+
+	successes, _, latencyMs := repo.summary("sendgrid")
+	assert.Equal(t, int64(800), successes)
+	assert.Equal(t, int64(800), latencyMs)
+}
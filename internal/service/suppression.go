@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"hermes-api/internal/dto"
+	"hermes-api/internal/model"
+	"hermes-api/internal/repository"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/phone"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SuppressionService defines the interface for the suppression list of applications
+type SuppressionService interface {
+	CreateSuppression(ctx context.Context, application *model.Application, req dto.CreateSuppressionRequest) (*model.Suppression, error)
+	GetSuppression(ctx context.Context, application *model.Application, id uuid.UUID) (*model.Suppression, error)
+	ListSuppressions(ctx context.Context, application *model.Application, channel model.Channel, limit, offset int) ([]*model.Suppression, error)
+	DeleteSuppression(ctx context.Context, application *model.Application, id uuid.UUID) error
+	Suppress(ctx context.Context, applicationID uuid.UUID, channel model.Channel, address string, reason model.SuppressionReason, source, description string) error
+	FilterSuppressed(ctx context.Context, applicationID uuid.UUID, channel model.Channel, addresses []string) ([]string, []*model.Suppression, error)
+}
+
+// suppressionService implements SuppressionService
+type suppressionService struct {
+	suppressionRepo repository.SuppressionRepository
+}
+
+// NewSuppressionService creates a new suppression service
+func NewSuppressionService(suppressionRepo repository.SuppressionRepository) SuppressionService {
+	return &suppressionService{
+		suppressionRepo: suppressionRepo,
+	}
+}
+
+// CreateSuppression manually suppresses an address, replacing an expired suppression of the same address
+func (s *suppressionService) CreateSuppression(ctx context.Context, application *model.Application, req dto.CreateSuppressionRequest) (*model.Suppression, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		appErr := errorx.New(errorx.ErrorTypeValidation, errorx.ErrorCodeInvalidValue, "Invalid request body")
+		return nil, appErr.WithDetails(map[string]any{"ExpiresAt": errorx.ErrorCodeValueOutOfRange})
+	}
+
+	channel := model.Channel(req.Channel)
+	address := normalizeAddress(channel, req.Address)
+
+	existing, err := s.suppressionRepo.GetByAddress(ctx, application.ID, channel, address)
+	if err == nil && (existing.ExpiresAt == nil || existing.ExpiresAt.After(time.Now())) {
+		return nil, errorx.NewWithTemplate(errorx.ErrorTypeConflict, errorx.ErrorCodeSuppressionAlreadyExists, address, channel)
+	}
+
+	reason := model.SuppressionReason(req.Reason)
+	if reason == "" {
+		reason = model.SuppressionReasonManual
+	}
+
+	suppression := &model.Suppression{
+		ApplicationID: application.ID,
+		Channel:       channel,
+		Address:       address,
+		Reason:        reason,
+		Source:        model.SuppressionSourceAPI,
+		Description:   req.Description,
+		ExpiresAt:     req.ExpiresAt,
+	}
+	if err := s.suppressionRepo.Upsert(ctx, suppression); err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to create suppression",
+		)
+		return nil, appErr
+	}
+
+	// The ID of a replaced suppression is not returned by the upsert
+	return s.getByAddress(ctx, application.ID, channel, address)
+}
+
+// GetSuppression retrieves a suppression of the application by ID
+func (s *suppressionService) GetSuppression(ctx context.Context, application *model.Application, id uuid.UUID) (*model.Suppression, error) {
+	suppression, err := s.suppressionRepo.GetByApplication(ctx, application.ID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errorx.NewWithTemplate(errorx.ErrorTypeNotFound, errorx.ErrorCodeSuppressionNotFound, id.String())
+		}
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch suppression",
+		)
+		return nil, appErr
+	}
+
+	return suppression, nil
+}
+
+// ListSuppressions retrieves the suppressions of the application with pagination, including expired ones
+func (s *suppressionService) ListSuppressions(ctx context.Context, application *model.Application, channel model.Channel, limit, offset int) ([]*model.Suppression, error) {
+	suppressions, err := s.suppressionRepo.ListByApplication(ctx, application.ID, channel, limit, offset)
+	if err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch suppressions",
+		)
+		return nil, appErr
+	}
+
+	return suppressions, nil
+}
+
+// DeleteSuppression removes an address from the suppression list
+func (s *suppressionService) DeleteSuppression(ctx context.Context, application *model.Application, id uuid.UUID) error {
+	suppression, err := s.GetSuppression(ctx, application, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.suppressionRepo.Delete(ctx, suppression.ID); err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to delete suppression",
+		)
+		return appErr
+	}
+
+	return nil
+}
+
+// Suppress permanently suppresses an address reported by a bounce or complaint event
+func (s *suppressionService) Suppress(ctx context.Context, applicationID uuid.UUID, channel model.Channel, address string, reason model.SuppressionReason, source, description string) error {
+	suppression := &model.Suppression{
+		ApplicationID: applicationID,
+		Channel:       channel,
+		Address:       normalizeAddress(channel, address),
+		Reason:        reason,
+		Source:        source,
+		Description:   description,
+	}
+	return s.suppressionRepo.Upsert(ctx, suppression)
+}
+
+// FilterSuppressed splits addresses into the ones that may be delivered to and the active
+// suppressions of the others
+func (s *suppressionService) FilterSuppressed(ctx context.Context, applicationID uuid.UUID, channel model.Channel, addresses []string) ([]string, []*model.Suppression, error) {
+	normalized := make([]string, len(addresses))
+	for i, address := range addresses {
+		normalized[i] = normalizeAddress(channel, address)
+	}
+
+	suppressions, err := s.suppressionRepo.ListActive(ctx, applicationID, channel, normalized)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(suppressions) == 0 {
+		return addresses, nil, nil
+	}
+
+	suppressed := make(map[string]bool, len(suppressions))
+	for _, suppression := range suppressions {
+		suppressed[suppression.Address] = true
+	}
+
+	allowed := make([]string, 0, len(addresses))
+	for i, address := range addresses {
+		if !suppressed[normalized[i]] {
+			allowed = append(allowed, address)
+		}
+	}
+	return allowed, suppressions, nil
+}
+
+// getByAddress retrieves the suppression of an address
+func (s *suppressionService) getByAddress(ctx context.Context, applicationID uuid.UUID, channel model.Channel, address string) (*model.Suppression, error) {
+	suppression, err := s.suppressionRepo.GetByAddress(ctx, applicationID, channel, address)
+	if err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch suppression",
+		)
+		return nil, appErr
+	}
+
+	return suppression, nil
+}
+
+// normalizeAddress returns the form an address is suppressed under, so that the same mailbox or
+// number written differently matches the same suppression
+func normalizeAddress(channel model.Channel, address string) string {
+	address = strings.TrimSpace(address)
+	switch channel {
+	case model.ChannelEmail:
+		return strings.ToLower(address)
+	case model.ChannelSMS:
+		if normalized, err := phone.NormalizeE164(address); err == nil {
+			return normalized
+		}
+	}
+	return address
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"hermes-api/internal/dto"
+	"hermes-api/internal/model"
+	"hermes-api/internal/repository"
+	"hermes-api/pkg/errorx"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// memorySuppressionRepo keeps suppressions in memory, keyed by application, channel and address
+type memorySuppressionRepo struct {
+	repository.SuppressionRepository
+	suppressions map[string]*model.Suppression
+	listErr      error
+}
+
+func newMemorySuppressionRepo(suppressions ...*model.Suppression) *memorySuppressionRepo {
+	repo := &memorySuppressionRepo{suppressions: make(map[string]*model.Suppression)}
+	for _, suppression := range suppressions {
+		repo.suppressions[suppressionKey(suppression.ApplicationID, suppression.Channel, suppression.Address)] = suppression
+	}
+	return repo
+}
+
+// suppressionKey returns the unique key of the suppression of an address
+func suppressionKey(applicationID uuid.UUID, channel model.Channel, address string) string {
+	return applicationID.String() + ":" + string(channel) + ":" + address
+}
+
+func (r *memorySuppressionRepo) GetByAddress(ctx context.Context, applicationID uuid.UUID, channel model.Channel, address string) (*model.Suppression, error) {
+	suppression, ok := r.suppressions[suppressionKey(applicationID, channel, address)]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return suppression, nil
+}
+
+func (r *memorySuppressionRepo) ListActive(ctx context.Context, applicationID uuid.UUID, channel model.Channel, addresses []string) ([]*model.Suppression, error) {
+	if r.listErr != nil {
+		return nil, r.listErr
+	}
+	var suppressions []*model.Suppression
+	for _, address := range addresses {
+		suppression, ok := r.suppressions[suppressionKey(applicationID, channel, address)]
+		if ok && (suppression.ExpiresAt == nil || suppression.ExpiresAt.After(time.Now())) {
+			suppressions = append(suppressions, suppression)
+		}
+	}
+	return suppressions, nil
+}
+
+func (r *memorySuppressionRepo) Upsert(ctx context.Context, suppression *model.Suppression) error {
+	key := suppressionKey(suppression.ApplicationID, suppression.Channel, suppression.Address)
+	if existing, ok := r.suppressions[key]; ok {
+		suppression.ID = existing.ID
+	} else {
+		suppression.ID = uuid.New()
+	}
+	r.suppressions[key] = suppression
+	return nil
+}
+
+func TestFilterSuppressed(t *testing.T) {
+	application := uuid.New()
+	expired := time.Now().Add(-time.Hour)
+	later := time.Now().Add(time.Hour)
+	repo := newMemorySuppressionRepo(
+		&model.Suppression{ApplicationID: application, Channel: model.ChannelEmail, Address: "bounced@example.com", Reason: model.SuppressionReasonBounce},
+		&model.Suppression{ApplicationID: application, Channel: model.ChannelEmail, Address: "paused@example.com", Reason: model.SuppressionReasonManual, ExpiresAt: &later},
+		&model.Suppression{ApplicationID: application, Channel: model.ChannelEmail, Address: "expired@example.com", Reason: model.SuppressionReasonManual, ExpiresAt: &expired},
+		&model.Suppression{ApplicationID: application, Channel: model.ChannelSMS, Address: "+15551234567", Reason: model.SuppressionReasonComplaint},
+		&model.Suppression{ApplicationID: uuid.New(), Channel: model.ChannelEmail, Address: "other@example.com", Reason: model.SuppressionReasonBounce},
+	)
+	service := NewSuppressionService(repo)
+
+	tests := []struct {
+		name        string
+		channel     model.Channel
+		addresses   []string
+		wantAllowed []string
+		wantReasons []model.SuppressionReason
+	}{
+		{
+			name:        "not suppressed",
+			channel:     model.ChannelEmail,
+			addresses:   []string{"user@example.com"},
+			wantAllowed: []string{"user@example.com"},
+		},
+		{
+			name:        "suppressed",
+			channel:     model.ChannelEmail,
+			addresses:   []string{"bounced@example.com"},
+			wantAllowed: []string{},
+			wantReasons: []model.SuppressionReason{model.SuppressionReasonBounce},
+		},
+		{
+			name:        "written differently",
+			channel:     model.ChannelEmail,
+			addresses:   []string{" Bounced@Example.COM "},
+			wantAllowed: []string{},
+			wantReasons: []model.SuppressionReason{model.SuppressionReasonBounce},
+		},
+		{
+			name:        "allowed addresses keep their form",
+			channel:     model.ChannelEmail,
+			addresses:   []string{"User@Example.com", "bounced@example.com", "paused@example.com"},
+			wantAllowed: []string{"User@Example.com"},
+			wantReasons: []model.SuppressionReason{model.SuppressionReasonBounce, model.SuppressionReasonManual},
+		},
+		{
+			name:        "expired suppression",
+			channel:     model.ChannelEmail,
+			addresses:   []string{"expired@example.com"},
+			wantAllowed: []string{"expired@example.com"},
+		},
+		{
+			name:        "suppressed by another application",
+			channel:     model.ChannelEmail,
+			addresses:   []string{"other@example.com"},
+			wantAllowed: []string{"other@example.com"},
+		},
+		{
+			name:        "suppressed on another channel",
+			channel:     model.ChannelPush,
+			addresses:   []string{"bounced@example.com"},
+			wantAllowed: []string{"bounced@example.com"},
+		},
+		{
+			name:        "phone number in another format",
+			channel:     model.ChannelSMS,
+			addresses:   []string{"+1 (555) 123-4567"},
+			wantAllowed: []string{},
+			wantReasons: []model.SuppressionReason{model.SuppressionReasonComplaint},
+		},
+		{
+			name:        "invalid phone number",
+			channel:     model.ChannelSMS,
+			addresses:   []string{"not-a-number"},
+			wantAllowed: []string{"not-a-number"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, suppressions, err := service.FilterSuppressed(context.Background(), application, tt.channel, tt.addresses)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAllowed, allowed)
+
+			var reasons []model.SuppressionReason
+			for _, suppression := range suppressions {
+				reasons = append(reasons, suppression.Reason)
+			}
+			assert.Equal(t, tt.wantReasons, reasons)
+		})
+	}
+}
+
+func TestCreateSuppression(t *testing.T) {
+	application := &model.Application{ID: uuid.New()}
+	expired := time.Now().Add(-time.Hour)
+	later := time.Now().Add(time.Hour)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		existing *model.Suppression
+		req      dto.CreateSuppressionRequest
+		wantErr  errorx.ErrorType
+	}{
+		{
+			name: "new address",
+			req:  dto.CreateSuppressionRequest{Channel: "email", Address: " User@Example.com"},
+		},
+		{
+			name:     "active suppression",
+			existing: &model.Suppression{ID: uuid.New(), Reason: model.SuppressionReasonBounce},
+			req:      dto.CreateSuppressionRequest{Channel: "email", Address: "USER@example.com"},
+			wantErr:  errorx.ErrorTypeConflict,
+		},
+		{
+			name:     "suppression expiring later",
+			existing: &model.Suppression{ID: uuid.New(), Reason: model.SuppressionReasonManual, ExpiresAt: &later},
+			req:      dto.CreateSuppressionRequest{Channel: "email", Address: "user@example.com"},
+			wantErr:  errorx.ErrorTypeConflict,
+		},
+		{
+			name:     "expired suppression is replaced",
+			existing: &model.Suppression{ID: uuid.New(), Reason: model.SuppressionReasonManual, ExpiresAt: &expired},
+			req:      dto.CreateSuppressionRequest{Channel: "email", Address: "user@example.com", Reason: "complaint"},
+		},
+		{
+			name:    "expiry in the past",
+			req:     dto.CreateSuppressionRequest{Channel: "email", Address: "user@example.com", ExpiresAt: &expired},
+			wantErr: errorx.ErrorTypeValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemorySuppressionRepo()
+			if tt.existing != nil {
+				tt.existing.ApplicationID, tt.existing.Channel, tt.existing.Address = application.ID, model.ChannelEmail, "user@example.com"
+				repo = newMemorySuppressionRepo(tt.existing)
+			}
+			service := NewSuppressionService(repo)
+
+			suppression, err := service.CreateSuppression(ctx, application, tt.req)
+			if tt.wantErr != "" {
+				var appErr *errorx.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, tt.wantErr, appErr.Type)
+				if tt.existing != nil {
+					assert.Same(t, tt.existing, repo.suppressions[suppressionKey(application.ID, model.ChannelEmail, "user@example.com")])
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "user@example.com", suppression.Address)
+			assert.Equal(t, model.SuppressionSourceAPI, suppression.Source)
+			assert.Nil(t, suppression.ExpiresAt)
+			if tt.existing != nil {
+				assert.Equal(t, tt.existing.ID, suppression.ID, "the expired suppression is replaced")
+				assert.Equal(t, model.SuppressionReasonComplaint, suppression.Reason)
+			} else {
+				assert.Equal(t, model.SuppressionReasonManual, suppression.Reason)
+			}
+		})
+	}
+}
+
+func TestCheckSuppressions(t *testing.T) {
+	application := uuid.New()
+	repo := newMemorySuppressionRepo(
+		&model.Suppression{ApplicationID: application, Channel: model.ChannelEmail, Address: "bounced@example.com", Reason: model.SuppressionReasonBounce},
+	)
+	service := &deliveryService{suppressionService: NewSuppressionService(repo)}
+	ctx := context.Background()
+
+	t.Run("suppressed recipients are removed", func(t *testing.T) {
+		notification := &model.Notification{ApplicationID: application, Channel: model.ChannelEmail, Recipient: "user@example.com,Bounced@example.com"}
+		recipients, err := service.checkSuppressions(ctx, notification, []string{"user@example.com", "Bounced@example.com"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"user@example.com"}, recipients)
+		assert.Equal(t, "user@example.com", notification.Recipient)
+	})
+
+	t.Run("recipient is kept as written", func(t *testing.T) {
+		notification := &model.Notification{ApplicationID: application, Channel: model.ChannelEmail, Recipient: "User@Example.com"}
+		recipients, err := service.checkSuppressions(ctx, notification, []string{"User@Example.com"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"User@Example.com"}, recipients)
+		assert.Equal(t, "User@Example.com", notification.Recipient)
+	})
+
+	t.Run("notification is skipped when every recipient is suppressed", func(t *testing.T) {
+		notification := &model.Notification{ApplicationID: application, Channel: model.ChannelEmail, Recipient: "bounced@example.com"}
+		_, err := service.checkSuppressions(ctx, notification, []string{"bounced@example.com"})
+
+		var skipped *deliverySkipped
+		require.ErrorAs(t, err, &skipped)
+		assert.Equal(t, model.NotificationStatusSkippedSuppressed, skipped.status)
+		assert.Contains(t, skipped.reason, "bounce")
+		assert.Equal(t, "bounced@example.com", notification.Recipient)
+	})
+
+	t.Run("lookup failures are retried", func(t *testing.T) {
+		failing := newMemorySuppressionRepo()
+		failing.listErr = errors.New("connection refused")
+		service := &deliveryService{suppressionService: NewSuppressionService(failing)}
+
+		notification := &model.Notification{ApplicationID: application, Channel: model.ChannelEmail, Recipient: "user@example.com"}
+		_, err := service.checkSuppressions(ctx, notification, []string{"user@example.com"})
+
+		var transient *transientFailure
+		assert.ErrorAs(t, err, &transient)
+	})
+}
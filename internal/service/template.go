@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"hermes-api/internal/dto"
+	"hermes-api/internal/model"
+	"hermes-api/internal/render"
+	"hermes-api/internal/repository"
+	"hermes-api/internal/validation"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/i18n"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TemplateService defines the interface for template business logic
+type TemplateService interface {
+	CreateTemplate(ctx context.Context, application *model.Application, req dto.CreateTemplateRequest) (*model.Template, error)
+	GetTemplateByID(ctx context.Context, application *model.Application, id uuid.UUID) (*model.Template, error)
+	ListTemplates(ctx context.Context, application *model.Application, limit, offset int) ([]*model.Template, error)
+	RenderTemplate(ctx context.Context, application *model.Application, id uuid.UUID, req dto.RenderTemplateRequest) (*render.Result, error)
+}
+
+// templateService implements TemplateService
+type templateService struct {
+	templateRepo repository.TemplateRepository
+}
+
+// NewTemplateService creates a new template service
+func NewTemplateService(templateRepo repository.TemplateRepository) TemplateService {
+	return &templateService{
+		templateRepo: templateRepo,
+	}
+}
+
+// CreateTemplate creates a template with its locale variants
+func (s *templateService) CreateTemplate(ctx context.Context, application *model.Application, req dto.CreateTemplateRequest) (*model.Template, error) {
+	// Check if a template with the same name already exists
+	existing, err := s.templateRepo.GetByName(ctx, application.ID, req.Name)
+	if err == nil && existing != nil {
+		return nil, errorx.NewWithTemplate(errorx.ErrorTypeConflict, errorx.ErrorCodeTemplateAlreadyExists, req.Name)
+	}
+
+	template := &model.Template{
+		ApplicationID: application.ID,
+		Name:          req.Name,
+		Channel:       model.Channel(req.Channel),
+		Category:      normalizeCategory(req.Category),
+		DefaultLocale: i18n.Normalize(req.DefaultLocale),
+		DataSchema:    req.DataSchema,
+		Markup:        req.Markup,
+		Fallbacks:     dto.FallbackChain(req.Fallbacks),
+	}
+
+	if template.Markup == "" {
+		template.Markup = render.MarkupHTML
+	}
+
+	details := make(map[string]any)
+	if req.DataSchema != nil {
+		if _, err := validation.CompileSchema(req.DataSchema); err != nil {
+			details["data_schema"] = err.Error()
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, variantReq := range req.Variants {
+		variant := model.TemplateVariant{
+			Locale:   i18n.Normalize(variantReq.Locale),
+			Subject:  variantReq.Subject,
+			Body:     variantReq.Body,
+			HTMLBody: variantReq.HTMLBody,
+		}
+
+		field := fmt.Sprintf("variants[%d]", i)
+		if seen[variant.Locale] {
+			details[field] = errorx.ErrorCodeTemplateInvalid
+			continue
+		}
+		seen[variant.Locale] = true
+
+		if err := render.Validate(&variant); err != nil {
+			details[field] = err.Error()
+			continue
+		}
+
+		template.Variants = append(template.Variants, variant)
+	}
+
+	fallbackDetails, err := validateFallbackChain(ctx, s.templateRepo, application.ID, template.Name, template.Fallbacks)
+	if err != nil {
+		return nil, err
+	}
+	for field, code := range fallbackDetails {
+		details[field] = code
+	}
+
+	if len(details) > 0 {
+		appErr := errorx.New(errorx.ErrorTypeValidation, errorx.ErrorCodeTemplateInvalid, "Invalid template")
+		return nil, appErr.WithDetails(details)
+	}
+
+	if err := s.templateRepo.Create(ctx, template); err != nil {
+		// A template of the same name created since the check above
+		if repository.IsUniqueViolation(err) {
+			return nil, errorx.NewWithTemplate(errorx.ErrorTypeConflict, errorx.ErrorCodeTemplateAlreadyExists, req.Name)
+		}
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to create template",
+		)
+		return nil, appErr
+	}
+
+	return template, nil
+}
+
+// GetTemplateByID retrieves a template of the application by ID
+func (s *templateService) GetTemplateByID(ctx context.Context, application *model.Application, id uuid.UUID) (*model.Template, error) {
+	template, err := s.templateRepo.GetByApplication(ctx, application.ID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errorx.NewWithTemplate(errorx.ErrorTypeNotFound, errorx.ErrorCodeTemplateNotFound, id.String())
+		}
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch template",
+		)
+		return nil, appErr
+	}
+
+	return template, nil
+}
+
+// ListTemplates retrieves the templates of the application with pagination
+func (s *templateService) ListTemplates(ctx context.Context, application *model.Application, limit, offset int) ([]*model.Template, error) {
+	templates, err := s.templateRepo.ListByApplication(ctx, application.ID, limit, offset)
+	if err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to fetch templates",
+		)
+		return nil, appErr
+	}
+
+	return templates, nil
+}
+
+// RenderTemplate renders a template for the requested locale, following the locale fallback chain
+func (s *templateService) RenderTemplate(ctx context.Context, application *model.Application, id uuid.UUID, req dto.RenderTemplateRequest) (*render.Result, error) {
+	template, err := s.GetTemplateByID(ctx, application, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return renderTemplate(template, application, req.Data, req.Locale)
+}
+
+// validateFallbackChain reports the steps of a fallback chain sending a template that does not exist,
+// or the template the chain starts from
+func validateFallbackChain(ctx context.Context, templateRepo repository.TemplateRepository, applicationID uuid.UUID, origin string, chain []model.FallbackStep) (map[string]any, error) {
+	details := make(map[string]any)
+	for i, step := range chain {
+		field := fmt.Sprintf("fallbacks[%d]", i)
+		if step.Template == origin {
+			details[field] = errorx.ErrorCodeInvalidValue
+			continue
+		}
+
+		_, err := templateRepo.GetByName(ctx, applicationID, step.Template)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			details[field] = errorx.ErrorCodeTemplateNotFound
+			continue
+		}
+		if err != nil {
+			appErr := errorx.New(
+				errorx.ErrorTypeInternal,
+				errorx.ErrorCodeDatabaseError,
+				"Failed to fetch template",
+			)
+			return nil, appErr
+		}
+	}
+	return details, nil
+}
+
+// renderTemplate renders a template and maps rendering failures to application errors
+func renderTemplate(template *model.Template, application *model.Application, data map[string]any, locales ...string) (*render.Result, error) {
+	result, err := render.Render(template, application.DefaultLocale, data, locales...)
+	if err != nil {
+		var localeErr *render.LocaleNotFoundError
+		if errors.As(err, &localeErr) {
+			return nil, errorx.NewWithTemplate(errorx.ErrorTypeValidation, errorx.ErrorCodeTemplateLocaleNotFound, localeErr.Template, localeErr.Chain)
+		}
+		appErr := errorx.New(
+			errorx.ErrorTypeValidation,
+			errorx.ErrorCodeTemplateRenderFailed,
+			err.Error(),
+		)
+		return nil, appErr
+	}
+
+	if template.Channel == model.ChannelEmail {
+		result, err = render.Email(result, render.EmailOptions{
+			Markup:  template.Markup,
+			BaseURL: application.BaseURL,
+		})
+		if err != nil {
+			appErr := errorx.New(
+				errorx.ErrorTypeValidation,
+				errorx.ErrorCodeTemplateRenderFailed,
+				err.Error(),
+			)
+			return nil, appErr
+		}
+	}
+
+	return result, nil
+}
+
+// normalizeCategory returns the canonical form of a notification category
+func normalizeCategory(category string) string {
+	return strings.ToLower(strings.TrimSpace(category))
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"hermes-api/config"
+	"hermes-api/internal/model"
+	"hermes-api/internal/render"
+	"hermes-api/internal/repository"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/signer"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// openPurpose is the purpose of the signed tokens of open tracking pixels
+	openPurpose = "open"
+	// clickPurpose is the purpose of the signed tokens of tracked links
+	clickPurpose = "click"
+)
+
+// machineUserAgents matches the user agents of link scanners, security gateways and link previews,
+// which fetch the pixel and follow the links of messages before, or instead of, the recipient
+var machineUserAgents = regexp.MustCompile(`(?i)bot|crawler|spider|preview|scanner|proofpoint|mimecast|barracuda|safelinks|urldefense|python|curl|wget|go-http-client|java/|okhttp|headless`)
+
+// trackingClaims identifies the notification, and for clicks the link, a tracking URL was issued for
+type trackingClaims struct {
+	NotificationID uuid.UUID `json:"n"`
+	URL            string    `json:"u,omitempty"`
+}
+
+// TrackingClient describes the client that loaded a tracking URL
+type TrackingClient struct {
+	Method    string
+	UserAgent string
+}
+
+// TrackingService defines the interface for email open and click tracking
+type TrackingService interface {
+	Instrument(notification *model.Notification, htmlBody string) (string, error)
+	RecordOpen(ctx context.Context, token string, client TrackingClient) error
+	RecordClick(ctx context.Context, token string, client TrackingClient) (string, error)
+}
+
+// trackingService implements TrackingService
+type trackingService struct {
+	notificationRepo  repository.NotificationRepository
+	contactRepo       repository.ContactRepository
+	trackingEventRepo repository.TrackingEventRepository
+	signer            *signer.Signer
+	publicURL         string
+	cfg               config.TrackingConfig
+}
+
+// NewTrackingService creates a new tracking service
+func NewTrackingService(notificationRepo repository.NotificationRepository, contactRepo repository.ContactRepository, trackingEventRepo repository.TrackingEventRepository, linkSigner *signer.Signer, publicURL string, cfg config.TrackingConfig) TrackingService {
+	return &trackingService{
+		notificationRepo:  notificationRepo,
+		contactRepo:       contactRepo,
+		trackingEventRepo: trackingEventRepo,
+		signer:            linkSigner,
+		publicURL:         strings.TrimRight(publicURL, "/"),
+		cfg:               cfg,
+	}
+}
+
+// Instrument adds the open tracking pixel and rewrites the links of the HTML body of an email, as enabled
+// by its application. Contacts who asked not to be tracked receive the body unchanged. Links to the public
+// pages, e.g. the preference center and unsubscribe links, are not tracked.
+func (s *trackingService) Instrument(notification *model.Notification, htmlBody string) (string, error) {
+	application := &notification.Application
+	if notification.Channel != model.ChannelEmail || htmlBody == "" || (!application.TrackOpens && !application.TrackClicks) {
+		return htmlBody, nil
+	}
+	if notification.Contact != nil && notification.Contact.DoNotTrack {
+		return htmlBody, nil
+	}
+
+	opts := render.TrackingOptions{Exclude: []string{s.publicURL + "/"}}
+	if application.TrackOpens {
+		token, err := s.signer.Sign(openPurpose, trackingClaims{NotificationID: notification.ID}, time.Time{})
+		if err != nil {
+			return "", s.signFailed()
+		}
+		opts.PixelURL = s.publicURL + "/t/open/" + token
+	}
+	if application.TrackClicks {
+		opts.ClickURL = func(target string) (string, error) {
+			token, err := s.signer.Sign(clickPurpose, trackingClaims{NotificationID: notification.ID, URL: target}, time.Time{})
+			if err != nil {
+				return "", s.signFailed()
+			}
+			return s.publicURL + "/t/click/" + token, nil
+		}
+	}
+
+	instrumented, err := render.AddTracking(htmlBody, opts)
+	if err != nil {
+		var appErr *errorx.AppError
+		if errors.As(err, &appErr) {
+			return "", err
+		}
+		appErr = errorx.New(
+			errorx.ErrorTypeValidation,
+			errorx.ErrorCodeTemplateRenderFailed,
+			err.Error(),
+		)
+		return "", appErr
+	}
+	return instrumented, nil
+}
+
+// RecordOpen records the open of the notification a pixel was issued for
+func (s *trackingService) RecordOpen(ctx context.Context, token string, client TrackingClient) error {
+	var claims trackingClaims
+	if err := s.verifyToken(openPurpose, token, &claims); err != nil {
+		return err
+	}
+
+	return s.record(ctx, claims, model.TrackingEventOpen, client)
+}
+
+// RecordClick records the click of a tracked link and returns the URL the recipient is redirected to.
+// The redirect does not depend on the event being recorded, only on the link being valid.
+func (s *trackingService) RecordClick(ctx context.Context, token string, client TrackingClient) (string, error) {
+	var claims trackingClaims
+	if err := s.verifyToken(clickPurpose, token, &claims); err != nil {
+		return "", err
+	}
+
+	return claims.URL, s.record(ctx, claims, model.TrackingEventClick, client)
+}
+
+// record stores a tracking event unless the contact of the notification has since asked not to be tracked
+func (s *trackingService) record(ctx context.Context, claims trackingClaims, eventType model.TrackingEventType, client TrackingClient) error {
+	notification, err := s.notificationRepo.GetByID(ctx, claims.NotificationID)
+	if err != nil {
+		return errorx.NewWithTemplate(errorx.ErrorTypeForbidden, errorx.ErrorCodeLinkInvalid)
+	}
+
+	if notification.ContactID != nil {
+		contact, err := s.contactRepo.GetByID(ctx, *notification.ContactID)
+		if err == nil && contact.DoNotTrack {
+			return nil
+		}
+	}
+
+	event := &model.TrackingEvent{
+		NotificationID: notification.ID,
+		ApplicationID:  notification.ApplicationID,
+		Type:           eventType,
+		URL:            claims.URL,
+		Machine:        s.isMachine(notification, client),
+		UserAgent:      client.UserAgent,
+	}
+	if _, err := s.trackingEventRepo.Record(ctx, event, s.cfg.DedupeWindow); err != nil {
+		appErr := errorx.New(
+			errorx.ErrorTypeInternal,
+			errorx.ErrorCodeDatabaseError,
+			"Failed to record tracking event",
+		)
+		return appErr
+	}
+	return nil
+}
+
+// isMachine reports whether an event was likely caused by software rather than the recipient: HEAD
+// requests, known scanner user agents, the bare user agent of Apple Mail Privacy Protection, and events
+// arriving right after the send, before a person could have read the message
+func (s *trackingService) isMachine(notification *model.Notification, client TrackingClient) bool {
+	userAgent := strings.TrimSpace(client.UserAgent)
+	switch {
+	case strings.EqualFold(client.Method, "HEAD"):
+		return true
+	case userAgent == "" || userAgent == "Mozilla/5.0":
+		return true
+	case machineUserAgents.MatchString(userAgent):
+		return true
+	case notification.SentAt != nil && time.Since(*notification.SentAt) < s.cfg.PrefetchWindow:
+		return true
+	}
+	return false
+}
+
+// verifyToken checks the signature and purpose of a tracking token, tracking tokens never expire
+func (s *trackingService) verifyToken(purpose, token string, claims any) error {
+	if err := s.signer.Verify(purpose, token, claims); err != nil {
+		return errorx.NewWithTemplate(errorx.ErrorTypeForbidden, errorx.ErrorCodeLinkInvalid)
+	}
+	return nil
+}
+
+// signFailed is returned when a tracking URL cannot be signed
+func (s *trackingService) signFailed() error {
+	appErr := errorx.New(
+		errorx.ErrorTypeInternal,
+		errorx.ErrorCodeUnknownError,
+		"Failed to sign tracking link",
+	)
+	return appErr
+}
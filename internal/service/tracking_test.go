@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"html"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"hermes-api/config"
+	"hermes-api/internal/model"
+	"hermes-api/internal/repository"
+	"hermes-api/pkg/errorx"
+	"hermes-api/pkg/signer"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const trackingPublicURL = "https://hermes.example.com"
+
+// trackingNotificationRepo holds the notifications tracking events are recorded for
+type trackingNotificationRepo struct {
+	repository.NotificationRepository
+	notifications map[uuid.UUID]*model.Notification
+}
+
+func (r *trackingNotificationRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.Notification, error) {
+	notification, ok := r.notifications[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return notification, nil
+}
+
+// trackingContactRepo holds the contacts of the notifications
+type trackingContactRepo struct {
+	repository.ContactRepository
+	contacts map[uuid.UUID]*model.Contact
+}
+
+func (r *trackingContactRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.Contact, error) {
+	contact, ok := r.contacts[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return contact, nil
+}
+
+// recordingTrackingEventRepo records the tracking events
+type recordingTrackingEventRepo struct {
+	repository.TrackingEventRepository
+	events []*model.TrackingEvent
+}
+
+func (r *recordingTrackingEventRepo) Record(ctx context.Context, event *model.TrackingEvent, window time.Duration) (bool, error) {
+	r.events = append(r.events, event)
+	return true, nil
+}
+
+// hrefPattern matches the link targets of rendered HTML
+var hrefPattern = regexp.MustCompile(`href="([^"]*)"`)
+
+// hrefs returns the link targets of an HTML body, in order
+func hrefs(body string) []string {
+	var targets []string
+	for _, match := range hrefPattern.FindAllStringSubmatch(body, -1) {
+		targets = append(targets, html.UnescapeString(match[1]))
+	}
+	return targets
+}
+
+func newTestTrackingService(notifications []*model.Notification, contacts ...*model.Contact) (*trackingService, *recordingTrackingEventRepo) {
+	notificationRepo := &trackingNotificationRepo{notifications: make(map[uuid.UUID]*model.Notification)}
+	for _, notification := range notifications {
+		notificationRepo.notifications[notification.ID] = notification
+	}
+	contactRepo := &trackingContactRepo{contacts: make(map[uuid.UUID]*model.Contact)}
+	for _, contact := range contacts {
+		contactRepo.contacts[contact.ID] = contact
+	}
+	events := &recordingTrackingEventRepo{}
+	service := NewTrackingService(notificationRepo, contactRepo, events, signer.New("link-secret"), trackingPublicURL+"/",
+		config.TrackingConfig{DedupeWindow: time.Hour, PrefetchWindow: 2 * time.Second})
+	return service.(*trackingService), events
+}
+
+func TestTrackingInstrument(t *testing.T) {
+	body := `<html><body>
+<a href="https://shop.example.com/orders/42?utm_source=email&amp;ref=1">Order</a>
+<a href=" http://example.com/plain ">Plain</a>
+<a href="mailto:help@example.com">Mail</a>
+<a href="tel:+15551234567">Call</a>
+<a href="#top">Top</a>
+<a href="/relative/path">Relative</a>
+<a href="javascript:alert(1)">Script</a>
+<a href="ftp://files.example.com/report.pdf">FTP</a>
+<a href="https://hermes.example.com/preferences/token">Preferences</a>
+</body></html>`
+
+	notification := &model.Notification{
+		ID:          uuid.New(),
+		Channel:     model.ChannelEmail,
+		Application: model.Application{TrackOpens: true, TrackClicks: true},
+	}
+	service, _ := newTestTrackingService(nil)
+
+	instrumented, err := service.Instrument(notification, body)
+	require.NoError(t, err)
+
+	links := hrefs(instrumented)
+	require.Len(t, links, 9)
+	for i, want := range []string{"https://shop.example.com/orders/42?utm_source=email&ref=1", "http://example.com/plain"} {
+		require.True(t, strings.HasPrefix(links[i], trackingPublicURL+"/t/click/"), "link %d must be tracked: %s", i, links[i])
+
+		var claims trackingClaims
+		require.NoError(t, service.signer.Verify(clickPurpose, strings.TrimPrefix(links[i], trackingPublicURL+"/t/click/"), &claims))
+		assert.Equal(t, notification.ID, claims.NotificationID)
+		assert.Equal(t, want, claims.URL)
+	}
+	assert.Equal(t, []string{
+		"mailto:help@example.com",
+		"tel:+15551234567",
+		"#top",
+		"/relative/path",
+		"javascript:alert(1)",
+		"ftp://files.example.com/report.pdf",
+		"https://hermes.example.com/preferences/token",
+	}, links[2:], "only absolute http(s) links outside of the public pages are tracked")
+
+	assert.Contains(t, instrumented, `<img src="`+trackingPublicURL+`/t/open/`)
+
+	t.Run("not instrumented", func(t *testing.T) {
+		contact := &model.Contact{DoNotTrack: true}
+		tests := []struct {
+			name         string
+			notification *model.Notification
+		}{
+			{name: "tracking disabled", notification: &model.Notification{Channel: model.ChannelEmail}},
+			{name: "not an email", notification: &model.Notification{Channel: model.ChannelSMS, Application: notification.Application}},
+			{name: "contact not tracked", notification: &model.Notification{Channel: model.ChannelEmail, Application: notification.Application, Contact: contact}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := service.Instrument(tt.notification, body)
+				require.NoError(t, err)
+				assert.Equal(t, body, got)
+			})
+		}
+	})
+
+	t.Run("opens only", func(t *testing.T) {
+		opensOnly := &model.Notification{ID: uuid.New(), Channel: model.ChannelEmail, Application: model.Application{TrackOpens: true}}
+		got, err := service.Instrument(opensOnly, body)
+		require.NoError(t, err)
+		assert.Equal(t, hrefs(body), hrefs(got))
+		assert.Contains(t, got, "/t/open/")
+	})
+}
+
+func TestTrackingRecord(t *testing.T) {
+	sentAt := time.Now().Add(-time.Hour)
+	notification := &model.Notification{ID: uuid.New(), ApplicationID: uuid.New(), SentAt: &sentAt}
+	const browser = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/605.1.15"
+
+	tests := []struct {
+		name        string
+		client      TrackingClient
+		sentAt      time.Time
+		wantMachine bool
+	}{
+		{name: "recipient", client: TrackingClient{Method: "GET", UserAgent: browser}},
+		{name: "HEAD request", client: TrackingClient{Method: "HEAD", UserAgent: browser}, wantMachine: true},
+		{name: "lower case HEAD request", client: TrackingClient{Method: "head", UserAgent: browser}, wantMachine: true},
+		{name: "no user agent", client: TrackingClient{Method: "GET"}, wantMachine: true},
+		{name: "Apple Mail Privacy Protection", client: TrackingClient{Method: "GET", UserAgent: "Mozilla/5.0"}, wantMachine: true},
+		{name: "security gateway", client: TrackingClient{Method: "GET", UserAgent: "Mozilla/5.0 (compatible; Proofpoint URL Defense)"}, wantMachine: true},
+		{name: "link preview", client: TrackingClient{Method: "GET", UserAgent: "Slackbot-LinkExpanding 1.0"}, wantMachine: true},
+		{name: "script", client: TrackingClient{Method: "GET", UserAgent: "python-requests/2.31"}, wantMachine: true},
+		{name: "right after the send", client: TrackingClient{Method: "GET", UserAgent: browser}, sentAt: time.Now(), wantMachine: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracked := *notification
+			if !tt.sentAt.IsZero() {
+				tracked.SentAt = &tt.sentAt
+			}
+			service, events := newTestTrackingService([]*model.Notification{&tracked})
+
+			openToken, err := service.signer.Sign(openPurpose, trackingClaims{NotificationID: tracked.ID}, time.Time{})
+			require.NoError(t, err)
+			require.NoError(t, service.RecordOpen(context.Background(), openToken, tt.client))
+
+			clickToken, err := service.signer.Sign(clickPurpose, trackingClaims{NotificationID: tracked.ID, URL: "https://shop.example.com"}, time.Time{})
+			require.NoError(t, err)
+			target, err := service.RecordClick(context.Background(), clickToken, tt.client)
+			require.NoError(t, err)
+			assert.Equal(t, "https://shop.example.com", target)
+
+			// Machine events are kept apart and never mark the notification opened or clicked
+			require.Len(t, events.events, 2)
+			for _, event := range events.events {
+				assert.Equal(t, tt.wantMachine, event.Machine, "%s event", event.Type)
+				assert.Equal(t, tracked.ApplicationID, event.ApplicationID)
+			}
+			assert.Equal(t, model.TrackingEventOpen, events.events[0].Type)
+			assert.Equal(t, model.TrackingEventClick, events.events[1].Type)
+			assert.Equal(t, "https://shop.example.com", events.events[1].URL)
+		})
+	}
+}
+
+func TestTrackingRecordRefused(t *testing.T) {
+	contact := &model.Contact{ID: uuid.New(), DoNotTrack: true}
+	notification := &model.Notification{ID: uuid.New(), ApplicationID: uuid.New()}
+	untracked := &model.Notification{ID: uuid.New(), ApplicationID: uuid.New(), ContactID: &contact.ID}
+	service, events := newTestTrackingService([]*model.Notification{notification, untracked}, contact)
+	client := TrackingClient{Method: "GET", UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64)"}
+
+	sign := func(purpose string, claims trackingClaims) string {
+		token, err := service.signer.Sign(purpose, claims, time.Time{})
+		require.NoError(t, err)
+		return token
+	}
+	clickToken := sign(clickPurpose, trackingClaims{NotificationID: notification.ID, URL: "https://shop.example.com"})
+	body, signature, _ := strings.Cut(clickToken, ".")
+
+	// tampered returns the click token with its claims replaced, keeping the original signature
+	tampered := func(url string) string {
+		forged := sign(clickPurpose, trackingClaims{NotificationID: notification.ID, URL: url})
+		forgedBody, _, _ := strings.Cut(forged, ".")
+		return forgedBody + "." + signature
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		open  bool
+	}{
+		{name: "tampered target", token: tampered("https://evil.example.com")},
+		{name: "signature of another token", token: body + "." + strings.SplitN(sign(clickPurpose, trackingClaims{NotificationID: uuid.New()}), ".", 2)[1]},
+		{name: "signed with another secret", token: func() string {
+			token, _ := signer.New("other-secret").Sign(clickPurpose, trackingClaims{NotificationID: notification.ID, URL: "https://evil.example.com"}, time.Time{})
+			return token
+		}()},
+		{name: "open token used as a link", token: sign(openPurpose, trackingClaims{NotificationID: notification.ID})},
+		{name: "click token used as a pixel", token: clickToken, open: true},
+		{name: "truncated", token: clickToken[:len(clickToken)-4]},
+		{name: "not a token", token: "not-a-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.open {
+				err = service.RecordOpen(context.Background(), tt.token, client)
+			} else {
+				var target string
+				target, err = service.RecordClick(context.Background(), tt.token, client)
+				assert.Empty(t, target, "refused links must not redirect")
+			}
+
+			var appErr *errorx.AppError
+			require.ErrorAs(t, err, &appErr)
+			assert.Equal(t, errorx.ErrorTypeForbidden, appErr.Type)
+		})
+	}
+	assert.Empty(t, events.events)
+
+	t.Run("deleted notification", func(t *testing.T) {
+		target, err := service.RecordClick(context.Background(), sign(clickPurpose, trackingClaims{NotificationID: uuid.New(), URL: "https://shop.example.com"}), client)
+		assert.Error(t, err)
+		assert.Equal(t, "https://shop.example.com", target, "valid links redirect even when the click can't be recorded")
+		assert.Empty(t, events.events)
+	})
+
+	t.Run("contact not tracked", func(t *testing.T) {
+		target, err := service.RecordClick(context.Background(), sign(clickPurpose, trackingClaims{NotificationID: untracked.ID, URL: "https://shop.example.com"}), client)
+		require.NoError(t, err)
+		assert.Equal(t, "https://shop.example.com", target, "the recipient is redirected without being tracked")
+		assert.Empty(t, events.events)
+	})
+}
@@ -0,0 +1,425 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"hermes-api/pkg/errorx"
+
+	"github.com/google/uuid"
+)
+
+// Schema is a compiled JSON Schema supporting the subset of keywords used to describe template data:
+// type, properties, required, additionalProperties, items, enum, const, minLength, maxLength, pattern,
+// format, minimum, maximum, exclusiveMinimum, exclusiveMaximum, minItems and maxItems
+type Schema struct {
+	Type                 schemaTypes        `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Const                json.RawMessage    `json:"const,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+
+	pattern              *regexp.Regexp
+	additional           *Schema // Schema for additional properties, nil when unrestricted
+	additionalDisallowed bool
+	constValue           any
+}
+
+// SchemaError describes a single schema violation at a JSON path such as "data.items[0].sku"
+type SchemaError struct {
+	Field string
+	Code  errorx.ErrorCode
+}
+
+// schemaTypes holds the "type" keyword, which may be a single type or a list of types
+type schemaTypes []string
+
+// UnmarshalJSON accepts both "string" and ["string", "null"]
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return fmt.Errorf("type must be a string or an array of strings")
+	}
+	*t = multiple
+	return nil
+}
+
+// validSchemaTypes lists the JSON Schema primitive types
+var validSchemaTypes = map[string]bool{
+	"string": true, "number": true, "integer": true, "boolean": true, "object": true, "array": true, "null": true,
+}
+
+// schemaKeywords lists the keywords a schema may use: the validated subset and the annotations, which
+// are accepted but not validated. Other keywords, e.g. oneOf or $ref, are rejected rather than ignored,
+// the data they describe would not be validated.
+var schemaKeywords = map[string]bool{
+	"type": true, "properties": true, "required": true, "additionalProperties": true, "items": true,
+	"enum": true, "const": true, "minLength": true, "maxLength": true, "pattern": true, "format": true,
+	"minimum": true, "maximum": true, "exclusiveMinimum": true, "exclusiveMaximum": true,
+	"minItems": true, "maxItems": true,
+
+	"$schema": true, "$id": true, "$comment": true, "title": true, "description": true,
+	"default": true, "examples": true, "deprecated": true, "readOnly": true, "writeOnly": true,
+}
+
+// CompileSchema parses and checks a JSON Schema given as a decoded JSON document
+func CompileSchema(document map[string]any) (*Schema, error) {
+	if err := checkKeywords(document, "#"); err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	var schema Schema
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	if err := schema.compile("#"); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// checkKeywords rejects the keywords of a schema document and its subschemas which are not supported
+func checkKeywords(document map[string]any, path string) error {
+	keywords := make([]string, 0, len(document))
+	for keyword := range document {
+		keywords = append(keywords, keyword)
+	}
+	sort.Strings(keywords)
+
+	for _, keyword := range keywords {
+		if !schemaKeywords[keyword] {
+			return fmt.Errorf("invalid schema at %s: unsupported keyword '%s'", path, keyword)
+		}
+
+		switch keyword {
+		case "items", "additionalProperties":
+			if subschema, ok := document[keyword].(map[string]any); ok {
+				if err := checkKeywords(subschema, path+"/"+keyword); err != nil {
+					return err
+				}
+			}
+		case "properties":
+			properties, _ := document[keyword].(map[string]any)
+			names := make([]string, 0, len(properties))
+			for name := range properties {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				if subschema, ok := properties[name].(map[string]any); ok {
+					if err := checkKeywords(subschema, path+"/properties/"+name); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// compile validates keywords and prepares derived values recursively
+func (s *Schema) compile(path string) error {
+	for _, t := range s.Type {
+		if !validSchemaTypes[t] {
+			return fmt.Errorf("invalid schema at %s: unknown type '%s'", path, t)
+		}
+	}
+
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid schema at %s: %w", path, err)
+		}
+		s.pattern = pattern
+	}
+
+	if len(s.Const) > 0 {
+		if err := json.Unmarshal(s.Const, &s.constValue); err != nil {
+			return fmt.Errorf("invalid schema at %s: %w", path, err)
+		}
+	}
+
+	if len(s.AdditionalProperties) > 0 {
+		var allowed bool
+		if err := json.Unmarshal(s.AdditionalProperties, &allowed); err == nil {
+			s.additionalDisallowed = !allowed
+		} else {
+			var additional Schema
+			if err := json.Unmarshal(s.AdditionalProperties, &additional); err != nil {
+				return fmt.Errorf("invalid schema at %s/additionalProperties: %w", path, err)
+			}
+			if err := additional.compile(path + "/additionalProperties"); err != nil {
+				return err
+			}
+			s.additional = &additional
+		}
+	}
+
+	for name, property := range s.Properties {
+		if err := property.compile(path + "/properties/" + name); err != nil {
+			return err
+		}
+	}
+
+	if s.Items != nil {
+		if err := s.Items.compile(path + "/items"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SchemaCache keeps compiled schemas by key, e.g. a template ID, compiling them again when their version changes
+type SchemaCache struct {
+	mu      sync.Mutex
+	schemas map[string]cachedSchema
+}
+
+type cachedSchema struct {
+	version time.Time
+	schema  *Schema
+}
+
+// NewSchemaCache creates an empty schema cache
+func NewSchemaCache() *SchemaCache {
+	return &SchemaCache{schemas: make(map[string]cachedSchema)}
+}
+
+// Compile returns the compiled schema of a document with the given key and version, compiling it unless
+// the same version was compiled before
+func (c *SchemaCache) Compile(key string, version time.Time, document map[string]any) (*Schema, error) {
+	c.mu.Lock()
+	cached, ok := c.schemas[key]
+	c.mu.Unlock()
+	if ok && cached.version.Equal(version) {
+		return cached.schema, nil
+	}
+
+	schema, err := CompileSchema(document)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.schemas[key] = cachedSchema{version: version, schema: schema}
+	c.mu.Unlock()
+	return schema, nil
+}
+
+// Validate checks a decoded JSON value against the schema and returns every violation found,
+// with paths rooted at the given field name
+func (s *Schema) Validate(field string, value any) []SchemaError {
+	var errs []SchemaError
+	s.validate(field, value, &errs)
+	return errs
+}
+
+// validate appends the violations of value to errs
+func (s *Schema) validate(path string, value any, errs *[]SchemaError) {
+	fail := func(code errorx.ErrorCode) {
+		*errs = append(*errs, SchemaError{Field: path, Code: code})
+	}
+
+	if len(s.Type) > 0 && !s.matchesType(value) {
+		fail(errorx.ErrorCodeInvalidType)
+		return
+	}
+
+	if len(s.Const) > 0 && !jsonEqual(value, s.constValue) {
+		fail(errorx.ErrorCodeInvalidValue)
+		return
+	}
+
+	if len(s.Enum) > 0 && !s.inEnum(value) {
+		fail(errorx.ErrorCodeInvalidValue)
+		return
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := len([]rune(v))
+		if s.MinLength != nil && length < *s.MinLength {
+			fail(errorx.ErrorCodeFieldTooShort)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			fail(errorx.ErrorCodeFieldTooLong)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			fail(errorx.ErrorCodeInvalidFormat)
+		}
+		if s.Format != "" && !validFormat(s.Format, v) {
+			fail(errorx.ErrorCodeInvalidFormat)
+		}
+
+	case float64:
+		if (s.Minimum != nil && v < *s.Minimum) ||
+			(s.Maximum != nil && v > *s.Maximum) ||
+			(s.ExclusiveMinimum != nil && v <= *s.ExclusiveMinimum) ||
+			(s.ExclusiveMaximum != nil && v >= *s.ExclusiveMaximum) {
+			fail(errorx.ErrorCodeValueOutOfRange)
+		}
+
+	case []any:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			fail(errorx.ErrorCodeFieldTooShort)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			fail(errorx.ErrorCodeFieldTooLong)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, SchemaError{Field: path + "." + name, Code: errorx.ErrorCodeRequiredField})
+			}
+		}
+
+		// Iterate in a stable order so error details are deterministic
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			property, ok := s.Properties[name]
+			switch {
+			case ok:
+				property.validate(path+"."+name, v[name], errs)
+			case s.additionalDisallowed:
+				*errs = append(*errs, SchemaError{Field: path + "." + name, Code: errorx.ErrorCodeUnknownField})
+			case s.additional != nil:
+				s.additional.validate(path+"."+name, v[name], errs)
+			}
+		}
+	}
+}
+
+// matchesType reports whether value has one of the schema's types
+func (s *Schema) matchesType(value any) bool {
+	for _, t := range s.Type {
+		switch v := value.(type) {
+		case nil:
+			if t == "null" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case float64:
+			if t == "number" || (t == "integer" && v == math.Trunc(v)) {
+				return true
+			}
+		case []any:
+			if t == "array" {
+				return true
+			}
+		case map[string]any:
+			if t == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inEnum reports whether value equals one of the enum values
+func (s *Schema) inEnum(value any) bool {
+	for _, candidate := range s.Enum {
+		if jsonEqual(value, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonEqual compares two decoded JSON values
+func jsonEqual(a, b any) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+// validFormat checks the well-known string formats; unknown formats are accepted as annotations
+func validFormat(format, value string) bool {
+	switch format {
+	case "email":
+		addr, err := mail.ParseAddress(value)
+		return err == nil && addr.Address == value
+	case "uri":
+		u, err := url.Parse(value)
+		return err == nil && u.Scheme != "" && u.Host != ""
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, value)
+		return err == nil
+	case "uuid":
+		_, err := uuid.Parse(value)
+		return err == nil
+	}
+	return true
+}
+
+// MapSchemaErrors converts schema violations into a validation AppError with field-level details,
+// mirroring MapValidationErrors
+func MapSchemaErrors(errs []SchemaError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	fieldErrors := make(map[string]any, len(errs))
+	for _, e := range errs {
+		// Keep the first violation reported for a field
+		if _, exists := fieldErrors[e.Field]; !exists {
+			fieldErrors[e.Field] = e.Code
+		}
+	}
+
+	appErr := errorx.New(errorx.ErrorTypeValidation, errorx.ErrorCodeInvalidValue, "Invalid notification data")
+	return appErr.WithDetails(fieldErrors)
+}
@@ -0,0 +1,232 @@
+package validation
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"hermes-api/pkg/errorx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decode decodes a JSON document the way request bodies are decoded
+func decode[T any](t *testing.T, document string) T {
+	t.Helper()
+
+	var value T
+	require.NoError(t, json.Unmarshal([]byte(document), &value), "invalid test document %s", document)
+	return value
+}
+
+func TestCompileSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr string // Empty when the schema compiles
+	}{
+		{
+			name:   "supported keywords",
+			schema: `{"type": "object", "required": ["name"], "properties": {"name": {"type": "string", "minLength": 1, "pattern": "^[a-z]+$"}}}`,
+		},
+		{
+			name:   "annotations",
+			schema: `{"$schema": "https://json-schema.org/draft/2020-12/schema", "title": "Order", "description": "An order", "type": "object"}`,
+		},
+		{
+			name:   "type list",
+			schema: `{"type": ["string", "null"]}`,
+		},
+		{
+			name:   "additional properties schema",
+			schema: `{"type": "object", "additionalProperties": {"type": "integer"}}`,
+		},
+		{
+			name:    "unsupported keyword",
+			schema:  `{"type": "object", "oneOf": [{"required": ["a"]}, {"required": ["b"]}]}`,
+			wantErr: "invalid schema at #: unsupported keyword 'oneOf'",
+		},
+		{
+			name:    "unsupported keyword in a property",
+			schema:  `{"type": "object", "properties": {"sku": {"$ref": "#/$defs/sku"}}}`,
+			wantErr: "invalid schema at #/properties/sku: unsupported keyword '$ref'",
+		},
+		{
+			name:    "unsupported keyword in items",
+			schema:  `{"type": "array", "items": {"type": "string", "uniqueItems": true}}`,
+			wantErr: "invalid schema at #/items: unsupported keyword 'uniqueItems'",
+		},
+		{
+			name:    "unsupported keyword in additional properties",
+			schema:  `{"type": "object", "additionalProperties": {"not": {"type": "null"}}}`,
+			wantErr: "invalid schema at #/additionalProperties: unsupported keyword 'not'",
+		},
+		{
+			name:    "unknown type",
+			schema:  `{"type": "date"}`,
+			wantErr: "invalid schema at #: unknown type 'date'",
+		},
+		{
+			name:    "unknown nested type",
+			schema:  `{"type": "object", "properties": {"total": {"type": "decimal"}}}`,
+			wantErr: "invalid schema at #/properties/total: unknown type 'decimal'",
+		},
+		{
+			name:    "invalid pattern",
+			schema:  `{"type": "string", "pattern": "("}`,
+			wantErr: "invalid schema at #:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CompileSchema(decode[map[string]any](t, tt.schema))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	const order = `{
+		"type": "object",
+		"required": ["id", "items"],
+		"additionalProperties": false,
+		"properties": {
+			"id": {"type": "string", "format": "uuid"},
+			"email": {"type": "string", "format": "email"},
+			"status": {"enum": ["paid", "shipped"]},
+			"total": {"type": "number", "minimum": 0, "exclusiveMaximum": 1000},
+			"quantity": {"type": "integer"},
+			"note": {"type": ["string", "null"], "maxLength": 5},
+			"code": {"type": "string", "pattern": "^[A-Z]{3}$"},
+			"items": {
+				"type": "array",
+				"minItems": 1,
+				"maxItems": 2,
+				"items": {"type": "object", "required": ["sku"], "properties": {"sku": {"type": "string", "minLength": 1}}}
+			}
+		}
+	}`
+	const id = `"0b9f3c6e-4a0e-4a7b-9d4e-2f8f4b1c7d10"`
+
+	tests := []struct {
+		name string
+		data string
+		want []SchemaError
+	}{
+		{
+			name: "valid",
+			data: `{"id": ` + id + `, "email": "ada@example.com", "status": "paid", "total": 12.5, "quantity": 2, "note": null, "code": "ABC", "items": [{"sku": "a-1"}]}`,
+		},
+		{
+			name: "missing required fields",
+			data: `{}`,
+			want: []SchemaError{
+				{Field: "data.id", Code: errorx.ErrorCodeRequiredField},
+				{Field: "data.items", Code: errorx.ErrorCodeRequiredField},
+			},
+		},
+		{
+			name: "unknown field",
+			data: `{"id": ` + id + `, "items": [{"sku": "a"}], "coupon": "FREE"}`,
+			want: []SchemaError{{Field: "data.coupon", Code: errorx.ErrorCodeUnknownField}},
+		},
+		{
+			name: "wrong types",
+			data: `{"id": 42, "quantity": 1.5, "items": {}}`,
+			want: []SchemaError{
+				{Field: "data.id", Code: errorx.ErrorCodeInvalidType},
+				{Field: "data.items", Code: errorx.ErrorCodeInvalidType},
+				{Field: "data.quantity", Code: errorx.ErrorCodeInvalidType},
+			},
+		},
+		{
+			name: "formats and patterns",
+			data: `{"id": "not-a-uuid", "email": "Ada <ada@example.com>", "code": "abc", "items": [{"sku": "a"}]}`,
+			want: []SchemaError{
+				{Field: "data.code", Code: errorx.ErrorCodeInvalidFormat},
+				{Field: "data.email", Code: errorx.ErrorCodeInvalidFormat},
+				{Field: "data.id", Code: errorx.ErrorCodeInvalidFormat},
+			},
+		},
+		{
+			name: "enum and ranges",
+			data: `{"id": ` + id + `, "status": "lost", "total": 1000, "note": "too long", "items": [{"sku": "a"}]}`,
+			want: []SchemaError{
+				{Field: "data.note", Code: errorx.ErrorCodeFieldTooLong},
+				{Field: "data.status", Code: errorx.ErrorCodeInvalidValue},
+				{Field: "data.total", Code: errorx.ErrorCodeValueOutOfRange},
+			},
+		},
+		{
+			name: "negative minimum",
+			data: `{"id": ` + id + `, "total": -1, "items": [{"sku": "a"}]}`,
+			want: []SchemaError{{Field: "data.total", Code: errorx.ErrorCodeValueOutOfRange}},
+		},
+		{
+			name: "array bounds",
+			data: `{"id": ` + id + `, "items": []}`,
+			want: []SchemaError{{Field: "data.items", Code: errorx.ErrorCodeFieldTooShort}},
+		},
+		{
+			name: "nested items",
+			data: `{"id": ` + id + `, "items": [{"sku": ""}, {}, {"sku": "c"}]}`,
+			want: []SchemaError{
+				{Field: "data.items", Code: errorx.ErrorCodeFieldTooLong},
+				{Field: "data.items[0].sku", Code: errorx.ErrorCodeFieldTooShort},
+				{Field: "data.items[1].sku", Code: errorx.ErrorCodeRequiredField},
+			},
+		},
+	}
+
+	schema, err := CompileSchema(decode[map[string]any](t, order))
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, schema.Validate("data", decode[any](t, tt.data)))
+		})
+	}
+}
+
+func TestSchemaCacheCompile(t *testing.T) {
+	cache := NewSchemaCache()
+	version := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	document := decode[map[string]any](t, `{"type": "object"}`)
+
+	first, err := cache.Compile("template", version, document)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		key      string
+		version  time.Time
+		document string
+		wantSame bool
+		wantErr  bool
+	}{
+		{name: "same version", key: "template", version: version, document: `{"type": "object"}`, wantSame: true},
+		{name: "same version ignores the document", key: "template", version: version, document: `{"oneOf": []}`, wantSame: true},
+		{name: "new version", key: "template", version: version.Add(time.Second), document: `{"type": "object"}`},
+		{name: "other key", key: "other", version: version, document: `{"type": "object"}`},
+		{name: "invalid new version", key: "invalid", version: version, document: `{"oneOf": []}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cache.Compile(tt.key, tt.version, decode[map[string]any](t, tt.document))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSame, got == first, "returned the cached schema")
+		})
+	}
+}
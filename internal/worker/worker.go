@@ -0,0 +1,328 @@
+// Package worker delivers queued notifications in the background
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"hermes-api/config"
+	"hermes-api/internal/model"
+	"hermes-api/internal/repository"
+	"hermes-api/internal/service"
+	"hermes-api/pkg/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Worker polls the queue for notifications and delivers them concurrently. Critical notifications are
+// claimed by their own poller and delivered by reserved workers, which the shared workers also help with
+// ahead of the other lanes, so that bulk traffic never holds them back.
+type Worker struct {
+	notificationRepo repository.NotificationRepository
+	deliveryService  service.DeliveryService
+	digestService    service.DigestService
+	analyticsService service.AnalyticsService
+	healthService    service.ProviderHealthService
+	config           config.NotificationsConfig
+	analyticsConfig  config.AnalyticsConfig
+	healthConfig     config.ProviderHealthConfig
+	weights          map[model.NotificationPriority]int
+}
+
+// deliveryTimeout bounds the delivery of a notification, claim leases must outlast it
+const deliveryTimeout = time.Minute
+
+// providerStatsFlushTimeout bounds the write of the provider sends counted since the last refresh when
+// the worker stops
+const providerStatsFlushTimeout = 5 * time.Second
+
+// sharedLanes are the lanes delivered by the shared workers, from the highest priority to the lowest
+var sharedLanes = []model.NotificationPriority{
+	model.NotificationPriorityHigh,
+	model.NotificationPriorityNormal,
+	model.NotificationPriorityLow,
+}
+
+// New creates a new worker
+func New(notificationRepo repository.NotificationRepository, deliveryService service.DeliveryService, digestService service.DigestService, analyticsService service.AnalyticsService, healthService service.ProviderHealthService, cfg config.NotificationsConfig, analyticsCfg config.AnalyticsConfig, healthCfg config.ProviderHealthConfig) *Worker {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = cfg.Workers
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.ClaimLease <= deliveryTimeout {
+		cfg.ClaimLease = 5 * time.Minute
+	}
+	if cfg.CriticalWorkers < 0 {
+		cfg.CriticalWorkers = 0
+	}
+	if analyticsCfg.RefreshInterval <= 0 {
+		analyticsCfg.RefreshInterval = time.Minute
+	}
+	if healthCfg.RefreshInterval <= 0 {
+		healthCfg.RefreshInterval = 10 * time.Second
+	}
+
+	weights := make(map[model.NotificationPriority]int, len(sharedLanes))
+	for _, lane := range sharedLanes {
+		weights[lane] = max(cfg.PriorityWeights[string(lane)], 0)
+	}
+
+	return &Worker{
+		notificationRepo: notificationRepo,
+		deliveryService:  deliveryService,
+		digestService:    digestService,
+		analyticsService: analyticsService,
+		healthService:    healthService,
+		config:           cfg,
+		analyticsConfig:  analyticsCfg,
+		healthConfig:     healthCfg,
+		weights:          weights,
+	}
+}
+
+// Run delivers notifications until the context is cancelled, then waits for in-flight deliveries
+func (w *Worker) Run(ctx context.Context) {
+	critical := make(chan uuid.UUID, w.config.BatchSize)
+	jobs := make(chan uuid.UUID, w.config.BatchSize)
+
+	var wg sync.WaitGroup
+	for i := 0; i < w.config.CriticalWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range critical {
+				w.deliver(id)
+			}
+		}()
+	}
+	for i := 0; i < w.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.work(critical, jobs)
+		}()
+	}
+
+	logger.Info("🚚 Delivery worker started",
+		zap.Int("workers", w.config.Workers),
+		zap.Int("critical_workers", w.config.CriticalWorkers),
+		zap.Int("batch_size", w.config.BatchSize),
+	)
+
+	var pollers sync.WaitGroup
+	pollers.Add(5)
+	go func() {
+		defer pollers.Done()
+		w.poll(ctx, func() int {
+			return w.claim(ctx, model.NotificationPriorityCritical, w.config.BatchSize, critical)
+		})
+	}()
+	go func() {
+		defer pollers.Done()
+		w.poll(ctx, func() int {
+			if _, err := w.digestService.FlushDue(ctx, w.config.BatchSize); err != nil && ctx.Err() == nil {
+				logger.Error("Failed to flush due digests", err)
+			}
+			return w.claimShared(ctx, jobs)
+		})
+	}()
+	go func() {
+		defer pollers.Done()
+		w.refreshAnalytics(ctx)
+	}()
+	go func() {
+		defer pollers.Done()
+		w.requeueExpired(ctx)
+	}()
+	go func() {
+		defer pollers.Done()
+		w.refreshProviderHealth(ctx)
+	}()
+
+	pollers.Wait()
+	close(critical)
+	close(jobs)
+	wg.Wait()
+
+	logger.Info("✅ Delivery worker stopped")
+}
+
+// refreshAnalytics refreshes the delivery rollups right away, then every refresh interval until the
+// context is cancelled
+func (w *Worker) refreshAnalytics(ctx context.Context) {
+	for {
+		if err := w.analyticsService.Refresh(ctx); err != nil && ctx.Err() == nil {
+			logger.Error("Failed to refresh delivery rollups", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.analyticsConfig.RefreshInterval):
+		}
+	}
+}
+
+// refreshProviderHealth reloads the provider health figures right away, then every refresh interval until
+// the context is cancelled
+func (w *Worker) refreshProviderHealth(ctx context.Context) {
+	for {
+		if err := w.healthService.Refresh(ctx); err != nil && ctx.Err() == nil {
+			logger.Error("Failed to refresh provider health", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			// Write the sends counted since the last refresh before the worker stops
+			flushCtx, cancel := context.WithTimeout(context.Background(), providerStatsFlushTimeout)
+			defer cancel()
+			if err := w.healthService.Flush(flushCtx); err != nil {
+				logger.Error("Failed to write provider sends", err)
+			}
+			return
+		case <-time.After(w.healthConfig.RefreshInterval):
+		}
+	}
+}
+
+// requeueExpired requeues the notifications whose claim lease expired, e.g. because their worker
+// crashed, every half lease until the context is cancelled
+func (w *Worker) requeueExpired(ctx context.Context) {
+	for {
+		requeued, err := w.notificationRepo.RequeueExpired(ctx, w.config.ClaimLease, w.config.BatchSize)
+		if err != nil && ctx.Err() == nil {
+			logger.Error("Failed to requeue expired notifications", err)
+		}
+		if requeued > 0 {
+			logger.Warn("Requeued notifications whose claim lease expired", zap.Int("count", requeued), zap.Duration("lease", w.config.ClaimLease))
+		}
+
+		// Keep going right away while there is a backlog
+		if requeued == w.config.BatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.config.ClaimLease / 2):
+		}
+	}
+}
+
+// work delivers notifications of every lane until both channels are closed, taking critical ones first
+func (w *Worker) work(critical, jobs <-chan uuid.UUID) {
+	for critical != nil || jobs != nil {
+		select {
+		case id, ok := <-critical:
+			if !ok {
+				critical = nil
+				continue
+			}
+			w.deliver(id)
+			continue
+		default:
+		}
+
+		select {
+		case id, ok := <-critical:
+			if !ok {
+				critical = nil
+				continue
+			}
+			w.deliver(id)
+		case id, ok := <-jobs:
+			if !ok {
+				jobs = nil
+				continue
+			}
+			w.deliver(id)
+		}
+	}
+}
+
+// poll calls claim until the context is cancelled, right away while it fills whole batches and after
+// the poll interval otherwise
+func (w *Worker) poll(ctx context.Context, claim func() int) {
+	for {
+		// Poll again right away while the queue is busy
+		if claim() == w.config.BatchSize {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.config.PollInterval):
+		}
+	}
+}
+
+// claimShared claims a batch of notifications of the shared lanes. Each lane is first given its share
+// of the batch by weight, then the capacity left by lanes running short goes to the others in priority
+// order, so that low priority notifications only wait while the lanes above them are busy.
+func (w *Worker) claimShared(ctx context.Context, jobs chan<- uuid.UUID) int {
+	total := 0
+	for _, lane := range sharedLanes {
+		total += w.weights[lane]
+	}
+
+	claimed := 0
+	drained := make(map[model.NotificationPriority]bool, len(sharedLanes))
+	if total > 0 {
+		for _, lane := range sharedLanes {
+			quota := w.config.BatchSize * w.weights[lane] / total
+			if quota == 0 {
+				continue
+			}
+			n := w.claim(ctx, lane, quota, jobs)
+			drained[lane] = n < quota
+			claimed += n
+		}
+	}
+
+	for _, lane := range sharedLanes {
+		if claimed >= w.config.BatchSize {
+			break
+		}
+		if !drained[lane] {
+			claimed += w.claim(ctx, lane, w.config.BatchSize-claimed, jobs)
+		}
+	}
+
+	return claimed
+}
+
+// claim claims up to limit notifications of a lane and feeds them to the delivery goroutines
+func (w *Worker) claim(ctx context.Context, priority model.NotificationPriority, limit int, jobs chan<- uuid.UUID) int {
+	notifications, err := w.notificationRepo.ClaimQueued(ctx, priority, limit)
+	if err != nil && ctx.Err() == nil {
+		logger.Error("Failed to claim queued notifications", err, zap.String("priority", string(priority)))
+	}
+
+	for _, notification := range notifications {
+		// Claimed notifications are always handed over, even during shutdown
+		jobs <- notification.ID
+	}
+	return len(notifications)
+}
+
+// deliver delivers a single notification; it does not use the run context so that
+// shutting down does not abort deliveries half way
+func (w *Worker) deliver(id uuid.UUID) {
+	ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+	defer cancel()
+
+	if err := w.deliveryService.Deliver(ctx, id); err != nil {
+		logger.Error("Failed to deliver notification", err, zap.String("notification_id", id.String()))
+	}
+}
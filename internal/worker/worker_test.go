@@ -0,0 +1,157 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"hermes-api/config"
+	"hermes-api/internal/model"
+	"hermes-api/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewClaimLease(t *testing.T) {
+	tests := []struct {
+		name  string
+		lease time.Duration
+		want  time.Duration
+	}{
+		{name: "not configured", lease: 0, want: 5 * time.Minute},
+		{name: "negative", lease: -time.Minute, want: 5 * time.Minute},
+		{name: "shorter than a delivery", lease: 30 * time.Second, want: 5 * time.Minute},
+		{name: "as long as a delivery", lease: deliveryTimeout, want: 5 * time.Minute},
+		{name: "longer than a delivery", lease: deliveryTimeout + time.Second, want: deliveryTimeout + time.Second},
+		{name: "configured", lease: 15 * time.Minute, want: 15 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := New(nil, nil, nil, nil, nil, config.NotificationsConfig{ClaimLease: tt.lease}, config.AnalyticsConfig{}, config.ProviderHealthConfig{})
+			assert.Equal(t, tt.want, w.config.ClaimLease)
+			assert.Greater(t, w.config.ClaimLease, deliveryTimeout, "a lease must outlast the delivery it covers")
+		})
+	}
+}
+
+// laneNotificationRepo holds the number of notifications queued in each lane and records the claims
+type laneNotificationRepo struct {
+	repository.NotificationRepository
+	queued  map[model.NotificationPriority]int
+	claimed map[model.NotificationPriority]int
+	claims  []string // "<lane>:<limit>" of each claim, in order
+}
+
+func (r *laneNotificationRepo) ClaimQueued(ctx context.Context, priority model.NotificationPriority, limit int) ([]*model.Notification, error) {
+	r.claims = append(r.claims, fmt.Sprintf("%s:%d", priority, limit))
+	n := min(limit, r.queued[priority])
+	r.queued[priority] -= n
+	if n > 0 {
+		r.claimed[priority] += n
+	}
+
+	notifications := make([]*model.Notification, n)
+	for i := range notifications {
+		notifications[i] = &model.Notification{ID: uuid.New(), Priority: priority}
+	}
+	return notifications, nil
+}
+
+func TestClaimShared(t *testing.T) {
+	const deep = 1000 // More notifications than any batch
+	weights := map[string]int{"high": 6, "normal": 3, "low": 1}
+
+	tests := []struct {
+		name       string
+		batchSize  int
+		weights    map[string]int
+		queued     map[model.NotificationPriority]int
+		wantClaims []string
+		wantLanes  map[model.NotificationPriority]int // Notifications claimed from each lane
+	}{
+		{
+			name:       "every lane busy",
+			batchSize:  10,
+			weights:    weights,
+			queued:     map[model.NotificationPriority]int{"high": deep, "normal": deep, "low": deep},
+			wantClaims: []string{"high:6", "normal:3", "low:1"},
+			wantLanes:  map[model.NotificationPriority]int{"high": 6, "normal": 3, "low": 1},
+		},
+		{
+			name:       "capacity of a short lane goes to the lanes below",
+			batchSize:  10,
+			weights:    weights,
+			queued:     map[model.NotificationPriority]int{"high": 2, "normal": deep, "low": deep},
+			wantClaims: []string{"high:6", "normal:3", "low:1", "normal:4"},
+			wantLanes:  map[model.NotificationPriority]int{"high": 2, "normal": 7, "low": 1},
+		},
+		{
+			name:       "low lane fills the batch when the others are empty",
+			batchSize:  10,
+			weights:    weights,
+			queued:     map[model.NotificationPriority]int{"low": deep},
+			wantClaims: []string{"high:6", "normal:3", "low:1", "low:9"},
+			wantLanes:  map[model.NotificationPriority]int{"low": 10},
+		},
+		{
+			name:       "capacity goes to the highest lane first",
+			batchSize:  10,
+			weights:    weights,
+			queued:     map[model.NotificationPriority]int{"high": deep, "normal": 1, "low": deep},
+			wantClaims: []string{"high:6", "normal:3", "low:1", "high:2"},
+			wantLanes:  map[model.NotificationPriority]int{"high": 8, "normal": 1, "low": 1},
+		},
+		{
+			name:       "drained lanes are not claimed again",
+			batchSize:  10,
+			weights:    weights,
+			queued:     map[model.NotificationPriority]int{"high": 1, "normal": 1},
+			wantClaims: []string{"high:6", "normal:3", "low:1"},
+			wantLanes:  map[model.NotificationPriority]int{"high": 1, "normal": 1},
+		},
+		{
+			name:       "rounded down quotas are backfilled",
+			batchSize:  4,
+			weights:    weights,
+			queued:     map[model.NotificationPriority]int{"high": deep, "normal": deep, "low": deep},
+			wantClaims: []string{"high:2", "normal:1", "high:1"},
+			wantLanes:  map[model.NotificationPriority]int{"high": 3, "normal": 1},
+		},
+		{
+			name:       "lane without a quota is claimed once the lanes above are short",
+			batchSize:  4,
+			weights:    weights,
+			queued:     map[model.NotificationPriority]int{"high": 1, "normal": 1, "low": deep},
+			wantClaims: []string{"high:2", "normal:1", "normal:2", "low:2"},
+			wantLanes:  map[model.NotificationPriority]int{"high": 1, "normal": 1, "low": 2},
+		},
+		{
+			name:       "zero weights claim in priority order",
+			batchSize:  10,
+			weights:    map[string]int{"high": 0, "normal": -2},
+			queued:     map[model.NotificationPriority]int{"high": 4, "normal": deep, "low": deep},
+			wantClaims: []string{"high:10", "normal:6"},
+			wantLanes:  map[model.NotificationPriority]int{"high": 4, "normal": 6},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &laneNotificationRepo{queued: tt.queued, claimed: make(map[model.NotificationPriority]int)}
+			cfg := config.NotificationsConfig{BatchSize: tt.batchSize, PriorityWeights: tt.weights}
+			w := New(repo, nil, nil, nil, nil, cfg, config.AnalyticsConfig{}, config.ProviderHealthConfig{})
+
+			jobs := make(chan uuid.UUID, tt.batchSize)
+			claimed := w.claimShared(context.Background(), jobs)
+			close(jobs)
+
+			assert.Equal(t, tt.wantClaims, repo.claims)
+			assert.Equal(t, tt.wantLanes, repo.claimed)
+			assert.LessOrEqual(t, claimed, tt.batchSize, "a poll never claims more than a batch")
+			assert.Len(t, jobs, claimed, "every claimed notification is handed over")
+		})
+	}
+}
@@ -0,0 +1,164 @@
+// Package envelope encrypts secrets at rest with envelope encryption: every secret has its own data
+// key, which is encrypted with a key encryption key of a keyring so that keys can be rotated by
+// re-encrypting the data keys only. Secrets are bound to additional data, e.g. the IDs of the record
+// they belong to, and cannot be opened with other additional data, e.g. once copied to another record.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrUnknownKey is returned when opening a secret sealed with a key that is not in the keyring
+	ErrUnknownKey = errors.New("envelope: unknown key")
+	// ErrDecrypt is returned for secrets that cannot be decrypted, e.g. tampered with
+	ErrDecrypt = errors.New("envelope: decryption failed")
+)
+
+// KeySize is the size in bytes of the AES-256 key encryption keys
+const KeySize = 32
+
+// Sealed is an encrypted secret with its encrypted data key
+type Sealed struct {
+	KeyID        string // ID of the key encryption key of the data key
+	EncryptedKey []byte
+	Ciphertext   []byte
+}
+
+// Keyring holds the key encryption keys by ID, new secrets are sealed with the active one
+type Keyring struct {
+	activeID string
+	keys     map[string][]byte
+}
+
+// NewKeyring creates a keyring from base64 encoded keys by key ID, see ParseKey
+func NewKeyring(activeID string, encodedKeys map[string]string) (*Keyring, error) {
+	if _, ok := encodedKeys[activeID]; !ok {
+		return nil, fmt.Errorf("envelope: active key '%s' is not configured", activeID)
+	}
+
+	keys := make(map[string][]byte, len(encodedKeys))
+	for id, encoded := range encodedKeys {
+		key, err := ParseKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("envelope: key '%s': %w", id, err)
+		}
+		keys[id] = key
+	}
+	return &Keyring{activeID: activeID, keys: keys}, nil
+}
+
+// ParseKey decodes a standard base64 encoded key encryption key of KeySize random bytes, e.g. generated
+// with "openssl rand -base64 32". Passphrases are refused rather than stretched into weak keys.
+func ParseKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("not base64 encoded")
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("%d bytes long, want %d", len(key), KeySize)
+	}
+	return key, nil
+}
+
+// ActiveKeyID returns the ID of the key new secrets are sealed with
+func (k *Keyring) ActiveKeyID() string {
+	return k.activeID
+}
+
+// Seal encrypts plaintext bound to aad with a new data key, encrypted with the active key
+func (k *Keyring) Seal(plaintext, aad []byte) (*Sealed, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := encrypt(dataKey, plaintext, aad)
+	if err != nil {
+		return nil, err
+	}
+	encryptedKey, err := encrypt(k.keys[k.activeID], dataKey, aad)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sealed{KeyID: k.activeID, EncryptedKey: encryptedKey, Ciphertext: ciphertext}, nil
+}
+
+// Open decrypts a sealed secret bound to aad
+func (k *Keyring) Open(sealed *Sealed, aad []byte) ([]byte, error) {
+	dataKey, err := k.dataKey(sealed, aad)
+	if err != nil {
+		return nil, err
+	}
+	return decrypt(dataKey, sealed.Ciphertext, aad)
+}
+
+// Rewrap re-encrypts the data key of a sealed secret bound to aad with the active key, the secret itself
+// is unchanged
+func (k *Keyring) Rewrap(sealed *Sealed, aad []byte) (*Sealed, error) {
+	dataKey, err := k.dataKey(sealed, aad)
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedKey, err := encrypt(k.keys[k.activeID], dataKey, aad)
+	if err != nil {
+		return nil, err
+	}
+	return &Sealed{KeyID: k.activeID, EncryptedKey: encryptedKey, Ciphertext: sealed.Ciphertext}, nil
+}
+
+// dataKey decrypts the data key of a sealed secret bound to aad
+func (k *Keyring) dataKey(sealed *Sealed, aad []byte) ([]byte, error) {
+	key, ok := k.keys[sealed.KeyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return decrypt(key, sealed.EncryptedKey, aad)
+}
+
+// encrypt encrypts plaintext with AES-GCM authenticating aad, prefixing the ciphertext with its random nonce
+func encrypt(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// decrypt decrypts a ciphertext produced by encrypt with the same aad
+func decrypt(key, ciphertext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	plaintext, err := gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], aad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// newGCM creates an AES-GCM cipher for a 32 byte key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
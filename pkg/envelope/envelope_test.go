@@ -0,0 +1,158 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKey returns a base64 encoded key made of KeySize times b
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, KeySize))
+}
+
+func TestNewKeyring(t *testing.T) {
+	tests := []struct {
+		name     string
+		activeID string
+		keys     map[string]string
+		wantErr  bool
+	}{
+		{name: "single key", activeID: "v1", keys: map[string]string{"v1": testKey(1)}},
+		{name: "previous keys", activeID: "v2", keys: map[string]string{"v1": testKey(1), "v2": testKey(2)}},
+		{name: "active key missing", activeID: "v2", keys: map[string]string{"v1": testKey(1)}, wantErr: true},
+		{name: "empty key", activeID: "v1", keys: map[string]string{"v1": ""}, wantErr: true},
+		{name: "passphrase", activeID: "v1", keys: map[string]string{"v1": "your-super-secret-credential-key"}, wantErr: true},
+		{name: "short key", activeID: "v1", keys: map[string]string{"v1": base64.StdEncoding.EncodeToString(make([]byte, 16))}, wantErr: true},
+		{name: "long key", activeID: "v1", keys: map[string]string{"v1": base64.StdEncoding.EncodeToString(make([]byte, 33))}, wantErr: true},
+		{name: "invalid previous key", activeID: "v2", keys: map[string]string{"v1": "secret", "v2": testKey(2)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring, err := NewKeyring(tt.activeID, tt.keys)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.activeID, keyring.ActiveKeyID())
+		})
+	}
+}
+
+func TestKeyringOpen(t *testing.T) {
+	keyring, err := NewKeyring("v1", map[string]string{"v1": testKey(1)})
+	require.NoError(t, err)
+
+	plaintext := []byte(`{"api_key":"SG.secret"}`)
+	aad := []byte("credential:1")
+	sealed, err := keyring.Seal(plaintext, aad)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", sealed.KeyID)
+	assert.NotContains(t, string(sealed.Ciphertext), "SG.secret")
+
+	// tamper returns a copy of b with its last byte flipped
+	tamper := func(b []byte) []byte {
+		tampered := append([]byte(nil), b...)
+		tampered[len(tampered)-1] ^= 1
+		return tampered
+	}
+
+	tests := []struct {
+		name    string
+		sealed  *Sealed
+		aad     []byte
+		wantErr error
+	}{
+		{name: "round trip", sealed: sealed, aad: aad},
+		{name: "other additional data", sealed: sealed, aad: []byte("credential:2"), wantErr: ErrDecrypt},
+		{name: "no additional data", sealed: sealed, wantErr: ErrDecrypt},
+		{
+			name:    "unknown key",
+			sealed:  &Sealed{KeyID: "v0", EncryptedKey: sealed.EncryptedKey, Ciphertext: sealed.Ciphertext},
+			aad:     aad,
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name:    "tampered data key",
+			sealed:  &Sealed{KeyID: "v1", EncryptedKey: tamper(sealed.EncryptedKey), Ciphertext: sealed.Ciphertext},
+			aad:     aad,
+			wantErr: ErrDecrypt,
+		},
+		{
+			name:    "tampered ciphertext",
+			sealed:  &Sealed{KeyID: "v1", EncryptedKey: sealed.EncryptedKey, Ciphertext: tamper(sealed.Ciphertext)},
+			aad:     aad,
+			wantErr: ErrDecrypt,
+		},
+		{
+			name:    "truncated ciphertext",
+			sealed:  &Sealed{KeyID: "v1", EncryptedKey: sealed.EncryptedKey, Ciphertext: sealed.Ciphertext[:4]},
+			aad:     aad,
+			wantErr: ErrDecrypt,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opened, err := keyring.Open(tt.sealed, tt.aad)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, plaintext, opened)
+		})
+	}
+
+	t.Run("seals with a new data key every time", func(t *testing.T) {
+		again, err := keyring.Seal(plaintext, aad)
+		require.NoError(t, err)
+		assert.NotEqual(t, sealed.EncryptedKey, again.EncryptedKey)
+		assert.NotEqual(t, sealed.Ciphertext, again.Ciphertext)
+	})
+}
+
+func TestKeyringRewrap(t *testing.T) {
+	previous, err := NewKeyring("v1", map[string]string{"v1": testKey(1)})
+	require.NoError(t, err)
+	plaintext := []byte("auth-token")
+	aad := []byte("credential:1")
+	sealed, err := previous.Seal(plaintext, aad)
+	require.NoError(t, err)
+
+	// The new key is made active while the previous one is kept to read the secrets sealed with it
+	rotating, err := NewKeyring("v2", map[string]string{"v1": testKey(1), "v2": testKey(2)})
+	require.NoError(t, err)
+
+	rewrapped, err := rotating.Rewrap(sealed, aad)
+	require.NoError(t, err)
+	assert.Equal(t, "v2", rewrapped.KeyID)
+	assert.Equal(t, sealed.Ciphertext, rewrapped.Ciphertext, "only the data key is re-encrypted")
+
+	// Once every secret is rewrapped, the previous key can be removed
+	rotated, err := NewKeyring("v2", map[string]string{"v2": testKey(2)})
+	require.NoError(t, err)
+	opened, err := rotated.Open(rewrapped, aad)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, opened)
+
+	_, err = rotated.Open(sealed, aad)
+	assert.ErrorIs(t, err, ErrUnknownKey, "secrets not rewrapped need the previous key")
+
+	t.Run("other additional data", func(t *testing.T) {
+		_, err := rotating.Rewrap(sealed, []byte("credential:2"))
+		assert.ErrorIs(t, err, ErrDecrypt)
+	})
+
+	t.Run("another key under the same ID", func(t *testing.T) {
+		impostor, err := NewKeyring("v1", map[string]string{"v1": testKey(3)})
+		require.NoError(t, err)
+		_, err = impostor.Open(sealed, aad)
+		assert.ErrorIs(t, err, ErrDecrypt)
+	})
+}
@@ -48,6 +48,53 @@ const (
 	ErrorCodeInvalidRecipient          ErrorCode = "INVALID_RECIPIENT"
 	ErrorCodeNotificationQuotaExceeded ErrorCode = "NOTIFICATION_QUOTA_EXCEEDED"
 
+	// Template related errors
+	ErrorCodeTemplateNotFound       ErrorCode = "TEMPLATE_NOT_FOUND"
+	ErrorCodeTemplateAlreadyExists  ErrorCode = "TEMPLATE_ALREADY_EXISTS"
+	ErrorCodeTemplateInvalid        ErrorCode = "TEMPLATE_INVALID"
+	ErrorCodeTemplateLocaleNotFound ErrorCode = "TEMPLATE_LOCALE_NOT_FOUND"
+	ErrorCodeTemplateRenderFailed   ErrorCode = "TEMPLATE_RENDER_FAILED"
+
+	// File and attachment related errors
+	ErrorCodeFileNotFound             ErrorCode = "FILE_NOT_FOUND"
+	ErrorCodeAttachmentTooLarge       ErrorCode = "ATTACHMENT_TOO_LARGE"
+	ErrorCodeAttachmentTypeNotAllowed ErrorCode = "ATTACHMENT_TYPE_NOT_ALLOWED"
+	ErrorCodeAttachmentLimitExceeded  ErrorCode = "ATTACHMENT_LIMIT_EXCEEDED"
+	ErrorCodeAttachmentsNotSupported  ErrorCode = "ATTACHMENTS_NOT_SUPPORTED"
+
+	// Contact related errors
+	ErrorCodeContactNotFound       ErrorCode = "CONTACT_NOT_FOUND"
+	ErrorCodeContactAlreadyExists  ErrorCode = "CONTACT_ALREADY_EXISTS"
+	ErrorCodeContactAddressMissing ErrorCode = "CONTACT_ADDRESS_MISSING"
+	ErrorCodeContactFormatInvalid  ErrorCode = "CONTACT_FORMAT_INVALID"
+	ErrorCodeContactImportNotFound ErrorCode = "CONTACT_IMPORT_NOT_FOUND"
+	ErrorCodeContactImportPending  ErrorCode = "CONTACT_IMPORT_PENDING"
+
+	// Suppression related errors
+	ErrorCodeSuppressionNotFound      ErrorCode = "SUPPRESSION_NOT_FOUND"
+	ErrorCodeSuppressionAlreadyExists ErrorCode = "SUPPRESSION_ALREADY_EXISTS"
+
+	// Frequency cap related errors
+	ErrorCodeFrequencyCapNotFound ErrorCode = "FREQUENCY_CAP_NOT_FOUND"
+
+	// Digest related errors
+	ErrorCodeDigestRuleNotFound ErrorCode = "DIGEST_RULE_NOT_FOUND"
+
+	// Provider credential related errors
+	ErrorCodeProviderCredentialNotFound ErrorCode = "PROVIDER_CREDENTIAL_NOT_FOUND"
+	ErrorCodeProviderCredentialConflict ErrorCode = "PROVIDER_CREDENTIAL_CONFLICT"
+	ErrorCodeCredentialKeyUnavailable   ErrorCode = "CREDENTIAL_KEY_UNAVAILABLE"
+
+	// Webhook related errors
+	ErrorCodeWebhookNotConfigured    ErrorCode = "WEBHOOK_NOT_CONFIGURED"
+	ErrorCodeWebhookSignatureInvalid ErrorCode = "WEBHOOK_SIGNATURE_INVALID"
+	ErrorCodeWebhookMessagePending   ErrorCode = "WEBHOOK_MESSAGE_PENDING"
+	ErrorCodeWebhookKeyInvalid       ErrorCode = "WEBHOOK_KEY_INVALID"
+
+	// Signed link related errors
+	ErrorCodeLinkInvalid ErrorCode = "LINK_INVALID"
+	ErrorCodeLinkExpired ErrorCode = "LINK_EXPIRED"
+
 	// Validation errors
 	ErrorCodeRequiredField       ErrorCode = "REQUIRED_FIELD"
 	ErrorCodeInvalidFormat       ErrorCode = "INVALID_FORMAT"
@@ -56,10 +103,14 @@ const (
 	ErrorCodeFieldTooShort       ErrorCode = "FIELD_TOO_SHORT"
 	ErrorCodeFieldLengthMismatch ErrorCode = "FIELD_LENGTH_MISMATCH"
 	ErrorCodeFieldNotEqual       ErrorCode = "FIELD_NOT_EQUAL"
+	ErrorCodeInvalidType         ErrorCode = "INVALID_TYPE"
+	ErrorCodeValueOutOfRange     ErrorCode = "VALUE_OUT_OF_RANGE"
+	ErrorCodeUnknownField        ErrorCode = "UNKNOWN_FIELD"
 
 	// System errors
 	ErrorCodeDatabaseError        ErrorCode = "DATABASE_ERROR"
 	ErrorCodeRedisError           ErrorCode = "REDIS_ERROR"
+	ErrorCodeStorageError         ErrorCode = "STORAGE_ERROR"
 	ErrorCodeExternalServiceError ErrorCode = "EXTERNAL_SERVICE_ERROR"
 	ErrorCodeUnknownError         ErrorCode = "UNKNOWN_ERROR"
 
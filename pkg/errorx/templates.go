@@ -16,6 +16,47 @@ var ErrorTemplates = map[ErrorCode]string{
 	ErrorCodeInvalidRecipient:          "Invalid recipient format: '%s'",
 	ErrorCodeNotificationQuotaExceeded: "Notification quota exceeded for app '%s'",
 
+	// Template errors
+	ErrorCodeTemplateNotFound:       "Template with ID '%s' not found",
+	ErrorCodeTemplateAlreadyExists:  "Template with name '%s' already exists",
+	ErrorCodeTemplateLocaleNotFound: "Template '%s' has no variant for locales %v",
+
+	// File and attachment errors
+	ErrorCodeFileNotFound:             "File with ID '%s' not found",
+	ErrorCodeAttachmentTooLarge:       "Attachments exceed the maximum size of %d bytes",
+	ErrorCodeAttachmentTypeNotAllowed: "Attachment type '%s' is not allowed",
+	ErrorCodeAttachmentLimitExceeded:  "Too many attachments. Maximum: %d",
+	ErrorCodeAttachmentsNotSupported:  "Attachments are not supported on channel '%s'",
+
+	// Contact errors
+	ErrorCodeContactNotFound:       "Contact with ID '%s' not found",
+	ErrorCodeContactAlreadyExists:  "Contact with ID '%s' already exists",
+	ErrorCodeContactAddressMissing: "Contact '%s' has no address for channel '%s'",
+	ErrorCodeContactFormatInvalid:  "Invalid contact format '%s'. Allowed formats: csv, ndjson",
+	ErrorCodeContactImportNotFound: "Contact import with ID '%s' not found",
+	ErrorCodeContactImportPending:  "Contact import '%s' has not finished yet",
+
+	// Suppression errors
+	ErrorCodeSuppressionNotFound:      "Suppression with ID '%s' not found",
+	ErrorCodeSuppressionAlreadyExists: "Address '%s' is already suppressed on channel '%s'",
+
+	// Frequency cap errors
+	ErrorCodeFrequencyCapNotFound: "Frequency cap with ID '%s' not found",
+
+	// Digest errors
+	ErrorCodeDigestRuleNotFound: "Digest rule with ID '%s' not found",
+
+	// Provider credential errors
+	ErrorCodeProviderCredentialNotFound: "No provider configured for channel '%s'",
+	ErrorCodeProviderCredentialConflict: "The provider of channel '%s' was replaced concurrently, retry the request",
+
+	// Webhook errors
+	ErrorCodeWebhookNotConfigured: "No webhook configured for driver '%s'",
+
+	// Signed link errors
+	ErrorCodeLinkInvalid: "This link is invalid",
+	ErrorCodeLinkExpired: "This link has expired",
+
 	// Validation errors
 	ErrorCodeRequiredField: "Field '%s' is required",
 	ErrorCodeInvalidFormat: "Field '%s' has invalid format",
@@ -26,6 +67,7 @@ var ErrorTemplates = map[ErrorCode]string{
 	// System errors
 	ErrorCodeDatabaseError:        "Database operation failed: %s",
 	ErrorCodeRedisError:           "Redis operation failed: %s",
+	ErrorCodeStorageError:         "Storage operation failed: %s",
 	ErrorCodeExternalServiceError: "External service error: %s",
 }
 
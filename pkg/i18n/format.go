@@ -0,0 +1,256 @@
+package i18n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// Date format styles supported by FormatDate
+const (
+	DateStyleShort  = "short"
+	DateStyleMedium = "medium"
+	DateStyleLong   = "long"
+	DateStyleFull   = "full"
+)
+
+// dateLayouts holds short/medium/long/full layouts per language or region, keyed like the fallback chain.
+// Month and weekday names in a layout ("January", "Jan", "Monday", "Mon") are taken from calendarNames.
+var dateLayouts = map[string]map[string]string{
+	"en":    {DateStyleShort: "1/2/06", DateStyleMedium: "Jan 2, 2006", DateStyleLong: "January 2, 2006", DateStyleFull: "Monday, January 2, 2006"},
+	"en-GB": {DateStyleShort: "02/01/2006", DateStyleMedium: "2 Jan 2006", DateStyleLong: "2 January 2006", DateStyleFull: "Monday 2 January 2006"},
+	"de":    {DateStyleShort: "02.01.06", DateStyleMedium: "02.01.2006", DateStyleLong: "2. January 2006", DateStyleFull: "Monday, 2. January 2006"},
+	"fr":    {DateStyleShort: "02/01/2006", DateStyleMedium: "2 Jan 2006", DateStyleLong: "2 January 2006", DateStyleFull: "Monday 2 January 2006"},
+	"es":    {DateStyleShort: "2/1/06", DateStyleMedium: "2 Jan 2006", DateStyleLong: "2 de January de 2006", DateStyleFull: "Monday, 2 de January de 2006"},
+	"it":    {DateStyleShort: "02/01/06", DateStyleMedium: "2 Jan 2006", DateStyleLong: "2 January 2006", DateStyleFull: "Monday 2 January 2006"},
+	"nl":    {DateStyleShort: "02-01-2006", DateStyleMedium: "2 Jan 2006", DateStyleLong: "2 January 2006", DateStyleFull: "Monday 2 January 2006"},
+	"pt":    {DateStyleShort: "02/01/2006", DateStyleMedium: "2 de Jan de 2006", DateStyleLong: "2 de January de 2006", DateStyleFull: "Monday, 2 de January de 2006"},
+	"ja":    {DateStyleShort: "2006/01/02", DateStyleMedium: "2006/01/02", DateStyleLong: "2006年1月2日", DateStyleFull: "2006年1月2日Monday"},
+	"zh":    {DateStyleShort: "2006/1/2", DateStyleMedium: "2006年1月2日", DateStyleLong: "2006年1月2日", DateStyleFull: "2006年1月2日Monday"},
+}
+
+// monthTable holds the names of the months of the year
+type monthTable [12]string
+
+// name returns the name of month
+func (m monthTable) name(month time.Month) string {
+	return m[month-1]
+}
+
+// weekdayTable holds the names of the days of the week, starting with Sunday like time.Weekday
+type weekdayTable [7]string
+
+// name returns the name of day
+func (w weekdayTable) name(day time.Weekday) string {
+	return w[day]
+}
+
+// calendar holds the full and abbreviated month and weekday names of a language
+type calendar struct {
+	months      monthTable
+	monthsShort monthTable
+	days        weekdayTable
+	daysShort   weekdayTable
+}
+
+// calendarNames holds the calendars of the languages in dateLayouts, keyed by base language
+var calendarNames = map[string]*calendar{
+	"en": {
+		months:      monthTable{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		monthsShort: monthTable{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		days:        weekdayTable{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		daysShort:   weekdayTable{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+	},
+	"de": {
+		months:      monthTable{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		monthsShort: monthTable{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+		days:        weekdayTable{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		daysShort:   weekdayTable{"So.", "Mo.", "Di.", "Mi.", "Do.", "Fr.", "Sa."},
+	},
+	"fr": {
+		months:      monthTable{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		monthsShort: monthTable{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		days:        weekdayTable{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		daysShort:   weekdayTable{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+	},
+	"es": {
+		months:      monthTable{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		monthsShort: monthTable{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		days:        weekdayTable{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		daysShort:   weekdayTable{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+	},
+	"it": {
+		months:      monthTable{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		monthsShort: monthTable{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		days:        weekdayTable{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		daysShort:   weekdayTable{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+	},
+	"nl": {
+		months:      monthTable{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		monthsShort: monthTable{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		days:        weekdayTable{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		daysShort:   weekdayTable{"zo", "ma", "di", "wo", "do", "vr", "za"},
+	},
+	"pt": {
+		months:      monthTable{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		monthsShort: monthTable{"jan.", "fev.", "mar.", "abr.", "mai.", "jun.", "jul.", "ago.", "set.", "out.", "nov.", "dez."},
+		days:        weekdayTable{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+		daysShort:   weekdayTable{"dom.", "seg.", "ter.", "qua.", "qui.", "sex.", "sáb."},
+	},
+	"ja": {
+		months:      monthTable{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+		monthsShort: monthTable{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+		days:        weekdayTable{"日曜日", "月曜日", "火曜日", "水曜日", "木曜日", "金曜日", "土曜日"},
+		daysShort:   weekdayTable{"日", "月", "火", "水", "木", "金", "土"},
+	},
+	"zh": {
+		months:      monthTable{"一月", "二月", "三月", "四月", "五月", "六月", "七月", "八月", "九月", "十月", "十一月", "十二月"},
+		monthsShort: monthTable{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+		days:        weekdayTable{"星期日", "星期一", "星期二", "星期三", "星期四", "星期五", "星期六"},
+		daysShort:   weekdayTable{"周日", "周一", "周二", "周三", "周四", "周五", "周六"},
+	},
+}
+
+// calendarTokens are the layout elements of time.Format naming a month or weekday, longest first so
+// "January" is not read as "Jan" followed by "uary"
+var calendarTokens = []string{"January", "Monday", "Jan", "Mon"}
+
+// FormatNumber formats a number with the locale's grouping and decimal separators.
+// Non-numeric values are returned unchanged.
+func FormatNumber(locale string, value any) string {
+	n, ok := toFloat(value)
+	if !ok {
+		return fmt.Sprint(value)
+	}
+	return message.NewPrinter(tag(locale)).Sprint(number.Decimal(n, number.MaxFractionDigits(fractionDigits(value))))
+}
+
+// FormatCurrency formats an amount in the given ISO 4217 currency (e.g. "EUR") for the locale
+func FormatCurrency(locale string, amount any, code string) (string, error) {
+	n, ok := toFloat(amount)
+	if !ok {
+		return "", fmt.Errorf("currency: %v is not a number", amount)
+	}
+
+	unit, err := currency.ParseISO(strings.ToUpper(code))
+	if err != nil {
+		return "", fmt.Errorf("currency: %w", err)
+	}
+
+	return message.NewPrinter(tag(locale)).Sprint(currency.Symbol(unit.Amount(n))), nil
+}
+
+// FormatDate formats a time for the locale using one of the DateStyle* styles.
+// Values may be a time.Time or an RFC 3339 string; an optional IANA timezone converts the time before formatting.
+func FormatDate(locale string, value any, style string, timezone ...string) (string, error) {
+	t, err := toTime(value)
+	if err != nil {
+		return "", err
+	}
+
+	if len(timezone) > 0 && timezone[0] != "" {
+		loc, err := time.LoadLocation(timezone[0])
+		if err != nil {
+			return "", fmt.Errorf("date: %w", err)
+		}
+		t = t.In(loc)
+	}
+
+	if style == "" {
+		style = DateStyleMedium
+	}
+
+	chain := FallbackChain(DefaultLocale, locale)
+	for _, candidate := range chain {
+		layouts, ok := dateLayouts[candidate]
+		if !ok {
+			continue
+		}
+		layout, ok := layouts[style]
+		if !ok {
+			return "", fmt.Errorf("date: unknown style '%s'", style)
+		}
+		return formatLayout(t, layout, calendarFor(chain)), nil
+	}
+
+	return t.Format(time.DateOnly), nil
+}
+
+// calendarFor returns the calendar of the first language of the chain that has one, English otherwise
+func calendarFor(chain []string) *calendar {
+	for _, candidate := range chain {
+		base, _ := language.MustParse(candidate).Base()
+		if names, ok := calendarNames[base.String()]; ok {
+			return names
+		}
+	}
+	return calendarNames[DefaultLocale]
+}
+
+// formatLayout formats t like time.Format, taking the month and weekday names in layout from names
+func formatLayout(t time.Time, layout string, names *calendar) string {
+	var b strings.Builder
+	for layout != "" {
+		at, token := len(layout), ""
+		for _, candidate := range calendarTokens {
+			if i := strings.Index(layout, candidate); i >= 0 && i < at {
+				at, token = i, candidate
+			}
+		}
+
+		b.WriteString(t.Format(layout[:at]))
+		switch token {
+		case "January":
+			b.WriteString(names.months.name(t.Month()))
+		case "Jan":
+			b.WriteString(names.monthsShort.name(t.Month()))
+		case "Monday":
+			b.WriteString(names.days.name(t.Weekday()))
+		case "Mon":
+			b.WriteString(names.daysShort.name(t.Weekday()))
+		}
+		layout = layout[at+len(token):]
+	}
+	return b.String()
+}
+
+// toFloat converts numeric values, including numeric strings from JSON payloads, to float64
+func toFloat(value any) (float64, bool) {
+	s := numberString(value)
+	if s == "" {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	return n, err == nil
+}
+
+// fractionDigits returns the number of fraction digits to keep when formatting value
+func fractionDigits(value any) int {
+	_, fraction, _ := strings.Cut(numberString(value), ".")
+	return len(fraction)
+}
+
+// toTime converts a time.Time or RFC 3339 string to time.Time
+func toTime(value any) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case *time.Time:
+		if v != nil {
+			return *v, nil
+		}
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("date: %w", err)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("date: unsupported value %v", value)
+}
@@ -0,0 +1,192 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatDate(t *testing.T) {
+	// A Monday in March, when abbreviated names differ from the first letters of the full ones
+	march := time.Date(2024, time.March, 4, 9, 30, 0, 0, time.UTC)
+	// A Thursday in May, whose English name is also its abbreviation
+	may := time.Date(2024, time.May, 9, 23, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		locale string
+		value  any
+		style  string
+		want   string
+	}{
+		{locale: "en", value: march, style: DateStyleShort, want: "3/4/24"},
+		{locale: "en", value: march, style: DateStyleMedium, want: "Mar 4, 2024"},
+		{locale: "en", value: march, style: DateStyleLong, want: "March 4, 2024"},
+		{locale: "en", value: march, style: DateStyleFull, want: "Monday, March 4, 2024"},
+		{locale: "en-GB", value: march, style: DateStyleMedium, want: "4 Mar 2024"},
+		{locale: "en-GB", value: march, style: DateStyleFull, want: "Monday 4 March 2024"},
+		{locale: "de", value: march, style: DateStyleShort, want: "04.03.24"},
+		{locale: "de", value: march, style: DateStyleMedium, want: "04.03.2024"},
+		{locale: "de", value: march, style: DateStyleLong, want: "4. März 2024"},
+		{locale: "de", value: march, style: DateStyleFull, want: "Montag, 4. März 2024"},
+		{locale: "fr", value: march, style: DateStyleMedium, want: "4 mars 2024"},
+		{locale: "fr", value: march, style: DateStyleLong, want: "4 mars 2024"},
+		{locale: "fr", value: may, style: DateStyleMedium, want: "9 mai 2024"},
+		{locale: "fr", value: march, style: DateStyleFull, want: "lundi 4 mars 2024"},
+		{locale: "es", value: march, style: DateStyleShort, want: "4/3/24"},
+		{locale: "es", value: march, style: DateStyleMedium, want: "4 mar 2024"},
+		{locale: "es", value: may, style: DateStyleLong, want: "9 de mayo de 2024"},
+		{locale: "es", value: may, style: DateStyleFull, want: "jueves, 9 de mayo de 2024"},
+		{locale: "it", value: may, style: DateStyleMedium, want: "9 mag 2024"},
+		{locale: "it", value: march, style: DateStyleLong, want: "4 marzo 2024"},
+		{locale: "it", value: march, style: DateStyleFull, want: "lunedì 4 marzo 2024"},
+		{locale: "nl", value: march, style: DateStyleShort, want: "04-03-2024"},
+		{locale: "nl", value: march, style: DateStyleMedium, want: "4 mrt 2024"},
+		{locale: "nl", value: may, style: DateStyleLong, want: "9 mei 2024"},
+		{locale: "pt", value: may, style: DateStyleMedium, want: "9 de mai. de 2024"},
+		{locale: "pt", value: march, style: DateStyleLong, want: "4 de março de 2024"},
+		{locale: "pt", value: march, style: DateStyleFull, want: "segunda-feira, 4 de março de 2024"},
+		{locale: "ja", value: march, style: DateStyleShort, want: "2024/03/04"},
+		{locale: "ja", value: march, style: DateStyleLong, want: "2024年3月4日"},
+		{locale: "ja", value: march, style: DateStyleFull, want: "2024年3月4日月曜日"},
+		{locale: "zh", value: march, style: DateStyleMedium, want: "2024年3月4日"},
+		{locale: "zh", value: may, style: DateStyleFull, want: "2024年5月9日星期四"},
+
+		// Regions without layouts of their own use the ones of their language
+		{locale: "de-AT", value: march, style: DateStyleLong, want: "4. März 2024"},
+		{locale: "pt_BR", value: march, style: DateStyleLong, want: "4 de março de 2024"},
+		{locale: "fr-CA", value: march, style: DateStyleMedium, want: "4 mars 2024"},
+		{locale: "en-AU", value: march, style: DateStyleMedium, want: "Mar 4, 2024"},
+
+		// Languages without layouts fall back to the default locale
+		{locale: "sv", value: march, style: DateStyleLong, want: "March 4, 2024"},
+		{locale: "", value: march, style: DateStyleLong, want: "March 4, 2024"},
+
+		// The medium style is the default and RFC 3339 strings are accepted
+		{locale: "de", value: "2024-03-04T09:30:00Z", style: "", want: "04.03.2024"},
+		{locale: "en", value: &may, style: DateStyleLong, want: "May 9, 2024"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale+"/"+tt.style, func(t *testing.T) {
+			got, err := FormatDate(tt.locale, tt.value, tt.style)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("timezone", func(t *testing.T) {
+		// 23:30 UTC is already the next day in Vienna
+		got, err := FormatDate("de", may, DateStyleLong, "Europe/Vienna")
+		require.NoError(t, err)
+		assert.Equal(t, "10. Mai 2024", got)
+
+		got, err = FormatDate("en", may, DateStyleFull, "America/New_York")
+		require.NoError(t, err)
+		assert.Equal(t, "Thursday, May 9, 2024", got)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := FormatDate("en", march, "tiny")
+		assert.ErrorContains(t, err, "unknown style")
+
+		_, err = FormatDate("en", march, DateStyleLong, "Mars/Olympus")
+		assert.Error(t, err)
+
+		_, err = FormatDate("en", "4 March 2024", DateStyleLong)
+		assert.Error(t, err)
+
+		_, err = FormatDate("en", 1709544600, DateStyleLong)
+		assert.Error(t, err)
+
+		_, err = FormatDate("en", (*time.Time)(nil), DateStyleLong)
+		assert.Error(t, err)
+	})
+}
+
+func TestCalendarNames(t *testing.T) {
+	for language, names := range calendarNames {
+		t.Run(language, func(t *testing.T) {
+			for month := time.January; month <= time.December; month++ {
+				assert.NotEmpty(t, names.months.name(month), month)
+				assert.NotEmpty(t, names.monthsShort.name(month), month)
+			}
+			for day := time.Sunday; day <= time.Saturday; day++ {
+				assert.NotEmpty(t, names.days.name(day), day)
+				assert.NotEmpty(t, names.daysShort.name(day), day)
+			}
+		})
+	}
+
+	// Every language with layouts has names for them
+	for locale := range dateLayouts {
+		base, _ := tag(locale).Base()
+		assert.Contains(t, calendarNames, base.String(), locale)
+	}
+
+	// The English names are those of the time package
+	for month := time.January; month <= time.December; month++ {
+		assert.Equal(t, month.String(), calendarNames["en"].months.name(month))
+		assert.Equal(t, month.String()[:3], calendarNames["en"].monthsShort.name(month))
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		assert.Equal(t, day.String(), calendarNames["en"].days.name(day))
+		assert.Equal(t, day.String()[:3], calendarNames["en"].daysShort.name(day))
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		locale string
+		value  any
+		want   string
+	}{
+		{locale: "en", value: 1234567, want: "1,234,567"},
+		{locale: "en", value: 1234.25, want: "1,234.25"},
+		{locale: "de", value: 1234.25, want: "1.234,25"},
+		{locale: "fr", value: 1234.25, want: "1\u00a0234,25"}, // Grouped with a no-break space
+		{locale: "de", value: "1234.5", want: "1.234,5"},
+		{locale: "en", value: int64(-42), want: "-42"},
+		{locale: "en", value: "n/a", want: "n/a"},
+		{locale: "en", value: nil, want: "<nil>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			assert.Equal(t, tt.want, FormatNumber(tt.locale, tt.value))
+		})
+	}
+}
+
+func TestFormatCurrency(t *testing.T) {
+	tests := []struct {
+		locale  string
+		amount  any
+		code    string
+		want    string
+		wantErr bool
+	}{
+		{locale: "en", amount: 1234.5, code: "USD", want: "$ 1,234.50"},
+		{locale: "en", amount: 1234.5, code: "EUR", want: "€ 1,234.50"},
+		{locale: "en-GB", amount: 1234.5, code: "USD", want: "US$ 1,234.50"},
+		{locale: "de", amount: 1234.5, code: "EUR", want: "€ 1.234,50"},
+		{locale: "de", amount: "19.9", code: "eur", want: "€ 19,90"},
+		{locale: "fr", amount: 1234.5, code: "EUR", want: "€ 1\u00a0234,50"},
+		{locale: "ja", amount: 1234.5, code: "JPY", want: "￥ 1,235"},
+		{locale: "en", amount: 10, code: "XYZ", wantErr: true},
+		{locale: "en", amount: "ten", code: "USD", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale+"/"+tt.code, func(t *testing.T) {
+			got, err := FormatCurrency(tt.locale, tt.amount, tt.code)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
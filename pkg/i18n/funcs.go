@@ -0,0 +1,32 @@
+package i18n
+
+import (
+	"text/template"
+)
+
+// FuncMap returns the locale-aware template helpers bound to the given locale:
+//
+//	{{ plural .count "one" "# new comment" "other" "# new comments" }}
+//	{{ number .total }}
+//	{{ currency .amount "EUR" }}
+//	{{ date .created_at "long" }}  {{ date .created_at "full" "Europe/Vienna" }}
+//	{{ locale }}
+func FuncMap(locale string) template.FuncMap {
+	return template.FuncMap{
+		"locale": func() string {
+			return locale
+		},
+		"plural": func(count any, cases ...string) (string, error) {
+			return Plural(locale, count, cases...)
+		},
+		"number": func(value any) string {
+			return FormatNumber(locale, value)
+		},
+		"currency": func(amount any, code string) (string, error) {
+			return FormatCurrency(locale, amount, code)
+		},
+		"date": func(value any, style string, timezone ...string) (string, error) {
+			return FormatDate(locale, value, style, timezone...)
+		},
+	}
+}
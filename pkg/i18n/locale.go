@@ -0,0 +1,79 @@
+package i18n
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLocale is used when neither the notification, the recipient nor the application specify one
+const DefaultLocale = "en"
+
+// Normalize converts a locale identifier to its canonical BCP 47 form (e.g. "de_at" -> "de-AT")
+func Normalize(locale string) string {
+	locale = strings.TrimSpace(strings.ReplaceAll(locale, "_", "-"))
+	if locale == "" {
+		return ""
+	}
+
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return ""
+	}
+	return tag.String()
+}
+
+// FallbackChain returns the ordered list of locales to try for the given candidates.
+// Each candidate is expanded to its parents (e.g. "de-AT" -> "de-AT", "de") and the
+// chain always ends with the default locale. Duplicates and invalid locales are dropped.
+func FallbackChain(defaultLocale string, candidates ...string) []string {
+	seen := make(map[string]bool)
+	chain := make([]string, 0, len(candidates)*2+1)
+
+	add := func(locale string) {
+		if locale != "" && !seen[locale] {
+			seen[locale] = true
+			chain = append(chain, locale)
+		}
+	}
+
+	candidates = append(append([]string{}, candidates...), defaultLocale, DefaultLocale)
+	for _, candidate := range candidates {
+		locale := Normalize(candidate)
+		if locale == "" {
+			continue
+		}
+
+		// Walk up the locale hierarchy: "zh-Hant-TW" -> "zh-Hant" -> "zh"
+		parts := strings.Split(locale, "-")
+		for i := len(parts); i > 0; i-- {
+			add(strings.Join(parts[:i], "-"))
+		}
+	}
+
+	return chain
+}
+
+// Match returns the first locale of the fallback chain that is present in available.
+// The second return value is false when no locale matched.
+func Match(available []string, chain []string) (string, bool) {
+	normalized := make(map[string]string, len(available))
+	for _, locale := range available {
+		normalized[Normalize(locale)] = locale
+	}
+
+	for _, locale := range chain {
+		if original, ok := normalized[locale]; ok {
+			return original, true
+		}
+	}
+	return "", false
+}
+
+// tag parses a locale into a language tag, falling back to the default locale
+func tag(locale string) language.Tag {
+	if t, err := language.Parse(Normalize(locale)); err == nil {
+		return t
+	}
+	return language.MustParse(DefaultLocale)
+}
@@ -0,0 +1,75 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{locale: "de", want: "de"},
+		{locale: "de_at", want: "de-AT"},
+		{locale: " EN-gb ", want: "en-GB"},
+		{locale: "zh-hant-tw", want: "zh-Hant-TW"},
+		{locale: "", want: ""},
+		{locale: "not a locale", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			assert.Equal(t, tt.want, Normalize(tt.locale))
+		})
+	}
+}
+
+func TestFallbackChain(t *testing.T) {
+	tests := []struct {
+		name          string
+		defaultLocale string
+		candidates    []string
+		want          []string
+	}{
+		{name: "no candidates", want: []string{"en"}},
+		{name: "region", candidates: []string{"de-AT"}, want: []string{"de-AT", "de", "en"}},
+		{name: "script and region", candidates: []string{"zh_hant_tw"}, want: []string{"zh-Hant-TW", "zh-Hant", "zh", "en"}},
+		{name: "application default", defaultLocale: "fr", candidates: []string{"de-AT"}, want: []string{"de-AT", "de", "fr", "en"}},
+		{name: "candidates in order", candidates: []string{"pt-BR", "es"}, want: []string{"pt-BR", "pt", "es", "en"}},
+		{name: "duplicates", defaultLocale: "de", candidates: []string{"de-AT", "de-CH", "en"}, want: []string{"de-AT", "de", "de-CH", "en"}},
+		{name: "invalid and empty candidates", candidates: []string{"", "not a locale", "nl"}, want: []string{"nl", "en"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, FallbackChain(tt.defaultLocale, tt.candidates...))
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		available []string
+		chain     []string
+		want      string
+		wantOK    bool
+	}{
+		{name: "exact", available: []string{"en", "de-AT"}, chain: []string{"de-AT", "de", "en"}, want: "de-AT", wantOK: true},
+		{name: "parent", available: []string{"en", "de"}, chain: []string{"de-AT", "de", "en"}, want: "de", wantOK: true},
+		{name: "default", available: []string{"en", "fr"}, chain: []string{"de-AT", "de", "en"}, want: "en", wantOK: true},
+		{name: "stored form is returned", available: []string{"de_at"}, chain: []string{"de-AT", "de"}, want: "de_at", wantOK: true},
+		{name: "region is not a parent", available: []string{"de-CH"}, chain: []string{"de-AT", "de", "en"}},
+		{name: "nothing available", chain: []string{"en"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Match(tt.available, tt.chain)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package i18n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/feature/plural"
+)
+
+// pluralCategories maps x/text plural forms to their CLDR category names
+var pluralCategories = map[plural.Form]string{
+	plural.Other: "other",
+	plural.Zero:  "zero",
+	plural.One:   "one",
+	plural.Two:   "two",
+	plural.Few:   "few",
+	plural.Many:  "many",
+}
+
+// PluralCategory returns the CLDR cardinal plural category ("zero", "one", "two", "few", "many" or "other")
+// of count in the given locale
+func PluralCategory(locale string, count any) string {
+	i, v, w, f, t, ok := pluralOperands(count)
+	if !ok {
+		return "other"
+	}
+	return pluralCategories[plural.Cardinal.MatchPlural(tag(locale), i, v, w, f, t)]
+}
+
+// Plural selects an ICU-style plural message for count. Cases are given as selector/message pairs where the
+// selector is either an exact match ("=0", "=1") or a plural category ("one", "few", "other").
+// A "#" inside the selected message is replaced by the locale-formatted count.
+//
+//	Plural("en", 3, "=0", "No comments", "one", "# comment", "other", "# comments") // "3 comments"
+func Plural(locale string, count any, cases ...string) (string, error) {
+	if len(cases)%2 != 0 {
+		return "", fmt.Errorf("plural: cases must be selector/message pairs")
+	}
+
+	messages := make(map[string]string, len(cases)/2)
+	for i := 0; i < len(cases); i += 2 {
+		messages[cases[i]] = cases[i+1]
+	}
+
+	message, ok := messages["="+numberString(count)]
+	if !ok {
+		message, ok = messages[PluralCategory(locale, count)]
+	}
+	if !ok {
+		message, ok = messages["other"]
+	}
+	if !ok {
+		return "", fmt.Errorf("plural: no message for count %v and no 'other' case", count)
+	}
+
+	return strings.ReplaceAll(message, "#", FormatNumber(locale, count)), nil
+}
+
+// pluralOperands computes the CLDR plural operands of a number:
+// i (integer digits), v (number of visible fraction digits), w (visible fraction digits without trailing zeros),
+// f (visible fraction digits as integer) and t (f without trailing zeros)
+func pluralOperands(count any) (i, v, w, f, t int, ok bool) {
+	s := strings.TrimPrefix(numberString(count), "-")
+	if s == "" {
+		return 0, 0, 0, 0, 0, false
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if i, ok = atoi(intPart); !ok {
+		return 0, 0, 0, 0, 0, false
+	}
+
+	trimmed := strings.TrimRight(fracPart, "0")
+	v, w = len(fracPart), len(trimmed)
+	if f, ok = atoi(fracPart); !ok {
+		return 0, 0, 0, 0, 0, false
+	}
+	if t, ok = atoi(trimmed); !ok {
+		return 0, 0, 0, 0, 0, false
+	}
+	return i, v, w, f, t, true
+}
+
+// numberString renders a numeric value in its plain decimal form, or "" if it is not a number
+func numberString(count any) string {
+	switch n := count.(type) {
+	case int:
+		return strconv.Itoa(n)
+	case int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", n)
+	case float32:
+		return strconv.FormatFloat(float64(n), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	case string:
+		if _, err := strconv.ParseFloat(n, 64); err == nil {
+			return n
+		}
+	}
+	return ""
+}
+
+// atoi parses a possibly empty string of digits
+func atoi(s string) (int, bool) {
+	if s == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
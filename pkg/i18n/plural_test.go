@@ -0,0 +1,120 @@
+package i18n
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluralCategory(t *testing.T) {
+	tests := []struct {
+		locale string
+		counts map[string][]any // Counts by the category they fall in
+	}{
+		{
+			locale: "en",
+			counts: map[string][]any{
+				"one":   {1, "1", int64(1)},
+				"other": {0, 2, 11, 21, 101, 1.5, "1.0", -2},
+			},
+		},
+		{
+			locale: "fr",
+			counts: map[string][]any{
+				"one":   {0, 1, 1.5, "1.0"},
+				"other": {2, 11, 21, 100},
+			},
+		},
+		{
+			locale: "ru",
+			counts: map[string][]any{
+				"one":   {1, 21, 101, 1001},
+				"few":   {2, 3, 4, 22, 102},
+				"many":  {0, 5, 11, 12, 14, 25, 111},
+				"other": {1.5, "2.0"},
+			},
+		},
+		{
+			locale: "ar",
+			counts: map[string][]any{
+				"zero":  {0},
+				"one":   {1, "1.0"},
+				"two":   {2},
+				"few":   {3, 5, 10, 103},
+				"many":  {11, 21, 99, 111},
+				"other": {100, 102, 1.5},
+			},
+		},
+		{
+			// Regions use the rules of their language
+			locale: "ru-UA",
+			counts: map[string][]any{"one": {21}, "few": {22}, "many": {25}},
+		},
+	}
+
+	for _, tt := range tests {
+		for category, counts := range tt.counts {
+			for _, count := range counts {
+				t.Run(fmt.Sprintf("%s/%v", tt.locale, count), func(t *testing.T) {
+					assert.Equal(t, category, PluralCategory(tt.locale, count))
+				})
+			}
+		}
+	}
+
+	t.Run("not a number", func(t *testing.T) {
+		assert.Equal(t, "other", PluralCategory("en", "many"))
+		assert.Equal(t, "other", PluralCategory("en", nil))
+	})
+}
+
+func TestPlural(t *testing.T) {
+	comments := []string{"=0", "No comments", "one", "# comment", "other", "# comments"}
+
+	tests := []struct {
+		name    string
+		locale  string
+		count   any
+		cases   []string
+		want    string
+		wantErr bool
+	}{
+		{name: "exact match", locale: "en", count: 0, cases: comments, want: "No comments"},
+		{name: "one", locale: "en", count: 1, cases: comments, want: "1 comment"},
+		{name: "other", locale: "en", count: 1200, cases: comments, want: "1,200 comments"},
+		{name: "count formatted for the locale", locale: "de", count: 1200, cases: comments, want: "1.200 comments"},
+		{name: "zero is one in French", locale: "fr", count: 0, cases: []string{"one", "# commentaire", "other", "# commentaires"}, want: "0 commentaire"},
+		{
+			name:   "russian few",
+			locale: "ru",
+			count:  3,
+			cases:  []string{"one", "# комментарий", "few", "# комментария", "many", "# комментариев", "other", "# комментария"},
+			want:   "3 комментария",
+		},
+		{
+			name:   "russian many",
+			locale: "ru",
+			count:  11,
+			cases:  []string{"one", "# комментарий", "few", "# комментария", "many", "# комментариев", "other", "# комментария"},
+			want:   "11 комментариев",
+		},
+		{name: "missing category uses other", locale: "ar", count: 2, cases: []string{"one", "تعليق", "other", "# تعليقات"}, want: "٢ تعليقات"}, // Counted in Arabic-Indic digits,
+		{name: "not a number uses other", locale: "en", count: "some", cases: comments, want: "some comments"},
+		{name: "odd cases", locale: "en", count: 1, cases: []string{"one"}, wantErr: true},
+		{name: "no other case", locale: "en", count: 2, cases: []string{"one", "# comment"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Plural(tt.locale, tt.count, tt.cases...)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+// Package phone normalises phone numbers to the E.164 format
+package phone
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidNumber is returned for numbers that cannot be normalised to E.164
+var ErrInvalidNumber = errors.New("phone: invalid E.164 number")
+
+// NormalizeE164 normalises a phone number written in international format to E.164
+// ("+" followed by at most 15 digits). Spaces, dots, dashes and parentheses are removed
+// and the "00" international call prefix is accepted in place of "+".
+func NormalizeE164(number string) (string, error) {
+	number = strings.TrimSpace(number)
+	if strings.HasPrefix(number, "00") {
+		number = "+" + number[2:]
+	}
+	if !strings.HasPrefix(number, "+") {
+		return "", ErrInvalidNumber
+	}
+
+	var digits strings.Builder
+	for _, r := range number[1:] {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == ' ' || r == '.' || r == '-' || r == '(' || r == ')':
+			// Formatting characters
+		default:
+			return "", ErrInvalidNumber
+		}
+	}
+
+	// Country codes never start with 0 and the shortest numbers have 7 digits
+	normalized := digits.String()
+	if len(normalized) < 7 || len(normalized) > 15 || normalized[0] == '0' {
+		return "", ErrInvalidNumber
+	}
+	return "+" + normalized, nil
+}
@@ -0,0 +1,51 @@
+package phone
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeE164(t *testing.T) {
+	tests := []struct {
+		name    string
+		number  string
+		want    string
+		wantErr bool
+	}{
+		{name: "already normalized", number: "+15551234567", want: "+15551234567"},
+		{name: "spaces", number: "+44 20 7946 0958", want: "+442079460958"},
+		{name: "formatting characters", number: "+1 (555) 123-4567", want: "+15551234567"},
+		{name: "dots", number: "+33.1.23.45.67.89", want: "+33123456789"},
+		{name: "international call prefix", number: "0049 30 1234567", want: "+49301234567"},
+		{name: "surrounding whitespace", number: "  +4915112345678\n", want: "+4915112345678"},
+		{name: "shortest number", number: "+2901234", want: "+2901234"},
+		{name: "longest number", number: "+123456789012345", want: "+123456789012345"},
+		{name: "national format", number: "030 1234567", wantErr: true},
+		{name: "no prefix", number: "15551234567", wantErr: true},
+		{name: "empty", number: "", wantErr: true},
+		{name: "plus only", number: "+", wantErr: true},
+		{name: "too short", number: "+123456", wantErr: true},
+		{name: "too long", number: "+1234567890123456", wantErr: true},
+		{name: "country code starting with 0", number: "+0123456789", wantErr: true},
+		{name: "international prefix then 0", number: "000123456789", wantErr: true},
+		{name: "letters", number: "+1 555 CALL NOW", wantErr: true},
+		{name: "extension", number: "+15551234567;ext=12", wantErr: true},
+		{name: "second plus", number: "++15551234567", wantErr: true},
+		{name: "non-ASCII digits", number: "+٤٩٣٠١٢٣٤٥٦٧", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeE164(tt.number)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidNumber)
+				assert.Empty(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
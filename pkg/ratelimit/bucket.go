@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TokenBucket throttles events per key with buckets refilled at a steady rate
+type TokenBucket interface {
+	// Take takes a token from the bucket of key, refilled at rate tokens per second up to burst
+	// tokens, and returns zero, or the time until a token is available when the bucket is empty
+	Take(ctx context.Context, key string, rate float64, burst int) (time.Duration, error)
+}
+
+// tokenBucketScript atomically refills the bucket of a hash for the time elapsed since its last use,
+// then takes a token or returns the milliseconds until one is available
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1]) or burst
+local ts = tonumber(bucket[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+else
+	wait = math.ceil((1 - tokens) / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate) + 1000)
+return wait
+`)
+
+// RedisTokenBucket keeps the buckets in Redis hashes, shared by every process using the same prefix
+type RedisTokenBucket struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisTokenBucket creates a token bucket storing its keys under prefix
+func NewRedisTokenBucket(client *redis.Client, prefix string) *RedisTokenBucket {
+	return &RedisTokenBucket{client: client, prefix: prefix}
+}
+
+// Take implements TokenBucket
+func (b *RedisTokenBucket) Take(ctx context.Context, key string, rate float64, burst int) (time.Duration, error) {
+	wait, err := tokenBucketScript.Run(ctx, b.client, []string{b.prefix + key},
+		rate/1000, burst, time.Now().UnixMilli()).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(wait) * time.Millisecond, nil
+}
+
+// localBucket is the state of a bucket of a LocalTokenBucket
+type localBucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// LocalTokenBucket keeps the buckets in memory, they are not shared with other processes
+type LocalTokenBucket struct {
+	mu      sync.Mutex
+	buckets map[string]*localBucket
+}
+
+// NewLocalTokenBucket creates an in-memory token bucket
+func NewLocalTokenBucket() *LocalTokenBucket {
+	return &LocalTokenBucket{buckets: make(map[string]*localBucket)}
+}
+
+// Take implements TokenBucket
+func (b *LocalTokenBucket) Take(ctx context.Context, key string, rate float64, burst int) (time.Duration, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	bucket, ok := b.buckets[key]
+	if !ok {
+		bucket = &localBucket{tokens: float64(burst), at: now}
+		b.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(float64(burst), bucket.tokens+now.Sub(bucket.at).Seconds()*rate)
+	bucket.at = now
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return 0, nil
+	}
+	return time.Duration(math.Ceil((1 - bucket.tokens) / rate * float64(time.Second))), nil
+}
@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalTokenBucketTake(t *testing.T) {
+	// take is a call to Take after elapsed, with the wait it must return
+	type take struct {
+		key     string
+		elapsed time.Duration
+		want    time.Duration
+	}
+
+	tests := []struct {
+		name  string
+		rate  float64
+		burst int
+		takes []take
+	}{
+		{
+			name:  "burst then wait",
+			rate:  1,
+			burst: 2,
+			takes: []take{{want: 0}, {want: 0}, {want: time.Second}},
+		},
+		{
+			name:  "refilled at the rate",
+			rate:  2,
+			burst: 1,
+			takes: []take{{want: 0}, {want: 500 * time.Millisecond}, {elapsed: 500 * time.Millisecond, want: 0}},
+		},
+		{
+			name:  "partially refilled",
+			rate:  1,
+			burst: 1,
+			takes: []take{{want: 0}, {elapsed: 250 * time.Millisecond, want: 750 * time.Millisecond}},
+		},
+		{
+			name:  "refill is capped at the burst",
+			rate:  10,
+			burst: 2,
+			takes: []take{{want: 0}, {want: 0}, {elapsed: time.Minute, want: 0}, {want: 0}, {want: 100 * time.Millisecond}},
+		},
+		{
+			name:  "keys have their own bucket",
+			rate:  1,
+			burst: 1,
+			takes: []take{{key: "a", want: 0}, {key: "b", want: 0}, {key: "a", want: time.Second}},
+		},
+		{
+			name:  "refused takes do not consume tokens",
+			rate:  1,
+			burst: 1,
+			takes: []take{{want: 0}, {want: time.Second}, {want: time.Second}, {elapsed: time.Second, want: 0}},
+		},
+	}
+
+	// Waits are computed from the clock, the test tolerates the time the calls take
+	const tolerance = 20 * time.Millisecond
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket := NewLocalTokenBucket()
+			for i, step := range tt.takes {
+				key := step.key
+				if key == "" {
+					key = "provider"
+				}
+				if state, ok := bucket.buckets[key]; ok {
+					state.at = state.at.Add(-step.elapsed)
+				}
+
+				got, err := bucket.Take(context.Background(), key, tt.rate, tt.burst)
+				require.NoError(t, err, "take %d", i)
+				assert.InDelta(t, step.want, got, float64(tolerance), "take %d: wait", i)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+// Package ratelimit provides counters limiting how often something may happen, shared between
+// processes through Redis
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Limit caps the events of a key within a sliding window
+type Limit struct {
+	Key    string
+	Limit  int
+	Window time.Duration
+}
+
+// SlidingWindow counts events per key over sliding time windows
+type SlidingWindow interface {
+	// Allow records an event under the key of every limit unless one of them already has its limit
+	// of events within its window. It returns the index of the first limit reached, or -1 when the
+	// event was recorded. Events are identified by event, one already recorded under a key within
+	// the window counts as allowed by that key without being counted twice. Events are timed with
+	// the clock of the store, not of the caller.
+	Allow(ctx context.Context, event string, limits []Limit) (int, error)
+}
+
+// slidingWindowScript atomically prunes the events of each sorted set older than its window, checks
+// every limit and, only when none is reached, adds the event to every set. Events are scored with the
+// Redis clock so that workers with skewed clocks share the same windows.
+var slidingWindowScript = redis.NewScript(`
+redis.replicate_commands()
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local event = ARGV[1]
+for i, key in ipairs(KEYS) do
+	local limit = tonumber(ARGV[i * 2])
+	local window = tonumber(ARGV[i * 2 + 1])
+	redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+	if not redis.call('ZSCORE', key, event) and redis.call('ZCARD', key) >= limit then
+		return i - 1
+	end
+end
+for i, key in ipairs(KEYS) do
+	redis.call('ZADD', key, 'NX', now, event)
+	redis.call('PEXPIRE', key, tonumber(ARGV[i * 2 + 1]))
+end
+return -1
+`)
+
+// RedisSlidingWindow keeps the events of each key in a Redis sorted set scored by time
+type RedisSlidingWindow struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisSlidingWindow creates a sliding window storing its keys under prefix
+func NewRedisSlidingWindow(client *redis.Client, prefix string) *RedisSlidingWindow {
+	return &RedisSlidingWindow{client: client, prefix: prefix}
+}
+
+// Allow implements SlidingWindow
+func (w *RedisSlidingWindow) Allow(ctx context.Context, event string, limits []Limit) (int, error) {
+	if len(limits) == 0 {
+		return -1, nil
+	}
+
+	keys := make([]string, len(limits))
+	args := make([]any, 0, 1+len(limits)*2)
+	args = append(args, event)
+	for i, limit := range limits {
+		keys[i] = w.prefix + limit.Key
+		args = append(args, limit.Limit, limit.Window.Milliseconds())
+	}
+
+	reached, err := slidingWindowScript.Run(ctx, w.client, keys, args...).Int()
+	if err != nil {
+		return 0, err
+	}
+	return reached, nil
+}
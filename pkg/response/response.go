@@ -38,6 +38,7 @@ type MetaInfo struct {
 	TotalPages int            `json:"total_pages,omitempty"`
 	HasNext    bool           `json:"has_next,omitempty"`
 	HasPrev    bool           `json:"has_prev,omitempty"`
+	NextCursor string         `json:"next_cursor,omitempty"` // Set by cursor-paginated lists when HasNext is set
 	SortBy     string         `json:"sort_by,omitempty"`
 	SortOrder  string         `json:"sort_order,omitempty"`
 	Filters    map[string]any `json:"filters,omitempty"`
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStoragePath(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "objects")
+	storage, err := NewLocalStorage(root)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		key  string
+		want string // Path relative to the root, empty when the key is rejected
+	}{
+		{name: "plain key", key: "attachments/report.pdf", want: "attachments/report.pdf"},
+		{name: "leading slash", key: "/attachments/report.pdf", want: "attachments/report.pdf"},
+		{name: "absolute path stays below the root", key: "/etc/passwd", want: "etc/passwd"},
+		{name: "duplicate separators", key: "attachments//report.pdf", want: "attachments/report.pdf"},
+		{name: "current directory", key: "./attachments/./report.pdf", want: "attachments/report.pdf"},
+		{name: "empty key", key: ""},
+		{name: "root", key: "/"},
+		{name: "current directory only", key: "."},
+		{name: "parent directory", key: "../secret"},
+		{name: "parent directory inside the key", key: "attachments/../../secret"},
+		{name: "parent directory back into the root", key: "attachments/../report.pdf"},
+		{name: "sibling of the root", key: "../objects-backup/report.pdf"},
+		{name: "absolute parent directory", key: "/../../etc/passwd"},
+		{name: "trailing parent directory", key: "attachments/.."},
+		{name: "double dots in a name are rejected too", key: "report..pdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := storage.path(tt.key)
+			if tt.want == "" {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, filepath.Join(root, tt.want), path)
+			assert.True(t, strings.HasPrefix(path, root+string(filepath.Separator)))
+		})
+	}
+}
+
+func TestLocalStorage(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "objects")
+	storage, err := NewLocalStorage(root)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, storage.Put(ctx, "attachments/1/report.pdf", strings.NewReader("first")))
+	require.NoError(t, storage.Put(ctx, "attachments/1/report.pdf", strings.NewReader("second")))
+
+	object, err := storage.Get(ctx, "attachments/1/report.pdf")
+	require.NoError(t, err)
+	content, err := io.ReadAll(object)
+	require.NoError(t, err)
+	require.NoError(t, object.Close())
+	assert.Equal(t, "second", string(content), "objects are replaced")
+
+	entries, err := os.ReadDir(filepath.Join(root, "attachments", "1"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files are removed")
+
+	require.NoError(t, storage.Delete(ctx, "attachments/1/report.pdf"))
+	require.NoError(t, storage.Delete(ctx, "attachments/1/report.pdf"), "deleting a missing object is not an error")
+	_, err = storage.Get(ctx, "attachments/1/report.pdf")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	t.Run("keys escaping the root", func(t *testing.T) {
+		secret := filepath.Join(parent, "secret")
+		require.NoError(t, os.WriteFile(secret, []byte("secret"), 0o600))
+
+		assert.Error(t, storage.Put(ctx, "../secret", strings.NewReader("overwritten")))
+		_, err := storage.Get(ctx, "../secret")
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrNotFound)
+		assert.Error(t, storage.Delete(ctx, "../secret"))
+
+		content, err := os.ReadFile(secret)
+		require.NoError(t, err)
+		assert.Equal(t, "secret", string(content))
+	})
+
+	t.Run("cancelled upload", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		assert.ErrorIs(t, storage.Put(cancelled, "attachments/2/report.pdf", strings.NewReader("content")), context.Canceled)
+		_, err := storage.Get(ctx, "attachments/2/report.pdf")
+		assert.ErrorIs(t, err, ErrNotFound, "partial objects are not kept")
+	})
+
+	t.Run("root is required", func(t *testing.T) {
+		_, err := NewLocalStorage("")
+		assert.Error(t, err)
+	})
+}
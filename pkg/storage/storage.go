@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrNotFound is returned when no object exists for a key
+var ErrNotFound = errors.New("storage: object not found")
+
+// Storage stores binary objects such as attachments by key.
+// Implementations must be safe for concurrent use.
+type Storage interface {
+	// Put stores the content read from r under key, replacing any existing object
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get opens the object stored under key; the caller must close it
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key, it is not an error if it does not exist
+	Delete(ctx context.Context, key string) error
+}
+
+// Driver names accepted by New
+const (
+	DriverLocal = "local"
+)
+
+// Config holds the settings of the storage backends
+type Config struct {
+	Driver    string
+	LocalPath string
+}
+
+// New creates the storage backend selected by the configuration
+func New(cfg Config) (Storage, error) {
+	switch cfg.Driver {
+	case "", DriverLocal:
+		return NewLocalStorage(cfg.LocalPath)
+	default:
+		return nil, fmt.Errorf("storage: unsupported driver '%s'", cfg.Driver)
+	}
+}